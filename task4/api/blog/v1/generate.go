@@ -0,0 +1,3 @@
+package blogv1
+
+//go:generate protoc -I ../.. --go_out=../.. --go_opt=paths=source_relative --go-grpc_out=../.. --go-grpc_opt=paths=source_relative blog/v1/user.proto blog/v1/post.proto blog/v1/comment.proto
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/miffyG/golearn/task4/pkg/config"
+)
+
+// configPrint 输出合并后的配置，密钥被隐去。配置校验失败时仍然输出，随后报告错误
+func configPrint(a *app, args []string) error {
+	fs := flag.NewFlagSet("config print", flag.ContinueOnError)
+	format := fs.String("format", "yaml", "输出格式：yaml 或 env，-o json 时忽略")
+	if rest, err := parseFlags(fs, args); err != nil || len(rest) != 0 {
+		return errUsage
+	}
+	if *format != "yaml" && *format != "env" {
+		return errUsage
+	}
+
+	cfg, err := config.Parse(a.configOpts)
+	if err != nil {
+		return err
+	}
+	if cfg.File() != "" {
+		fmt.Fprintf(os.Stderr, "配置文件: %s\n", cfg.File())
+	}
+	switch {
+	case a.out.format == "json":
+		err = a.out.print(cfg.Entries(), nil, nil)
+	case *format == "env":
+		err = cfg.WriteEnv(a.out.w)
+	default:
+		err = cfg.WriteYAML(a.out.w)
+	}
+	if err != nil {
+		return err
+	}
+	return cfg.Validate()
+}
@@ -0,0 +1,246 @@
+// blogctl 是博客的运维命令行工具，与服务端读取相同的配置文件、.env 和环境变量，直接操作数据库。
+//
+// 用法：
+//
+//	blogctl [-env .env] [-config <文件>] [-profile dev|test|prod] [-o table|json] <命令> <子命令> [参数]
+//
+// 命令：
+//
+//	user create|list|ban|unban|reset-password|set-role
+//	post list|delete|restore
+//	token issue|inspect
+//	stats
+//	export|import
+//	audit list|verify
+//	config print
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/miffyG/golearn/task4/internal/audit"
+	"github.com/miffyG/golearn/task4/internal/models/entity"
+	"github.com/miffyG/golearn/task4/internal/repository"
+	"github.com/miffyG/golearn/task4/internal/service"
+	"github.com/miffyG/golearn/task4/internal/tokens"
+	"github.com/miffyG/golearn/task4/pkg/config"
+	"github.com/miffyG/golearn/task4/pkg/db"
+	"github.com/miffyG/golearn/task4/pkg/logger"
+	"gorm.io/gorm"
+)
+
+// command 是一个子命令，needDB 为 false 的命令不连接数据库
+type command struct {
+	usage  string
+	needDB bool
+	run    func(a *app, args []string) error
+}
+
+var commands = map[string]map[string]command{
+	"user": {
+		"create":         {"user create -username <名称> -password <密码> -email <邮箱> [-phone <手机号>] [-role user|moderator|admin]", true, userCreate},
+		"list":           {"user list [-page 1] [-page-size 20]", true, userList},
+		"ban":            {"user ban <ID|用户名>", true, userBan(true)},
+		"unban":          {"user unban <ID|用户名>", true, userBan(false)},
+		"reset-password": {"user reset-password <ID|用户名> -password <新密码>", true, userResetPassword},
+		"set-role":       {"user set-role <ID|用户名> <user|moderator|admin>", true, userSetRole},
+	},
+	"post": {
+		"list":    {"post list [-user <ID|用户名>] [-deleted] [-page 1] [-page-size 20]", true, postList},
+		"delete":  {"post delete <ID>", true, postDelete},
+		"restore": {"post restore <ID>", true, postRestore},
+		"purge":   {"post purge [-older-than <时长，默认 TRASH_RETENTION>]", true, postPurge},
+	},
+	"token": {
+		"issue":   {"token issue <ID|用户名> [-ttl 24h]", true, tokenIssue},
+		"inspect": {"token inspect <token>", false, tokenInspect},
+	},
+	"stats": {
+		"": {"stats", true, showStats},
+	},
+	"export": {
+		"": {"export [-format ndjson|json] [-file <文件>] [-with-passwords]", true, exportData},
+	},
+	"import": {
+		"": {"import [-format ndjson|json] [-file <文件>] [-dry-run]", true, importData},
+	},
+	"audit": {
+		"list":   {"audit list [-actor <ID>] [-action <操作>] [-target-type <类型>] [-target-id <ID>] [-request-id <ID>] [-since <RFC3339>] [-until <RFC3339>] [-page 1] [-page-size 20]", true, auditList},
+		"verify": {"audit verify", true, auditVerify},
+	},
+	"config": {
+		"print": {"config print [-format yaml|env]", false, configPrint},
+	},
+}
+
+// errUsage 表示参数错误，输出用法并以状态码 2 退出
+var errUsage = errors.New("usage")
+
+type app struct {
+	out *output
+	// configOpts 是加载配置时使用的来源，config 命令用它重新读取配置
+	configOpts config.Options
+	// cfg 是加载并校验过的配置，config 命令不加载，为 nil
+	cfg *config.Config
+	db  *gorm.DB
+	// ctx 携带审计日志的来源信息，命令行的修改操作记为 blogctl 发起
+	ctx context.Context
+	// keys 由 keySet 在第一次使用时加载
+	keys *tokens.KeySet
+
+	userService     *service.UserService
+	postService     *service.PostService
+	statsService    *service.StatsService
+	transferService *service.TransferService
+	auditService    *service.AuditService
+}
+
+func main() {
+	envFile := flag.String("env", ".env", "环境变量文件")
+	configFile := flag.String("config", "", "YAML 或 TOML 配置文件，默认读取 CONFIG_FILE 或 configs/<profile>.yaml")
+	profile := flag.String("profile", "", "运行环境：dev、test 或 prod，默认读取 APP_PROFILE")
+	format := flag.String("o", "table", "输出格式：table 或 json")
+	flag.Usage = usage
+	flag.Parse()
+
+	if *format != "table" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "不支持的输出格式: %s\n", *format)
+		os.Exit(2)
+	}
+
+	args := flag.Args()
+	if len(args) == 0 {
+		usage()
+		os.Exit(2)
+	}
+	group, ok := commands[args[0]]
+	if !ok {
+		usage()
+		os.Exit(2)
+	}
+	name, rest := "", args[1:]
+	if _, single := group[""]; !single {
+		if len(rest) == 0 {
+			usage()
+			os.Exit(2)
+		}
+		name, rest = rest[0], rest[1:]
+	}
+	cmd, ok := group[name]
+	if !ok {
+		usage()
+		os.Exit(2)
+	}
+
+	a := &app{
+		out:        &output{format: *format, w: os.Stdout},
+		configOpts: config.Options{Profile: *profile, File: *configFile, EnvFile: *envFile},
+		ctx:        audit.WithMeta(context.Background(), audit.Meta{UserAgent: "blogctl"}),
+	}
+	// config 命令自行读取配置，配置不合法时也能输出，便于排查
+	if args[0] != "config" {
+		cfg, err := config.Load(a.configOpts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			os.Exit(1)
+		}
+		a.cfg = cfg
+	}
+	if cmd.needDB {
+		if err := a.connect(); err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			os.Exit(1)
+		}
+		defer db.Close(a.db)
+	}
+
+	if err := cmd.run(a, rest); err != nil {
+		if errors.Is(err, errUsage) {
+			fmt.Fprintf(os.Stderr, "用法: blogctl %s\n", cmd.usage)
+			os.Exit(2)
+		}
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "用法: blogctl [-env .env] [-config <文件>] [-profile dev|test|prod] [-o table|json] <命令> <子命令> [参数]")
+	fmt.Fprintln(os.Stderr)
+	var lines []string
+	for _, group := range commands {
+		for _, cmd := range group {
+			lines = append(lines, "  blogctl "+cmd.usage)
+		}
+	}
+	sort.Strings(lines)
+	fmt.Fprintln(os.Stderr, strings.Join(lines, "\n"))
+	fmt.Fprintln(os.Stderr)
+	flag.PrintDefaults()
+}
+
+func (a *app) connect() error {
+	// 命令行工具连接失败时直接报错，不等待重试；不输出 SQL 日志，查询不到记录等情况由命令自行输出错误
+	dbCfg := a.cfg.Db
+	dbCfg.ConnectRetries = 0
+	gormDb, err := db.Open(a.ctx, &dbCfg, nil)
+	if err != nil {
+		return err
+	}
+	a.db = gormDb
+	// 审计日志写入失败等错误输出到标准错误
+	log, err := logger.New(a.cfg.Profile)
+	if err != nil {
+		return err
+	}
+	a.auditService = service.NewAuditService(repository.NewAuditRepository(gormDb), a.cfg.Audit.HashChain, log.Sugar())
+	keys, err := keySet(a)
+	if err != nil {
+		return err
+	}
+	a.userService = service.NewUserService(repository.NewUserRepository(gormDb), repository.NewTxManager(gormDb), a.auditService, keys)
+	a.postService = service.NewPostService(repository.NewPostRepository(gormDb), repository.NewTxManager(gormDb), nil, a.auditService)
+	a.statsService = service.NewStatsService(repository.NewStatsRepository(gormDb))
+	a.transferService = service.NewTransferService(repository.NewTransferRepository(gormDb))
+	return nil
+}
+
+// findUser 按 ID 或用户名查找用户
+func (a *app) findUser(ref string) (*entity.User, error) {
+	if id, err := strconv.ParseUint(ref, 10, 64); err == nil {
+		return a.userService.GetByID(a.ctx, uint(id))
+	}
+	return a.userService.GetByUsername(a.ctx, ref)
+}
+
+// parseFlags 解析子命令参数，允许位置参数出现在选项之前
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	fs.SetOutput(os.Stderr)
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, errUsage
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func parseID(s string) (uint, error) {
+	id, err := strconv.ParseUint(s, 10, 64)
+	if err != nil || id == 0 {
+		return 0, fmt.Errorf("无效的 ID: %s", s)
+	}
+	return uint(id), nil
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+type output struct {
+	format string
+	w      io.Writer
+}
+
+// print 按输出格式打印：json 格式直接编码 v，table 格式打印 header 和 rows
+func (o *output) print(v interface{}, header []string, rows [][]string) error {
+	if o.format == "json" {
+		enc := json.NewEncoder(o.w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+	tw := tabwriter.NewWriter(o.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+// message 打印一条操作结果，json 格式输出 {"message": ...}
+func (o *output) message(format string, args ...interface{}) error {
+	msg := fmt.Sprintf(format, args...)
+	if o.format == "json" {
+		return o.print(map[string]string{"message": msg}, nil, nil)
+	}
+	_, err := fmt.Fprintln(o.w, msg)
+	return err
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04:05")
+}
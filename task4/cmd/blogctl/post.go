@@ -0,0 +1,121 @@
+package main
+
+import (
+	"flag"
+	"strconv"
+	"time"
+
+	"github.com/miffyG/golearn/task4/internal/models/entity"
+	"github.com/miffyG/golearn/task4/internal/repository"
+)
+
+type postView struct {
+	ID        uint       `json:"id"`
+	Title     string     `json:"title"`
+	UserID    uint       `json:"user_id"`
+	Author    string     `json:"author"`
+	Version   uint       `json:"version"`
+	CreatedAt time.Time  `json:"created_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+func newPostView(p *entity.Post) postView {
+	v := postView{
+		ID:        p.ID,
+		Title:     p.Title,
+		UserID:    p.UserID,
+		Version:   p.Version,
+		CreatedAt: p.CreatedAt,
+	}
+	if p.User != nil {
+		v.Author = p.User.UserName
+	}
+	if p.DeletedAt.Valid {
+		v.DeletedAt = &p.DeletedAt.Time
+	}
+	return v
+}
+
+func postList(a *app, args []string) error {
+	fs := flag.NewFlagSet("post list", flag.ContinueOnError)
+	user := fs.String("user", "", "只列出该用户（ID 或用户名）的文章")
+	deleted := fs.Bool("deleted", false, "只列出已删除的文章")
+	page := fs.Int("page", 1, "页码")
+	pageSize := fs.Int("page-size", 20, "每页数量")
+	if rest, err := parseFlags(fs, args); err != nil || len(rest) != 0 {
+		return errUsage
+	}
+
+	filter := repository.PostFilter{Deleted: *deleted}
+	if *user != "" {
+		u, err := a.findUser(*user)
+		if err != nil {
+			return err
+		}
+		filter.UserID = u.ID
+	}
+	posts, total, err := a.postService.Search(a.ctx, filter, *page, *pageSize)
+	if err != nil {
+		return err
+	}
+
+	header := []string{"ID", "TITLE", "AUTHOR", "VERSION", "CREATED"}
+	if *deleted {
+		header = append(header, "DELETED")
+	}
+	views := make([]postView, len(posts))
+	rows := make([][]string, len(posts))
+	for i := range posts {
+		v := newPostView(&posts[i])
+		views[i] = v
+		rows[i] = []string{strconv.FormatUint(uint64(v.ID), 10), v.Title, v.Author, strconv.FormatUint(uint64(v.Version), 10), formatTime(v.CreatedAt)}
+		if v.DeletedAt != nil {
+			rows[i] = append(rows[i], formatTime(*v.DeletedAt))
+		}
+	}
+	return a.out.print(map[string]interface{}{"total": total, "posts": views}, header, rows)
+}
+
+func postDelete(a *app, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	id, err := parseID(args[0])
+	if err != nil {
+		return err
+	}
+	if err := a.postService.Remove(a.ctx, id); err != nil {
+		return err
+	}
+	return a.out.message("已删除文章 %d，可以通过 post restore 恢复", id)
+}
+
+func postRestore(a *app, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	id, err := parseID(args[0])
+	if err != nil {
+		return err
+	}
+	if err := a.postService.Restore(a.ctx, id); err != nil {
+		return err
+	}
+	return a.out.message("已恢复文章 %d", id)
+}
+
+func postPurge(a *app, args []string) error {
+	fs := flag.NewFlagSet("post purge", flag.ContinueOnError)
+	olderThan := fs.Duration("older-than", a.cfg.Trash.Retention, "彻底删除删除时间早于该时长的文章和评论")
+	if rest, err := parseFlags(fs, args); err != nil || len(rest) != 0 {
+		return errUsage
+	}
+
+	res, err := a.postService.Purge(a.ctx, *olderThan)
+	if err != nil {
+		return err
+	}
+	return a.out.print(res, []string{"POSTS", "COMMENTS"}, [][]string{{
+		strconv.FormatInt(res.Posts, 10), strconv.FormatInt(res.Comments, 10),
+	}})
+}
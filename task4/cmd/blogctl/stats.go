@@ -0,0 +1,23 @@
+package main
+
+import "strconv"
+
+func showStats(a *app, args []string) error {
+	if len(args) != 0 {
+		return errUsage
+	}
+	s, err := a.statsService.Get(a.ctx)
+	if err != nil {
+		return err
+	}
+	rows := [][]string{
+		{"users", strconv.FormatInt(s.Users, 10)},
+		{"admin_users", strconv.FormatInt(s.AdminUsers, 10)},
+		{"banned_users", strconv.FormatInt(s.BannedUsers, 10)},
+		{"posts", strconv.FormatInt(s.Posts, 10)},
+		{"deleted_posts", strconv.FormatInt(s.DeletedPosts, 10)},
+		{"comments", strconv.FormatInt(s.Comments, 10)},
+		{"revisions", strconv.FormatInt(s.Revisions, 10)},
+	}
+	return a.out.print(s, []string{"METRIC", "COUNT"}, rows)
+}
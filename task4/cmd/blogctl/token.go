@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/miffyG/golearn/task4/internal/tokens"
+)
+
+// keySet 按配置加载签发和校验 token 的密钥，与服务端使用同一组密钥
+func keySet(a *app) (*tokens.KeySet, error) {
+	if a.keys == nil {
+		keys, err := tokens.NewKeySet(&a.cfg.Jwt, a.cfg.Secret.JwtSecret)
+		if err != nil {
+			return nil, fmt.Errorf("加载 JWT 密钥失败: %w", err)
+		}
+		a.keys = keys
+	}
+	return a.keys, nil
+}
+
+func tokenIssue(a *app, args []string) error {
+	fs := flag.NewFlagSet("token issue", flag.ContinueOnError)
+	ttl := fs.Duration("ttl", 24*time.Hour, "有效期")
+	rest, err := parseFlags(fs, args)
+	if err != nil || len(rest) != 1 || *ttl <= 0 {
+		return errUsage
+	}
+	keys, err := keySet(a)
+	if err != nil {
+		return err
+	}
+
+	user, err := a.findUser(rest[0])
+	if err != nil {
+		return err
+	}
+	if user.Banned {
+		return errors.New("用户已被封禁")
+	}
+	token, err := keys.IssueWithTTL(user.ID, user.UserName, *ttl)
+	if err != nil {
+		return err
+	}
+	expiresAt := time.Now().Add(*ttl)
+	if a.out.format == "json" {
+		return a.out.print(map[string]interface{}{"token": token, "user_id": user.ID, "expires_at": expiresAt}, nil, nil)
+	}
+	_, err = a.out.w.Write([]byte(token + "\n"))
+	return err
+}
+
+func tokenInspect(a *app, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	keys, err := keySet(a)
+	if err != nil {
+		return err
+	}
+	claims, err := keys.Parse(args[0])
+	if err != nil {
+		return err
+	}
+
+	var issuedAt, expiresAt time.Time
+	if claims.IssuedAt != nil {
+		issuedAt = claims.IssuedAt.Time
+	}
+	if claims.ExpiresAt != nil {
+		expiresAt = claims.ExpiresAt.Time
+	}
+	v := map[string]interface{}{
+		"user_id":    claims.UserID,
+		"username":   claims.Username,
+		"issued_at":  issuedAt,
+		"expires_at": expiresAt,
+	}
+	return a.out.print(v, []string{"USER_ID", "USERNAME", "ISSUED", "EXPIRES"}, [][]string{{
+		strconv.FormatUint(uint64(claims.UserID), 10), claims.Username, formatTime(issuedAt), formatTime(expiresAt),
+	}})
+}
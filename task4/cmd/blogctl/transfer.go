@@ -0,0 +1,76 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/miffyG/golearn/task4/internal/models/dto"
+	"github.com/miffyG/golearn/task4/internal/service"
+)
+
+func exportData(a *app, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := fs.String("format", dto.TransferFormatNDJSON, "导出格式：ndjson 或 json")
+	file := fs.String("file", "", "输出文件，默认为标准输出")
+	withPasswords := fs.Bool("with-passwords", false, "导出用户的密码哈希")
+	if rest, err := parseFlags(fs, args); err != nil || len(rest) != 0 {
+		return errUsage
+	}
+
+	var w io.Writer = a.out.w
+	if *file != "" {
+		f, err := os.Create(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	return a.transferService.Export(a.ctx, w, service.ExportOptions{Format: *format, IncludePasswords: *withPasswords})
+}
+
+func importData(a *app, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	format := fs.String("format", dto.TransferFormatNDJSON, "数据格式：ndjson 或 json")
+	file := fs.String("file", "", "输入文件，默认为标准输入")
+	dryRun := fs.Bool("dry-run", false, "只校验并统计，不写入数据库")
+	if rest, err := parseFlags(fs, args); err != nil || len(rest) != 0 {
+		return errUsage
+	}
+
+	var r io.Reader = os.Stdin
+	if *file != "" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	result, err := a.transferService.Import(a.ctx, r, service.ImportOptions{
+		Format: *format,
+		DryRun: *dryRun,
+		Progress: func(processed int) {
+			fmt.Fprintf(os.Stderr, "\r已处理 %d 条记录", processed)
+		},
+	})
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return err
+	}
+
+	rows := [][]string{
+		{"users", fmt.Sprint(result.Users.Created), fmt.Sprint(result.Users.Updated)},
+		{"posts", fmt.Sprint(result.Posts.Created), fmt.Sprint(result.Posts.Updated)},
+		{"comments", fmt.Sprint(result.Comments.Created), fmt.Sprint(result.Comments.Updated)},
+	}
+	if err := a.out.print(result, []string{"TYPE", "CREATED", "UPDATED"}, rows); err != nil {
+		return err
+	}
+	if result.DryRun && a.out.format == "table" {
+		return a.out.message("试运行，数据库没有被修改")
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin/binding"
+	dto "github.com/miffyG/golearn/task4/internal/models/dto/v2"
+	"github.com/miffyG/golearn/task4/internal/models/entity"
+	"github.com/miffyG/golearn/task4/internal/utils"
+)
+
+type userView struct {
+	ID        uint      `json:"id"`
+	Username  string    `json:"username"`
+	Email     string    `json:"email"`
+	Phone     string    `json:"phone,omitempty"`
+	Role      string    `json:"role"`
+	Banned    bool      `json:"banned"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func newUserView(u *entity.User) userView {
+	return userView{
+		ID:        u.ID,
+		Username:  u.UserName,
+		Email:     u.Email,
+		Phone:     u.Phone,
+		Role:      u.Role,
+		Banned:    u.Banned,
+		CreatedAt: u.CreatedAt,
+	}
+}
+
+var userHeader = []string{"ID", "USERNAME", "EMAIL", "ROLE", "BANNED", "CREATED"}
+
+func (v userView) row() []string {
+	return []string{strconv.FormatUint(uint64(v.ID), 10), v.Username, v.Email, v.Role, strconv.FormatBool(v.Banned), formatTime(v.CreatedAt)}
+}
+
+func userCreate(a *app, args []string) error {
+	fs := flag.NewFlagSet("user create", flag.ContinueOnError)
+	username := fs.String("username", "", "用户名")
+	password := fs.String("password", "", "密码")
+	email := fs.String("email", "", "邮箱")
+	phone := fs.String("phone", "", "手机号")
+	role := fs.String("role", entity.RoleUser, "角色：user、moderator 或 admin")
+	if rest, err := parseFlags(fs, args); err != nil || len(rest) != 0 {
+		return errUsage
+	}
+
+	// 与注册接口使用相同的校验规则
+	utils.RegisterValidators()
+	if err := binding.Validator.ValidateStruct(&dto.RegisterRequest{
+		Username: *username,
+		Password: *password,
+		Email:    *email,
+		Phone:    *phone,
+	}); err != nil {
+		return err
+	}
+	if !entity.ValidRole(*role) {
+		return errUsage
+	}
+
+	user := entity.User{
+		UserName: *username,
+		Password: *password,
+		Email:    *email,
+		Phone:    *phone,
+		Role:     *role,
+	}
+	if err := a.userService.Register(a.ctx, &user); err != nil {
+		return err
+	}
+	v := newUserView(&user)
+	return a.out.print(v, userHeader, [][]string{v.row()})
+}
+
+func userList(a *app, args []string) error {
+	fs := flag.NewFlagSet("user list", flag.ContinueOnError)
+	page := fs.Int("page", 1, "页码")
+	pageSize := fs.Int("page-size", 20, "每页数量")
+	if rest, err := parseFlags(fs, args); err != nil || len(rest) != 0 {
+		return errUsage
+	}
+
+	users, total, err := a.userService.List(a.ctx, *page, *pageSize)
+	if err != nil {
+		return err
+	}
+	views := make([]userView, len(users))
+	rows := make([][]string, len(users))
+	for i := range users {
+		views[i] = newUserView(&users[i])
+		rows[i] = views[i].row()
+	}
+	return a.out.print(map[string]interface{}{"total": total, "users": views}, userHeader, rows)
+}
+
+func userBan(banned bool) func(a *app, args []string) error {
+	return func(a *app, args []string) error {
+		if len(args) != 1 {
+			return errUsage
+		}
+		user, err := a.findUser(args[0])
+		if err != nil {
+			return err
+		}
+		if err := a.userService.SetBanned(a.ctx, user.ID, banned); err != nil {
+			return err
+		}
+		if banned {
+			return a.out.message("已封禁用户 %s（ID %d），已签发的 token 在过期前仍然有效", user.UserName, user.ID)
+		}
+		return a.out.message("已解封用户 %s（ID %d）", user.UserName, user.ID)
+	}
+}
+
+func userResetPassword(a *app, args []string) error {
+	fs := flag.NewFlagSet("user reset-password", flag.ContinueOnError)
+	password := fs.String("password", "", "新密码")
+	rest, err := parseFlags(fs, args)
+	if err != nil || len(rest) != 1 {
+		return errUsage
+	}
+	// 与注册接口的密码规则一致
+	if len(*password) < 6 || len(*password) > 26 {
+		return errors.New("密码长度需要在 6 到 26 之间")
+	}
+
+	user, err := a.findUser(rest[0])
+	if err != nil {
+		return err
+	}
+	if err := a.userService.ResetPassword(a.ctx, user.ID, *password); err != nil {
+		return err
+	}
+	return a.out.message("已重置用户 %s（ID %d）的密码", user.UserName, user.ID)
+}
+
+func userSetRole(a *app, args []string) error {
+	if len(args) != 2 {
+		return errUsage
+	}
+	user, err := a.findUser(args[0])
+	if err != nil {
+		return err
+	}
+	if err := a.userService.SetRole(a.ctx, user.ID, args[1]); err != nil {
+		return err
+	}
+	return a.out.message("已将用户 %s（ID %d）的角色设置为 %s", user.UserName, user.ID, args[1])
+}
@@ -0,0 +1,294 @@
+// clientcheck 把 pkg/client 调用的接口与 docs/swagger.json 对照：路径、方法、查询参数、
+// 请求体和响应 data 的字段名与类型必须与文档一致，不一致时列出差异并以状态码 1 退出。
+//
+// 用法：
+//
+//	go run ./cmd/clientcheck [-spec docs/swagger.json]
+//
+// 修改接口或重新生成文档后，通过 go generate ./pkg/client 运行。
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/miffyG/golearn/task4/pkg/client"
+)
+
+type spec struct {
+	BasePath    string                          `json:"basePath"`
+	Paths       map[string]map[string]operation `json:"paths"`
+	Definitions map[string]*schema              `json:"definitions"`
+}
+
+type operation struct {
+	Parameters []struct {
+		Name   string  `json:"name"`
+		In     string  `json:"in"`
+		Schema *schema `json:"schema"`
+	} `json:"parameters"`
+	Responses map[string]struct {
+		Schema *schema `json:"schema"`
+	} `json:"responses"`
+}
+
+type schema struct {
+	Ref                  string             `json:"$ref"`
+	Type                 string             `json:"type"`
+	Items                *schema            `json:"items"`
+	AllOf                []*schema          `json:"allOf"`
+	Properties           map[string]*schema `json:"properties"`
+	AdditionalProperties interface{}        `json:"additionalProperties"`
+}
+
+func main() {
+	specPath := flag.String("spec", "docs/swagger.json", "swagger 文档的路径")
+	flag.Parse()
+
+	raw, err := os.ReadFile(*specPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "读取文档失败: %v\n", err)
+		os.Exit(1)
+	}
+	c, err := check(raw)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "解析文档失败: %v\n", err)
+		os.Exit(1)
+	}
+
+	// 客户端没有覆盖的接口只作提示
+	for _, key := range c.uncovered {
+		fmt.Printf("未覆盖 %s\n", key)
+	}
+
+	if len(c.errs) > 0 {
+		for _, e := range c.errs {
+			fmt.Fprintln(os.Stderr, e)
+		}
+		fmt.Fprintf(os.Stderr, "客户端与 %s 不一致，共 %d 处\n", *specPath, len(c.errs))
+		os.Exit(1)
+	}
+	fmt.Printf("客户端的 %d 个接口与 %s 一致\n", c.covered, *specPath)
+}
+
+// check 把 client.Endpoints() 与 swagger 文档 raw 对照，返回的 checker 中记录了差异和客户端没有覆盖的接口
+func check(raw []byte) (*checker, error) {
+	var s spec
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, err
+	}
+
+	c := &checker{spec: &s}
+	if s.BasePath != client.BasePath {
+		c.errorf("basePath 为 %q，客户端使用 %q", s.BasePath, client.BasePath)
+	}
+	covered := make(map[string]bool)
+	for _, ep := range client.Endpoints() {
+		covered[ep.Method+" "+ep.Path] = true
+		c.endpoint(ep)
+	}
+	c.covered = len(covered)
+
+	for path, ops := range s.Paths {
+		for method := range ops {
+			if key := strings.ToUpper(method) + " " + path; !covered[key] {
+				c.uncovered = append(c.uncovered, key)
+			}
+		}
+	}
+	sort.Strings(c.uncovered)
+	return c, nil
+}
+
+type checker struct {
+	spec *spec
+	errs []string
+	// covered 是客户端调用的接口数，uncovered 是文档中客户端没有调用的接口
+	covered   int
+	uncovered []string
+}
+
+func (c *checker) errorf(format string, args ...interface{}) {
+	c.errs = append(c.errs, fmt.Sprintf(format, args...))
+}
+
+func (c *checker) endpoint(ep client.Endpoint) {
+	name := ep.Method + " " + ep.Path
+	op, ok := c.spec.Paths[ep.Path][strings.ToLower(ep.Method)]
+	if !ok {
+		c.errorf("%s: 文档中没有这个接口", name)
+		return
+	}
+
+	query := make(map[string]bool)
+	var body *schema
+	for _, p := range op.Parameters {
+		switch p.In {
+		case "query":
+			query[p.Name] = true
+		case "body":
+			body = p.Schema
+		}
+	}
+	for _, q := range ep.Query {
+		if !query[q] {
+			c.errorf("%s: 文档中没有查询参数 %s", name, q)
+		}
+	}
+
+	switch {
+	case ep.Body == nil && body != nil:
+		c.errorf("%s: 文档要求请求体，客户端没有发送", name)
+	case ep.Body != nil && body == nil:
+		c.errorf("%s: 文档没有请求体，客户端发送了 %T", name, ep.Body)
+	case ep.Body != nil:
+		c.ref(name+" 请求体", body, ep.BodySchema)
+		c.compare(name+" 请求体", reflect.TypeOf(ep.Body), c.resolve(body), map[string]bool{})
+	}
+
+	if ep.Data == nil {
+		return
+	}
+	resp, ok := op.Responses["200"]
+	if !ok || resp.Schema == nil {
+		c.errorf("%s: 文档中没有 200 响应", name)
+		return
+	}
+	data := dataSchema(resp.Schema)
+	if data == nil {
+		c.errorf("%s: 文档没有描述响应的 data，客户端按 %T 解析", name, ep.Data)
+		return
+	}
+	t := reflect.TypeOf(ep.Data)
+	if t.Kind() == reflect.Slice {
+		if data.Type != "array" || data.Items == nil {
+			c.errorf("%s: 响应的 data 在文档中不是数组", name)
+			return
+		}
+		data, t = data.Items, t.Elem()
+	}
+	c.ref(name+" 响应", data, ep.DataSchema)
+	c.compare(name+" 响应", t, c.resolve(data), map[string]bool{})
+}
+
+// dataSchema 从 dto.Response{data=...} 生成的 allOf 中取出 data 的定义
+func dataSchema(s *schema) *schema {
+	for _, part := range s.AllOf {
+		if d, ok := part.Properties["data"]; ok {
+			return d
+		}
+	}
+	return nil
+}
+
+func (c *checker) ref(name string, s *schema, want string) {
+	if got := strings.TrimPrefix(s.Ref, "#/definitions/"); got != want {
+		c.errorf("%s: 文档引用 %q，客户端声明为 %q", name, got, want)
+	}
+}
+
+// resolve 展开 $ref，找不到定义时返回 nil
+func (c *checker) resolve(s *schema) *schema {
+	if s == nil || s.Ref == "" {
+		return s
+	}
+	return c.spec.Definitions[strings.TrimPrefix(s.Ref, "#/definitions/")]
+}
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	rawType  = reflect.TypeOf(json.RawMessage{})
+)
+
+// compare 检查 Go 类型 t 与文档中的定义 s 是否一致，结构体按 json 标签逐个字段比较。
+// visiting 记录正在比较的结构体，避免递归定义死循环
+func (c *checker) compare(name string, t reflect.Type, s *schema, visiting map[string]bool) {
+	if s == nil {
+		c.errorf("%s: 文档中找不到定义", name)
+		return
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		c.typ(name, s, "string")
+	case t == rawType || t.Kind() == reflect.Map || t.Kind() == reflect.Interface:
+		// 任意 JSON，文档中为 object 或未指定类型
+		if s.Type != "" && s.Type != "object" {
+			c.errorf("%s: 文档类型为 %s，客户端为任意 JSON", name, s.Type)
+		}
+	case t.Kind() == reflect.Slice:
+		if !c.typ(name, s, "array") || s.Items == nil {
+			return
+		}
+		c.compare(name+"[]", t.Elem(), c.resolve(s.Items), visiting)
+	case t.Kind() == reflect.Struct:
+		if len(s.Properties) == 0 {
+			c.errorf("%s: 文档中的定义没有字段，客户端为 %s", name, t)
+			return
+		}
+		if visiting[t.String()] {
+			return
+		}
+		visiting[t.String()] = true
+		defer delete(visiting, t.String())
+		c.fields(name, t, s, visiting)
+	case t.Kind() == reflect.String:
+		c.typ(name, s, "string")
+	case t.Kind() == reflect.Bool:
+		c.typ(name, s, "boolean")
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		c.typ(name, s, "integer")
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		c.typ(name, s, "number")
+	default:
+		c.errorf("%s: 不支持的类型 %s", name, t)
+	}
+}
+
+func (c *checker) typ(name string, s *schema, want string) bool {
+	if s.Type != want {
+		c.errorf("%s: 文档类型为 %q，客户端为 %s", name, s.Type, want)
+		return false
+	}
+	return true
+}
+
+// fields 要求结构体的字段与文档的字段一一对应
+func (c *checker) fields(name string, t reflect.Type, s *schema, visiting map[string]bool) {
+	seen := make(map[string]bool)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		key := strings.Split(f.Tag.Get("json"), ",")[0]
+		if !f.IsExported() || key == "-" {
+			continue
+		}
+		if key == "" {
+			key = f.Name
+		}
+		seen[key] = true
+		prop, ok := s.Properties[key]
+		if !ok {
+			c.errorf("%s: 客户端字段 %s 在文档中不存在", name, key)
+			continue
+		}
+		c.compare(name+"."+key, f.Type, c.resolve(prop), visiting)
+	}
+	var missing []string
+	for key := range s.Properties {
+		if !seen[key] {
+			missing = append(missing, key)
+		}
+	}
+	sort.Strings(missing)
+	for _, key := range missing {
+		c.errorf("%s: 文档字段 %s 在客户端中不存在", name, key)
+	}
+}
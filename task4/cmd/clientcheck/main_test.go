@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+)
+
+const specPath = "../../docs/swagger.json"
+
+// TestSwagger 在客户端与文档不一致时失败，修改接口后需要同步修改 pkg/client 并重新生成文档
+func TestSwagger(t *testing.T) {
+	raw, err := os.ReadFile(specPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := check(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range c.errs {
+		t.Error(e)
+	}
+	if c.covered == 0 {
+		t.Error("客户端没有声明任何接口")
+	}
+}
+
+// TestDrift 修改文档中的字段和路径，检查差异都能被发现
+func TestDrift(t *testing.T) {
+	raw, err := os.ReadFile(specPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	// 删除文章响应的 title 字段、把登录接口改名
+	defs := doc["definitions"].(map[string]interface{})
+	props := defs["dto.PostResponse"].(map[string]interface{})["properties"].(map[string]interface{})
+	delete(props, "title")
+	paths := doc["paths"].(map[string]interface{})
+	paths["/auth/signin"] = paths["/auth/login"]
+	delete(paths, "/auth/login")
+
+	mutated, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := check(mutated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	all := strings.Join(c.errs, "\n")
+	for _, want := range []string{"POST /auth/login: 文档中没有这个接口", "客户端字段 title 在文档中不存在"} {
+		if !strings.Contains(all, want) {
+			t.Errorf("差异中没有 %q:\n%s", want, all)
+		}
+	}
+}
@@ -1,93 +1,56 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"math/rand"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/gin-gonic/gin"
-	"github.com/miffyG/golearn/task4/internal/handler"
-	"github.com/miffyG/golearn/task4/internal/middleware"
+	"github.com/miffyG/golearn/task4/internal/app"
 	"github.com/miffyG/golearn/task4/internal/models/entity"
-	"github.com/miffyG/golearn/task4/internal/repository"
-	"github.com/miffyG/golearn/task4/internal/service"
 	"github.com/miffyG/golearn/task4/pkg/config"
-	"github.com/miffyG/golearn/task4/pkg/db"
-	"github.com/miffyG/golearn/task4/pkg/logger"
-	swaggerFiles "github.com/swaggo/files"
-	ginSwagger "github.com/swaggo/gin-swagger"
+	"gorm.io/gorm"
 )
 
+// @title golearn 博客 API
+// @version 1.0
+// @description 博客系统的用户、帖子和评论接口
+// @BasePath /api/v1
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description 登录接口返回的 JWT，格式为 Bearer <token>
 func main() {
-	config.LoadConfig()
-
-	if err := logger.Init(); err != nil {
-		log.Fatalf("日志初始化失败: %v", err)
-	}
-	defer func() {
-		if err := logger.Sync(); err != nil {
-			_ = err
-		}
-	}()
-
-	db.InitGormDb(config.GetDbConfig())
-
-	if err := db.GormDb.AutoMigrate(&entity.User{}, &entity.Post{}, &entity.Comment{}); err != nil {
-		logger.Sugar.Fatalf("数据库自动迁移失败: %v", err)
+	cfg, err := config.Load(config.Options{})
+	if err != nil {
+		log.Fatalf("加载配置失败: %v", err)
 	}
-	// insertBlogTestData()
-
-	userRepo := repository.NewUserRepository(db.GormDb)
-	postRepo := repository.NewPostRepository(db.GormDb)
-	commentRepo := repository.NewCommentRepository(db.GormDb)
-
-	userService := service.NewUserService(userRepo)
-	postService := service.NewPostService(postRepo)
-	commentService := service.NewCommentService(commentRepo)
-
-	userHandler := handler.NewAuthHandler(userService)
-	postHandler := handler.NewPostHandler(postService)
-	commentHandler := handler.NewCommentHandler(commentService)
-
-	r := gin.Default()
 
-	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
-	setupRoutes(r, userHandler, postHandler, commentHandler)
-	logger.Sugar.Info("服务器启动，监听端口 8080")
-	if err := r.Run(":8080"); err != nil {
-		logger.Sugar.Fatalf("服务器启动失败: %v", err)
+	// 收到 Ctrl+C 或 SIGTERM 后停止等待数据库或优雅关闭
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	gin.SetMode(app.GinMode(cfg.Profile))
+	a, err := app.New(ctx, cfg)
+	if err != nil {
+		stop()
+		log.Fatalf("服务初始化失败: %v", err)
 	}
-
-}
-
-func setupRoutes(r *gin.Engine, userHandler *handler.AuthHandler, postHandler *handler.PostHandler, commentHandler *handler.CommentHandler) {
-	v1 := r.Group("/api/v1")
-	{
-		authGroup := v1.Group("/auth")
-		{
-			authGroup.POST("/register", userHandler.Register)
-			authGroup.POST("/login", userHandler.Login)
-		}
-
-		v1.GET("/posts", postHandler.GetPosts)
-		v1.GET("/posts/:post_id", postHandler.GetPostsById)
-
-		protected := v1.Group("/")
-		protected.Use(middleware.JwtAuthMiddleware())
-		{
-			protected.POST("/posts", postHandler.CreatePost)
-			protected.PUT("/posts/:post_id", postHandler.UpdatePost)
-			protected.DELETE("/posts/:post_id", postHandler.DeletePost)
-			protected.POST("/posts/:post_id/comments", commentHandler.CreateComment)
-		}
-
-		v1.GET("/posts/:post_id/comments", commentHandler.GetCommentsByPost)
+	a.Logger.Sugar().Infof("加载配置: %s", cfg)
+	// insertBlogTestData(a.DB)
+
+	err = a.Run(ctx)
+	stop()
+	a.Close()
+	if err != nil {
+		log.Fatalf("服务器运行失败: %v", err)
 	}
 }
 
 // 如果没有数据则插入一些数据
-func insertBlogTestData() {
-	db := db.GormDb
+func insertBlogTestData(db *gorm.DB) {
 	var userCount int64
 	if err := db.Model(&entity.User{}).Count(&userCount).Error; err != nil {
 		fmt.Println("count error:", err)
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/miffyG/golearn/task4/pkg/openapi"
+)
+
+// swagger 是 swag 生成的 Swagger 2.0 文档中用到的部分
+type swagger struct {
+	Info struct {
+		Title       string `json:"title"`
+		Description string `json:"description"`
+		Version     string `json:"version"`
+	} `json:"info"`
+	BasePath            string                           `json:"basePath"`
+	Paths               map[string]map[string]*operation `json:"paths"`
+	Definitions         map[string]*openapi.Schema       `json:"definitions"`
+	SecurityDefinitions map[string]*securityDefinition   `json:"securityDefinitions"`
+}
+
+type operation struct {
+	Tags        []string              `json:"tags"`
+	Summary     string                `json:"summary"`
+	Description string                `json:"description"`
+	Consumes    []string              `json:"consumes"`
+	Produces    []string              `json:"produces"`
+	Parameters  []*parameter          `json:"parameters"`
+	Responses   map[string]*response  `json:"responses"`
+	Security    []map[string][]string `json:"security"`
+}
+
+// parameter 中 in 为 body 时使用 Schema，其余位置的类型和限制直接写在参数上
+type parameter struct {
+	Name        string          `json:"name"`
+	In          string          `json:"in"`
+	Description string          `json:"description"`
+	Required    bool            `json:"required"`
+	Schema      *openapi.Schema `json:"schema"`
+
+	Type      string          `json:"type"`
+	Format    string          `json:"format"`
+	Enum      []interface{}   `json:"enum"`
+	Items     *openapi.Schema `json:"items"`
+	Minimum   *float64        `json:"minimum"`
+	Maximum   *float64        `json:"maximum"`
+	MinLength *int            `json:"minLength"`
+	MaxLength *int            `json:"maxLength"`
+}
+
+type response struct {
+	Description string          `json:"description"`
+	Schema      *openapi.Schema `json:"schema"`
+}
+
+type securityDefinition struct {
+	Type        string `json:"type"`
+	In          string `json:"in"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+const jsonMediaType = "application/json"
+
+// bodySchemas 按媒体类型覆盖请求体的 schema。Swagger 2.0 的请求体只有一个 schema，
+// 而 PATCH /posts/{post_id} 的 Merge Patch 是对象、JSON Patch 是操作数组
+var bodySchemas = map[string]map[string]*openapi.Schema{
+	"PATCH /posts/{post_id}": {
+		"application/json-patch+json": {
+			Type: openapi.Types{"array"},
+			Items: &openapi.Schema{
+				Type:     openapi.Types{"object"},
+				Required: []string{"op", "path"},
+				Properties: map[string]*openapi.Schema{
+					"op":    {Type: openapi.Types{"string"}, Enum: []interface{}{"add", "remove", "replace", "move", "copy", "test"}},
+					"path":  {Type: openapi.Types{"string"}},
+					"from":  {Type: openapi.Types{"string"}},
+					"value": {},
+				},
+			},
+		},
+	},
+}
+
+// closedPrefixes 是响应使用的定义，转换时设置 additionalProperties: false，
+// handler 返回文档中没有的字段时响应校验失败
+var closedPrefixes = []string{"dto.", "service."}
+
+func convert(src *swagger) *openapi.Document {
+	doc := &openapi.Document{
+		OpenAPI: "3.1.0",
+		Info: openapi.Info{
+			Title:       src.Info.Title,
+			Description: src.Info.Description,
+			Version:     src.Info.Version,
+		},
+		Paths: make(map[string]openapi.PathItem),
+		Components: openapi.Components{
+			Schemas: make(map[string]*openapi.Schema),
+		},
+	}
+	if src.BasePath != "" {
+		doc.Servers = []openapi.Server{{URL: src.BasePath}}
+	}
+
+	for name, s := range src.Definitions {
+		rewriteRefs(s)
+		if len(s.Properties) > 0 && s.AdditionalProperties == nil && hasPrefix(name, closedPrefixes) {
+			s.AdditionalProperties = openapi.False()
+		}
+		doc.Components.Schemas[name] = s
+	}
+	for name, def := range src.SecurityDefinitions {
+		if doc.Components.SecuritySchemes == nil {
+			doc.Components.SecuritySchemes = make(map[string]*openapi.SecurityScheme)
+		}
+		doc.Components.SecuritySchemes[name] = securityScheme(def)
+	}
+
+	for path, item := range src.Paths {
+		out := make(openapi.PathItem)
+		for method, op := range item {
+			out[method] = convertOperation(strings.ToUpper(method)+" "+path, op)
+		}
+		doc.Paths[path] = out
+	}
+	return doc
+}
+
+// securityScheme 把 swag 的 apiKey 定义转换为 3.x 的 bearer 认证，Authorization 头以外的 apiKey 保持原样
+func securityScheme(def *securityDefinition) *openapi.SecurityScheme {
+	if def.Type == "apiKey" && def.In == "header" && strings.EqualFold(def.Name, "Authorization") {
+		return &openapi.SecurityScheme{Type: "http", Scheme: "bearer", BearerFormat: "JWT", Description: def.Description}
+	}
+	return &openapi.SecurityScheme{Type: def.Type, Description: def.Description}
+}
+
+func convertOperation(key string, op *operation) *openapi.Operation {
+	out := &openapi.Operation{
+		Tags:        op.Tags,
+		Summary:     op.Summary,
+		Description: op.Description,
+		Responses:   make(map[string]*openapi.Response),
+		Security:    op.Security,
+	}
+	consumes := orDefault(op.Consumes)
+	for _, p := range op.Parameters {
+		if p.In == "body" {
+			rewriteRefs(p.Schema)
+			body := &openapi.RequestBody{
+				Description: p.Description,
+				Required:    p.Required,
+				Content:     make(map[string]*openapi.MediaType),
+			}
+			for _, mt := range consumes {
+				schema := p.Schema
+				if override, ok := bodySchemas[key][mt]; ok {
+					schema = override
+				}
+				body.Content[mt] = &openapi.MediaType{Schema: schema}
+			}
+			out.RequestBody = body
+			continue
+		}
+		rewriteRefs(p.Items)
+		out.Parameters = append(out.Parameters, &openapi.Parameter{
+			Name:        p.Name,
+			In:          p.In,
+			Description: p.Description,
+			Required:    p.Required || p.In == "path",
+			Schema: &openapi.Schema{
+				Type:      openapi.Types{p.Type},
+				Format:    p.Format,
+				Enum:      p.Enum,
+				Items:     p.Items,
+				Minimum:   p.Minimum,
+				Maximum:   p.Maximum,
+				MinLength: p.MinLength,
+				MaxLength: p.MaxLength,
+			},
+		})
+	}
+
+	for code, resp := range op.Responses {
+		out.Responses[code] = convertResponse(code, resp, orDefault(op.Produces))
+	}
+	return out
+}
+
+// convertResponse 在描述是 JSON 时把它作为 application/json 的示例，描述改为状态码的标准说明
+func convertResponse(code string, resp *response, produces []string) *openapi.Response {
+	out := &openapi.Response{Description: resp.Description}
+	var example interface{}
+	if trimmed := strings.TrimSpace(resp.Description); strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+		if err := json.Unmarshal([]byte(trimmed), &example); err == nil {
+			out.Description = statusText(code)
+		}
+	}
+	if resp.Schema == nil {
+		return out
+	}
+	rewriteRefs(resp.Schema)
+	out.Content = make(map[string]*openapi.MediaType)
+	for _, mt := range produces {
+		m := &openapi.MediaType{Schema: resp.Schema}
+		if mt == jsonMediaType {
+			m.Example = example
+		}
+		out.Content[mt] = m
+	}
+	return out
+}
+
+// verify 重新加载生成的文档，检查引用和示例
+func verify(data []byte) []string {
+	doc, err := openapi.Load(data)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	var errs []string
+	for path, item := range doc.Paths {
+		for method, op := range item {
+			for code, resp := range op.Responses {
+				mt := resp.Content[jsonMediaType]
+				if mt == nil || mt.Example == nil {
+					continue
+				}
+				if err := doc.ValidateValue(mt.Schema, mt.Example); err != nil {
+					errs = append(errs, fmt.Sprintf("%s %s %s 的示例: %v", strings.ToUpper(method), path, code, err))
+				}
+			}
+		}
+	}
+	sort.Strings(errs)
+	return errs
+}
+
+// rewriteRefs 把 #/definitions/ 的引用改为 #/components/schemas/
+func rewriteRefs(s *openapi.Schema) {
+	if s == nil {
+		return
+	}
+	if strings.HasPrefix(s.Ref, "#/definitions/") {
+		s.Ref = "#/components/schemas/" + strings.TrimPrefix(s.Ref, "#/definitions/")
+	}
+	for _, p := range s.Properties {
+		rewriteRefs(p)
+	}
+	for _, sub := range s.AllOf {
+		rewriteRefs(sub)
+	}
+	rewriteRefs(s.Items)
+	rewriteRefs(s.AdditionalProperties)
+}
+
+func orDefault(types []string) []string {
+	if len(types) == 0 {
+		return []string{jsonMediaType}
+	}
+	return types
+}
+
+func statusText(code string) string {
+	n, err := strconv.Atoi(code)
+	if err != nil || http.StatusText(n) == "" {
+		return code
+	}
+	return http.StatusText(n)
+}
+
+func hasPrefix(name string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(name, p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+// openapi 把 swag 生成的 Swagger 2.0 文档转换为 OpenAPI 3.1 文档，供请求校验中间件使用。
+//
+// 注释中以 JSON 写出的响应示例，如 "{"code":404,"message":"帖子未找到"}"，会作为示例写入文档，
+// 并按该响应的 schema 校验，示例与 schema 不一致时不输出文档并以状态码 1 退出。
+//
+// 用法：
+//
+//	go run ./cmd/openapi [-in docs/swagger.json] [-out docs/openapi.json] [-check]
+//
+// 修改注释并运行 swag init 后，通过 go generate ./docs 重新生成；-check 只检查 -out 是否为最新。
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+)
+
+func main() {
+	in := flag.String("in", "docs/swagger.json", "swag 生成的 Swagger 2.0 文档")
+	out := flag.String("out", "docs/openapi.json", "输出的 OpenAPI 3.1 文档")
+	check := flag.Bool("check", false, "只检查输出文件是否与转换结果一致，不写入")
+	flag.Parse()
+
+	raw, err := os.ReadFile(*in)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "读取文档失败: %v\n", err)
+		os.Exit(1)
+	}
+	var src swagger
+	if err := json.Unmarshal(raw, &src); err != nil {
+		fmt.Fprintf(os.Stderr, "解析 %s 失败: %v\n", *in, err)
+		os.Exit(1)
+	}
+
+	doc := convert(&src)
+	data, err := encode(doc)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "生成文档失败: %v\n", err)
+		os.Exit(1)
+	}
+	if errs := verify(data); len(errs) > 0 {
+		for _, e := range errs {
+			fmt.Fprintln(os.Stderr, e)
+		}
+		fmt.Fprintf(os.Stderr, "%s 中有 %d 处示例与 schema 不一致\n", *in, len(errs))
+		os.Exit(1)
+	}
+
+	if *check {
+		old, err := os.ReadFile(*out)
+		if err != nil || !bytes.Equal(old, data) {
+			fmt.Fprintf(os.Stderr, "%s 不是最新的，请运行 go generate ./docs\n", *out)
+			os.Exit(1)
+		}
+		return
+	}
+	if err := os.WriteFile(*out, data, 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "写入 %s 失败: %v\n", *out, err)
+		os.Exit(1)
+	}
+}
+
+// encode 按键排序输出，不转义 HTML 字符，使文档中的中文和示例保持可读
+func encode(doc interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "    ")
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,11 @@
+package docs
+
+import _ "embed"
+
+//go:generate go run ../cmd/openapi -in swagger.json -out openapi.json
+
+// OpenAPI 是由 swagger.json 转换得到的 OpenAPI 3.1 文档，v1 接口按它校验请求和响应。
+// swag init 不会修改本文件，重新生成 swagger.json 后需要再运行 go generate ./docs
+//
+//go:embed openapi.json
+var OpenAPI []byte
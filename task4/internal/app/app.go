@@ -0,0 +1,316 @@
+// Package app 把配置、日志、数据库、repository、service、handler 和路由组装成一个可以运行的服务。
+// 所有依赖都通过构造函数显式传递，同一进程中可以创建多个互不影响的 App，便于测试。
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/miffyG/golearn/task4/docs"
+	_ "github.com/miffyG/golearn/task4/docs/v2"
+	"github.com/miffyG/golearn/task4/internal/gql"
+	"github.com/miffyG/golearn/task4/internal/handler"
+	v2 "github.com/miffyG/golearn/task4/internal/handler/v2"
+	"github.com/miffyG/golearn/task4/internal/middleware"
+	"github.com/miffyG/golearn/task4/internal/models/entity"
+	"github.com/miffyG/golearn/task4/internal/moderation"
+	"github.com/miffyG/golearn/task4/internal/oidc"
+	"github.com/miffyG/golearn/task4/internal/repository"
+	"github.com/miffyG/golearn/task4/internal/rpc"
+	"github.com/miffyG/golearn/task4/internal/service"
+	"github.com/miffyG/golearn/task4/internal/tokens"
+	"github.com/miffyG/golearn/task4/pkg/config"
+	"github.com/miffyG/golearn/task4/pkg/db"
+	"github.com/miffyG/golearn/task4/pkg/logger"
+	"github.com/miffyG/golearn/task4/pkg/openapi"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"gorm.io/gorm"
+)
+
+// Services 是 App 创建的全部 service，测试可以直接用它们准备数据
+type Services struct {
+	Users      *service.UserService
+	Posts      *service.PostService
+	Comments   *service.CommentService
+	Moderation *service.ModerationService
+	Transfer   *service.TransferService
+	Audit      *service.AuditService
+	Identities *service.IdentityService
+}
+
+// App 是组装好的服务，由 New 创建，Run 或 Serve 运行，Close 释放资源
+type App struct {
+	Config   *config.Config
+	Logger   *zap.Logger
+	DB       *gorm.DB
+	Services *Services
+
+	router *gin.Engine
+	// handler 在 router 之前处理版本协商，对外提供服务时使用它
+	handler http.Handler
+	// grpc 在没有配置 GRPC_ADDR 时为 nil
+	grpc *grpc.Server
+	// grpcLn 不为 nil 时 gRPC 服务在它上面监听，不再按 GRPC_ADDR 监听
+	grpcLn net.Listener
+
+	ownsDB     bool
+	ownsLogger bool
+}
+
+// Option 修改 New 的默认行为
+type Option func(*App)
+
+// WithDB 使用已有的数据库连接代替按 cfg.Db 连接 MySQL，如测试用的 SQLite。App 不负责关闭它
+func WithDB(gormDb *gorm.DB) Option {
+	return func(a *App) { a.DB = gormDb }
+}
+
+// WithLogger 使用已有的日志代替按 cfg.Profile 创建的日志。App 不负责同步它
+func WithLogger(log *zap.Logger) Option {
+	return func(a *App) { a.Logger = log }
+}
+
+// WithGRPCListener 让 gRPC 服务在 ln 上监听，代替 GRPC_ADDR。测试可以传入监听 127.0.0.1:0 的 ln，
+// 多个 App 不会争用同一个端口。GRPC_ADDR 为空时不启动 gRPC 服务，ln 不会被使用
+func WithGRPCListener(ln net.Listener) Option {
+	return func(a *App) { a.grpcLn = ln }
+}
+
+// GinMode 返回 profile 对应的 Gin 运行模式。gin.SetMode 影响整个进程，New 不会调用它，
+// 由程序入口在创建 App 之前设置
+func GinMode(profile string) string {
+	switch profile {
+	case config.ProfileProd:
+		return gin.ReleaseMode
+	case config.ProfileTest:
+		return gin.TestMode
+	}
+	return gin.DebugMode
+}
+
+// New 按 cfg 创建日志、连接数据库并迁移表结构，再依次创建 repository、service、handler 和路由。
+// ctx 取消时停止等待数据库就绪，返回错误时已经创建的资源会被释放
+func New(ctx context.Context, cfg *config.Config, opts ...Option) (_ *App, err error) {
+	a := &App{Config: cfg}
+	for _, opt := range opts {
+		opt(a)
+	}
+	if a.Logger == nil {
+		if a.Logger, err = logger.New(cfg.Profile); err != nil {
+			return nil, fmt.Errorf("日志初始化失败: %w", err)
+		}
+		a.ownsLogger = true
+	}
+	if a.DB == nil {
+		if a.DB, err = db.Open(ctx, &cfg.Db, a.Logger); err != nil {
+			a.Close()
+			return nil, fmt.Errorf("数据库初始化失败: %w", err)
+		}
+		a.ownsDB = true
+	}
+	defer func() {
+		if err != nil {
+			a.Close()
+		}
+	}()
+
+	if err := a.DB.AutoMigrate(&entity.User{}, &entity.Post{}, &entity.Comment{}, &entity.PostRevision{}, &entity.Tag{}, &entity.PostSlug{}, &entity.Report{}, &entity.AuditLog{}, &entity.AuditChainHead{}, &entity.Identity{}, &entity.OIDCFlow{}); err != nil {
+		return nil, fmt.Errorf("数据库自动迁移失败: %w", err)
+	}
+	if err := a.build(); err != nil {
+		return nil, err
+	}
+
+	if n, err := a.Services.Posts.BackfillSlugs(context.Background()); err != nil {
+		return nil, fmt.Errorf("生成文章 slug 失败: %w", err)
+	} else if n > 0 {
+		a.Logger.Sugar().Infof("已为 %d 篇文章生成 slug", n)
+	}
+	return a, nil
+}
+
+// build 创建 repository、service、handler、路由和 gRPC 服务
+func (a *App) build() error {
+	cfg, log := a.Config, a.Logger.Sugar()
+
+	userRepo := repository.NewUserRepository(a.DB)
+	postRepo := repository.NewPostRepository(a.DB)
+	commentRepo := repository.NewCommentRepository(a.DB)
+	transferRepo := repository.NewTransferRepository(a.DB)
+	moderationRepo := repository.NewModerationRepository(a.DB)
+	auditRepo := repository.NewAuditRepository(a.DB)
+	identityRepo := repository.NewIdentityRepository(a.DB)
+	txManager := repository.NewTxManager(a.DB)
+
+	filters, err := moderation.NewChain(&cfg.Moderation)
+	if err != nil {
+		return fmt.Errorf("内容过滤器初始化失败: %w", err)
+	}
+
+	keys, err := tokens.NewKeySet(&cfg.Jwt, cfg.Secret.JwtSecret)
+	if err != nil {
+		return fmt.Errorf("加载 JWT 密钥失败: %w", err)
+	}
+
+	auditService := service.NewAuditService(auditRepo, cfg.Audit.HashChain, log)
+	userService := service.NewUserService(userRepo, txManager, auditService, keys)
+	moderationService := service.NewModerationService(moderationRepo, txManager, filters, auditService)
+	postService := service.NewPostService(postRepo, txManager, moderationService, auditService)
+	commentService := service.NewCommentService(commentRepo, txManager, moderationService, auditService)
+	transferService := service.NewTransferService(transferRepo)
+	identityService := service.NewIdentityService(identityRepo, txManager, userService, auditService)
+	a.Services = &Services{
+		Users:      userService,
+		Posts:      postService,
+		Comments:   commentService,
+		Moderation: moderationService,
+		Transfer:   transferService,
+		Audit:      auditService,
+		Identities: identityService,
+	}
+
+	gqlSchema, err := gql.NewSchema(userService, postService, commentService, cfg.Graphql.MaxDepth, cfg.Graphql.MaxComplexity)
+	if err != nil {
+		return fmt.Errorf("GraphQL schema 构建失败: %w", err)
+	}
+
+	apiDoc, err := openapi.Load(docs.OpenAPI)
+	if err != nil {
+		return fmt.Errorf("加载接口文档失败: %w", err)
+	}
+	validateResponses := cfg.Api.ValidateResponses || cfg.Profile == config.ProfileTest
+
+	a.router = gin.Default()
+	// gin 默认信任所有代理，任何客户端都能用 X-Forwarded-For 伪造审计日志中的 IP
+	if err := a.router.SetTrustedProxies(cfg.Http.TrustedProxies); err != nil {
+		return fmt.Errorf("配置可信代理失败: %w", err)
+	}
+	a.handler = setupRoutes(a.router, cfg, keys, middleware.OpenAPIValidator(apiDoc, cfg.Api.ValidateRequests, validateResponses, log), &handlers{
+		user:       handler.NewAuthHandler(userService, &cfg.AuthCookie, log),
+		post:       handler.NewPostHandler(postService, log),
+		comment:    handler.NewCommentHandler(commentService),
+		admin:      handler.NewAdminHandler(transferService, auditService, &cfg.Api, log),
+		moderation: handler.NewModerationHandler(moderationService, log),
+		feed:       handler.NewFeedHandler(postService, userService, &cfg.Site, log),
+		sitemap:    handler.NewSitemapHandler(postService, &cfg.Site, log),
+		jwks:       handler.NewJWKSHandler(keys),
+		oidc:       handler.NewOIDCHandler(oidc.NewRegistry(&cfg.Oidc, nil), identityService, &cfg.Oidc, &cfg.Site, &cfg.AuthCookie, log),
+		v2:         v2.NewHandler(userService, postService, commentService, keys, log),
+		gql:        gqlSchema,
+	})
+
+	if cfg.Grpc.Addr != "" {
+		a.grpc = rpc.NewServer(userService, postService, commentService, keys, log)
+	}
+	return nil
+}
+
+// Handler 返回 HTTP 路由，可以直接交给 httptest 使用
+func (a *App) Handler() http.Handler {
+	return a.handler
+}
+
+// Run 在 cfg.Http.Addr 上监听并运行服务，直到 ctx 取消或服务出错
+func (a *App) Run(ctx context.Context) error {
+	ln, err := net.Listen("tcp", a.Config.Http.Addr)
+	if err != nil {
+		return fmt.Errorf("HTTP 监听失败: %w", err)
+	}
+	return a.Serve(ctx, ln)
+}
+
+// Serve 在 ln 上提供 HTTP 服务，并按配置启动 gRPC 服务和回收站清理任务。
+// 测试可以传入监听 127.0.0.1:0 的 ln 得到随机端口。ctx 取消后停止接收新请求，
+// 最多等待 cfg.Http.ShutdownTimeout 让进行中的请求完成，然后返回 nil
+func (a *App) Serve(ctx context.Context, ln net.Listener) error {
+	log := a.Logger.Sugar()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errc := make(chan error, 2)
+	if a.grpc != nil {
+		grpcLn := a.grpcLn
+		if grpcLn == nil {
+			var err error
+			if grpcLn, err = net.Listen("tcp", a.Config.Grpc.Addr); err != nil {
+				ln.Close()
+				return fmt.Errorf("gRPC 监听失败: %w", err)
+			}
+		}
+		log.Infof("gRPC 服务启动，监听地址 %s", grpcLn.Addr())
+		go func() {
+			if err := a.grpc.Serve(grpcLn); err != nil {
+				errc <- fmt.Errorf("gRPC 服务出错: %w", err)
+			}
+		}()
+		defer a.grpc.GracefulStop()
+	}
+
+	if trash := a.Config.Trash; trash.PurgeInterval > 0 {
+		go a.purgeTrash(ctx, trash.PurgeInterval, trash.Retention)
+	}
+
+	srv := &http.Server{Handler: a.handler}
+	log.Infof("服务器启动，监听地址 %s", ln.Addr())
+	go func() {
+		if err := srv.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+			errc <- fmt.Errorf("HTTP 服务出错: %w", err)
+		}
+	}()
+
+	select {
+	case <-ctx.Done():
+		log.Info("服务器正在关闭")
+	case err := <-errc:
+		srv.Close()
+		return err
+	}
+	shutdownCtx, stop := context.WithTimeout(context.WithoutCancel(ctx), a.Config.Http.ShutdownTimeout)
+	defer stop()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("HTTP 服务关闭失败: %w", err)
+	}
+	return nil
+}
+
+// purgeTrash 每隔 interval 彻底删除回收站中超过 retention 的文章和评论，直到 ctx 取消
+func (a *App) purgeTrash(ctx context.Context, interval, retention time.Duration) {
+	log := a.Logger.Sugar()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		res, err := a.Services.Posts.Purge(ctx, retention)
+		if err != nil {
+			log.Errorf("清理回收站失败: %v", err)
+			continue
+		}
+		if res.Posts > 0 || res.Comments > 0 {
+			log.Infof("清理回收站：彻底删除 %d 篇文章、%d 条评论", res.Posts, res.Comments)
+		}
+	}
+}
+
+// Close 关闭 New 打开的数据库连接并同步日志，WithDB、WithLogger 传入的资源由调用方负责
+func (a *App) Close() error {
+	var errs []error
+	if a.ownsDB && a.DB != nil {
+		errs = append(errs, db.Close(a.DB))
+		a.DB = nil
+	}
+	if a.ownsLogger && a.Logger != nil {
+		// 日志输出到终端时 Sync 会返回 ENOTTY 等错误，忽略
+		_ = a.Logger.Sync()
+	}
+	return errors.Join(errs...)
+}
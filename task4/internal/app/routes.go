@@ -0,0 +1,161 @@
+package app
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/miffyG/golearn/task4/internal/feed"
+	"github.com/miffyG/golearn/task4/internal/gql"
+	"github.com/miffyG/golearn/task4/internal/handler"
+	v2 "github.com/miffyG/golearn/task4/internal/handler/v2"
+	"github.com/miffyG/golearn/task4/internal/middleware"
+	dtov2 "github.com/miffyG/golearn/task4/internal/models/dto/v2"
+	"github.com/miffyG/golearn/task4/internal/models/entity"
+	"github.com/miffyG/golearn/task4/internal/tokens"
+	"github.com/miffyG/golearn/task4/pkg/config"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+)
+
+// swaggerPolicy 是 swagger 页面的 Content-Security-Policy，页面使用内联的脚本和样式
+const swaggerPolicy = "default-src 'self'; script-src 'self' 'unsafe-inline'; style-src 'self' 'unsafe-inline'; img-src 'self' data:; frame-ancestors 'none'"
+
+// handlers 是 HTTP 路由用到的全部 handler
+type handlers struct {
+	user       *handler.AuthHandler
+	post       *handler.PostHandler
+	comment    *handler.CommentHandler
+	admin      *handler.AdminHandler
+	moderation *handler.ModerationHandler
+	feed       *handler.FeedHandler
+	sitemap    *handler.SitemapHandler
+	jwks       *handler.JWKSHandler
+	oidc       *handler.OIDCHandler
+	v2         *v2.Handler
+	gql        *gql.Schema
+}
+
+// setupRoutes 注册各版本的路由，返回最终处理请求的 handler。v1 和 v2 共用同一组 service，分别使用各自的 handler 和 dto 包；
+// 在 v1 路径上携带 Accept: application/vnd.golearn.v2+json 的请求在路由之前被改写到 v2 的同名路由。
+// /graphql 允许匿名查询，携带合法 token 时可以执行写操作。所有请求都会分配请求 ID，写入响应头和审计日志，
+// 并按路由设置超时时间。token 由 keys 校验，公钥在 /.well-known/jwks.json 公开。v1 的请求和响应由 validator 按 OpenAPI 文档校验。
+// 所有响应都带有安全响应头，跨域请求按 CORS 配置处理，开启 cookie 认证时认证 cookie 在这里转为 Authorization 头
+func setupRoutes(r *gin.Engine, cfg *config.Config, keys *tokens.KeySet, validator gin.HandlerFunc, h *handlers) http.Handler {
+	r.Use(
+		middleware.RequestID(),
+		middleware.SecurityHeaders(&cfg.Headers),
+		middleware.CORS(&cfg.Cors),
+		middleware.CookieAuth(&cfg.AuthCookie),
+		middleware.Timeout(cfg.Timeout.Default, cfg.Timeout.Routes),
+	)
+
+	swaggerCSP := middleware.ContentSecurityPolicy(swaggerPolicy)
+	r.GET("/swagger/*any", swaggerCSP, ginSwagger.WrapHandler(swaggerFiles.Handler))
+	r.GET("/swagger-v2/*any", swaggerCSP, ginSwagger.WrapHandler(swaggerFiles.Handler, ginSwagger.InstanceName("v2")))
+
+	api := r.Group("/api")
+	setupV1Routes(api, &cfg.Api, keys, validator, h)
+	h.v2.RegisterRoutes(api.Group("/v2"))
+
+	setupFeedRoutes(r, h.feed)
+	r.GET("/sitemap.xml", h.sitemap.Sitemap)
+	r.GET("/sitemaps/:name", h.sitemap.SitemapPage)
+	r.GET("/.well-known/jwks.json", h.jwks.JWKS)
+	setupOIDCRoutes(r, keys, h.oidc)
+
+	graphqlHandler := h.gql.Handler()
+	r.GET("/graphql", middleware.OptionalJwtAuth(keys), graphqlHandler)
+	r.POST("/graphql", middleware.OptionalJwtAuth(keys), graphqlHandler)
+
+	return middleware.NegotiateVersion(r, dtov2.MediaType, "/api/v1", "/api/v2")
+}
+
+func setupV1Routes(api *gin.RouterGroup, apiCfg *config.Api, keys *tokens.KeySet, validator gin.HandlerFunc, h *handlers) {
+	userHandler, postHandler, commentHandler := h.user, h.post, h.comment
+	adminHandler, moderationHandler := h.admin, h.moderation
+	v1 := api.Group("/v1")
+	v1.Use(
+		middleware.Deprecated(apiCfg.V1DeprecatedAt, apiCfg.V1Sunset, "/api/v2"),
+		middleware.CompatFields(apiCfg.LegacyFieldNames),
+	)
+	{
+		// validator 在认证之后执行，未登录或权限不足的请求先得到 401/403，不会因为请求体不合法而暴露接口的校验规则
+		authGroup := v1.Group("/auth")
+		authGroup.Use(validator)
+		{
+			authGroup.POST("/register", userHandler.Register)
+			authGroup.POST("/login", userHandler.Login)
+			authGroup.POST("/logout", userHandler.Logout)
+			authGroup.GET("/csrf", userHandler.CSRFToken)
+		}
+
+		public := v1.Group("/")
+		public.Use(validator)
+		{
+			public.GET("/posts", postHandler.GetPosts)
+			public.GET("/posts/:post_id", postHandler.GetPostsById)
+			public.GET("/posts/by-slug/:slug", postHandler.GetPostBySlug)
+			public.GET("/posts/:post_id/comments", commentHandler.GetCommentsByPost)
+		}
+
+		// 作者、审核员和管理员登录后可以查看待审核和已移除文章的修订记录
+		optional := v1.Group("/")
+		optional.Use(middleware.OptionalJwtAuth(keys), validator)
+		{
+			optional.GET("/posts/:post_id/revisions", postHandler.GetRevisions)
+			optional.GET("/posts/:post_id/revisions/:rev", postHandler.GetRevision)
+			optional.GET("/posts/:post_id/revisions/:rev/diff", postHandler.DiffRevisions)
+		}
+
+		protected := v1.Group("/")
+		protected.Use(middleware.JwtAuthMiddleware(keys), validator)
+		{
+			protected.POST("/posts", postHandler.CreatePost)
+			protected.PUT("/posts/:post_id", postHandler.UpdatePost)
+			protected.PATCH("/posts/:post_id", postHandler.PatchPost)
+			protected.DELETE("/posts/:post_id", postHandler.DeletePost)
+			protected.POST("/posts/:post_id/restore", postHandler.RestorePost)
+			protected.GET("/me/trash", postHandler.GetTrash)
+			protected.POST("/posts/:post_id/revisions/:rev/restore", postHandler.RestoreRevision)
+			protected.PUT("/posts/:post_id/tags", postHandler.SetTags)
+			protected.POST("/posts/:post_id/comments", commentHandler.CreateComment)
+			protected.POST("/reports", moderationHandler.CreateReport)
+		}
+
+		admin := v1.Group("/admin")
+		admin.Use(middleware.JwtAuthMiddleware(keys), middleware.RequireRole(userHandler.UserService, entity.RoleAdmin), validator)
+		{
+			admin.GET("/export", adminHandler.Export)
+			admin.POST("/import", adminHandler.Import)
+			admin.GET("/audit", adminHandler.AuditLogs)
+			admin.GET("/audit/verify", adminHandler.VerifyAuditLogs)
+		}
+
+		mod := v1.Group("/moderation")
+		mod.Use(middleware.JwtAuthMiddleware(keys), middleware.RequireRole(userHandler.UserService, entity.RoleModerator, entity.RoleAdmin), validator)
+		{
+			mod.GET("/queue", moderationHandler.Queue)
+			mod.POST("/reports/:report_id/approve", moderationHandler.Approve)
+			mod.POST("/reports/:report_id/remove", moderationHandler.Remove)
+			mod.POST("/reports/:report_id/ban", moderationHandler.BanAuthor)
+		}
+	}
+}
+
+// setupOIDCRoutes 注册第三方登录。GET 的登录入口只用于匿名登录；关联外部账号必须登录后用 POST 发起，
+// 使用 cookie 认证时由 CookieAuth 检查 CSRF 请求头，其他站点无法替用户发起关联
+func setupOIDCRoutes(r *gin.Engine, keys *tokens.KeySet, oidcHandler *handler.OIDCHandler) {
+	r.GET("/auth/oidc", oidcHandler.Providers)
+	r.GET("/auth/oidc/:provider/login", oidcHandler.Login)
+	r.POST("/auth/oidc/:provider/link", middleware.JwtAuthMiddleware(keys), oidcHandler.Link)
+	r.GET("/auth/oidc/:provider/callback", oidcHandler.Callback)
+}
+
+// setupFeedRoutes 注册订阅源，每种范围都提供 rss、atom、json 三种格式
+func setupFeedRoutes(r *gin.Engine, feedHandler *handler.FeedHandler) {
+	for _, format := range []string{feed.FormatRSS, feed.FormatAtom, feed.FormatJSON} {
+		r.GET("/feed."+format, feedHandler.SiteFeed(format))
+		r.GET("/authors/:username/feed."+format, feedHandler.AuthorFeed(format))
+		r.GET("/tags/:tag/feed."+format, feedHandler.TagFeed(format))
+	}
+}
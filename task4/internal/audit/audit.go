@@ -0,0 +1,101 @@
+// Package audit 定义审计日志的动作名、请求信息在 context 中的传递方式和哈希链的计算方法
+package audit
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+
+	"github.com/miffyG/golearn/task4/internal/models/entity"
+)
+
+// 审计日志记录的动作
+const (
+	ActionLogin          = "user.login"
+	ActionLoginFailed    = "user.login_failed"
+	ActionRegister       = "user.register"
+	ActionPasswordReset  = "user.password_reset"
+	ActionRoleChange     = "user.role_change"
+	ActionBan            = "user.ban"
+	ActionUnban          = "user.unban"
+	ActionIdentityLink   = "user.identity_link"
+	ActionPostUpdate     = "post.update"
+	ActionPostDelete     = "post.delete"
+	ActionPostRestore    = "post.restore"
+	ActionPostPurge      = "post.purge"
+	ActionCommentUpdate  = "comment.update"
+	ActionCommentDelete  = "comment.delete"
+	ActionReportApprove  = "moderation.approve"
+	ActionReportRemove   = "moderation.remove"
+	ActionReportBanOwner = "moderation.ban"
+)
+
+// 审计日志的目标类型，文章和评论沿用举报的目标类型
+const (
+	TargetUser    = "user"
+	TargetPost    = entity.ReportTargetPost
+	TargetComment = entity.ReportTargetComment
+	TargetReport  = "report"
+)
+
+// Meta 是写审计日志时需要的请求信息
+type Meta struct {
+	RequestID string
+	ActorID   uint
+	IP        string
+	UserAgent string
+}
+
+type metaKey struct{}
+
+func WithMeta(ctx context.Context, m Meta) context.Context {
+	return context.WithValue(ctx, metaKey{}, m)
+}
+
+// WithActor 在 ctx 已有的请求信息上设置操作人
+func WithActor(ctx context.Context, actorId uint) context.Context {
+	m := MetaFrom(ctx)
+	m.ActorID = actorId
+	return WithMeta(ctx, m)
+}
+
+func MetaFrom(ctx context.Context) Meta {
+	if ctx == nil {
+		return Meta{}
+	}
+	m, _ := ctx.Value(metaKey{}).(Meta)
+	return m
+}
+
+// NewRequestID 生成 32 位十六进制的随机请求 ID
+func NewRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// TimeLayout 是参与哈希计算的时间格式，精确到毫秒以兼容 MySQL 的 datetime(3)
+const TimeLayout = "2006-01-02T15:04:05.000Z"
+
+// ChainHash 计算审计日志在哈希链中的 hash ：对上一条记录的 hash 和本条记录除 ID 外的全部字段做 SHA-256
+func ChainHash(prev string, l *entity.AuditLog) string {
+	fields := []string{
+		prev,
+		l.CreatedAt.UTC().Format(TimeLayout),
+		l.RequestID,
+		strconv.FormatUint(uint64(l.ActorID), 10),
+		l.IP,
+		l.UserAgent,
+		l.Action,
+		l.TargetType,
+		strconv.FormatUint(uint64(l.TargetID), 10),
+		l.Before,
+		l.After,
+		l.Detail,
+	}
+	sum := sha256.Sum256([]byte(strings.Join(fields, "\x1f")))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,87 @@
+package feed
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomEntry struct {
+	Title      string         `xml:"title"`
+	ID         string         `xml:"id"`
+	Link       atomLink       `xml:"link"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+	Author     *atomPerson    `xml:"author,omitempty"`
+	Categories []atomCategory `xml:"category"`
+	Content    atomContent    `xml:"content"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomContent struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+func atomDate(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// emptyFeedUpdated 是没有条目的 Atom 订阅源的 updated。使用固定的时间，内容不变时响应也不变，ETag 保持有效
+var emptyFeedUpdated = time.Unix(0, 0)
+
+func renderAtom(f *Feed) ([]byte, error) {
+	// Atom 要求 feed 必须有 updated
+	updated := f.Updated
+	if updated.IsZero() {
+		updated = emptyFeedUpdated
+	}
+	doc := atomFeed{
+		Title:   f.Title,
+		ID:      f.FeedURL,
+		Updated: atomDate(updated),
+		Links: []atomLink{
+			{Href: f.FeedURL, Rel: "self", Type: "application/atom+xml"},
+			{Href: f.Link, Rel: "alternate"},
+		},
+	}
+	for _, it := range f.Items {
+		entry := atomEntry{
+			Title:     it.Title,
+			ID:        it.ID,
+			Link:      atomLink{Href: it.Link, Rel: "alternate"},
+			Published: atomDate(it.Published),
+			Updated:   atomDate(it.Updated),
+			Content:   atomContent{Type: "text", Value: it.Content},
+		}
+		if it.Author != "" {
+			entry.Author = &atomPerson{Name: it.Author}
+		}
+		for _, tag := range it.Tags {
+			entry.Categories = append(entry.Categories, atomCategory{Term: tag})
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+	return marshalXML(doc)
+}
@@ -0,0 +1,59 @@
+// Package feed 把文章列表渲染为 RSS 2.0、Atom 1.0 和 JSON Feed 1.1 格式的订阅源。
+package feed
+
+import (
+	"errors"
+	"time"
+)
+
+// 订阅源格式
+const (
+	FormatRSS  = "rss"
+	FormatAtom = "atom"
+	FormatJSON = "json"
+)
+
+var ErrUnknownFormat = errors.New("unknown feed format")
+
+// ContentTypes 是各格式响应的 Content-Type
+var ContentTypes = map[string]string{
+	FormatRSS:  "application/rss+xml; charset=utf-8",
+	FormatAtom: "application/atom+xml; charset=utf-8",
+	FormatJSON: "application/feed+json; charset=utf-8",
+}
+
+// Feed 是与格式无关的订阅源
+type Feed struct {
+	Title       string
+	Description string
+	// Link 是订阅源对应的网页地址，FeedURL 是订阅源自身的地址
+	Link    string
+	FeedURL string
+	// Updated 是所有条目中最晚的更新时间，没有条目时为零值
+	Updated time.Time
+	Items   []Item
+}
+
+type Item struct {
+	ID        string
+	Title     string
+	Link      string
+	Content   string
+	Author    string
+	Tags      []string
+	Published time.Time
+	Updated   time.Time
+}
+
+// Render 按格式渲染订阅源
+func Render(f *Feed, format string) ([]byte, error) {
+	switch format {
+	case FormatRSS:
+		return renderRSS(f)
+	case FormatAtom:
+		return renderAtom(f)
+	case FormatJSON:
+		return renderJSON(f)
+	}
+	return nil, ErrUnknownFormat
+}
@@ -0,0 +1,95 @@
+package feed
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "用实际输出覆盖 testdata 中的 golden 文件")
+
+func sampleFeed() *Feed {
+	published := time.Date(2024, 5, 1, 8, 30, 0, 0, time.UTC)
+	updated := time.Date(2024, 5, 2, 9, 0, 0, 0, time.FixedZone("CST", 8*3600))
+	return &Feed{
+		Title:       "示例博客",
+		Description: "最新文章",
+		Link:        "https://blog.example.com/",
+		FeedURL:     "https://blog.example.com/feed.atom",
+		Updated:     updated,
+		Items: []Item{
+			{
+				ID:        "https://blog.example.com/posts/2",
+				Title:     "第二篇 <特殊字符> & 转义",
+				Link:      "https://blog.example.com/posts/2",
+				Content:   "<p>正文</p>",
+				Author:    "alice",
+				Tags:      []string{"go", "博客"},
+				Published: published.Add(time.Hour),
+				Updated:   updated,
+			},
+			{
+				ID:        "https://blog.example.com/posts/1",
+				Title:     "第一篇",
+				Link:      "https://blog.example.com/posts/1",
+				Content:   "没有作者和标签",
+				Published: published,
+				Updated:   published,
+			},
+		},
+	}
+}
+
+func TestRender(t *testing.T) {
+	empty := &Feed{Title: "空订阅源", Link: "https://blog.example.com/", FeedURL: "https://blog.example.com/feed.atom"}
+	tests := []struct {
+		golden string
+		feed   *Feed
+		format string
+	}{
+		{"feed.rss.xml", sampleFeed(), FormatRSS},
+		{"feed.atom.xml", sampleFeed(), FormatAtom},
+		{"empty.rss.xml", empty, FormatRSS},
+		{"empty.atom.xml", empty, FormatAtom},
+	}
+	for _, tc := range tests {
+		t.Run(tc.golden, func(t *testing.T) {
+			got, err := Render(tc.feed, tc.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// 同样的内容必须渲染出同样的字节，否则按响应体计算的 ETag 永远不会命中
+			again, err := Render(tc.feed, tc.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, again) {
+				t.Fatal("两次渲染的结果不同")
+			}
+
+			path := filepath.Join("testdata", tc.golden)
+			if *update {
+				if err := os.WriteFile(path, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("输出与 %s 不一致:\n%s", path, got)
+			}
+		})
+	}
+}
+
+func TestRenderUnknownFormat(t *testing.T) {
+	if _, err := Render(sampleFeed(), "txt"); err != ErrUnknownFormat {
+		t.Errorf("未知格式返回 %v，期望 ErrUnknownFormat", err)
+	}
+}
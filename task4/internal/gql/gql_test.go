@@ -0,0 +1,205 @@
+package gql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/graphql-go/graphql"
+	"github.com/miffyG/golearn/task4/internal/models/entity"
+	"github.com/miffyG/golearn/task4/internal/repository"
+	"github.com/miffyG/golearn/task4/internal/service"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+const (
+	testMaxDepth      = 6
+	testMaxComplexity = 5000
+)
+
+var databases atomic.Int64
+
+// testSchema 在独立的内存数据库上构建 schema，返回的计数器记录执行过的查询语句数
+func testSchema(t *testing.T) (*Schema, *gorm.DB, *atomic.Int64) {
+	t.Helper()
+	dsn := fmt.Sprintf("file:gql%d?mode=memory&cache=shared", databases.Add(1))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: gormlogger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := db.AutoMigrate(&entity.User{}, &entity.Post{}, &entity.Comment{}, &entity.Tag{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 子查询构建时也会以 DryRun 方式经过查询回调，不计入
+	var queries atomic.Int64
+	if err := db.Callback().Query().After("gorm:query").Register("test:count", func(tx *gorm.DB) {
+		if !tx.DryRun {
+			queries.Add(1)
+		}
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	tx := repository.NewTxManager(db)
+	schema, err := NewSchema(
+		service.NewUserService(repository.NewUserRepository(db), tx, nil, nil),
+		service.NewPostService(repository.NewPostRepository(db), tx, nil, nil),
+		service.NewCommentService(repository.NewCommentRepository(db), tx, nil, nil),
+		testMaxDepth, testMaxComplexity,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return schema, db, &queries
+}
+
+// seed 创建 authors 个作者，轮流写 posts 篇已发布的文章，每篇文章 comments 条评论
+func seed(t *testing.T, db *gorm.DB, authors, posts, comments int) {
+	t.Helper()
+	users := make([]entity.User, authors)
+	for i := range users {
+		users[i] = entity.User{UserName: fmt.Sprintf("author%d", i), Password: "x", Email: fmt.Sprintf("author%d@example.com", i)}
+	}
+	if err := db.Create(&users).Error; err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < posts; i++ {
+		post := entity.Post{
+			Title: fmt.Sprintf("Post %d", i), Slug: fmt.Sprintf("post-%d", i), Content: "正文",
+			UserID: users[i%authors].ID, Status: entity.StatusPublished,
+		}
+		if err := db.Create(&post).Error; err != nil {
+			t.Fatal(err)
+		}
+		for j := 0; j < comments; j++ {
+			comment := entity.Comment{Content: fmt.Sprintf("评论 %d", j), UserID: users[j%authors].ID, PostID: post.ID, Status: entity.StatusPublished}
+			if err := db.Create(&comment).Error; err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+}
+
+func execute(t *testing.T, s *Schema, query string) *graphql.Result {
+	t.Helper()
+	res := s.Execute(context.Background(), query, "", nil)
+	if res.HasErrors() {
+		t.Fatalf("查询返回错误: %v", res.Errors)
+	}
+	return res
+}
+
+// TestBatchLoading 检查关联字段的查询次数不随文章数增长：50 篇文章的作者只需要一次查询，评论也只需要一次
+func TestBatchLoading(t *testing.T) {
+	s, db, queries := testSchema(t)
+	seed(t, db, 7, 50, 3)
+
+	count := func(query string) int64 {
+		before := queries.Load()
+		execute(t, s, query)
+		return queries.Load() - before
+	}
+	base := count(`{ posts(pageSize: 50) { items { id } } }`)
+	if n := count(`{ posts(pageSize: 50) { items { id author { id username } } } }`) - base; n != 1 {
+		t.Errorf("解析 50 篇文章的作者执行了 %d 次查询，期望 1 次", n)
+	}
+	if n := count(`{ posts(pageSize: 50) { items { id comments { id author { id } } } } }`) - base; n != 2 {
+		t.Errorf("解析 50 篇文章的评论及评论作者执行了 %d 次查询，期望 2 次", n)
+	}
+}
+
+// TestNestedListFirst 检查关联列表按 first 截断，超过上限时按 maxPageSize 截断
+func TestNestedListFirst(t *testing.T) {
+	s, db, _ := testSchema(t)
+	seed(t, db, 2, 6, 3)
+
+	res := execute(t, s, `{ posts(pageSize: 6) { items { comments(first: 2) { id } author { posts(first: 1) { id } } } } }`)
+	items := res.Data.(map[string]interface{})["posts"].(map[string]interface{})["items"].([]interface{})
+	if len(items) != 6 {
+		t.Fatalf("返回 %d 篇文章，期望 6 篇", len(items))
+	}
+	for _, item := range items {
+		post := item.(map[string]interface{})
+		if n := len(post["comments"].([]interface{})); n != 2 {
+			t.Errorf("comments(first: 2) 返回 %d 条", n)
+		}
+		if n := len(post["author"].(map[string]interface{})["posts"].([]interface{})); n != 1 {
+			t.Errorf("posts(first: 1) 返回 %d 篇", n)
+		}
+	}
+
+	if first := firstParam(map[string]interface{}{"first": 1000}); first != maxPageSize {
+		t.Errorf("first 1000 截断为 %d，期望 %d", first, maxPageSize)
+	}
+}
+
+// TestLimits 检查超过深度或复杂度限制的查询在执行前被拒绝，关联列表的复杂度按 first 计算
+func TestLimits(t *testing.T) {
+	s, _, queries := testSchema(t)
+
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{
+			name:  "within-limits",
+			query: `{ posts { items { id author { id } comments { id } } } }`,
+		},
+		{
+			name:  "too-deep",
+			query: `{ posts { items { author { posts(first: 1) { author { posts(first: 1) { id } } } } } } }`,
+			want:  "查询深度 7 超过限制 6",
+		},
+		{
+			name:  "too-deep-through-fragment",
+			query: `fragment p on Post { author { posts(first: 1) { author { posts(first: 1) { id } } } } } { posts { items { ...p } } }`,
+			want:  "查询深度 7 超过限制 6",
+		},
+		{
+			name:  "too-complex",
+			query: `{ posts(pageSize: 100) { items { comments(first: 100) { id } } } }`,
+			want:  "超过限制 5000",
+		},
+		{
+			// 未传 first 时按 defaultPageSize 计算，不能绕过限制
+			name:  "too-complex-default-first",
+			query: `{ posts(pageSize: 100) { items { comments { id author { id } } } } }`,
+			want:  "超过限制 5000",
+		},
+		{
+			// first 超过上限时按 maxPageSize 计算，与实际返回的条数一致
+			name:  "first-above-cap",
+			query: `{ posts(pageSize: 1) { items { comments(first: 100000) { id } } } }`,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			before := queries.Load()
+			res := s.Execute(context.Background(), tc.query, "", nil)
+			if tc.want == "" {
+				if res.HasErrors() {
+					t.Fatalf("查询返回错误: %v", res.Errors)
+				}
+				return
+			}
+			if len(res.Errors) != 1 || !strings.Contains(res.Errors[0].Message, tc.want) {
+				t.Fatalf("返回错误 %v，期望包含 %q", res.Errors, tc.want)
+			}
+			if n := queries.Load() - before; n != 0 {
+				t.Errorf("被拒绝的查询执行了 %d 次数据库查询", n)
+			}
+		})
+	}
+}
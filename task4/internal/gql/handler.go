@@ -0,0 +1,98 @@
+package gql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+)
+
+type request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// Execute 解析并执行一次 GraphQL 请求：先做语法和 schema 校验，再检查深度和复杂度限制，
+// 最后为本次请求创建新的批量加载器执行查询
+func (s *Schema) Execute(ctx context.Context, query, operationName string, variables map[string]interface{}) *graphql.Result {
+	doc, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{Body: []byte(query), Name: "GraphQL request"})})
+	if err != nil {
+		return &graphql.Result{Errors: gqlerrors.FormatErrors(err)}
+	}
+	if res := graphql.ValidateDocument(&s.schema, doc, nil); !res.IsValid {
+		return &graphql.Result{Errors: res.Errors}
+	}
+	if err := checkLimits(doc, operationName, variables, s.maxDepth, s.maxComplexity); err != nil {
+		return &graphql.Result{Errors: gqlerrors.FormatErrors(err)}
+	}
+	return graphql.Execute(graphql.ExecuteParams{
+		Schema:        s.schema,
+		AST:           doc,
+		OperationName: operationName,
+		Args:          variables,
+		Context:       context.WithValue(ctx, loadersKey{}, s.newLoaders(ctx)),
+	})
+}
+
+// Handler 处理 GET 和 POST /graphql。GET 只能执行查询，参数通过 query、operationName、variables 查询参数传入；
+// 需要在前面挂上可选的 JWT 中间件，登录用户的 ID 从 user_id 读取
+func (s *Schema) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req request
+		if c.Request.Method == http.MethodGet {
+			req.Query = c.Query("query")
+			req.OperationName = c.Query("operationName")
+			if vars := c.Query("variables"); vars != "" {
+				if err := json.Unmarshal([]byte(vars), &req.Variables); err != nil {
+					c.JSON(http.StatusBadRequest, &graphql.Result{Errors: gqlerrors.FormatErrors(err)})
+					return
+				}
+			}
+		} else if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, &graphql.Result{Errors: gqlerrors.FormatErrors(err)})
+			return
+		}
+		if req.Query == "" {
+			c.JSON(http.StatusBadRequest, &graphql.Result{Errors: gqlerrors.FormatErrors(errMissingQuery)})
+			return
+		}
+		if c.Request.Method == http.MethodGet && isMutation(req.Query, req.OperationName) {
+			c.JSON(http.StatusMethodNotAllowed, &graphql.Result{Errors: gqlerrors.FormatErrors(errMutationOverGet)})
+			return
+		}
+
+		ctx := WithViewer(c.Request.Context(), c.GetUint("user_id"))
+		res := s.Execute(ctx, req.Query, req.OperationName, req.Variables)
+		// 超时时部分字段可能已经解析成功，仍然返回结果和错误，只把状态码改为 504
+		status := http.StatusOK
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			status = http.StatusGatewayTimeout
+		}
+		c.JSON(status, res)
+	}
+}
+
+func isMutation(query, operationName string) bool {
+	doc, err := parser.Parse(parser.ParseParams{Source: query})
+	if err != nil {
+		return false
+	}
+	for _, def := range doc.Definitions {
+		op, ok := def.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		if operationName == "" || (op.Name != nil && op.Name.Value == operationName) {
+			return op.Operation == ast.OperationTypeMutation
+		}
+	}
+	return false
+}
@@ -0,0 +1,114 @@
+package gql
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// listFields 是返回列表的字段，复杂度按 pageSize 或 first 参数放大子字段的开销。
+// 参数缺省时按 defaultPageSize，超过 maxPageSize 时按 maxPageSize 计算，与解析器实际返回的条数上限一致
+var listFields = map[string]bool{
+	"posts":    true,
+	"comments": true,
+}
+
+// queryCost 遍历查询文档，计算将要执行的操作的最大深度和复杂度。
+// 每个字段的开销为 1 加上子字段开销乘以列表放大系数，以 __ 开头的内省字段不计入。
+type queryCost struct {
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+	visiting  map[string]bool
+}
+
+func checkLimits(doc *ast.Document, operationName string, variables map[string]interface{}, maxDepth, maxComplexity int) error {
+	qc := &queryCost{
+		fragments: make(map[string]*ast.FragmentDefinition),
+		variables: variables,
+		visiting:  make(map[string]bool),
+	}
+	var op *ast.OperationDefinition
+	for _, def := range doc.Definitions {
+		switch d := def.(type) {
+		case *ast.FragmentDefinition:
+			qc.fragments[d.Name.Value] = d
+		case *ast.OperationDefinition:
+			if op == nil && (operationName == "" || (d.Name != nil && d.Name.Value == operationName)) {
+				op = d
+			}
+		}
+	}
+	if op == nil {
+		return nil
+	}
+
+	depth, complexity := qc.selectionSet(op.SelectionSet)
+	if maxDepth > 0 && depth > maxDepth {
+		return fmt.Errorf("查询深度 %d 超过限制 %d", depth, maxDepth)
+	}
+	if maxComplexity > 0 && complexity > maxComplexity {
+		return fmt.Errorf("查询复杂度 %d 超过限制 %d", complexity, maxComplexity)
+	}
+	return nil
+}
+
+func (qc *queryCost) selectionSet(set *ast.SelectionSet) (depth, complexity int) {
+	if set == nil {
+		return 0, 0
+	}
+	for _, sel := range set.Selections {
+		var d, c int
+		switch s := sel.(type) {
+		case *ast.Field:
+			if len(s.Name.Value) >= 2 && s.Name.Value[:2] == "__" {
+				continue
+			}
+			childDepth, childCost := qc.selectionSet(s.SelectionSet)
+			d = childDepth + 1
+			c = 1 + childCost*qc.listFactor(s)
+		case *ast.InlineFragment:
+			d, c = qc.selectionSet(s.SelectionSet)
+		case *ast.FragmentSpread:
+			name := s.Name.Value
+			frag, ok := qc.fragments[name]
+			if !ok || qc.visiting[name] {
+				continue
+			}
+			qc.visiting[name] = true
+			d, c = qc.selectionSet(frag.SelectionSet)
+			qc.visiting[name] = false
+		}
+		depth = max(depth, d)
+		complexity += c
+	}
+	return depth, complexity
+}
+
+func (qc *queryCost) listFactor(field *ast.Field) int {
+	if !listFields[field.Name.Value] {
+		return 1
+	}
+	n := 0
+	for _, arg := range field.Arguments {
+		if arg.Name.Value != "pageSize" && arg.Name.Value != "first" {
+			continue
+		}
+		switch v := arg.Value.(type) {
+		case *ast.IntValue:
+			n, _ = strconv.Atoi(v.Value)
+		case *ast.Variable:
+			switch val := qc.variables[v.Name.Value].(type) {
+			case float64:
+				n = int(val)
+			case int:
+				n = val
+			}
+		}
+	}
+	// 与 pageParams、firstParam 相同：非正数按默认值，超过上限按上限
+	if n < 1 {
+		return defaultPageSize
+	}
+	return min(n, maxPageSize)
+}
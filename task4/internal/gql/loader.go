@@ -0,0 +1,167 @@
+package gql
+
+import (
+	"context"
+	"sync"
+
+	"github.com/miffyG/golearn/task4/internal/models/entity"
+)
+
+// Loader 在一次请求内合并对同一类数据的按 key 查询。
+// 解析器调用 Load 登记 key 并拿到一个延迟求值的函数，graphql-go 会在同一层字段全部解析完之后才执行这些函数，
+// 第一个被执行的函数把所有已登记但未加载的 key 一次性交给 fetch，因此 50 篇文章的作者只需要一次查询。
+type Loader[K comparable, V any] struct {
+	fetch func(keys []K) (map[K]V, error)
+
+	mu      sync.Mutex
+	pending []K
+	cache   map[K]V
+	errs    map[K]error
+}
+
+func NewLoader[K comparable, V any](fetch func(keys []K) (map[K]V, error)) *Loader[K, V] {
+	return &Loader[K, V]{
+		fetch: fetch,
+		cache: make(map[K]V),
+		errs:  make(map[K]error),
+	}
+}
+
+func (l *Loader[K, V]) Load(key K) func() (V, error) {
+	l.mu.Lock()
+	if _, ok := l.cache[key]; !ok {
+		l.pending = append(l.pending, key)
+	}
+	l.mu.Unlock()
+
+	return func() (V, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if v, ok := l.cache[key]; ok {
+			return v, nil
+		}
+		if err, ok := l.errs[key]; ok {
+			var zero V
+			return zero, err
+		}
+		l.flush()
+		if err, ok := l.errs[key]; ok {
+			var zero V
+			return zero, err
+		}
+		return l.cache[key], nil
+	}
+}
+
+// flush 加载所有待加载的 key，调用方需要持有锁
+func (l *Loader[K, V]) flush() {
+	seen := make(map[K]bool, len(l.pending))
+	keys := make([]K, 0, len(l.pending))
+	for _, k := range l.pending {
+		if _, cached := l.cache[k]; cached || seen[k] {
+			continue
+		}
+		seen[k] = true
+		keys = append(keys, k)
+	}
+	l.pending = nil
+	if len(keys) == 0 {
+		return
+	}
+
+	values, err := l.fetch(keys)
+	for _, k := range keys {
+		if err != nil {
+			l.errs[k] = err
+			continue
+		}
+		// 查不到的 key 缓存为零值，避免重复查询
+		l.cache[k] = values[k]
+	}
+}
+
+// listKey 是关联列表的加载 key：父对象 ID 和每个父对象最多返回的条数
+type listKey struct {
+	ID    uint
+	First int
+}
+
+// groupByFirst 按 First 把 key 分组，每组的父对象 ID 用一次查询加载
+func groupByFirst(keys []listKey) map[int][]uint {
+	groups := make(map[int][]uint)
+	for _, k := range keys {
+		groups[k.First] = append(groups[k.First], k.ID)
+	}
+	return groups
+}
+
+// loaders 是一次请求使用的全部批量加载器
+type loaders struct {
+	users          *Loader[uint, *entity.User]
+	posts          *Loader[uint, *entity.Post]
+	postsByUser    *Loader[listKey, []entity.Post]
+	commentsByPost *Loader[listKey, []entity.Comment]
+}
+
+type loadersKey struct{}
+
+// newLoaders 创建一次请求使用的加载器，批量查询随 ctx 取消
+func (s *Schema) newLoaders(ctx context.Context) *loaders {
+	return &loaders{
+		users: NewLoader(func(ids []uint) (map[uint]*entity.User, error) {
+			users, err := s.userService.GetByIDs(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+			res := make(map[uint]*entity.User, len(users))
+			for i := range users {
+				res[users[i].ID] = &users[i]
+			}
+			return res, nil
+		}),
+		posts: NewLoader(func(ids []uint) (map[uint]*entity.Post, error) {
+			posts, err := s.postService.GetByIDs(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+			res := make(map[uint]*entity.Post, len(posts))
+			for i := range posts {
+				res[posts[i].ID] = &posts[i]
+			}
+			return res, nil
+		}),
+		postsByUser: NewLoader(func(keys []listKey) (map[listKey][]entity.Post, error) {
+			res := make(map[listKey][]entity.Post, len(keys))
+			for first, userIds := range groupByFirst(keys) {
+				posts, err := s.postService.GetByUserIDs(ctx, userIds, first)
+				if err != nil {
+					return nil, err
+				}
+				for _, p := range posts {
+					k := listKey{ID: p.UserID, First: first}
+					res[k] = append(res[k], p)
+				}
+			}
+			return res, nil
+		}),
+		commentsByPost: NewLoader(func(keys []listKey) (map[listKey][]entity.Comment, error) {
+			res := make(map[listKey][]entity.Comment, len(keys))
+			for first, postIds := range groupByFirst(keys) {
+				comments, err := s.commentService.GetByPostIds(ctx, postIds, first)
+				if err != nil {
+					return nil, err
+				}
+				for _, c := range comments {
+					k := listKey{ID: c.PostID, First: first}
+					res[k] = append(res[k], c)
+				}
+			}
+			return res, nil
+		}),
+	}
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	l, _ := ctx.Value(loadersKey{}).(*loaders)
+	return l
+}
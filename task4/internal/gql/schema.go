@@ -0,0 +1,593 @@
+// Package gql 在现有 service 层之上提供 /graphql 接口，暴露 User、Post、Comment 及其关联关系。
+// 关联字段通过请求级的批量加载器解析，避免列表查询时逐条查询作者和评论。
+package gql
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/miffyG/golearn/task4/internal/models/entity"
+	"github.com/miffyG/golearn/task4/internal/service"
+	"github.com/miffyG/golearn/task4/internal/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+type Schema struct {
+	userService    *service.UserService
+	postService    *service.PostService
+	commentService *service.CommentService
+
+	schema        graphql.Schema
+	maxDepth      int
+	maxComplexity int
+}
+
+// NewSchema 构建 GraphQL schema，maxDepth、maxComplexity 为 0 时不做对应的限制
+func NewSchema(userService *service.UserService, postService *service.PostService, commentService *service.CommentService, maxDepth, maxComplexity int) (*Schema, error) {
+	s := &Schema{
+		userService:    userService,
+		postService:    postService,
+		commentService: commentService,
+		maxDepth:       maxDepth,
+		maxComplexity:  maxComplexity,
+	}
+	schema, err := s.build()
+	if err != nil {
+		return nil, err
+	}
+	s.schema = schema
+	return s, nil
+}
+
+var (
+	errMissingQuery    = errors.New("缺少 query 参数")
+	errMutationOverGet = errors.New("GET 请求只能执行查询")
+)
+
+type viewerKey struct{}
+
+// WithViewer 把当前登录用户的 ID 放入上下文，0 表示未登录
+func WithViewer(ctx context.Context, userId uint) context.Context {
+	return context.WithValue(ctx, viewerKey{}, userId)
+}
+
+func viewerFrom(ctx context.Context) uint {
+	id, _ := ctx.Value(viewerKey{}).(uint)
+	return id
+}
+
+func requireViewer(ctx context.Context) (uint, error) {
+	id := viewerFrom(ctx)
+	if id == 0 {
+		return 0, errors.New("未登录")
+	}
+	return id, nil
+}
+
+func (s *Schema) build() (graphql.Schema, error) {
+	var userType, postType, commentType *graphql.Object
+
+	pageArgs := graphql.FieldConfigArgument{
+		"page":     &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 1},
+		"pageSize": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: defaultPageSize},
+	}
+	// 关联列表不分页，只返回前 first 条，超过 maxPageSize 时按 maxPageSize 截断
+	firstArg := &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: defaultPageSize}
+
+	userType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "User",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id": &graphql.Field{
+					Type:    graphql.NewNonNull(graphql.ID),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) { return p.Source.(*entity.User).ID, nil },
+				},
+				"username": &graphql.Field{
+					Type:    graphql.NewNonNull(graphql.String),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) { return p.Source.(*entity.User).UserName, nil },
+				},
+				// 邮箱和手机号只对本人可见
+				"email": &graphql.Field{
+					Type: graphql.String,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						u := p.Source.(*entity.User)
+						if viewerFrom(p.Context) != u.ID {
+							return nil, nil
+						}
+						return u.Email, nil
+					},
+				},
+				"phone": &graphql.Field{
+					Type: graphql.String,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						u := p.Source.(*entity.User)
+						if viewerFrom(p.Context) != u.ID {
+							return nil, nil
+						}
+						return u.Phone, nil
+					},
+				},
+				"createdAt": &graphql.Field{
+					Type:    graphql.NewNonNull(graphql.DateTime),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) { return p.Source.(*entity.User).CreatedAt, nil },
+				},
+				"posts": &graphql.Field{
+					Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(postType))),
+					Description: "用户最新的已发布文章，最多 first 篇",
+					Args:        graphql.FieldConfigArgument{"first": firstArg},
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						key := listKey{ID: p.Source.(*entity.User).ID, First: firstParam(p.Args)}
+						thunk := loadersFrom(p.Context).postsByUser.Load(key)
+						return func() (interface{}, error) {
+							posts, err := thunk()
+							return postPointers(posts), err
+						}, nil
+					},
+				},
+			}
+		}),
+	})
+
+	postType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Post",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id": &graphql.Field{
+					Type:    graphql.NewNonNull(graphql.ID),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) { return p.Source.(*entity.Post).ID, nil },
+				},
+				"title": &graphql.Field{
+					Type:    graphql.NewNonNull(graphql.String),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) { return p.Source.(*entity.Post).Title, nil },
+				},
+				"content": &graphql.Field{
+					Type:    graphql.NewNonNull(graphql.String),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) { return p.Source.(*entity.Post).Content, nil },
+				},
+				"version": &graphql.Field{
+					Type:    graphql.NewNonNull(graphql.Int),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) { return p.Source.(*entity.Post).Version, nil },
+				},
+				"createdAt": &graphql.Field{
+					Type:    graphql.NewNonNull(graphql.DateTime),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) { return p.Source.(*entity.Post).CreatedAt, nil },
+				},
+				"updatedAt": &graphql.Field{
+					Type:    graphql.NewNonNull(graphql.DateTime),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) { return p.Source.(*entity.Post).UpdatedAt, nil },
+				},
+				"author": &graphql.Field{
+					Type: userType,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						post := p.Source.(*entity.Post)
+						thunk := loadersFrom(p.Context).users.Load(post.UserID)
+						return func() (interface{}, error) {
+							u, err := thunk()
+							if u == nil {
+								return nil, err
+							}
+							return u, err
+						}, nil
+					},
+				},
+				"comments": &graphql.Field{
+					Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(commentType))),
+					Description: "文章最早的评论，最多 first 条",
+					Args:        graphql.FieldConfigArgument{"first": firstArg},
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						key := listKey{ID: p.Source.(*entity.Post).ID, First: firstParam(p.Args)}
+						thunk := loadersFrom(p.Context).commentsByPost.Load(key)
+						return func() (interface{}, error) {
+							comments, err := thunk()
+							return commentPointers(comments), err
+						}, nil
+					},
+				},
+			}
+		}),
+	})
+
+	commentType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Comment",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id": &graphql.Field{
+					Type:    graphql.NewNonNull(graphql.ID),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) { return p.Source.(*entity.Comment).ID, nil },
+				},
+				"content": &graphql.Field{
+					Type:    graphql.NewNonNull(graphql.String),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) { return p.Source.(*entity.Comment).Content, nil },
+				},
+				"createdAt": &graphql.Field{
+					Type:    graphql.NewNonNull(graphql.DateTime),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) { return p.Source.(*entity.Comment).CreatedAt, nil },
+				},
+				"updatedAt": &graphql.Field{
+					Type:    graphql.NewNonNull(graphql.DateTime),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) { return p.Source.(*entity.Comment).UpdatedAt, nil },
+				},
+				"author": &graphql.Field{
+					Type: userType,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						thunk := loadersFrom(p.Context).users.Load(p.Source.(*entity.Comment).UserID)
+						return func() (interface{}, error) {
+							u, err := thunk()
+							if u == nil {
+								return nil, err
+							}
+							return u, err
+						}, nil
+					},
+				},
+				"post": &graphql.Field{
+					Type: postType,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						thunk := loadersFrom(p.Context).posts.Load(p.Source.(*entity.Comment).PostID)
+						return func() (interface{}, error) {
+							post, err := thunk()
+							if post == nil {
+								return nil, err
+							}
+							return post, err
+						}, nil
+					},
+				},
+			}
+		}),
+	})
+
+	postPageType := pageType("PostPage", postType)
+	commentPageType := pageType("CommentPage", commentType)
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"posts": &graphql.Field{
+				Type: graphql.NewNonNull(postPageType),
+				Args: pageArgs,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					page, pageSize := pageParams(p.Args)
+					posts, total, err := s.postService.List(p.Context, page, pageSize)
+					if err != nil {
+						return nil, err
+					}
+					return page_{Items: postPointers(posts), Total: total, Page: page, PageSize: pageSize}, nil
+				},
+			},
+			"post": &graphql.Field{
+				Type: postType,
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					id, err := idArg(p.Args, "id")
+					if err != nil {
+						return nil, err
+					}
+					post, err := s.postService.GetByID(p.Context, id)
+					return nilIfNotFound(post, err)
+				},
+			},
+			"comments": &graphql.Field{
+				Type: graphql.NewNonNull(commentPageType),
+				Args: graphql.FieldConfigArgument{
+					"postId":   &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+					"page":     pageArgs["page"],
+					"pageSize": pageArgs["pageSize"],
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					postId, err := idArg(p.Args, "postId")
+					if err != nil {
+						return nil, err
+					}
+					page, pageSize := pageParams(p.Args)
+					comments, total, err := s.commentService.List(p.Context, postId, page, pageSize)
+					if err != nil {
+						return nil, err
+					}
+					return page_{Items: commentPointers(comments), Total: total, Page: page, PageSize: pageSize}, nil
+				},
+			},
+			"user": &graphql.Field{
+				Type: userType,
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					id, err := idArg(p.Args, "id")
+					if err != nil {
+						return nil, err
+					}
+					user, err := s.userService.GetByID(p.Context, id)
+					return nilIfNotFound(user, err)
+				},
+			},
+			"me": &graphql.Field{
+				Type: userType,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					id := viewerFrom(p.Context)
+					if id == 0 {
+						return nil, nil
+					}
+					user, err := s.userService.GetByID(p.Context, id)
+					return nilIfNotFound(user, err)
+				},
+			},
+		},
+	})
+
+	mutation := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createPost": &graphql.Field{
+				Type: graphql.NewNonNull(postType),
+				Args: graphql.FieldConfigArgument{
+					"title":   &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"content": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					userId, err := requireViewer(p.Context)
+					if err != nil {
+						return nil, err
+					}
+					title, content := p.Args["title"].(string), p.Args["content"].(string)
+					if strings.TrimSpace(title) == "" || strings.TrimSpace(content) == "" {
+						return nil, errors.New("标题和内容不能为空")
+					}
+					post := &entity.Post{Title: title, Content: content, UserID: userId}
+					if err := s.postService.Create(p.Context, post); err != nil {
+						return nil, err
+					}
+					return post, nil
+				},
+			},
+			"updatePost": &graphql.Field{
+				Type:        graphql.NewNonNull(postType),
+				Description: "只修改传入的字段；传入 version 时作为乐观锁条件",
+				Args: graphql.FieldConfigArgument{
+					"id":      &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+					"title":   &graphql.ArgumentConfig{Type: graphql.String},
+					"content": &graphql.ArgumentConfig{Type: graphql.String},
+					"version": &graphql.ArgumentConfig{Type: graphql.Int},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					userId, err := requireViewer(p.Context)
+					if err != nil {
+						return nil, err
+					}
+					id, err := idArg(p.Args, "id")
+					if err != nil {
+						return nil, err
+					}
+					var patch service.PostPatch
+					if title, ok := p.Args["title"].(string); ok {
+						if strings.TrimSpace(title) == "" {
+							return nil, errors.New("标题不能为空")
+						}
+						patch.Title = &title
+					}
+					if content, ok := p.Args["content"].(string); ok {
+						if strings.TrimSpace(content) == "" {
+							return nil, errors.New("内容不能为空")
+						}
+						patch.Content = &content
+					}
+					var version uint
+					if v, ok := p.Args["version"].(int); ok && v > 0 {
+						version = uint(v)
+					}
+					post, err := s.postService.Patch(p.Context, userId, id, patch, utils.ExpectVersion(version))
+					if err != nil {
+						return nil, mapServiceError(err)
+					}
+					return post, nil
+				},
+			},
+			"deletePost": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Boolean),
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					userId, err := requireViewer(p.Context)
+					if err != nil {
+						return nil, err
+					}
+					id, err := idArg(p.Args, "id")
+					if err != nil {
+						return nil, err
+					}
+					if err := s.postService.Delete(p.Context, userId, id); err != nil {
+						return nil, mapServiceError(err)
+					}
+					return true, nil
+				},
+			},
+			"createComment": &graphql.Field{
+				Type: graphql.NewNonNull(commentType),
+				Args: graphql.FieldConfigArgument{
+					"postId":  &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+					"content": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					userId, err := requireViewer(p.Context)
+					if err != nil {
+						return nil, err
+					}
+					postId, err := idArg(p.Args, "postId")
+					if err != nil {
+						return nil, err
+					}
+					content := p.Args["content"].(string)
+					if strings.TrimSpace(content) == "" {
+						return nil, errors.New("评论内容不能为空")
+					}
+					comment := &entity.Comment{Content: content, UserID: userId, PostID: postId}
+					if err := s.commentService.Create(p.Context, comment); err != nil {
+						return nil, err
+					}
+					return comment, nil
+				},
+			},
+			"updateComment": &graphql.Field{
+				Type: graphql.NewNonNull(commentType),
+				Args: graphql.FieldConfigArgument{
+					"id":      &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+					"content": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					userId, err := requireViewer(p.Context)
+					if err != nil {
+						return nil, err
+					}
+					id, err := idArg(p.Args, "id")
+					if err != nil {
+						return nil, err
+					}
+					content := p.Args["content"].(string)
+					if strings.TrimSpace(content) == "" {
+						return nil, errors.New("评论内容不能为空")
+					}
+					comment, err := s.commentService.Update(p.Context, userId, id, content)
+					if err != nil {
+						return nil, mapServiceError(err)
+					}
+					return comment, nil
+				},
+			},
+			"deleteComment": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Boolean),
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					userId, err := requireViewer(p.Context)
+					if err != nil {
+						return nil, err
+					}
+					id, err := idArg(p.Args, "id")
+					if err != nil {
+						return nil, err
+					}
+					if err := s.commentService.Delete(p.Context, userId, id); err != nil {
+						return nil, mapServiceError(err)
+					}
+					return true, nil
+				},
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query, Mutation: mutation})
+}
+
+// page_ 是分页查询结果，字段名与 pageType 中的字段一一对应
+type page_ struct {
+	Items    interface{}
+	Total    int64
+	Page     int
+	PageSize int
+}
+
+func pageType(name string, item *graphql.Object) *graphql.Object {
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name: name,
+		Fields: graphql.Fields{
+			"items": &graphql.Field{
+				Type:    graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(item))),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) { return p.Source.(page_).Items, nil },
+			},
+			"total": &graphql.Field{
+				Type:    graphql.NewNonNull(graphql.Int),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) { return p.Source.(page_).Total, nil },
+			},
+			"page": &graphql.Field{
+				Type:    graphql.NewNonNull(graphql.Int),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) { return p.Source.(page_).Page, nil },
+			},
+			"pageSize": &graphql.Field{
+				Type:    graphql.NewNonNull(graphql.Int),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) { return p.Source.(page_).PageSize, nil },
+			},
+		},
+	})
+}
+
+func pageParams(args map[string]interface{}) (int, int) {
+	page, _ := args["page"].(int)
+	pageSize, _ := args["pageSize"].(int)
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = defaultPageSize
+	}
+	return page, min(pageSize, maxPageSize)
+}
+
+// firstParam 返回关联列表的 first 参数，缺省或非法时取 defaultPageSize，最大为 maxPageSize
+func firstParam(args map[string]interface{}) int {
+	first, _ := args["first"].(int)
+	if first < 1 {
+		first = defaultPageSize
+	}
+	return min(first, maxPageSize)
+}
+
+func idArg(args map[string]interface{}, name string) (uint, error) {
+	s, _ := args[name].(string)
+	id, err := strconv.ParseUint(s, 10, 64)
+	if err != nil || id == 0 {
+		return 0, errors.New("无效的 " + name)
+	}
+	return uint(id), nil
+}
+
+func nilIfNotFound[T any](v *T, err error) (interface{}, error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil || v == nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func mapServiceError(err error) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return errors.New("资源不存在")
+	case errors.Is(err, service.ErrPreconditionFailed):
+		return errors.New("版本不匹配")
+	case errors.Is(err, service.ErrContentRejected):
+		return errors.New("内容未通过审核")
+	case err.Error() == "unauthorized":
+		return errors.New("没有权限")
+	}
+	return err
+}
+
+func postPointers(posts []entity.Post) []*entity.Post {
+	res := make([]*entity.Post, len(posts))
+	for i := range posts {
+		res[i] = &posts[i]
+	}
+	return res
+}
+
+func commentPointers(comments []entity.Comment) []*entity.Comment {
+	res := make([]*entity.Comment, len(comments))
+	for i := range comments {
+		res[i] = &comments[i]
+	}
+	return res
+}
@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/miffyG/golearn/task4/internal/middleware"
+	"github.com/miffyG/golearn/task4/internal/models/dto"
+	"github.com/miffyG/golearn/task4/internal/service"
+	"github.com/miffyG/golearn/task4/pkg/config"
+	"go.uber.org/zap"
+)
+
+// exportBufferSize 是导出时缓冲的字节数，超过后响应开始输出
+const exportBufferSize = 64 * 1024
+
+type AdminHandler struct {
+	transferService *service.TransferService
+	auditService    *service.AuditService
+	cfg             *config.Api
+	log             *zap.SugaredLogger
+}
+
+func NewAdminHandler(s *service.TransferService, audit *service.AuditService, cfg *config.Api, log *zap.SugaredLogger) *AdminHandler {
+	return &AdminHandler{transferService: s, auditService: audit, cfg: cfg, log: log}
+}
+
+// @Summary 导出数据
+// @Description 以 NDJSON 或单个 JSON 文档流式导出用户（包括已删除的用户）和未删除的文章、评论，仅管理员可用
+// @Tags admin
+// @Produce json
+// @Produce application/x-ndjson
+// @Param format query string false "导出格式：ndjson（默认）或 json"
+// @Param include_passwords query bool false "是否导出密码哈希"
+// @Success 200 {file} file "导出文件"
+// @Failure 400 {object} dto.ErrorResponse "{"code":400,"message":"参数错误"}"
+// @Failure 401 {object} dto.ErrorResponse "{"code":401,"message":"未授权"}"
+// @Failure 403 {object} dto.ErrorResponse "{"code":403,"message":"没有权限"}"
+// @Failure 500 {object} dto.ErrorResponse "{"code":500,"message":"导出失败"}"
+// @Failure 504 {object} dto.ErrorResponse "{"code":504,"message":"请求超时"}"
+// @Security BearerAuth
+// @Router /admin/export [get]
+func (h *AdminHandler) Export(c *gin.Context) {
+	format := c.DefaultQuery("format", dto.TransferFormatNDJSON)
+	contentType := "application/x-ndjson"
+	switch format {
+	case dto.TransferFormatNDJSON:
+	case dto.TransferFormatJSON:
+		contentType = "application/json"
+	default:
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Code:    400,
+			Message: "参数错误",
+		})
+		return
+	}
+
+	filename := fmt.Sprintf("blog-export-%s.%s", time.Now().UTC().Format("20060102T150405Z"), format)
+	c.Header("Content-Type", contentType+"; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	// 输出先写入缓冲区，缓冲区写满前出错（如第一次查询超时）时响应还没有开始，可以返回错误状态码
+	buf := bufio.NewWriterSize(c.Writer, exportBufferSize)
+	opts := service.ExportOptions{Format: format, IncludePasswords: c.Query("include_passwords") == "true"}
+	err := h.transferService.Export(c.Request.Context(), buf, opts)
+	if err == nil {
+		err = buf.Flush()
+	}
+	if err != nil {
+		h.log.Errorf("导出数据失败: %v", err)
+		if !c.Writer.Written() {
+			c.Writer.Header().Del("Content-Type")
+			c.Writer.Header().Del("Content-Disposition")
+			respondInternalError(c, err, "导出失败")
+			return
+		}
+		// 响应已经开始输出，只能中断连接
+		c.Abort()
+		return
+	}
+	h.log.Infof("管理员 %s 导出数据成功", middleware.CurrentUser(c).UserName)
+}
+
+// @Summary 导入数据
+// @Description 导入 export 接口导出的数据，按自然键新建或更新记录，整个导入在一个事务中完成，仅管理员可用
+// @Tags admin
+// @Accept json
+// @Accept application/x-ndjson
+// @Produce json
+// @Param format query string false "数据格式：ndjson（默认）或 json"
+// @Param dry_run query bool false "只校验并统计，不写入数据库"
+// @Success 200 {object} dto.Response{data=dto.ImportResult} "导入成功"
+// @Failure 400 {object} dto.ErrorResponse "{"code":400,"message":"导入数据格式错误"}"
+// @Failure 401 {object} dto.ErrorResponse "{"code":401,"message":"未授权"}"
+// @Failure 403 {object} dto.ErrorResponse "{"code":403,"message":"没有权限"}"
+// @Failure 413 {object} dto.ErrorResponse "{"code":413,"message":"导入数据过大"}"
+// @Failure 500 {object} dto.ErrorResponse "{"code":500,"message":"导入失败"}"
+// @Failure 504 {object} dto.ErrorResponse "{"code":504,"message":"请求超时"}"
+// @Security BearerAuth
+// @Router /admin/import [post]
+func (h *AdminHandler) Import(c *gin.Context) {
+	format := c.DefaultQuery("format", dto.TransferFormatNDJSON)
+	if format != dto.TransferFormatNDJSON && format != dto.TransferFormatJSON {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Code:    400,
+			Message: "参数错误",
+		})
+		return
+	}
+
+	// 导入在一个事务中完成，限制请求体的大小，避免过大的文件长时间占用事务
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, h.cfg.ImportMaxBytes)
+	admin := middleware.CurrentUser(c).UserName
+	result, err := h.transferService.Import(c.Request.Context(), body, service.ImportOptions{
+		Format: format,
+		DryRun: c.Query("dry_run") == "true",
+		Progress: func(processed int) {
+			h.log.Infof("管理员 %s 导入数据，已处理 %d 条记录", admin, processed)
+		},
+	})
+	if err != nil {
+		h.log.Errorf("导入数据失败: %v", err)
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, dto.ErrorResponse{
+				Code:    413,
+				Message: fmt.Sprintf("导入数据过大，不能超过 %d 字节", tooLarge.Limit),
+			})
+			return
+		}
+		if errors.Is(err, service.ErrInvalidTransferData) {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+				Code:    400,
+				Message: "导入数据格式错误: " + err.Error(),
+			})
+			return
+		}
+		respondInternalError(c, err, "导入失败")
+		return
+	}
+	c.JSON(http.StatusOK, dto.Response{
+		Code:    200,
+		Message: "导入成功",
+		Data:    result,
+	})
+}
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/miffyG/golearn/task4/internal/models/dto"
+	"github.com/miffyG/golearn/task4/internal/repository"
+)
+
+type AuditQuery struct {
+	ActorID    uint      `form:"actor_id"`
+	Action     string    `form:"action"`
+	TargetType string    `form:"target_type"`
+	TargetID   uint      `form:"target_id"`
+	RequestID  string    `form:"request_id"`
+	Since      time.Time `form:"since" time_format:"2006-01-02T15:04:05Z07:00"`
+	Until      time.Time `form:"until" time_format:"2006-01-02T15:04:05Z07:00"`
+	Page       int       `form:"page,default=1" binding:"min=1"`
+	PageSize   int       `form:"page_size,default=20" binding:"min=1,max=100"`
+}
+
+// @Summary 查询审计日志
+// @Description 按操作人、动作、目标、请求 ID 和时间范围分页查询审计日志，按时间倒序，仅管理员可用
+// @Tags admin
+// @Produce json
+// @Param actor_id query int false "操作人ID"
+// @Param action query string false "动作，如 user.login、post.delete"
+// @Param target_type query string false "目标类型：user、post、comment"
+// @Param target_id query int false "目标ID"
+// @Param request_id query string false "请求ID"
+// @Param since query string false "起始时间（含），RFC3339 格式"
+// @Param until query string false "结束时间（不含），RFC3339 格式"
+// @Param page query int false "页码，从 1 开始"
+// @Param page_size query int false "每页数量，最大 100"
+// @Success 200 {object} dto.Response{data=dto.AuditLogListResponse} "查询审计日志成功"
+// @Failure 400 {object} dto.ErrorResponse "{"code":400,"message":"参数错误"}"
+// @Failure 401 {object} dto.ErrorResponse "{"code":401,"message":"未授权"}"
+// @Failure 403 {object} dto.ErrorResponse "{"code":403,"message":"没有权限"}"
+// @Failure 500 {object} dto.ErrorResponse "{"code":500,"message":"查询审计日志失败"}"
+// @Failure 504 {object} dto.ErrorResponse "{"code":504,"message":"请求超时"}"
+// @Security BearerAuth
+// @Router /admin/audit [get]
+func (h *AdminHandler) AuditLogs(c *gin.Context) {
+	var query AuditQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Code:    400,
+			Message: "参数错误",
+		})
+		return
+	}
+
+	filter := repository.AuditFilter{
+		ActorID:    query.ActorID,
+		Action:     query.Action,
+		TargetType: query.TargetType,
+		TargetID:   query.TargetID,
+		RequestID:  query.RequestID,
+		Since:      query.Since,
+		Until:      query.Until,
+	}
+	logs, total, err := h.auditService.Search(c.Request.Context(), filter, query.Page, query.PageSize)
+	if err != nil {
+		h.log.Errorf("查询审计日志失败: %v", err)
+		respondInternalError(c, err, "查询审计日志失败")
+		return
+	}
+
+	respondOK(c, "查询审计日志成功", dto.NewAuditLogListResponse(logs, total))
+}
+
+// @Summary 校验审计日志
+// @Description 重新计算审计日志的哈希链，检查记录是否被修改、删除或插入，仅管理员可用
+// @Tags admin
+// @Produce json
+// @Success 200 {object} dto.Response{data=service.AuditVerifyResult} "校验完成"
+// @Failure 401 {object} dto.ErrorResponse "{"code":401,"message":"未授权"}"
+// @Failure 403 {object} dto.ErrorResponse "{"code":403,"message":"没有权限"}"
+// @Failure 500 {object} dto.ErrorResponse "{"code":500,"message":"校验审计日志失败"}"
+// @Failure 504 {object} dto.ErrorResponse "{"code":504,"message":"请求超时"}"
+// @Security BearerAuth
+// @Router /admin/audit/verify [get]
+func (h *AdminHandler) VerifyAuditLogs(c *gin.Context) {
+	res, err := h.auditService.Verify(c.Request.Context())
+	if err != nil {
+		h.log.Errorf("校验审计日志失败: %v", err)
+		respondInternalError(c, err, "校验审计日志失败")
+		return
+	}
+
+	respondOK(c, "校验完成", res)
+}
@@ -3,26 +3,30 @@ package handler
 import (
 	"errors"
 	"net/http"
-	"regexp"
 
 	"github.com/gin-gonic/gin"
-	"github.com/gin-gonic/gin/binding"
-	"github.com/go-playground/validator/v10"
+	"github.com/miffyG/golearn/task4/internal/middleware"
 	"github.com/miffyG/golearn/task4/internal/models/dto"
 	"github.com/miffyG/golearn/task4/internal/models/entity"
 	"github.com/miffyG/golearn/task4/internal/service"
-	"github.com/miffyG/golearn/task4/pkg/logger"
+	"github.com/miffyG/golearn/task4/internal/utils"
+	"github.com/miffyG/golearn/task4/pkg/config"
+	"go.uber.org/zap"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
 type AuthHandler struct {
 	UserService *service.UserService
+	cookie      *config.AuthCookie
+	log         *zap.SugaredLogger
 }
 
-func NewAuthHandler(s *service.UserService) *AuthHandler {
+func NewAuthHandler(s *service.UserService, cookie *config.AuthCookie, log *zap.SugaredLogger) *AuthHandler {
 	return &AuthHandler{
 		UserService: s,
+		cookie:      cookie,
+		log:         log,
 	}
 }
 
@@ -33,18 +37,8 @@ type RegisterRequest struct {
 	Phone    string `json:"phone" form:"phone" binding:"phone"`
 }
 
-var phone validator.Func = func(fl validator.FieldLevel) bool {
-	phone := fl.Field().String()
-	if phone == "" {
-		return true // 空值通过
-	}
-	return regexp.MustCompile(`^(\+86)?1[3-9]\d{9}$`).MatchString(phone)
-}
-
 func init() {
-	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
-		v.RegisterValidation("phone", phone)
-	}
+	utils.RegisterValidators()
 }
 
 // @Summary 用户注册
@@ -53,14 +47,15 @@ func init() {
 // @Accept json
 // @Produce json
 // @Param user body RegisterRequest true "用户信息"
-// @Success 200 {object} dto.Response "{"code":200,"data":{"user_id":1},"msg":"注册成功"}"
-// @Failure 400 {object} dto.ErrorResponse "{"code":400,"msg":"参数错误或密码设置失败"}"
-// @Failure 500 {object} dto.ErrorResponse "{"code":500,"msg":"注册失败"}"
+// @Success 200 {object} dto.Response "{"code":200,"data":{"user_id":1},"message":"注册成功"}"
+// @Failure 400 {object} dto.ErrorResponse "{"code":400,"message":"参数错误或密码设置失败"}"
+// @Failure 500 {object} dto.ErrorResponse "{"code":500,"message":"注册失败"}"
+// @Failure 504 {object} dto.ErrorResponse "{"code":504,"message":"请求超时"}"
 // @Router /auth/register [post]
 func (h *AuthHandler) Register(c *gin.Context) {
 	var req RegisterRequest
 	if err := c.ShouldBind(&req); err != nil {
-		logger.Sugar.Errorf("参数错误: %v", err)
+		h.log.Errorf("参数错误: %v", err)
 		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
 			Code:    400,
 			Message: "参数错误或密码设置失败",
@@ -73,16 +68,13 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		Password: req.Password,
 		Email:    req.Email,
 	}
-	if err := h.UserService.Register(&user); err != nil {
-		logger.Sugar.Errorf("用户注册失败: %v", err)
-		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
-			Code:    500,
-			Message: "注册失败",
-		})
+	if err := h.UserService.Register(c.Request.Context(), &user); err != nil {
+		h.log.Errorf("用户注册失败: %v", err)
+		respondInternalError(c, err, "注册失败")
 		return
 	}
 
-	logger.Sugar.Infof("用户注册成功: id %s name: %s", user.UserName, user.ID)
+	h.log.Infof("用户注册成功: id %s name: %s", user.UserName, user.ID)
 	c.JSON(http.StatusOK, dto.Response{
 		Code:    200,
 		Message: "注册成功",
@@ -96,20 +88,23 @@ type LoginRequest struct {
 }
 
 // @Summary 用户登录
-// @Description 用户登录接口
+// @Description 用户登录接口。开启 cookie 认证时同时写入 HttpOnly 的认证 cookie 和 CSRF cookie，
+// @Description 响应的 csrf_token 需要在之后的写请求中通过 X-CSRF-Token 请求头带上
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param user body LoginRequest true "用户信息"
-// @Success 200 {object} dto.Response "{"code":200,"data":{"token":"xxx"},"msg":"登录成功"}"
-// @Failure 400 {object} dto.ErrorResponse "{"code":400,"msg":"参数错误"}"
-// @Failure 401 {object} dto.ErrorResponse "{"code":401,"msg":"无效的凭证"}"
-// @Failure 500 {object} dto.ErrorResponse "{"code":500,"msg":"登录失败"}"
+// @Success 200 {object} dto.Response "{"code":200,"data":{"token":"xxx","csrf_token":"yyy"},"message":"登录成功"}"
+// @Failure 400 {object} dto.ErrorResponse "{"code":400,"message":"参数错误"}"
+// @Failure 401 {object} dto.ErrorResponse "{"code":401,"message":"无效的凭证"}"
+// @Failure 403 {object} dto.ErrorResponse "{"code":403,"message":"用户已被封禁"}"
+// @Failure 500 {object} dto.ErrorResponse "{"code":500,"message":"登录失败"}"
+// @Failure 504 {object} dto.ErrorResponse "{"code":504,"message":"请求超时"}"
 // @Router /auth/login [post]
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req LoginRequest
 	if err := c.ShouldBind(&req); err != nil {
-		logger.Sugar.Errorf("参数错误: %v", err)
+		h.log.Errorf("参数错误: %v", err)
 		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
 			Code:    400,
 			Message: "参数错误",
@@ -117,36 +112,90 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	token, user, err := h.UserService.Login(req.Username, req.Password)
+	token, user, err := h.UserService.Login(c.Request.Context(), req.Username, req.Password)
 
 	if err != nil {
-		logger.Sugar.Errorf("用户登录失败: %v", err)
+		h.log.Errorf("用户登录失败: %v", err)
 		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) || errors.Is(err, gorm.ErrRecordNotFound) {
 			c.JSON(http.StatusUnauthorized, dto.ErrorResponse{
 				Code:    401,
 				Message: "用户不存在或密码错误",
 			})
-		} else {
-			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
-				Code:    500,
-				Message: "登录失败",
+		} else if errors.Is(err, service.ErrUserBanned) {
+			c.JSON(http.StatusForbidden, dto.ErrorResponse{
+				Code:    403,
+				Message: "用户已被封禁",
 			})
+		} else {
+			respondInternalError(c, err, "登录失败")
 		}
 		return
 	}
 	if user == nil || token == "" {
-		logger.Sugar.Warnf("用户或token为空！")
-		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
-			Code:    500,
-			Message: "登录失败",
-		})
+		h.log.Warnf("用户或token为空！")
+		respondInternalError(c, err, "登录失败")
 		return
 	}
 
-	logger.Sugar.Infof("用户登录成功: id %s name: %s", user.UserName, user.ID)
+	data := map[string]interface{}{"token": token}
+	if h.cookie.Enabled {
+		csrf, err := middleware.SetAuthCookies(c, h.cookie, token)
+		if err != nil {
+			respondInternalError(c, err, "登录失败")
+			return
+		}
+		data["csrf_token"] = csrf
+	}
+
+	h.log.Infof("用户登录成功: id %s name: %s", user.UserName, user.ID)
 	c.JSON(http.StatusOK, dto.Response{
 		Code:    200,
 		Message: "登录成功",
-		Data:    map[string]interface{}{"token": token},
+		Data:    data,
+	})
+}
+
+// @Summary 退出登录
+// @Description 删除 cookie 认证写入的认证 cookie 和 CSRF cookie，使用 Authorization 头时由客户端自行丢弃 token
+// @Tags auth
+// @Produce json
+// @Success 200 {object} dto.Response "{"code":200,"message":"已退出登录"}"
+// @Router /auth/logout [post]
+func (h *AuthHandler) Logout(c *gin.Context) {
+	if h.cookie.Enabled {
+		middleware.ClearAuthCookies(c, h.cookie)
+	}
+	c.JSON(http.StatusOK, dto.Response{
+		Code:    200,
+		Message: "已退出登录",
+	})
+}
+
+// @Summary 获取 CSRF token
+// @Description 返回当前的 CSRF token，没有时生成新的并写入 cookie，用于页面刷新后重新取得 token。未开启 cookie 认证时返回 404
+// @Tags auth
+// @Produce json
+// @Success 200 {object} dto.Response "{"code":200,"data":{"csrf_token":"yyy"},"message":"获取成功"}"
+// @Failure 404 {object} dto.ErrorResponse "{"code":404,"message":"未开启 cookie 认证"}"
+// @Failure 500 {object} dto.ErrorResponse "{"code":500,"message":"生成 CSRF token 失败"}"
+// @Failure 504 {object} dto.ErrorResponse "{"code":504,"message":"请求超时"}"
+// @Router /auth/csrf [get]
+func (h *AuthHandler) CSRFToken(c *gin.Context) {
+	if !h.cookie.Enabled {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{
+			Code:    404,
+			Message: "未开启 cookie 认证",
+		})
+		return
+	}
+	csrf, err := middleware.CSRFToken(c, h.cookie)
+	if err != nil {
+		respondInternalError(c, err, "生成 CSRF token 失败")
+		return
+	}
+	c.JSON(http.StatusOK, dto.Response{
+		Code:    200,
+		Message: "获取成功",
+		Data:    map[string]interface{}{"csrf_token": csrf},
 	})
 }
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 
@@ -29,9 +30,13 @@ type CreateCommentRequest struct {
 // @Produce json
 // @Param post_id path int true "帖子ID"
 // @Param comment body CreateCommentRequest true "评论信息"
-// @Success 200 {object} dto.Response "{"code":200,"data":{"id":1,"content":"内容","user_id":1,"post_id":1},"msg":"创建评论成功"}"
-// @Failure 400 {object} dto.ErrorResponse "{"code":400,"msg":"参数错误"}"
-// @Failure 500 {object} dto.ErrorResponse "{"code":500,"msg":"创建评论失败"}"
+// @Success 200 {object} dto.Response{data=dto.CommentResponse} "创建评论成功，status 为 pending 时需要等待审核"
+// @Failure 400 {object} dto.ErrorResponse "{"code":400,"message":"参数错误"}"
+// @Failure 401 {object} dto.ErrorResponse "{"code":401,"message":"未授权"}"
+// @Failure 422 {object} dto.ErrorResponse "{"code":422,"message":"内容未通过审核"}"
+// @Failure 500 {object} dto.ErrorResponse "{"code":500,"message":"创建评论失败"}"
+// @Failure 504 {object} dto.ErrorResponse "{"code":504,"message":"请求超时"}"
+// @Security BearerAuth
 // @Router /posts/{post_id}/comments [post]
 func (h *CommentHandler) CreateComment(c *gin.Context) {
 	var req CreateCommentRequest
@@ -58,19 +63,23 @@ func (h *CommentHandler) CreateComment(c *gin.Context) {
 		UserID:  userId,
 		PostID:  postId,
 	}
-	if err := h.service.Create(&comment); err != nil {
-		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
-			Code:    500,
-			Message: "创建评论失败",
-		})
+	if err := h.service.Create(c.Request.Context(), &comment); err != nil {
+		if errors.Is(err, service.ErrContentRejected) {
+			c.JSON(http.StatusUnprocessableEntity, dto.ErrorResponse{
+				Code:    422,
+				Message: "内容未通过审核",
+			})
+			return
+		}
+		respondInternalError(c, err, "创建评论失败")
 		return
 	}
 
-	c.JSON(http.StatusOK, dto.Response{
-		Code:    200,
-		Message: "创建评论成功",
-		Data:    comment,
-	})
+	if comment.Status == entity.StatusPending {
+		respondOK(c, "评论已提交，审核通过后发布", dto.NewCommentResponse(&comment))
+		return
+	}
+	respondOK(c, "创建评论成功", dto.NewCommentResponse(&comment))
 }
 
 // @Summary 获取帖子评论
@@ -79,8 +88,9 @@ func (h *CommentHandler) CreateComment(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param post_id path int true "帖子ID"
-// @Success 200 {object} dto.Response "{"code":200,"data":[{"id":1,"content":"内容","user_id":1,"post_id":1}],"msg":""}"
-// @Failure 500 {object} dto.ErrorResponse "{"code":500,"msg":"获取评论失败"}"
+// @Success 200 {object} dto.Response{data=[]dto.CommentResponse} "获取评论成功"
+// @Failure 500 {object} dto.ErrorResponse "{"code":500,"message":"获取评论失败"}"
+// @Failure 504 {object} dto.ErrorResponse "{"code":504,"message":"请求超时"}"
 // @Router /posts/{post_id}/comments [get]
 func (h *CommentHandler) GetCommentsByPost(c *gin.Context) {
 	pidStr := c.Param("post_id")
@@ -92,26 +102,11 @@ func (h *CommentHandler) GetCommentsByPost(c *gin.Context) {
 		})
 		return
 	}
-	comments, err := h.service.GetByPostId(postId)
+	comments, err := h.service.GetByPostId(c.Request.Context(), postId)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
-			Code:    http.StatusInternalServerError,
-			Message: "获取评论失败",
-		})
+		respondInternalError(c, err, "获取评论失败")
 		return
 	}
 
-	var commentRes []dto.CommentResponse
-	for _, comment := range comments {
-		commentRes = append(commentRes, dto.CommentResponse{
-			Content: comment.Content,
-			UserID:  comment.UserID,
-			PostID:  comment.PostID,
-		})
-	}
-	c.JSON(http.StatusOK, dto.Response{
-		Code:    http.StatusOK,
-		Message: "",
-		Data:    commentRes,
-	})
+	respondOK(c, "", dto.NewCommentResponses(comments))
 }
@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/miffyG/golearn/task4/internal/feed"
+	"github.com/miffyG/golearn/task4/internal/models/dto"
+	"github.com/miffyG/golearn/task4/internal/models/entity"
+	"github.com/miffyG/golearn/task4/internal/service"
+	"github.com/miffyG/golearn/task4/internal/utils"
+	"github.com/miffyG/golearn/task4/pkg/config"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// FeedHandler 输出全站、作者和标签的订阅源，路由形如 /feed.rss、/authors/:username/feed.atom、/tags/:tag/feed.json
+type FeedHandler struct {
+	postService *service.PostService
+	userService *service.UserService
+	site        *config.Site
+	log         *zap.SugaredLogger
+}
+
+func NewFeedHandler(postService *service.PostService, userService *service.UserService, site *config.Site, log *zap.SugaredLogger) *FeedHandler {
+	return &FeedHandler{
+		postService: postService,
+		userService: userService,
+		site:        site,
+		log:         log,
+	}
+}
+
+// SiteFeed 返回全站最新文章的订阅源
+func (h *FeedHandler) SiteFeed(format string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		posts, err := h.postService.Feed(c.Request.Context(), 0, "", h.site.FeedSize)
+		h.render(c, format, h.site.Title, fmt.Sprintf("/feed.%s", format), posts, err)
+	}
+}
+
+// AuthorFeed 返回某个作者最新文章的订阅源
+func (h *FeedHandler) AuthorFeed(format string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		username := c.Param("username")
+		user, err := h.userService.GetByUsername(c.Request.Context(), username)
+		var posts []entity.Post
+		if err == nil {
+			posts, err = h.postService.Feed(c.Request.Context(), user.ID, "", h.site.FeedSize)
+		}
+		title := fmt.Sprintf("%s - %s", h.site.Title, username)
+		h.render(c, format, title, fmt.Sprintf("/authors/%s/feed.%s", username, format), posts, err)
+	}
+}
+
+// TagFeed 返回某个标签下最新文章的订阅源
+func (h *FeedHandler) TagFeed(format string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tag := c.Param("tag")
+		posts, err := h.postService.Feed(c.Request.Context(), 0, tag, h.site.FeedSize)
+		title := fmt.Sprintf("%s - #%s", h.site.Title, tag)
+		h.render(c, format, title, fmt.Sprintf("/tags/%s/feed.%s", tag, format), posts, err)
+	}
+}
+
+// render 构造并输出订阅源。ETag 由渲染结果计算，文章被删除、下架或修改后都会变化；
+// Last-Modified 取最新的文章更新时间，删除文章不会让它变晚，所以只在请求没有 If-None-Match 时才按
+// If-Modified-Since 判断（RFC 9110 13.2.2），未修改时返回 304
+func (h *FeedHandler) render(c *gin.Context, format, title, path string, posts []entity.Post, err error) {
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{
+				Code:    404,
+				Message: "订阅源不存在",
+			})
+			return
+		}
+		h.log.Errorf("获取订阅源失败: %v", err)
+		respondInternalError(c, err, "获取订阅源失败")
+		return
+	}
+
+	f := h.build(title, path, posts)
+	body, err := feed.Render(f, format)
+	if err != nil {
+		h.log.Errorf("生成订阅源失败: %v", err)
+		respondInternalError(c, err, "获取订阅源失败")
+		return
+	}
+	etag := utils.ContentETag(body)
+	c.Header("ETag", etag)
+	if !f.Updated.IsZero() {
+		c.Header("Last-Modified", f.Updated.UTC().Format(http.TimeFormat))
+	}
+	notModified := utils.NotModifiedSince(c.GetHeader("If-Modified-Since"), f.Updated)
+	if ifNoneMatch := c.GetHeader("If-None-Match"); ifNoneMatch != "" {
+		notModified = utils.MatchesETag(ifNoneMatch, etag)
+	}
+	if notModified {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, feed.ContentTypes[format], body)
+}
+
+func (h *FeedHandler) build(title, path string, posts []entity.Post) *feed.Feed {
+	f := &feed.Feed{
+		Title:       title,
+		Description: title,
+		Link:        h.site.URL,
+		FeedURL:     h.site.URL + path,
+	}
+	for _, p := range posts {
+		link := postURL(h.site.URL, p.ID, p.Slug)
+		item := feed.Item{
+			ID:        link,
+			Title:     p.Title,
+			Link:      link,
+			Content:   p.Content,
+			Tags:      dto.TagNames(p.Tags),
+			Published: p.CreatedAt,
+			Updated:   p.UpdatedAt,
+		}
+		if p.User != nil {
+			item.Author = p.User.UserName
+		}
+		if p.UpdatedAt.After(f.Updated) {
+			f.Updated = p.UpdatedAt
+		}
+		f.Items = append(f.Items, item)
+	}
+	return f
+}
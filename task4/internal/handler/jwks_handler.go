@@ -0,0 +1,26 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/miffyG/golearn/task4/internal/tokens"
+)
+
+// jwksMaxAge 是 JWKS 的缓存时间。轮换密钥时新密钥要先加入 JWT_KEYS 的校验密钥并等待一个缓存周期，再改为签名密钥
+const jwksMaxAge = "max-age=300"
+
+// JWKSHandler 公开校验 token 的公钥
+type JWKSHandler struct {
+	keys *tokens.KeySet
+}
+
+func NewJWKSHandler(keys *tokens.KeySet) *JWKSHandler {
+	return &JWKSHandler{keys: keys}
+}
+
+// JWKS 处理 /.well-known/jwks.json ，返回全部非对称密钥的公钥，使用 HS256 时 keys 为空
+func (h *JWKSHandler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, "+jwksMaxAge)
+	c.JSON(http.StatusOK, h.keys.JWKS())
+}
@@ -0,0 +1,218 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/miffyG/golearn/task4/internal/models/dto"
+	"github.com/miffyG/golearn/task4/internal/models/entity"
+	"github.com/miffyG/golearn/task4/internal/service"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type ModerationHandler struct {
+	service *service.ModerationService
+	log     *zap.SugaredLogger
+}
+
+func NewModerationHandler(service *service.ModerationService, log *zap.SugaredLogger) *ModerationHandler {
+	return &ModerationHandler{service: service, log: log}
+}
+
+type CreateReportRequest struct {
+	TargetType string `json:"target_type" binding:"required,oneof=post comment"`
+	TargetID   uint   `json:"target_id" binding:"required"`
+	Reason     string `json:"reason" binding:"required,max=500"`
+}
+
+type ModerationQueueQuery struct {
+	Page     int `form:"page,default=1" binding:"min=1"`
+	PageSize int `form:"page_size,default=20" binding:"min=1,max=100"`
+}
+
+// @Summary 举报内容
+// @Description 举报已发布的帖子或评论，对同一内容重复举报时返回之前尚未处理的举报
+// @Tags moderation
+// @Accept json
+// @Produce json
+// @Param report body CreateReportRequest true "举报信息"
+// @Success 200 {object} dto.Response{data=dto.ReportResponse} "举报成功"
+// @Failure 400 {object} dto.ErrorResponse "{"code":400,"message":"参数错误"}"
+// @Failure 401 {object} dto.ErrorResponse "{"code":401,"message":"未授权"}"
+// @Failure 404 {object} dto.ErrorResponse "{"code":404,"message":"举报的内容不存在"}"
+// @Failure 500 {object} dto.ErrorResponse "{"code":500,"message":"举报失败"}"
+// @Failure 504 {object} dto.ErrorResponse "{"code":504,"message":"请求超时"}"
+// @Security BearerAuth
+// @Router /reports [post]
+func (h *ModerationHandler) CreateReport(c *gin.Context) {
+	var req CreateReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Code:    400,
+			Message: "参数错误",
+		})
+		return
+	}
+
+	report, err := h.service.Report(c.Request.Context(), c.GetUint("user_id"), req.TargetType, req.TargetID, req.Reason)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{
+				Code:    404,
+				Message: "举报的内容不存在",
+			})
+			return
+		}
+		h.log.Errorf("举报失败: %v", err)
+		respondInternalError(c, err, "举报失败")
+		return
+	}
+
+	respondOK(c, "举报成功", dto.NewReportResponse(report))
+}
+
+// @Summary 获取审核队列
+// @Description 按时间顺序分页列出未处理的举报和被过滤器拦截的内容，需要 moderator 或 admin 角色
+// @Tags moderation
+// @Accept json
+// @Produce json
+// @Param page query int false "页码，从 1 开始"
+// @Param page_size query int false "每页数量，最大 100"
+// @Success 200 {object} dto.Response{data=dto.ModerationQueueResponse} "获取审核队列成功"
+// @Failure 400 {object} dto.ErrorResponse "{"code":400,"message":"参数错误"}"
+// @Failure 401 {object} dto.ErrorResponse "{"code":401,"message":"未授权"}"
+// @Failure 403 {object} dto.ErrorResponse "{"code":403,"message":"没有权限"}"
+// @Failure 500 {object} dto.ErrorResponse "{"code":500,"message":"获取审核队列失败"}"
+// @Failure 504 {object} dto.ErrorResponse "{"code":504,"message":"请求超时"}"
+// @Security BearerAuth
+// @Router /moderation/queue [get]
+func (h *ModerationHandler) Queue(c *gin.Context) {
+	var query ModerationQueueQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Code:    400,
+			Message: "参数错误",
+		})
+		return
+	}
+	items, total, err := h.service.Queue(c.Request.Context(), query.Page, query.PageSize)
+	if err != nil {
+		h.log.Errorf("获取审核队列失败: %v", err)
+		respondInternalError(c, err, "获取审核队列失败")
+		return
+	}
+
+	res := dto.ModerationQueueResponse{Total: total, Items: make([]dto.ModerationItemResponse, len(items))}
+	for i, item := range items {
+		res.Items[i].Report = dto.NewReportResponse(&item.Report)
+		if t := item.Target; t != nil {
+			res.Items[i].Target = &dto.ModerationTargetResponse{
+				AuthorID: t.AuthorID,
+				Title:    t.Title,
+				Content:  t.Content,
+				Status:   t.Status,
+			}
+		}
+	}
+	respondOK(c, "获取审核队列成功", res)
+}
+
+// @Summary 通过审核
+// @Description 保留举报针对的内容，待审核的内容会被发布，这条内容的全部举报一起关闭
+// @Tags moderation
+// @Accept json
+// @Produce json
+// @Param report_id path int true "举报ID"
+// @Success 200 {object} dto.Response{data=dto.ReportResponse} "处理成功"
+// @Failure 400 {object} dto.ErrorResponse "{"code":400,"message":"参数错误"}"
+// @Failure 401 {object} dto.ErrorResponse "{"code":401,"message":"未授权"}"
+// @Failure 404 {object} dto.ErrorResponse "{"code":404,"message":"举报或内容不存在"}"
+// @Failure 409 {object} dto.ErrorResponse "{"code":409,"message":"举报已被处理"}"
+// @Failure 500 {object} dto.ErrorResponse "{"code":500,"message":"处理举报失败"}"
+// @Failure 504 {object} dto.ErrorResponse "{"code":504,"message":"请求超时"}"
+// @Security BearerAuth
+// @Router /moderation/reports/{report_id}/approve [post]
+func (h *ModerationHandler) Approve(c *gin.Context) {
+	h.resolve(c, h.service.Approve)
+}
+
+// @Summary 移除内容
+// @Description 移除举报针对的内容，这条内容的全部举报一起关闭
+// @Tags moderation
+// @Accept json
+// @Produce json
+// @Param report_id path int true "举报ID"
+// @Success 200 {object} dto.Response{data=dto.ReportResponse} "处理成功"
+// @Failure 400 {object} dto.ErrorResponse "{"code":400,"message":"参数错误"}"
+// @Failure 401 {object} dto.ErrorResponse "{"code":401,"message":"未授权"}"
+// @Failure 404 {object} dto.ErrorResponse "{"code":404,"message":"举报或内容不存在"}"
+// @Failure 409 {object} dto.ErrorResponse "{"code":409,"message":"举报已被处理"}"
+// @Failure 500 {object} dto.ErrorResponse "{"code":500,"message":"处理举报失败"}"
+// @Failure 504 {object} dto.ErrorResponse "{"code":504,"message":"请求超时"}"
+// @Security BearerAuth
+// @Router /moderation/reports/{report_id}/remove [post]
+func (h *ModerationHandler) Remove(c *gin.Context) {
+	h.resolve(c, h.service.Remove)
+}
+
+// @Summary 移除内容并封禁作者
+// @Description 移除举报针对的内容并封禁内容的作者，被封禁的用户不能再登录
+// @Tags moderation
+// @Accept json
+// @Produce json
+// @Param report_id path int true "举报ID"
+// @Success 200 {object} dto.Response{data=dto.ReportResponse} "处理成功"
+// @Failure 400 {object} dto.ErrorResponse "{"code":400,"message":"参数错误"}"
+// @Failure 401 {object} dto.ErrorResponse "{"code":401,"message":"未授权"}"
+// @Failure 403 {object} dto.ErrorResponse "{"code":403,"message":"不能封禁管理员、审核员或自己"}"
+// @Failure 404 {object} dto.ErrorResponse "{"code":404,"message":"举报或内容不存在"}"
+// @Failure 409 {object} dto.ErrorResponse "{"code":409,"message":"举报已被处理"}"
+// @Failure 500 {object} dto.ErrorResponse "{"code":500,"message":"处理举报失败"}"
+// @Failure 504 {object} dto.ErrorResponse "{"code":504,"message":"请求超时"}"
+// @Security BearerAuth
+// @Router /moderation/reports/{report_id}/ban [post]
+func (h *ModerationHandler) BanAuthor(c *gin.Context) {
+	h.resolve(c, h.service.BanAuthor)
+}
+
+func (h *ModerationHandler) resolve(c *gin.Context, action func(ctx context.Context, moderatorId, reportId uint) (*entity.Report, error)) {
+	var reportId uint
+	if _, err := fmt.Sscanf(c.Param("report_id"), "%d", &reportId); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Code:    400,
+			Message: "参数错误",
+		})
+		return
+	}
+
+	report, err := action(c.Request.Context(), c.GetUint("user_id"), reportId)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{
+				Code:    404,
+				Message: "举报或内容不存在",
+			})
+		case errors.Is(err, service.ErrBanForbidden):
+			c.JSON(http.StatusForbidden, dto.ErrorResponse{
+				Code:    403,
+				Message: "不能封禁管理员、审核员或自己",
+			})
+		case errors.Is(err, service.ErrReportClosed):
+			c.JSON(http.StatusConflict, dto.ErrorResponse{
+				Code:    409,
+				Message: "举报已被处理",
+			})
+		default:
+			h.log.Errorf("处理举报失败: %v", err)
+			respondInternalError(c, err, "处理举报失败")
+		}
+		return
+	}
+
+	respondOK(c, "处理成功", dto.NewReportResponse(report))
+}
@@ -0,0 +1,238 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/miffyG/golearn/task4/internal/middleware"
+	"github.com/miffyG/golearn/task4/internal/models/dto"
+	"github.com/miffyG/golearn/task4/internal/oidc"
+	"github.com/miffyG/golearn/task4/internal/service"
+	"github.com/miffyG/golearn/task4/pkg/config"
+	"go.uber.org/zap"
+)
+
+const (
+	// oidcFlowCookie 把登录流程与发起登录的浏览器绑定，值为 state，回调时必须与参数中的 state 一致
+	oidcFlowCookie = "blog_oidc"
+	oidcCookiePath = "/auth/oidc/"
+)
+
+// OIDCHandler 处理第三方登录。回调地址登记在提供方处，不随接口版本变化，所以路由不在 /api 下
+type OIDCHandler struct {
+	providers  *oidc.Registry
+	identities *service.IdentityService
+	cfg        *config.Oidc
+	site       *config.Site
+	cookie     *config.AuthCookie
+	log        *zap.SugaredLogger
+}
+
+func NewOIDCHandler(providers *oidc.Registry, identities *service.IdentityService, cfg *config.Oidc, site *config.Site, cookie *config.AuthCookie, log *zap.SugaredLogger) *OIDCHandler {
+	return &OIDCHandler{
+		providers:  providers,
+		identities: identities,
+		cfg:        cfg,
+		site:       site,
+		cookie:     cookie,
+		log:        log,
+	}
+}
+
+// Providers 处理 /auth/oidc ，返回可以使用的提供方名称，前端据此显示登录按钮
+func (h *OIDCHandler) Providers(c *gin.Context) {
+	c.JSON(http.StatusOK, dto.Response{
+		Code:    200,
+		Message: "获取成功",
+		Data:    map[string]interface{}{"providers": h.providers.Names()},
+	})
+}
+
+// Login 处理 GET /auth/oidc/:provider/login ：开始匿名登录并跳转到提供方的授权页面。
+// 即使请求带有 token 也不会关联账号，跨站的链接或跳转无法把攻击者的外部账号关联到当前用户
+func (h *OIDCHandler) Login(c *gin.Context) {
+	authURL, ok := h.start(c, 0)
+	if !ok {
+		return
+	}
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// Link 处理 POST /auth/oidc/:provider/link ：已登录的用户开始关联外部账号，回调后把外部账号关联到当前用户。
+// 路由要求登录，使用 cookie 认证时必须带上 CSRF 请求头；返回授权页面的地址，由前端跳转
+func (h *OIDCHandler) Link(c *gin.Context) {
+	authURL, ok := h.start(c, c.GetUint("user_id"))
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, dto.Response{
+		Code:    200,
+		Message: "获取成功",
+		Data:    map[string]interface{}{"authorization_url": authURL},
+	})
+}
+
+// start 生成 state、nonce 和 code_verifier 并保存，state 同时写入 cookie，返回提供方的授权地址。
+// linkUserID 不为 0 时回调后关联到该用户。login_hint 参数原样转给提供方，出错时已写入响应，返回 false
+func (h *OIDCHandler) start(c *gin.Context, linkUserID uint) (string, bool) {
+	p, ok := h.provider(c)
+	if !ok {
+		return "", false
+	}
+	flow, err := oidc.NewFlow()
+	if err != nil {
+		respondInternalError(c, err, "登录失败")
+		return "", false
+	}
+	authURL, err := p.AuthCodeURL(c.Request.Context(), flow, h.redirectURI(p.Name), c.Query("login_hint"))
+	if err != nil {
+		h.log.Errorf("获取 %s 的登录地址失败: %v", p.Name, err)
+		c.JSON(http.StatusBadGateway, dto.ErrorResponse{
+			Code:    502,
+			Message: "登录提供方不可用",
+		})
+		return "", false
+	}
+	if err := h.identities.StartFlow(c.Request.Context(), p.Name, flow, linkUserID, h.cfg.FlowTTL); err != nil {
+		h.log.Errorf("保存登录流程失败: %v", err)
+		respondInternalError(c, err, "登录失败")
+		return "", false
+	}
+	h.setFlowCookie(c, flow.State, int(h.cfg.FlowTTL.Seconds()))
+	return authURL, true
+}
+
+// Callback 处理提供方的回调 /auth/oidc/:provider/callback ：校验 state，用授权码换取并校验 ID token，
+// 登录或创建关联的用户后签发 token。开启 cookie 认证时同时写入认证 cookie，配置了 OIDC_SUCCESS_URL 时跳转到前端
+func (h *OIDCHandler) Callback(c *gin.Context) {
+	p, ok := h.provider(c)
+	if !ok {
+		return
+	}
+	state := c.Query("state")
+	bound, _ := c.Cookie(oidcFlowCookie)
+	h.setFlowCookie(c, "", -1)
+	if state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(bound)) != 1 {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Code:    400,
+			Message: "登录状态校验失败，请重新登录",
+		})
+		return
+	}
+	flow, linkUserID, err := h.identities.TakeFlow(c.Request.Context(), p.Name, state)
+	if err != nil {
+		if errors.Is(err, service.ErrFlowNotFound) {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+				Code:    400,
+				Message: "登录已过期或已完成，请重新登录",
+			})
+			return
+		}
+		respondInternalError(c, err, "登录失败")
+		return
+	}
+	if reason := c.Query("error"); reason != "" {
+		h.log.Infof("%s 拒绝了登录: %s %s", p.Name, reason, c.Query("error_description"))
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponse{
+			Code:    401,
+			Message: "登录提供方拒绝了登录: " + reason,
+		})
+		return
+	}
+
+	identity, err := p.Exchange(c.Request.Context(), c.Query("code"), h.redirectURI(p.Name), flow)
+	if err != nil {
+		h.log.Errorf("%s 登录失败: %v", p.Name, err)
+		if errors.Is(err, oidc.ErrInvalidIDToken) {
+			c.JSON(http.StatusUnauthorized, dto.ErrorResponse{
+				Code:    401,
+				Message: "登录提供方返回的身份无效",
+			})
+		} else if errors.Is(err, oidc.ErrProvider) {
+			c.JSON(http.StatusBadGateway, dto.ErrorResponse{
+				Code:    502,
+				Message: "登录提供方不可用",
+			})
+		} else {
+			respondInternalError(c, err, "登录失败")
+		}
+		return
+	}
+
+	result, err := h.identities.Login(c.Request.Context(), identity, linkUserID)
+	if err != nil {
+		if errors.Is(err, service.ErrIdentityLinked) {
+			c.JSON(http.StatusConflict, dto.ErrorResponse{
+				Code:    409,
+				Message: "该账号已关联其他用户",
+			})
+		} else if errors.Is(err, service.ErrUserBanned) {
+			c.JSON(http.StatusForbidden, dto.ErrorResponse{
+				Code:    403,
+				Message: "用户已被封禁",
+			})
+		} else {
+			h.log.Errorf("%s 登录失败: %v", p.Name, err)
+			respondInternalError(c, err, "登录失败")
+		}
+		return
+	}
+
+	data := map[string]interface{}{
+		"token":    result.Token,
+		"user_id":  result.User.ID,
+		"username": result.User.UserName,
+		"new_user": result.Created,
+		"linked":   result.Linked,
+	}
+	if h.cookie.Enabled {
+		csrf, err := middleware.SetAuthCookies(c, h.cookie, result.Token)
+		if err != nil {
+			respondInternalError(c, err, "登录失败")
+			return
+		}
+		if h.cfg.SuccessURL != "" {
+			c.Redirect(http.StatusFound, h.cfg.SuccessURL)
+			return
+		}
+		data["csrf_token"] = csrf
+	}
+	h.log.Infof("用户通过 %s 登录成功: id %d name: %s", p.Name, result.User.ID, result.User.UserName)
+	c.JSON(http.StatusOK, dto.Response{
+		Code:    200,
+		Message: "登录成功",
+		Data:    data,
+	})
+}
+
+// provider 返回路由参数中的提供方，不存在时返回 404
+func (h *OIDCHandler) provider(c *gin.Context) (*oidc.Provider, bool) {
+	p, ok := h.providers.Get(c.Param("provider"))
+	if !ok {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{
+			Code:    404,
+			Message: "未知的登录提供方",
+		})
+	}
+	return p, ok
+}
+
+// redirectURI 返回在提供方处登记的回调地址
+func (h *OIDCHandler) redirectURI(provider string) string {
+	return h.site.URL + oidcCookiePath + provider + "/callback"
+}
+
+// setFlowCookie 写入或删除登录流程的 cookie。提供方通过顶层跳转回调，SameSite 必须是 Lax
+func (h *OIDCHandler) setFlowCookie(c *gin.Context, state string, maxAge int) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     oidcFlowCookie,
+		Value:    state,
+		Path:     oidcCookiePath,
+		MaxAge:   maxAge,
+		Secure:   h.cookie.Secure,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
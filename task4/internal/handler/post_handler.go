@@ -8,17 +8,20 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/miffyG/golearn/task4/internal/models/dto"
 	"github.com/miffyG/golearn/task4/internal/models/entity"
+	"github.com/miffyG/golearn/task4/internal/repository"
 	"github.com/miffyG/golearn/task4/internal/service"
-	"github.com/miffyG/golearn/task4/pkg/logger"
+	"github.com/miffyG/golearn/task4/internal/utils"
+	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
 type PostHandler struct {
 	service *service.PostService
+	log     *zap.SugaredLogger
 }
 
-func NewPostHandler(s *service.PostService) *PostHandler {
-	return &PostHandler{service: s}
+func NewPostHandler(s *service.PostService, log *zap.SugaredLogger) *PostHandler {
+	return &PostHandler{service: s, log: log}
 }
 
 type CreatePostRequest struct {
@@ -32,14 +35,18 @@ type CreatePostRequest struct {
 // @Accept json
 // @Produce json
 // @Param post body CreatePostRequest true "帖子信息"
-// @Success 200 {object} dto.Response "{"code":200,"data":{"post_id":1},"msg":"创建帖子成功"}"
-// @Failure 400 {object} dto.ErrorResponse "{"code":400,"msg":"参数错误"}"
-// @Failure 500 {object} dto.ErrorResponse "{"code":500,"msg":"创建帖子失败"}"
+// @Success 200 {object} dto.Response{data=dto.PostResponse} "创建帖子成功，status 为 pending 时需要等待审核"
+// @Failure 400 {object} dto.ErrorResponse "{"code":400,"message":"参数错误"}"
+// @Failure 401 {object} dto.ErrorResponse "{"code":401,"message":"未授权"}"
+// @Failure 422 {object} dto.ErrorResponse "{"code":422,"message":"内容未通过审核"}"
+// @Failure 500 {object} dto.ErrorResponse "{"code":500,"message":"创建帖子失败"}"
+// @Failure 504 {object} dto.ErrorResponse "{"code":504,"message":"请求超时"}"
+// @Security BearerAuth
 // @Router /posts [post]
 func (h *PostHandler) CreatePost(c *gin.Context) {
 	var req CreatePostRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		logger.Sugar.Errorf("参数错误: %v", err)
+		h.log.Errorf("参数错误: %v", err)
 		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
 			Code:    400,
 			Message: "参数错误",
@@ -53,19 +60,23 @@ func (h *PostHandler) CreatePost(c *gin.Context) {
 		Content: req.Content,
 		UserID:  userId,
 	}
-	if err := h.service.Create(&post); err != nil {
-		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
-			Code:    500,
-			Message: "创建帖子失败",
-		})
+	if err := h.service.Create(c.Request.Context(), &post); err != nil {
+		if errors.Is(err, service.ErrContentRejected) {
+			c.JSON(http.StatusUnprocessableEntity, dto.ErrorResponse{
+				Code:    422,
+				Message: "内容未通过审核",
+			})
+			return
+		}
+		respondInternalError(c, err, "创建帖子失败")
 		return
 	}
 
-	c.JSON(http.StatusOK, dto.Response{
-		Code:    200,
-		Message: "创建帖子成功",
-		Data:    post,
-	})
+	if post.Status == entity.StatusPending {
+		respondOK(c, "帖子已提交，审核通过后发布", dto.NewPostResponse(&post))
+		return
+	}
+	respondOK(c, "创建帖子成功", dto.NewPostResponse(&post))
 }
 
 // @Summary 获取帖子列表
@@ -73,45 +84,18 @@ func (h *PostHandler) CreatePost(c *gin.Context) {
 // @Tags posts
 // @Accept json
 // @Produce json
-// @Success 200 {object} dto.Response "{"code":200,"data":[{"id":1,"title":"标题","user_id":1}],"msg":"获取帖子成功"}"
-// @Failure 500 {object} dto.ErrorResponse "{"code":500,"msg":"获取帖子失败"}"
+// @Success 200 {object} dto.Response{data=[]dto.PostResponse} "获取帖子成功"
+// @Failure 500 {object} dto.ErrorResponse "{"code":500,"message":"获取帖子失败"}"
+// @Failure 504 {object} dto.ErrorResponse "{"code":504,"message":"请求超时"}"
 // @Router /posts [get]
 func (h *PostHandler) GetPosts(c *gin.Context) {
-	posts, err := h.service.GetAll()
+	posts, err := h.service.GetAll(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
-			Code:    500,
-			Message: "获取帖子失败",
-		})
+		respondInternalError(c, err, "获取帖子失败")
 		return
 	}
 
-	postResponses := make([]dto.PostResponse, len(posts))
-	for i, post := range posts {
-		postResponses[i] = dto.PostResponse{
-			Title:   post.Title,
-			Content: post.Content,
-			UserID:  post.UserID,
-			User: &dto.UserResponse{
-				ID:       post.User.ID,
-				Username: post.User.UserName,
-				Email:    post.User.Email,
-				Phone:    post.User.Phone,
-			},
-		}
-		for _, comment := range post.Comments {
-			postResponses[i].Comments = append(postResponses[i].Comments, dto.CommentResponse{
-				Content: comment.Content,
-				UserID:  comment.UserID,
-				PostID:  comment.PostID,
-			})
-		}
-	}
-	c.JSON(http.StatusOK, dto.Response{
-		Code:    200,
-		Message: "获取帖子成功",
-		Data:    postResponses,
-	})
+	respondOK(c, "获取帖子成功", dto.NewPostResponses(posts))
 }
 
 // @Summary 获取帖子详情
@@ -120,10 +104,11 @@ func (h *PostHandler) GetPosts(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param post_id path int true "帖子ID"
-// @Success 200 {object} dto.Response "{"code":200,"data":{"id":1,"title":"标题","content":"内容","user_id":1},"msg":"获取帖子成功"}"
-// @Failure 400 {object} dto.ErrorResponse "{"code":400,"msg":"参数错误"}"
-// @Failure 404 {object} dto.ErrorResponse "{"code":404,"msg":"帖子未找到"}"
-// @Failure 500 {object} dto.ErrorResponse "{"code":500,"msg":"获取帖子失败"}"
+// @Success 200 {object} dto.Response{data=dto.PostResponse} "获取帖子成功"
+// @Failure 400 {object} dto.ErrorResponse "{"code":400,"message":"参数错误"}"
+// @Failure 404 {object} dto.ErrorResponse "{"code":404,"message":"帖子未找到"}"
+// @Failure 500 {object} dto.ErrorResponse "{"code":500,"message":"获取帖子失败"}"
+// @Failure 504 {object} dto.ErrorResponse "{"code":504,"message":"请求超时"}"
 // @Router /posts/{post_id} [get]
 func (h *PostHandler) GetPostsById(c *gin.Context) {
 	postIdStr := c.Param("post_id")
@@ -136,13 +121,10 @@ func (h *PostHandler) GetPostsById(c *gin.Context) {
 		})
 		return
 	}
-	p, err := h.service.GetByID(postId)
+	p, err := h.service.GetByID(c.Request.Context(), postId)
 	if err != nil {
 		if err.Error() != "record not found" {
-			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
-				Code:    500,
-				Message: "获取帖子失败",
-			})
+			respondInternalError(c, err, "获取帖子失败")
 			return
 		}
 		c.JSON(http.StatusNotFound, dto.ErrorResponse{
@@ -152,41 +134,28 @@ func (h *PostHandler) GetPostsById(c *gin.Context) {
 		return
 	}
 
-	postRes := dto.PostResponse{
-		Title:   p.Title,
-		Content: p.Content,
-		UserID:  p.UserID,
-		User: &dto.UserResponse{
-			ID:       p.User.ID,
-			Username: p.User.UserName,
-			Email:    p.User.Email,
-			Phone:    p.User.Phone,
-		},
-	}
-	for _, comment := range p.Comments {
-		postRes.Comments = append(postRes.Comments, dto.CommentResponse{
-			Content: comment.Content,
-		})
-	}
-	c.JSON(http.StatusOK, dto.Response{
-		Code:    200,
-		Message: "获取帖子成功",
-		Data:    postRes,
-	})
+	c.Header("ETag", utils.VersionETag(p.Version))
+	respondOK(c, "获取帖子成功", dto.NewPostResponse(p))
 }
 
 // @Summary 更新帖子
-// @Description 更新帖子接口
+// @Description 更新帖子接口，可通过 If-Match 携带获取帖子时返回的 ETag，版本不一致时拒绝更新
 // @Tags posts
 // @Accept json
 // @Produce json
 // @Param post_id path int true "帖子ID"
+// @Param If-Match header string false "帖子的 ETag，例如 \"3\""
 // @Param post body CreatePostRequest true "帖子信息"
-// @Success 200 {object} dto.Response "{"code":200,"data":{"id":1,"title":"标题","content":"内容","user_id":1},"msg":"更新帖子成功"}"
-// @Failure 400 {object} dto.ErrorResponse "{"code":400,"msg":"参数错误"}"
-// @Failure 403 {object} dto.ErrorResponse "{"code":403,"msg":"没有权限"}"
-// @Failure 404 {object} dto.ErrorResponse "{"code":404,"msg":"帖子未找到"}"
-// @Failure 500 {object} dto.ErrorResponse "{"code":500,"msg":"更新帖子失败"}"
+// @Success 200 {object} dto.Response{data=dto.PostResponse} "更新帖子成功"
+// @Failure 400 {object} dto.ErrorResponse "{"code":400,"message":"参数错误"}"
+// @Failure 401 {object} dto.ErrorResponse "{"code":401,"message":"未授权"}"
+// @Failure 403 {object} dto.ErrorResponse "{"code":403,"message":"没有权限"}"
+// @Failure 404 {object} dto.ErrorResponse "{"code":404,"message":"帖子未找到"}"
+// @Failure 409 {object} dto.Response "{"code":409,"data":{"current_version":3},"message":"帖子已被其他人修改"}"
+// @Failure 412 {object} dto.Response "{"code":412,"data":{"current_version":3},"message":"帖子版本不匹配"}"
+// @Failure 500 {object} dto.ErrorResponse "{"code":500,"message":"更新帖子失败"}"
+// @Failure 504 {object} dto.ErrorResponse "{"code":504,"message":"请求超时"}"
+// @Security BearerAuth
 // @Router /posts/{post_id} [put]
 func (h *PostHandler) UpdatePost(c *gin.Context) {
 	var req CreatePostRequest
@@ -207,14 +176,38 @@ func (h *PostHandler) UpdatePost(c *gin.Context) {
 		})
 		return
 	}
+	ifMatch, ok := utils.ParseIfMatch(c.GetHeader("If-Match"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Code:    400,
+			Message: "If-Match 格式错误",
+		})
+		return
+	}
 	userId := c.GetUint("user_id")
 	post := &entity.Post{
 		Title:   req.Title,
 		Content: req.Content,
 	}
 	post.ID = postId
-	if err := h.service.Update(userId, post); err != nil {
-		if err.Error() == "unauthorized" {
+	if err := h.service.Update(c.Request.Context(), userId, post, ifMatch); err != nil {
+		if errors.Is(err, service.ErrPreconditionFailed) {
+			c.Header("ETag", utils.VersionETag(post.Version))
+			c.JSON(http.StatusPreconditionFailed, dto.Response{
+				Code:    412,
+				Message: "帖子版本不匹配",
+				Data:    map[string]interface{}{"current_version": post.Version},
+			})
+			return
+		} else if errors.Is(err, repository.ErrVersionConflict) {
+			c.Header("ETag", utils.VersionETag(post.Version))
+			c.JSON(http.StatusConflict, dto.Response{
+				Code:    409,
+				Message: "帖子已被其他人修改",
+				Data:    map[string]interface{}{"current_version": post.Version},
+			})
+			return
+		} else if err.Error() == "unauthorized" {
 			c.JSON(http.StatusForbidden, dto.ErrorResponse{
 				Code:    403,
 				Message: "没有权限",
@@ -227,19 +220,13 @@ func (h *PostHandler) UpdatePost(c *gin.Context) {
 			})
 			return
 		} else {
-			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
-				Code:    500,
-				Message: "更新帖子失败",
-			})
+			respondInternalError(c, err, "更新帖子失败")
 			return
 		}
 	}
 
-	c.JSON(http.StatusOK, dto.Response{
-		Code:    200,
-		Message: "更新帖子成功",
-		Data:    post,
-	})
+	c.Header("ETag", utils.VersionETag(post.Version))
+	respondOK(c, "更新帖子成功", dto.NewPostResponse(post))
 }
 
 // @Summary 删除帖子
@@ -248,11 +235,14 @@ func (h *PostHandler) UpdatePost(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param post_id path int true "帖子ID"
-// @Success 200 {object} dto.Response "{"code":200,"msg":"删除帖子成功"}"
-// @Failure 400 {object} dto.ErrorResponse "{"code":400,"msg":"参数错误"}"
-// @Failure 403 {object} dto.ErrorResponse "{"code":403,"msg":"没有权限"}"
-// @Failure 404 {object} dto.ErrorResponse "{"code":404,"msg":"帖子未找到"}"
-// @Failure 500 {object} dto.ErrorResponse "{"code":500,"msg":"删除帖子失败"}"
+// @Success 200 {object} dto.Response "{"code":200,"message":"删除帖子成功"}"
+// @Failure 400 {object} dto.ErrorResponse "{"code":400,"message":"参数错误"}"
+// @Failure 401 {object} dto.ErrorResponse "{"code":401,"message":"未授权"}"
+// @Failure 403 {object} dto.ErrorResponse "{"code":403,"message":"没有权限"}"
+// @Failure 404 {object} dto.ErrorResponse "{"code":404,"message":"帖子未找到"}"
+// @Failure 500 {object} dto.ErrorResponse "{"code":500,"message":"删除帖子失败"}"
+// @Failure 504 {object} dto.ErrorResponse "{"code":504,"message":"请求超时"}"
+// @Security BearerAuth
 // @Router /posts/{post_id} [delete]
 func (h *PostHandler) DeletePost(c *gin.Context) {
 	postIdStr := c.Param("post_id")
@@ -266,7 +256,7 @@ func (h *PostHandler) DeletePost(c *gin.Context) {
 		return
 	}
 	userId := c.GetUint("user_id")
-	if err := h.service.Delete(userId, postId); err != nil {
+	if err := h.service.Delete(c.Request.Context(), userId, postId); err != nil {
 		if err.Error() == "unauthorized" {
 			c.JSON(http.StatusForbidden, dto.ErrorResponse{
 				Code:    403,
@@ -280,10 +270,7 @@ func (h *PostHandler) DeletePost(c *gin.Context) {
 			})
 			return
 		} else {
-			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
-				Code:    500,
-				Message: "删除帖子失败",
-			})
+			respondInternalError(c, err, "删除帖子失败")
 			return
 		}
 	}
@@ -0,0 +1,179 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/miffyG/golearn/task4/internal/models/dto"
+	"github.com/miffyG/golearn/task4/internal/models/entity"
+	"github.com/miffyG/golearn/task4/internal/repository"
+	"github.com/miffyG/golearn/task4/internal/service"
+	"github.com/miffyG/golearn/task4/internal/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	mergePatchContentType = "application/merge-patch+json"
+	jsonPatchContentType  = "application/json-patch+json"
+)
+
+var (
+	// errPatchNotApplicable 表示补丁无法应用到当前文章上，对应 422
+	errPatchNotApplicable = errors.New("patch not applicable")
+	// errPatchInvalid 表示补丁的结果不是合法的文章，对应 400
+	errPatchInvalid = errors.New("invalid patch result")
+)
+
+// @Summary 部分更新帖子
+// @Description 支持 JSON Merge Patch (RFC 7396，Content-Type: application/merge-patch+json) 和
+// @Description JSON Patch (RFC 6902，Content-Type: application/json-patch+json)，
+// @Description 补丁作用于 {"title","content"} 文档，结果按创建帖子的规则校验，只写入发生变化的字段
+// @Tags posts
+// @Accept json,application/merge-patch+json,application/json-patch+json
+// @Produce json
+// @Param post_id path int true "帖子ID"
+// @Param If-Match header string false "帖子的 ETag，例如 \"3\""
+// @Param patch body object true "Merge Patch 对象或 JSON Patch 操作数组"
+// @Success 200 {object} dto.Response{data=dto.PostResponse} "更新帖子成功"
+// @Failure 400 {object} dto.ErrorResponse "{"code":400,"message":"参数错误"}"
+// @Failure 401 {object} dto.ErrorResponse "{"code":401,"message":"未授权"}"
+// @Failure 403 {object} dto.ErrorResponse "{"code":403,"message":"没有权限"}"
+// @Failure 404 {object} dto.ErrorResponse "{"code":404,"message":"帖子未找到"}"
+// @Failure 409 {object} dto.Response "{"code":409,"data":{"current_version":3},"message":"帖子已被其他人修改"}"
+// @Failure 412 {object} dto.Response "{"code":412,"data":{"current_version":3},"message":"帖子版本不匹配"}"
+// @Failure 415 {object} dto.ErrorResponse "{"code":415,"message":"不支持的补丁格式"}"
+// @Failure 422 {object} dto.ErrorResponse "{"code":422,"message":"补丁无法应用"}"
+// @Failure 500 {object} dto.ErrorResponse "{"code":500,"message":"更新帖子失败"}"
+// @Failure 504 {object} dto.ErrorResponse "{"code":504,"message":"请求超时"}"
+// @Security BearerAuth
+// @Router /posts/{post_id} [patch]
+func (h *PostHandler) PatchPost(c *gin.Context) {
+	var postId uint
+	if _, err := fmt.Sscanf(c.Param("post_id"), "%d", &postId); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Code:    400,
+			Message: "参数错误",
+		})
+		return
+	}
+	ifMatch, ok := utils.ParseIfMatch(c.GetHeader("If-Match"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Code:    400,
+			Message: "If-Match 格式错误",
+		})
+		return
+	}
+	contentType := c.ContentType()
+	if contentType != mergePatchContentType && contentType != jsonPatchContentType && contentType != binding.MIMEJSON {
+		c.JSON(http.StatusUnsupportedMediaType, dto.ErrorResponse{
+			Code:    415,
+			Message: "不支持的补丁格式",
+		})
+		return
+	}
+	patchBody, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Code:    400,
+			Message: "参数错误",
+		})
+		return
+	}
+
+	// 补丁在服务层加锁读取文章之后应用，作者可以修改自己待审核的文章，读取和写入之间也不会被其他修改插入。
+	// 服务层先确认权限再调用 build，其他用户不能通过 400、422 的区别试探文章的内容
+	userId := c.GetUint("user_id")
+	post, err := h.service.PatchWith(c.Request.Context(), userId, postId, func(cur *entity.Post) (service.PostPatch, error) {
+		original, err := json.Marshal(CreatePostRequest{Title: cur.Title, Content: cur.Content})
+		if err != nil {
+			return service.PostPatch{}, err
+		}
+		patched, err := applyPatch(contentType, original, patchBody)
+		if err != nil {
+			return service.PostPatch{}, fmt.Errorf("%w: %v", errPatchNotApplicable, err)
+		}
+
+		// 补丁结果只能包含可编辑的字段，并且需要满足创建帖子时的校验规则
+		var req CreatePostRequest
+		decoder := json.NewDecoder(bytes.NewReader(patched))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&req); err != nil {
+			return service.PostPatch{}, fmt.Errorf("%w: %v", errPatchInvalid, err)
+		}
+		if err := binding.Validator.ValidateStruct(&req); err != nil {
+			return service.PostPatch{}, fmt.Errorf("%w: %v", errPatchInvalid, err)
+		}
+		return service.PostPatch{Title: &req.Title, Content: &req.Content}, nil
+	}, ifMatch)
+	if err != nil {
+		if errors.Is(err, service.ErrPreconditionFailed) {
+			c.Header("ETag", utils.VersionETag(post.Version))
+			c.JSON(http.StatusPreconditionFailed, dto.Response{
+				Code:    412,
+				Message: "帖子版本不匹配",
+				Data:    map[string]interface{}{"current_version": post.Version},
+			})
+			return
+		} else if errors.Is(err, repository.ErrVersionConflict) {
+			c.Header("ETag", utils.VersionETag(post.Version))
+			c.JSON(http.StatusConflict, dto.Response{
+				Code:    409,
+				Message: "帖子已被其他人修改",
+				Data:    map[string]interface{}{"current_version": post.Version},
+			})
+			return
+		} else if errors.Is(err, errPatchNotApplicable) {
+			h.log.Errorf("应用补丁失败: %v", err)
+			c.JSON(http.StatusUnprocessableEntity, dto.ErrorResponse{
+				Code:    422,
+				Message: "补丁无法应用",
+			})
+			return
+		} else if errors.Is(err, errPatchInvalid) {
+			h.log.Errorf("参数错误: %v", err)
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+				Code:    400,
+				Message: "参数错误",
+			})
+			return
+		} else if err.Error() == "unauthorized" {
+			c.JSON(http.StatusForbidden, dto.ErrorResponse{
+				Code:    403,
+				Message: "没有权限",
+			})
+			return
+		} else if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{
+				Code:    404,
+				Message: "帖子未找到",
+			})
+			return
+		}
+		h.log.Errorf("更新帖子失败: %v", err)
+		respondInternalError(c, err, "更新帖子失败")
+		return
+	}
+
+	c.Header("ETag", utils.VersionETag(post.Version))
+	respondOK(c, "更新帖子成功", dto.NewPostResponse(post))
+}
+
+// applyPatch 按 Content-Type 将补丁应用到 original 上，application/json 按 Merge Patch 处理
+func applyPatch(contentType string, original, patch []byte) ([]byte, error) {
+	if contentType == jsonPatchContentType {
+		ops, err := jsonpatch.DecodePatch(patch)
+		if err != nil {
+			return nil, err
+		}
+		return ops.Apply(original)
+	}
+	return jsonpatch.MergePatch(original, patch)
+}
@@ -0,0 +1,219 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/miffyG/golearn/task4/internal/models/dto"
+	"github.com/miffyG/golearn/task4/internal/repository"
+	"github.com/miffyG/golearn/task4/internal/utils"
+	"gorm.io/gorm"
+)
+
+// @Summary 获取帖子修订历史
+// @Description 按版本号升序返回帖子的修订记录（不含正文）。待审核和已移除的帖子只有作者、审核员和管理员可以查看
+// @Tags posts
+// @Accept json
+// @Produce json
+// @Param post_id path int true "帖子ID"
+// @Success 200 {object} dto.Response{data=[]dto.RevisionResponse} "获取修订历史成功"
+// @Failure 400 {object} dto.ErrorResponse "{"code":400,"message":"参数错误"}"
+// @Failure 404 {object} dto.ErrorResponse "{"code":404,"message":"帖子未找到"}"
+// @Failure 500 {object} dto.ErrorResponse "{"code":500,"message":"获取修订历史失败"}"
+// @Failure 504 {object} dto.ErrorResponse "{"code":504,"message":"请求超时"}"
+// @Router /posts/{post_id}/revisions [get]
+func (h *PostHandler) GetRevisions(c *gin.Context) {
+	var postId uint
+	if _, err := fmt.Sscanf(c.Param("post_id"), "%d", &postId); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Code:    400,
+			Message: "参数错误",
+		})
+		return
+	}
+	revisions, err := h.service.GetRevisions(c.Request.Context(), c.GetUint("user_id"), postId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{
+				Code:    404,
+				Message: "帖子未找到",
+			})
+			return
+		}
+		h.log.Errorf("获取修订历史失败: %v", err)
+		respondInternalError(c, err, "获取修订历史失败")
+		return
+	}
+
+	respondOK(c, "获取修订历史成功", dto.NewRevisionResponses(revisions))
+}
+
+// @Summary 获取帖子指定版本
+// @Description 获取帖子某一修订版本的完整内容，可见性与修订历史相同
+// @Tags posts
+// @Accept json
+// @Produce json
+// @Param post_id path int true "帖子ID"
+// @Param rev path int true "版本号"
+// @Success 200 {object} dto.Response{data=dto.RevisionResponse} "获取修订版本成功"
+// @Failure 400 {object} dto.ErrorResponse "{"code":400,"message":"参数错误"}"
+// @Failure 404 {object} dto.ErrorResponse "{"code":404,"message":"修订版本未找到"}"
+// @Failure 500 {object} dto.ErrorResponse "{"code":500,"message":"获取修订版本失败"}"
+// @Failure 504 {object} dto.ErrorResponse "{"code":504,"message":"请求超时"}"
+// @Router /posts/{post_id}/revisions/{rev} [get]
+func (h *PostHandler) GetRevision(c *gin.Context) {
+	var postId, rev uint
+	_, errPost := fmt.Sscanf(c.Param("post_id"), "%d", &postId)
+	_, errRev := fmt.Sscanf(c.Param("rev"), "%d", &rev)
+	if errPost != nil || errRev != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Code:    400,
+			Message: "参数错误",
+		})
+		return
+	}
+	r, err := h.service.GetRevision(c.Request.Context(), c.GetUint("user_id"), postId, rev)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{
+				Code:    404,
+				Message: "修订版本未找到",
+			})
+			return
+		}
+		h.log.Errorf("获取修订版本失败: %v", err)
+		respondInternalError(c, err, "获取修订版本失败")
+		return
+	}
+
+	respondOK(c, "获取修订版本成功", dto.NewRevisionResponse(r, true))
+}
+
+// @Summary 比较帖子的两个版本
+// @Description 返回从版本 base 到版本 rev 的逐行 unified diff，base 默认为 rev 的上一个版本，可见性与修订历史相同
+// @Tags posts
+// @Accept json
+// @Produce json
+// @Param post_id path int true "帖子ID"
+// @Param rev path int true "版本号"
+// @Param base query int false "对比的基准版本号"
+// @Success 200 {object} dto.Response{data=dto.RevisionDiffResponse} "获取版本差异成功"
+// @Failure 400 {object} dto.ErrorResponse "{"code":400,"message":"参数错误"}"
+// @Failure 404 {object} dto.ErrorResponse "{"code":404,"message":"修订版本未找到"}"
+// @Failure 413 {object} dto.ErrorResponse "{"code":413,"message":"版本差异过大，无法比较"}"
+// @Failure 500 {object} dto.ErrorResponse "{"code":500,"message":"获取版本差异失败"}"
+// @Failure 504 {object} dto.ErrorResponse "{"code":504,"message":"请求超时"}"
+// @Router /posts/{post_id}/revisions/{rev}/diff [get]
+func (h *PostHandler) DiffRevisions(c *gin.Context) {
+	var postId, rev, base uint
+	_, errPost := fmt.Sscanf(c.Param("post_id"), "%d", &postId)
+	_, errRev := fmt.Sscanf(c.Param("rev"), "%d", &rev)
+	if errPost != nil || errRev != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Code:    400,
+			Message: "参数错误",
+		})
+		return
+	}
+	if baseStr := c.Query("base"); baseStr != "" {
+		if _, err := fmt.Sscanf(baseStr, "%d", &base); err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+				Code:    400,
+				Message: "参数错误",
+			})
+			return
+		}
+	} else if rev > 1 {
+		base = rev - 1
+	} else {
+		base = rev
+	}
+
+	diff, err := h.service.DiffRevisions(c.Request.Context(), c.GetUint("user_id"), postId, base, rev)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{
+				Code:    404,
+				Message: "修订版本未找到",
+			})
+			return
+		}
+		if errors.Is(err, utils.ErrDiffTooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, dto.ErrorResponse{
+				Code:    413,
+				Message: "版本差异过大，无法比较",
+			})
+			return
+		}
+		h.log.Errorf("获取版本差异失败: %v", err)
+		respondInternalError(c, err, "获取版本差异失败")
+		return
+	}
+
+	respondOK(c, "获取版本差异成功", dto.RevisionDiffResponse{
+		PostID: postId,
+		Base:   base,
+		Rev:    rev,
+		Diff:   diff,
+	})
+}
+
+// @Summary 恢复帖子到指定版本
+// @Description 用指定版本的标题和内容覆盖帖子，并记录一条新的修订，仅作者可操作
+// @Tags posts
+// @Accept json
+// @Produce json
+// @Param post_id path int true "帖子ID"
+// @Param rev path int true "版本号"
+// @Success 200 {object} dto.Response{data=dto.PostResponse} "恢复帖子成功"
+// @Failure 400 {object} dto.ErrorResponse "{"code":400,"message":"参数错误"}"
+// @Failure 401 {object} dto.ErrorResponse "{"code":401,"message":"未授权"}"
+// @Failure 403 {object} dto.ErrorResponse "{"code":403,"message":"没有权限"}"
+// @Failure 404 {object} dto.ErrorResponse "{"code":404,"message":"帖子或修订版本未找到"}"
+// @Failure 409 {object} dto.ErrorResponse "{"code":409,"message":"帖子已被其他人修改"}"
+// @Failure 500 {object} dto.ErrorResponse "{"code":500,"message":"恢复帖子失败"}"
+// @Failure 504 {object} dto.ErrorResponse "{"code":504,"message":"请求超时"}"
+// @Security BearerAuth
+// @Router /posts/{post_id}/revisions/{rev}/restore [post]
+func (h *PostHandler) RestoreRevision(c *gin.Context) {
+	var postId, rev uint
+	_, errPost := fmt.Sscanf(c.Param("post_id"), "%d", &postId)
+	_, errRev := fmt.Sscanf(c.Param("rev"), "%d", &rev)
+	if errPost != nil || errRev != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Code:    400,
+			Message: "参数错误",
+		})
+		return
+	}
+	userId := c.GetUint("user_id")
+	p, err := h.service.RestoreRevision(c.Request.Context(), userId, postId, rev)
+	if err != nil {
+		if errors.Is(err, repository.ErrVersionConflict) {
+			c.JSON(http.StatusConflict, dto.ErrorResponse{
+				Code:    409,
+				Message: "帖子已被其他人修改",
+			})
+			return
+		} else if err.Error() == "unauthorized" {
+			c.JSON(http.StatusForbidden, dto.ErrorResponse{
+				Code:    403,
+				Message: "没有权限",
+			})
+			return
+		} else if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{
+				Code:    404,
+				Message: "帖子或修订版本未找到",
+			})
+			return
+		}
+		h.log.Errorf("恢复帖子失败: %v", err)
+		respondInternalError(c, err, "恢复帖子失败")
+		return
+	}
+
+	respondOK(c, "恢复帖子成功", dto.NewPostResponse(p))
+}
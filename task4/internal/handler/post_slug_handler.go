@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/miffyG/golearn/task4/internal/models/dto"
+	"github.com/miffyG/golearn/task4/internal/utils"
+	"gorm.io/gorm"
+)
+
+// postURL 返回文章对外的链接，优先使用 slug
+func postURL(siteURL string, id uint, slug string) string {
+	if slug == "" {
+		return fmt.Sprintf("%s/api/v1/posts/%d", siteURL, id)
+	}
+	return slugURL(siteURL, slug)
+}
+
+func slugURL(siteURL, slug string) string {
+	return siteURL + "/api/v1/posts/by-slug/" + url.PathEscape(slug)
+}
+
+// @Summary 按 slug 获取帖子
+// @Description 按 slug 获取帖子详情，使用帖子改标题之前的旧 slug 访问时 301 重定向到当前 slug
+// @Tags posts
+// @Accept json
+// @Produce json
+// @Param slug path string true "帖子 slug"
+// @Success 200 {object} dto.Response{data=dto.PostResponse} "获取帖子成功"
+// @Success 301 "重定向到帖子当前的 slug"
+// @Failure 404 {object} dto.ErrorResponse "{"code":404,"message":"帖子未找到"}"
+// @Failure 500 {object} dto.ErrorResponse "{"code":500,"message":"获取帖子失败"}"
+// @Failure 504 {object} dto.ErrorResponse "{"code":504,"message":"请求超时"}"
+// @Router /posts/by-slug/{slug} [get]
+func (h *PostHandler) GetPostBySlug(c *gin.Context) {
+	slug := c.Param("slug")
+	p, err := h.service.GetBySlug(c.Request.Context(), slug)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{
+				Code:    404,
+				Message: "帖子未找到",
+			})
+			return
+		}
+		h.log.Errorf("按 slug 获取帖子失败: %v", err)
+		respondInternalError(c, err, "获取帖子失败")
+		return
+	}
+
+	if p.Slug != slug {
+		// 在当前路由下重定向，同一个处理函数挂在其他路由组时也能指向正确的地址
+		prefix := strings.TrimSuffix(c.FullPath(), ":slug")
+		c.Redirect(http.StatusMovedPermanently, prefix+url.PathEscape(p.Slug))
+		return
+	}
+	c.Header("ETag", utils.VersionETag(p.Version))
+	respondOK(c, "获取帖子成功", dto.NewPostResponse(p))
+}
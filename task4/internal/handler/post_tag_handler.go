@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/miffyG/golearn/task4/internal/models/dto"
+	"gorm.io/gorm"
+)
+
+type SetTagsRequest struct {
+	Tags []string `json:"tags" binding:"max=10,dive,required,max=32"`
+}
+
+// @Summary 设置帖子标签
+// @Description 用请求中的标签替换帖子的全部标签，只有作者可以修改
+// @Tags posts
+// @Accept json
+// @Produce json
+// @Param post_id path int true "帖子ID"
+// @Param tags body SetTagsRequest true "标签列表，最多 10 个"
+// @Success 200 {object} dto.Response{data=dto.PostResponse} "设置标签成功"
+// @Failure 400 {object} dto.ErrorResponse "{"code":400,"message":"参数错误"}"
+// @Failure 401 {object} dto.ErrorResponse "{"code":401,"message":"未授权"}"
+// @Failure 403 {object} dto.ErrorResponse "{"code":403,"message":"没有权限"}"
+// @Failure 404 {object} dto.ErrorResponse "{"code":404,"message":"帖子未找到"}"
+// @Failure 500 {object} dto.ErrorResponse "{"code":500,"message":"设置标签失败"}"
+// @Failure 504 {object} dto.ErrorResponse "{"code":504,"message":"请求超时"}"
+// @Security BearerAuth
+// @Router /posts/{post_id}/tags [put]
+func (h *PostHandler) SetTags(c *gin.Context) {
+	var postId uint
+	if _, err := fmt.Sscanf(c.Param("post_id"), "%d", &postId); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Code:    400,
+			Message: "参数错误",
+		})
+		return
+	}
+	var req SetTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Code:    400,
+			Message: "参数错误",
+		})
+		return
+	}
+
+	p, err := h.service.SetTags(c.Request.Context(), c.GetUint("user_id"), postId, req.Tags)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{
+				Code:    404,
+				Message: "帖子未找到",
+			})
+		case err.Error() == "unauthorized":
+			c.JSON(http.StatusForbidden, dto.ErrorResponse{
+				Code:    403,
+				Message: "没有权限",
+			})
+		default:
+			h.log.Errorf("设置标签失败: %v", err)
+			respondInternalError(c, err, "设置标签失败")
+		}
+		return
+	}
+	respondOK(c, "设置标签成功", dto.NewPostResponse(p))
+}
@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/miffyG/golearn/task4/internal/middleware"
+	"github.com/miffyG/golearn/task4/internal/models/dto"
+)
+
+// respondOK 返回 200 和统一格式的响应，兼容模式下把数据转换为旧字段名
+func respondOK(c *gin.Context, message string, data interface{}) {
+	c.JSON(http.StatusOK, dto.Response{
+		Code:    200,
+		Message: message,
+		Data:    dto.Present(data, c.GetBool(middleware.LegacyFieldsKey)),
+	})
+}
+
+// respondInternalError 返回 500；请求超过路由的超时时间、数据库操作被取消时返回 504
+func respondInternalError(c *gin.Context, err error, message string) {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(c.Request.Context().Err(), context.DeadlineExceeded) {
+		c.JSON(http.StatusGatewayTimeout, dto.ErrorResponse{
+			Code:    504,
+			Message: "请求超时",
+		})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+		Code:    500,
+		Message: message,
+	})
+}
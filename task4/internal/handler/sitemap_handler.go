@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/miffyG/golearn/task4/internal/models/dto"
+	"github.com/miffyG/golearn/task4/internal/service"
+	"github.com/miffyG/golearn/task4/pkg/config"
+	"go.uber.org/zap"
+)
+
+// DefaultSitemapPageSize 是 sitemap 协议允许单个文件列出的最大 URL 数
+const DefaultSitemapPageSize = 50000
+
+const sitemapNS = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name     `xml:"sitemapindex"`
+	XMLNS    string       `xml:"xmlns,attr"`
+	Sitemaps []sitemapURL `xml:"sitemap"`
+}
+
+// SitemapHandler 输出文章的站点地图
+type SitemapHandler struct {
+	postService *service.PostService
+	site        *config.Site
+	log         *zap.SugaredLogger
+	// pageSize 是单个 sitemap 文件最多列出的文章数，文章更多时 /sitemap.xml 改为输出索引文件
+	pageSize int
+}
+
+func NewSitemapHandler(postService *service.PostService, site *config.Site, log *zap.SugaredLogger) *SitemapHandler {
+	pageSize := site.SitemapPageSize
+	if pageSize <= 0 {
+		pageSize = DefaultSitemapPageSize
+	}
+	return &SitemapHandler{
+		postService: postService,
+		site:        site,
+		log:         log,
+		pageSize:    pageSize,
+	}
+}
+
+// Sitemap 处理 /sitemap.xml ，文章数不超过 pageSize 时直接列出全部文章，
+// 否则返回指向 /sitemaps/posts-1.xml、/sitemaps/posts-2.xml 等分页文件的索引
+func (h *SitemapHandler) Sitemap(c *gin.Context) {
+	total, err := h.postService.SitemapCount(c.Request.Context())
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	if total <= int64(h.pageSize) {
+		h.renderPage(c, 1)
+		return
+	}
+
+	index := sitemapIndex{XMLNS: sitemapNS}
+	pages := int((total + int64(h.pageSize) - 1) / int64(h.pageSize))
+	for i := 1; i <= pages; i++ {
+		index.Sitemaps = append(index.Sitemaps, sitemapURL{
+			Loc: fmt.Sprintf("%s/sitemaps/posts-%d.xml", h.site.URL, i),
+		})
+	}
+	h.write(c, index)
+}
+
+// SitemapPage 处理 /sitemaps/:name ，name 形如 posts-2.xml
+func (h *SitemapHandler) SitemapPage(c *gin.Context) {
+	var page int
+	if _, err := fmt.Sscanf(c.Param("name"), "posts-%d.xml", &page); err != nil || page < 1 {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{
+			Code:    404,
+			Message: "站点地图不存在",
+		})
+		return
+	}
+	h.renderPage(c, page)
+}
+
+func (h *SitemapHandler) renderPage(c *gin.Context, page int) {
+	entries, err := h.postService.SitemapPage(c.Request.Context(), page, h.pageSize)
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	if len(entries) == 0 && page > 1 {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{
+			Code:    404,
+			Message: "站点地图不存在",
+		})
+		return
+	}
+
+	set := sitemapURLSet{XMLNS: sitemapNS, URLs: make([]sitemapURL, 0, len(entries))}
+	for _, e := range entries {
+		set.URLs = append(set.URLs, sitemapURL{
+			Loc:     postURL(h.site.URL, e.ID, e.Slug),
+			LastMod: e.UpdatedAt.UTC().Format(time.RFC3339),
+		})
+	}
+	h.write(c, set)
+}
+
+func (h *SitemapHandler) write(c *gin.Context, v interface{}) {
+	body, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	c.Data(http.StatusOK, "application/xml; charset=utf-8", append([]byte(xml.Header), body...))
+}
+
+func (h *SitemapHandler) fail(c *gin.Context, err error) {
+	h.log.Errorf("生成站点地图失败: %v", err)
+	respondInternalError(c, err, "生成站点地图失败")
+}
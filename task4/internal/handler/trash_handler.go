@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/miffyG/golearn/task4/internal/models/dto"
+	"github.com/miffyG/golearn/task4/internal/utils"
+	"gorm.io/gorm"
+)
+
+type TrashQuery struct {
+	Page     int `form:"page,default=1" binding:"min=1"`
+	PageSize int `form:"page_size,default=20" binding:"min=1,max=100"`
+}
+
+// @Summary 获取回收站
+// @Description 分页列出当前用户已删除的帖子，超过保留期的帖子会被彻底删除
+// @Tags posts
+// @Accept json
+// @Produce json
+// @Param page query int false "页码，从 1 开始"
+// @Param page_size query int false "每页数量，最大 100"
+// @Success 200 {object} dto.Response{data=dto.TrashResponse} "获取回收站成功"
+// @Failure 400 {object} dto.ErrorResponse "{"code":400,"message":"参数错误"}"
+// @Failure 401 {object} dto.ErrorResponse "{"code":401,"message":"未授权"}"
+// @Failure 500 {object} dto.ErrorResponse "{"code":500,"message":"获取回收站失败"}"
+// @Failure 504 {object} dto.ErrorResponse "{"code":504,"message":"请求超时"}"
+// @Security BearerAuth
+// @Router /me/trash [get]
+func (h *PostHandler) GetTrash(c *gin.Context) {
+	var query TrashQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Code:    400,
+			Message: "参数错误",
+		})
+		return
+	}
+	posts, total, err := h.service.Trash(c.Request.Context(), c.GetUint("user_id"), query.Page, query.PageSize)
+	if err != nil {
+		h.log.Errorf("获取回收站失败: %v", err)
+		respondInternalError(c, err, "获取回收站失败")
+		return
+	}
+
+	respondOK(c, "获取回收站成功", dto.NewTrashResponse(posts, total))
+}
+
+// @Summary 恢复帖子
+// @Description 从回收站恢复自己删除的帖子，随帖子一起删除的评论也会恢复
+// @Tags posts
+// @Accept json
+// @Produce json
+// @Param post_id path int true "帖子ID"
+// @Success 200 {object} dto.Response{data=dto.PostResponse} "恢复帖子成功"
+// @Failure 400 {object} dto.ErrorResponse "{"code":400,"message":"参数错误"}"
+// @Failure 401 {object} dto.ErrorResponse "{"code":401,"message":"未授权"}"
+// @Failure 403 {object} dto.ErrorResponse "{"code":403,"message":"没有权限"}"
+// @Failure 404 {object} dto.ErrorResponse "{"code":404,"message":"回收站中没有该帖子"}"
+// @Failure 500 {object} dto.ErrorResponse "{"code":500,"message":"恢复帖子失败"}"
+// @Failure 504 {object} dto.ErrorResponse "{"code":504,"message":"请求超时"}"
+// @Security BearerAuth
+// @Router /posts/{post_id}/restore [post]
+func (h *PostHandler) RestorePost(c *gin.Context) {
+	var postId uint
+	if _, err := fmt.Sscanf(c.Param("post_id"), "%d", &postId); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Code:    400,
+			Message: "参数错误",
+		})
+		return
+	}
+
+	p, err := h.service.RestoreByAuthor(c.Request.Context(), c.GetUint("user_id"), postId)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{
+				Code:    404,
+				Message: "回收站中没有该帖子",
+			})
+		case err.Error() == "unauthorized":
+			c.JSON(http.StatusForbidden, dto.ErrorResponse{
+				Code:    403,
+				Message: "没有权限",
+			})
+		default:
+			h.log.Errorf("恢复帖子失败: %v", err)
+			respondInternalError(c, err, "恢复帖子失败")
+		}
+		return
+	}
+
+	c.Header("ETag", utils.VersionETag(p.Version))
+	respondOK(c, "恢复帖子成功", dto.NewPostResponse(p))
+}
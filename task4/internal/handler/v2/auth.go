@@ -0,0 +1,71 @@
+package v2
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	dto "github.com/miffyG/golearn/task4/internal/models/dto/v2"
+	"github.com/miffyG/golearn/task4/internal/models/entity"
+	"gorm.io/gorm"
+)
+
+// @Summary 用户注册
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param user body dto.RegisterRequest true "用户信息"
+// @Success 201 {object} dto.DataResponse{data=dto.User}
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /auth/register [post]
+func (h *Handler) Register(c *gin.Context) {
+	var req dto.RegisterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.Errorf("参数错误: %v", err)
+		renderError(c, http.StatusBadRequest, "invalid_argument", "参数错误")
+		return
+	}
+
+	user := entity.User{
+		UserName: req.Username,
+		Password: req.Password,
+		Email:    req.Email,
+		Phone:    req.Phone,
+	}
+	if err := h.userService.Register(c.Request.Context(), &user); err != nil {
+		h.renderServiceError(c, err, "注册")
+		return
+	}
+	render(c, http.StatusCreated, dto.DataResponse{Data: dto.NewUser(&user)})
+}
+
+// @Summary 用户登录
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param user body dto.LoginRequest true "用户信息"
+// @Success 200 {object} dto.DataResponse{data=dto.Token}
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /auth/login [post]
+func (h *Handler) Login(c *gin.Context) {
+	var req dto.LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		renderError(c, http.StatusBadRequest, "invalid_argument", "参数错误")
+		return
+	}
+
+	token, _, err := h.userService.Login(c.Request.Context(), req.Username, req.Password)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			renderError(c, http.StatusUnauthorized, "invalid_credentials", "用户不存在或密码错误")
+			return
+		}
+		h.renderServiceError(c, err, "登录")
+		return
+	}
+	render(c, http.StatusOK, dto.DataResponse{Data: dto.Token{AccessToken: token, TokenType: "Bearer"}})
+}
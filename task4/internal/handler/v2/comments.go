@@ -0,0 +1,64 @@
+package v2
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	dto "github.com/miffyG/golearn/task4/internal/models/dto/v2"
+	"github.com/miffyG/golearn/task4/internal/models/entity"
+)
+
+// @Summary 获取帖子评论
+// @Tags comments
+// @Produce json
+// @Param post_id path int true "帖子ID"
+// @Success 200 {object} dto.DataResponse{data=[]dto.Comment}
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /posts/{post_id}/comments [get]
+func (h *Handler) ListComments(c *gin.Context) {
+	postId, ok := pathID(c, "post_id")
+	if !ok {
+		return
+	}
+	comments, err := h.commentService.GetByPostId(c.Request.Context(), postId)
+	if err != nil {
+		h.renderServiceError(c, err, "获取评论")
+		return
+	}
+	render(c, http.StatusOK, dto.DataResponse{Data: dto.NewComments(comments)})
+}
+
+// @Summary 创建评论
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param post_id path int true "帖子ID"
+// @Param comment body dto.CommentRequest true "评论信息"
+// @Success 201 {object} dto.DataResponse{data=dto.Comment}
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /posts/{post_id}/comments [post]
+func (h *Handler) CreateComment(c *gin.Context) {
+	postId, ok := pathID(c, "post_id")
+	if !ok {
+		return
+	}
+	var req dto.CommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		renderError(c, http.StatusBadRequest, "invalid_argument", "参数错误")
+		return
+	}
+	comment := entity.Comment{
+		Content: req.Content,
+		UserID:  c.GetUint("user_id"),
+		PostID:  postId,
+	}
+	if err := h.commentService.Create(c.Request.Context(), &comment); err != nil {
+		h.renderServiceError(c, err, "创建评论")
+		return
+	}
+	render(c, http.StatusCreated, dto.DataResponse{Data: dto.NewComment(&comment)})
+}
@@ -0,0 +1,137 @@
+// Package v2 实现 /api/v2 的接口，与 v1 共用 service 层，只在请求和响应结构上演进。
+//
+// @title golearn 博客 API
+// @version 2.0
+// @description 博客系统的用户、帖子和评论接口（v2）
+// @BasePath /api/v2
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+package v2
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/miffyG/golearn/task4/internal/middleware"
+	dto "github.com/miffyG/golearn/task4/internal/models/dto/v2"
+	"github.com/miffyG/golearn/task4/internal/repository"
+	"github.com/miffyG/golearn/task4/internal/service"
+	"github.com/miffyG/golearn/task4/internal/tokens"
+	"github.com/miffyG/golearn/task4/internal/utils"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+type Handler struct {
+	userService    *service.UserService
+	postService    *service.PostService
+	commentService *service.CommentService
+	keys           *tokens.KeySet
+	log            *zap.SugaredLogger
+}
+
+// NewHandler 创建 v2 的 handler，需要登录的路由用 keys 校验 token
+func NewHandler(userService *service.UserService, postService *service.PostService, commentService *service.CommentService, keys *tokens.KeySet, log *zap.SugaredLogger) *Handler {
+	utils.RegisterValidators()
+	return &Handler{
+		userService:    userService,
+		postService:    postService,
+		commentService: commentService,
+		keys:           keys,
+		log:            log,
+	}
+}
+
+// RegisterRoutes 在 rg 上注册 v2 的全部路由
+func (h *Handler) RegisterRoutes(rg *gin.RouterGroup) {
+	auth := middleware.JwtAuth(h.keys, func(c *gin.Context, message string) {
+		c.Abort()
+		renderError(c, http.StatusUnauthorized, "unauthorized", message)
+	})
+
+	rg.POST("/auth/register", h.Register)
+	rg.POST("/auth/login", h.Login)
+
+	rg.GET("/posts", h.ListPosts)
+	rg.GET("/posts/:post_id", h.GetPost)
+	rg.GET("/posts/:post_id/comments", h.ListComments)
+
+	protected := rg.Group("/")
+	protected.Use(auth)
+	{
+		protected.POST("/posts", h.CreatePost)
+		protected.PUT("/posts/:post_id", h.UpdatePost)
+		protected.DELETE("/posts/:post_id", h.DeletePost)
+		protected.POST("/posts/:post_id/comments", h.CreateComment)
+	}
+}
+
+func render(c *gin.Context, status int, body interface{}) {
+	c.Header("Content-Type", dto.MediaType+"; charset=utf-8")
+	if body == nil {
+		c.Status(status)
+		return
+	}
+	c.JSON(status, body)
+}
+
+func renderError(c *gin.Context, status int, code, message string) {
+	render(c, status, dto.ErrorResponse{Error: dto.ErrorBody{Code: code, Message: message}})
+}
+
+// renderServiceError 把 service 层返回的错误统一映射为 HTTP 状态码和错误码
+func (h *Handler) renderServiceError(c *gin.Context, err error, action string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		renderError(c, http.StatusNotFound, "not_found", "资源不存在")
+	case errors.Is(err, bcrypt.ErrMismatchedHashAndPassword):
+		renderError(c, http.StatusUnauthorized, "invalid_credentials", "用户不存在或密码错误")
+	case errors.Is(err, service.ErrUserBanned):
+		renderError(c, http.StatusForbidden, "user_banned", "用户已被封禁")
+	case errors.Is(err, service.ErrPreconditionFailed):
+		renderError(c, http.StatusPreconditionFailed, "precondition_failed", "版本不匹配")
+	case errors.Is(err, service.ErrContentRejected):
+		renderError(c, http.StatusUnprocessableEntity, "content_rejected", "内容未通过审核")
+	case errors.Is(err, repository.ErrVersionConflict):
+		renderError(c, http.StatusConflict, "version_conflict", "资源已被其他人修改")
+	case err.Error() == "unauthorized":
+		renderError(c, http.StatusForbidden, "forbidden", "没有权限")
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(c.Request.Context().Err(), context.DeadlineExceeded):
+		renderError(c, http.StatusGatewayTimeout, "timeout", "请求超时")
+	default:
+		h.log.Errorf("%s失败: %v", action, err)
+		renderError(c, http.StatusInternalServerError, "internal", action+"失败")
+	}
+}
+
+func pathID(c *gin.Context, name string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(name), 10, 64)
+	if err != nil || id == 0 {
+		renderError(c, http.StatusBadRequest, "invalid_argument", "参数错误")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// pagination 读取 page、page_size 查询参数，非法或缺省时使用默认值
+func pagination(c *gin.Context) (int, int) {
+	page, err := strconv.Atoi(c.Query("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	pageSize, err := strconv.Atoi(c.Query("page_size"))
+	if err != nil || pageSize < 1 {
+		pageSize = defaultPageSize
+	}
+	return page, min(pageSize, maxPageSize)
+}
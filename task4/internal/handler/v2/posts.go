@@ -0,0 +1,155 @@
+package v2
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	dto "github.com/miffyG/golearn/task4/internal/models/dto/v2"
+	"github.com/miffyG/golearn/task4/internal/models/entity"
+	"github.com/miffyG/golearn/task4/internal/utils"
+)
+
+// @Summary 分页获取帖子列表
+// @Tags posts
+// @Produce json
+// @Param page query int false "页码，从 1 开始"
+// @Param page_size query int false "每页数量，最大 100"
+// @Success 200 {object} dto.ListResponse{data=[]dto.Post}
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /posts [get]
+func (h *Handler) ListPosts(c *gin.Context) {
+	page, pageSize := pagination(c)
+	posts, total, err := h.postService.List(c.Request.Context(), page, pageSize)
+	if err != nil {
+		h.renderServiceError(c, err, "获取帖子")
+		return
+	}
+	render(c, http.StatusOK, dto.ListResponse{
+		Data:       dto.NewPosts(posts),
+		Pagination: dto.Pagination{Page: page, PageSize: pageSize, Total: total},
+	})
+}
+
+// @Summary 获取帖子详情
+// @Tags posts
+// @Produce json
+// @Param post_id path int true "帖子ID"
+// @Success 200 {object} dto.DataResponse{data=dto.Post}
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /posts/{post_id} [get]
+func (h *Handler) GetPost(c *gin.Context) {
+	postId, ok := pathID(c, "post_id")
+	if !ok {
+		return
+	}
+	p, err := h.postService.GetByID(c.Request.Context(), postId)
+	if err != nil {
+		h.renderServiceError(c, err, "获取帖子")
+		return
+	}
+	c.Header("ETag", utils.VersionETag(p.Version))
+	render(c, http.StatusOK, dto.DataResponse{Data: dto.NewPost(p)})
+}
+
+// @Summary 创建帖子
+// @Tags posts
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param post body dto.PostRequest true "帖子信息"
+// @Success 201 {object} dto.DataResponse{data=dto.Post}
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /posts [post]
+func (h *Handler) CreatePost(c *gin.Context) {
+	var req dto.PostRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		renderError(c, http.StatusBadRequest, "invalid_argument", "参数错误")
+		return
+	}
+	post := entity.Post{
+		Title:   req.Title,
+		Content: req.Content,
+		UserID:  c.GetUint("user_id"),
+	}
+	if err := h.postService.Create(c.Request.Context(), &post); err != nil {
+		h.renderServiceError(c, err, "创建帖子")
+		return
+	}
+	c.Header("Location", fmt.Sprintf("/api/v2/posts/%d", post.ID))
+	c.Header("ETag", utils.VersionETag(post.Version))
+	render(c, http.StatusCreated, dto.DataResponse{Data: dto.NewPost(&post)})
+}
+
+// @Summary 更新帖子
+// @Tags posts
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param post_id path int true "帖子ID"
+// @Param If-Match header string false "帖子的 ETag"
+// @Param post body dto.PostRequest true "帖子信息"
+// @Success 200 {object} dto.DataResponse{data=dto.Post}
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 412 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /posts/{post_id} [put]
+func (h *Handler) UpdatePost(c *gin.Context) {
+	postId, ok := pathID(c, "post_id")
+	if !ok {
+		return
+	}
+	ifMatch, ok := utils.ParseIfMatch(c.GetHeader("If-Match"))
+	if !ok {
+		renderError(c, http.StatusBadRequest, "invalid_argument", "If-Match 格式错误")
+		return
+	}
+	var req dto.PostRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		renderError(c, http.StatusBadRequest, "invalid_argument", "参数错误")
+		return
+	}
+	post := &entity.Post{
+		Title:   req.Title,
+		Content: req.Content,
+	}
+	post.ID = postId
+	if err := h.postService.Update(c.Request.Context(), c.GetUint("user_id"), post, ifMatch); err != nil {
+		if post.Version != 0 {
+			c.Header("ETag", utils.VersionETag(post.Version))
+		}
+		h.renderServiceError(c, err, "更新帖子")
+		return
+	}
+	c.Header("ETag", utils.VersionETag(post.Version))
+	render(c, http.StatusOK, dto.DataResponse{Data: dto.NewPost(post)})
+}
+
+// @Summary 删除帖子
+// @Tags posts
+// @Security BearerAuth
+// @Param post_id path int true "帖子ID"
+// @Success 204
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /posts/{post_id} [delete]
+func (h *Handler) DeletePost(c *gin.Context) {
+	postId, ok := pathID(c, "post_id")
+	if !ok {
+		return
+	}
+	if err := h.postService.Delete(c.Request.Context(), c.GetUint("user_id"), postId); err != nil {
+		h.renderServiceError(c, err, "删除帖子")
+		return
+	}
+	render(c, http.StatusNoContent, nil)
+}
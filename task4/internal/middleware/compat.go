@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// LegacyFieldsKey 是 gin.Context 中标记本次请求是否使用旧字段名的键
+	LegacyFieldsKey = "legacy_fields"
+	// CompatHeader 允许客户端显式选择字段命名：legacy 为旧的 PascalCase 字段，current 为 snake_case 字段
+	CompatHeader = "X-API-Compat"
+)
+
+// CompatFields 根据全局开关和 X-API-Compat 请求头决定响应使用的字段命名，
+// 使用旧字段名时附带 Warning 响应头提醒客户端迁移
+func CompatFields(defaultLegacy bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		legacy := defaultLegacy
+		switch strings.ToLower(c.GetHeader(CompatHeader)) {
+		case "legacy":
+			legacy = true
+		case "current":
+			legacy = false
+		}
+		if legacy {
+			c.Header("Warning", `299 - "legacy field names are deprecated"`)
+		}
+		c.Set(LegacyFieldsKey, legacy)
+		c.Next()
+	}
+}
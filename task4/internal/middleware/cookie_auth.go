@@ -0,0 +1,119 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/miffyG/golearn/task4/pkg/config"
+)
+
+// csrfFailedKey 标记本次请求携带了认证 cookie 但 CSRF 校验失败，JwtAuth 据此给出具体的错误信息
+const csrfFailedKey = "csrf_failed"
+
+// CookieAuth 让浏览器用 HttpOnly cookie 代替 Authorization 头：请求没有 Authorization 头但带有认证 cookie 时，
+// 把 cookie 中的 token 转为 Authorization: Bearer 交给后面的 JwtAuth 校验。
+// 写请求还必须在 CSRF 请求头中带上与 CSRF cookie 相同的值，否则忽略认证 cookie，按匿名请求处理
+func CookieAuth(cfg *config.AuthCookie) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !cfg.Enabled || c.GetHeader("Authorization") != "" {
+			c.Next()
+			return
+		}
+		token, err := c.Cookie(cfg.Name)
+		if err != nil || token == "" {
+			c.Next()
+			return
+		}
+		if !safeMethod(c.Request.Method) && !validCSRF(c, cfg) {
+			c.Set(csrfFailedKey, true)
+			c.Next()
+			return
+		}
+		c.Request.Header.Set("Authorization", "Bearer "+token)
+		c.Next()
+	}
+}
+
+// SetAuthCookies 在登录成功后写入认证 cookie 和新的 CSRF cookie，返回 CSRF token。
+// 前端与接口不同源时读不到接口域名下的 cookie，需要保存返回的 CSRF token
+func SetAuthCookies(c *gin.Context, cfg *config.AuthCookie, token string) (string, error) {
+	csrf, err := newCSRFToken()
+	if err != nil {
+		return "", err
+	}
+	maxAge := int(cfg.MaxAge.Seconds())
+	setCookie(c, cfg, cfg.Name, token, maxAge, true)
+	setCookie(c, cfg, cfg.CSRFName, csrf, maxAge, false)
+	return csrf, nil
+}
+
+// CSRFToken 返回请求携带的 CSRF token，没有时生成一个新的并写入 cookie
+func CSRFToken(c *gin.Context, cfg *config.AuthCookie) (string, error) {
+	if csrf, err := c.Cookie(cfg.CSRFName); err == nil && csrf != "" {
+		return csrf, nil
+	}
+	csrf, err := newCSRFToken()
+	if err != nil {
+		return "", err
+	}
+	setCookie(c, cfg, cfg.CSRFName, csrf, int(cfg.MaxAge.Seconds()), false)
+	return csrf, nil
+}
+
+// ClearAuthCookies 删除认证 cookie 和 CSRF cookie
+func ClearAuthCookies(c *gin.Context, cfg *config.AuthCookie) {
+	setCookie(c, cfg, cfg.Name, "", -1, true)
+	setCookie(c, cfg, cfg.CSRFName, "", -1, false)
+}
+
+// setCookie 写入 cookie，CSRF cookie 不设置 HttpOnly，同源的前端可以直接读取
+func setCookie(c *gin.Context, cfg *config.AuthCookie, name, value string, maxAge int, httpOnly bool) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		Domain:   cfg.Domain,
+		MaxAge:   maxAge,
+		Secure:   cfg.Secure,
+		HttpOnly: httpOnly,
+		SameSite: sameSite(cfg.SameSite),
+	})
+}
+
+func validCSRF(c *gin.Context, cfg *config.AuthCookie) bool {
+	cookie, err := c.Cookie(cfg.CSRFName)
+	header := c.GetHeader(cfg.CSRFHeader)
+	if err != nil || cookie == "" || header == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(cookie), []byte(header)) == 1
+}
+
+func newCSRFToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func safeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+func sameSite(mode string) http.SameSite {
+	switch mode {
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	}
+	return http.SameSiteLaxMode
+}
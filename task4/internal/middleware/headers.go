@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/miffyG/golearn/task4/pkg/config"
+)
+
+// SecurityHeaders 为所有响应添加安全相关的响应头。HSTS 只在 HTTPS 请求的响应中发送，
+// 服务部署在终止 TLS 的反向代理之后时，由代理设置的 X-Forwarded-Proto 判断
+func SecurityHeaders(cfg *config.Headers) gin.HandlerFunc {
+	hsts := ""
+	if cfg.HSTSMaxAge > 0 {
+		hsts = fmt.Sprintf("max-age=%d", int(cfg.HSTSMaxAge.Seconds()))
+		if cfg.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+	}
+	return func(c *gin.Context) {
+		h := c.Writer.Header()
+		h.Set("X-Content-Type-Options", "nosniff")
+		if cfg.CSP != "" {
+			h.Set("Content-Security-Policy", cfg.CSP)
+		}
+		if cfg.FrameOptions != "" {
+			h.Set("X-Frame-Options", cfg.FrameOptions)
+		}
+		if cfg.ReferrerPolicy != "" {
+			h.Set("Referrer-Policy", cfg.ReferrerPolicy)
+		}
+		if hsts != "" && (c.Request.TLS != nil || strings.EqualFold(c.GetHeader("X-Forwarded-Proto"), "https")) {
+			h.Set("Strict-Transport-Security", hsts)
+		}
+		c.Next()
+	}
+}
+
+// ContentSecurityPolicy 覆盖 SecurityHeaders 设置的 Content-Security-Policy，用于需要加载脚本和样式的页面
+func ContentSecurityPolicy(policy string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Content-Security-Policy", policy)
+		c.Next()
+	}
+}
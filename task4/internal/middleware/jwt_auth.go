@@ -5,40 +5,61 @@ import (
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/miffyG/golearn/task4/internal/audit"
 	"github.com/miffyG/golearn/task4/internal/models/dto"
-	"github.com/miffyG/golearn/task4/internal/utils"
-	"github.com/miffyG/golearn/task4/pkg/config"
+	"github.com/miffyG/golearn/task4/internal/tokens"
 )
 
-func JwtAuthMiddleware() gin.HandlerFunc {
+func JwtAuthMiddleware(keys *tokens.KeySet) gin.HandlerFunc {
+	return JwtAuth(keys, func(c *gin.Context, message string) {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, dto.ErrorResponse{
+			Code:    http.StatusUnauthorized,
+			Message: message,
+		})
+	})
+}
+
+// JwtAuth 用 keys 校验 Authorization: Bearer <token>，通过后把 user_id 写入上下文，同时作为审计日志的操作人；
+// 使用 cookie 认证时 token 由 CookieAuth 转为 Authorization 头。校验失败时调用 onFail 输出错误，由调用方决定响应格式并中止请求
+func JwtAuth(keys *tokens.KeySet, onFail func(c *gin.Context, message string)) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, dto.ErrorResponse{
-				Code:    http.StatusUnauthorized,
-				Message: "未授权",
-			})
+			if c.GetBool(csrfFailedKey) {
+				onFail(c, "CSRF 校验失败")
+				return
+			}
+			onFail(c, "未授权")
 			return
 		}
 		parts := strings.SplitN(authHeader, " ", 2)
 		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, dto.ErrorResponse{
-				Code:    http.StatusUnauthorized,
-				Message: "未授权",
-			})
+			onFail(c, "未授权")
 			return
 		}
 
 		tokenStr := parts[1]
-		claims, err := utils.ParseJWTToken(config.GetSecretConfig().JwtSecret, tokenStr)
+		claims, err := keys.Parse(tokenStr)
 		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, dto.ErrorResponse{
-				Code:    http.StatusUnauthorized,
-				Message: "token无效",
-			})
+			onFail(c, "token无效")
 			return
 		}
 		c.Set("user_id", claims.UserID)
+		c.Request = c.Request.WithContext(audit.WithActor(c.Request.Context(), claims.UserID))
+		c.Next()
+	}
+}
+
+// OptionalJwtAuth 携带合法 token 时写入 user_id，未携带或无效时按匿名请求放行
+func OptionalJwtAuth(keys *tokens.KeySet) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		parts := strings.SplitN(c.GetHeader("Authorization"), " ", 2)
+		if len(parts) == 2 && parts[0] == "Bearer" {
+			if claims, err := keys.Parse(parts[1]); err == nil {
+				c.Set("user_id", claims.UserID)
+				c.Request = c.Request.WithContext(audit.WithActor(c.Request.Context(), claims.UserID))
+			}
+		}
 		c.Next()
 	}
 }
@@ -0,0 +1,144 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/miffyG/golearn/task4/internal/models/dto"
+	"github.com/miffyG/golearn/task4/pkg/openapi"
+	"go.uber.org/zap"
+)
+
+// OpenAPIValidator 按 OpenAPI 文档校验接口，文档中没有的路由不做检查。validateRequests 为 true 时校验请求，
+// 参数或请求体不符合文档时返回 400，Content-Type 不在文档中时返回 415；
+// validateResponses 为 true 时缓存响应并按文档校验状态码和响应体，
+// 不一致时记录日志并改为返回 500，用于在测试中发现 handler 与文档的偏差，会影响流式响应，不应在生产环境开启。
+// 使用旧字段名的响应不在文档中，不做校验
+func OpenAPIValidator(doc *openapi.Document, validateRequests, validateResponses bool, log *zap.SugaredLogger) gin.HandlerFunc {
+	basePath := doc.BasePath()
+	return func(c *gin.Context) {
+		path := c.FullPath()
+		if !strings.HasPrefix(path, basePath) {
+			c.Next()
+			return
+		}
+		op := doc.Operation(c.Request.Method, templatePath(strings.TrimPrefix(path, basePath)))
+		if op == nil {
+			c.Next()
+			return
+		}
+
+		if validateRequests {
+			params := make(map[string]string, len(c.Params))
+			for _, p := range c.Params {
+				params[p.Key] = p.Value
+			}
+			if err := doc.ValidateRequest(op, c.Request, params); err != nil {
+				status, message := http.StatusBadRequest, "参数错误: "
+				var reqErr *openapi.RequestError
+				if errors.As(err, &reqErr) && reqErr.Status == http.StatusUnsupportedMediaType {
+					status, message = reqErr.Status, "不支持的请求格式: "
+				}
+				c.AbortWithStatusJSON(status, dto.ErrorResponse{
+					Code:    status,
+					Message: message + err.Error(),
+				})
+				return
+			}
+		}
+
+		if !validateResponses {
+			c.Next()
+			return
+		}
+		w := &bufferedWriter{ResponseWriter: c.Writer, status: http.StatusOK}
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+
+		if legacy, _ := c.Get(LegacyFieldsKey); legacy == true {
+			w.flush()
+			return
+		}
+		if err := doc.ValidateResponse(op, w.status, w.Header(), w.body.Bytes()); err != nil {
+			log.Errorw("响应不符合接口文档", "method", c.Request.Method, "route", path, "status", w.status, "error", err)
+			w.Header().Del("Content-Length")
+			w.Header().Del("ETag")
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "响应不符合接口文档: " + err.Error(),
+			})
+			return
+		}
+		w.flush()
+	}
+}
+
+// templatePath 把 gin 的路由模板转换为文档中的写法，如 /posts/:post_id 转换为 /posts/{post_id}
+func templatePath(route string) string {
+	parts := strings.Split(route, "/")
+	for i, p := range parts {
+		if strings.HasPrefix(p, ":") || strings.HasPrefix(p, "*") {
+			parts[i] = "{" + p[1:] + "}"
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// bufferedWriter 缓存状态码和响应体，校验通过后由 flush 写出，响应头直接写入原 ResponseWriter
+type bufferedWriter struct {
+	gin.ResponseWriter
+	status  int
+	written bool
+	body    bytes.Buffer
+}
+
+func (w *bufferedWriter) WriteHeader(code int) {
+	if code > 0 && !w.written {
+		w.status = code
+	}
+}
+
+func (w *bufferedWriter) WriteHeaderNow() {
+	w.written = true
+}
+
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	w.written = true
+	return w.body.Write(data)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	w.written = true
+	return w.body.WriteString(s)
+}
+
+func (w *bufferedWriter) Status() int {
+	return w.status
+}
+
+func (w *bufferedWriter) Size() int {
+	if !w.written {
+		return -1
+	}
+	return w.body.Len()
+}
+
+func (w *bufferedWriter) Written() bool {
+	return w.written
+}
+
+// Flush 在缓存期间不向客户端输出
+func (w *bufferedWriter) Flush() {}
+
+func (w *bufferedWriter) flush() {
+	w.ResponseWriter.WriteHeader(w.status)
+	if w.body.Len() > 0 {
+		w.ResponseWriter.Write(w.body.Bytes())
+	} else {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/miffyG/golearn/task4/internal/audit"
+)
+
+// RequestIDHeader 是请求 ID 的请求头和响应头
+const RequestIDHeader = "X-Request-ID"
+
+// RequestID 沿用客户端传入的 X-Request-ID，没有或过长时生成新的请求 ID 并写入响应头；
+// 请求 ID、客户端 IP 和 UA 写入 request context，供 service 层写审计日志
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if id == "" || len(id) > 64 {
+			id = audit.NewRequestID()
+		}
+		c.Set("request_id", id)
+		c.Header(RequestIDHeader, id)
+		ctx := audit.WithMeta(c.Request.Context(), audit.Meta{
+			RequestID: id,
+			IP:        c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+		})
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
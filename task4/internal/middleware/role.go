@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"github.com/miffyG/golearn/task4/internal/models/dto"
+	"github.com/miffyG/golearn/task4/internal/models/entity"
+	"github.com/miffyG/golearn/task4/internal/service"
+)
+
+// CurrentUserKey 是 gin.Context 中保存当前登录用户（*entity.User）的键
+const CurrentUserKey = "current_user"
+
+// RequireRole 需要挂在 JWT 中间件之后，从数据库加载当前用户并校验角色是否为 roles 之一，
+// 角色不符或用户已被封禁时返回 403，校验通过后把用户写入上下文
+func RequireRole(userService *service.UserService, roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := userService.GetByID(c.Request.Context(), c.GetUint("user_id"))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, dto.ErrorResponse{
+				Code:    http.StatusUnauthorized,
+				Message: "未授权",
+			})
+			return
+		}
+		if user.Banned || !slices.Contains(roles, user.Role) {
+			c.AbortWithStatusJSON(http.StatusForbidden, dto.ErrorResponse{
+				Code:    http.StatusForbidden,
+				Message: "没有权限",
+			})
+			return
+		}
+		c.Set(CurrentUserKey, user)
+		c.Next()
+	}
+}
+
+// CurrentUser 返回 RequireRole 写入上下文的当前用户
+func CurrentUser(c *gin.Context) *entity.User {
+	user, _ := c.Get(CurrentUserKey)
+	u, _ := user.(*entity.User)
+	return u
+}
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Timeout 为请求的 context 设置超时时间，service 和 repository 使用这个 context 访问数据库，
+// 超时后查询被取消，由 handler 返回 504。routes 按 "方法 路由模板" 覆盖默认值，0 表示不限制
+func Timeout(defaultTimeout time.Duration, routes map[string]time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		d := defaultTimeout
+		if v, ok := routes[c.Request.Method+" "+c.FullPath()]; ok {
+			d = v
+		}
+		if d <= 0 {
+			c.Next()
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), d)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
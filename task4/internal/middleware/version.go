@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NegotiateVersion 包装 engine，在 gin 路由之前处理版本协商：请求路径以 fromPrefix 开头、Accept 请求头要求 mediaType，
+// 并且新旧版本都注册了同样的路由时，把路径中的 fromPrefix 替换为 toPrefix 后交给 engine，否则按旧版本处理。
+// 改写发生在路由之前，请求 ID、超时等全局中间件只执行一次
+func NegotiateVersion(engine *gin.Engine, mediaType, fromPrefix, toPrefix string) http.Handler {
+	var (
+		once   sync.Once
+		routes []routePattern
+	)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, fromPrefix+"/") && acceptsMediaType(r.Header.Get("Accept"), mediaType) {
+			// 路由在服务启动前已全部注册，第一次请求时再收集两个版本共有的路由
+			once.Do(func() { routes = sharedRoutes(engine.Routes(), fromPrefix, toPrefix) })
+			suffix := strings.TrimPrefix(r.URL.Path, fromPrefix)
+			for _, route := range routes {
+				if route.match(r.Method, suffix) {
+					r = r.Clone(r.Context())
+					r.URL.Path = toPrefix + suffix
+					r.URL.RawPath = ""
+					break
+				}
+			}
+		}
+		engine.ServeHTTP(w, r)
+	})
+}
+
+// routePattern 是去掉版本前缀的路由模板，按 / 分段，:name 匹配任意一段，*name 匹配剩余部分
+type routePattern struct {
+	method   string
+	segments []string
+}
+
+// sharedRoutes 返回 fromPrefix 和 toPrefix 下都注册了的路由
+func sharedRoutes(all gin.RoutesInfo, fromPrefix, toPrefix string) []routePattern {
+	old := make(map[string]bool)
+	for _, r := range all {
+		if suffix, ok := strings.CutPrefix(r.Path, fromPrefix+"/"); ok {
+			old[r.Method+" "+suffix] = true
+		}
+	}
+	var routes []routePattern
+	for _, r := range all {
+		if suffix, ok := strings.CutPrefix(r.Path, toPrefix+"/"); ok && old[r.Method+" "+suffix] {
+			routes = append(routes, routePattern{method: r.Method, segments: strings.Split(suffix, "/")})
+		}
+	}
+	return routes
+}
+
+func (p routePattern) match(method, path string) bool {
+	if method != p.method {
+		return false
+	}
+	segments := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	for i, s := range p.segments {
+		if strings.HasPrefix(s, "*") {
+			return true
+		}
+		if i >= len(segments) {
+			return false
+		}
+		if strings.HasPrefix(s, ":") {
+			if segments[i] == "" {
+				return false
+			}
+			continue
+		}
+		if s != segments[i] {
+			return false
+		}
+	}
+	return len(segments) == len(p.segments)
+}
+
+func acceptsMediaType(accept, mediaType string) bool {
+	for _, part := range strings.Split(accept, ",") {
+		mt, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err == nil && mt == mediaType {
+			return true
+		}
+	}
+	return false
+}
+
+// Deprecated 为已弃用的接口添加 Deprecation (RFC 9745)、Sunset (RFC 8594) 响应头，
+// 并通过 Link 指向替代版本；时间为零值时省略对应的响应头
+func Deprecated(deprecatedAt, sunset time.Time, successor string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !deprecatedAt.IsZero() {
+			c.Header("Deprecation", fmt.Sprintf("@%d", deprecatedAt.Unix()))
+		}
+		if !sunset.IsZero() {
+			c.Header("Sunset", sunset.UTC().Format(http.TimeFormat))
+		}
+		if successor != "" {
+			c.Header("Link", fmt.Sprintf(`<%s>; rel="successor-version"`, successor))
+		}
+		c.Next()
+	}
+}
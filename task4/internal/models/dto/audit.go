@@ -0,0 +1,61 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/miffyG/golearn/task4/internal/models/entity"
+)
+
+type AuditLogResponse struct {
+	ID         uint   `json:"id"`
+	RequestID  string `json:"request_id,omitempty"`
+	ActorID    uint   `json:"actor_id"`
+	IP         string `json:"ip,omitempty"`
+	UserAgent  string `json:"user_agent,omitempty"`
+	Action     string `json:"action"`
+	TargetType string `json:"target_type,omitempty"`
+	TargetID   uint   `json:"target_id,omitempty"`
+	// 变更前后的 JSON 摘要，没有时省略
+	Before    json.RawMessage `json:"before,omitempty" swaggertype:"object"`
+	After     json.RawMessage `json:"after,omitempty" swaggertype:"object"`
+	Detail    string          `json:"detail,omitempty"`
+	Hash      string          `json:"hash,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type AuditLogListResponse struct {
+	Total int64              `json:"total"`
+	Logs  []AuditLogResponse `json:"logs"`
+}
+
+func NewAuditLogResponse(l *entity.AuditLog) AuditLogResponse {
+	resp := AuditLogResponse{
+		ID:         l.ID,
+		RequestID:  l.RequestID,
+		ActorID:    l.ActorID,
+		IP:         l.IP,
+		UserAgent:  l.UserAgent,
+		Action:     l.Action,
+		TargetType: l.TargetType,
+		TargetID:   l.TargetID,
+		Detail:     l.Detail,
+		Hash:       l.Hash,
+		CreatedAt:  l.CreatedAt,
+	}
+	if l.Before != "" {
+		resp.Before = json.RawMessage(l.Before)
+	}
+	if l.After != "" {
+		resp.After = json.RawMessage(l.After)
+	}
+	return resp
+}
+
+func NewAuditLogListResponse(logs []entity.AuditLog, total int64) AuditLogListResponse {
+	resp := AuditLogListResponse{Total: total, Logs: make([]AuditLogResponse, len(logs))}
+	for i := range logs {
+		resp.Logs[i] = NewAuditLogResponse(&logs[i])
+	}
+	return resp
+}
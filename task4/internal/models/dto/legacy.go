@@ -0,0 +1,81 @@
+package dto
+
+import "time"
+
+// 旧版接口的响应结构，字段名沿用 PascalCase（"Title"、"UserID" 等）。
+// 仅在兼容模式下返回，给老客户端留出迁移时间，弃用期结束后删除。
+
+type LegacyPostResponse struct {
+	ID        uint
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Title     string
+	Content   string
+	UserID    uint
+	Version   uint
+	User      *UserResponse           `json:"User,omitempty"`
+	Comments  []LegacyCommentResponse `json:"Comments,omitempty"`
+}
+
+type LegacyCommentResponse struct {
+	ID        uint
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Content   string
+	UserID    uint
+	PostID    uint
+}
+
+func (p PostResponse) Legacy() LegacyPostResponse {
+	res := LegacyPostResponse{
+		ID:        p.ID,
+		CreatedAt: p.CreatedAt,
+		UpdatedAt: p.UpdatedAt,
+		Title:     p.Title,
+		Content:   p.Content,
+		UserID:    p.UserID,
+		Version:   p.Version,
+		User:      p.User,
+	}
+	for _, c := range p.Comments {
+		res.Comments = append(res.Comments, c.Legacy())
+	}
+	return res
+}
+
+func (c CommentResponse) Legacy() LegacyCommentResponse {
+	return LegacyCommentResponse{
+		ID:        c.ID,
+		CreatedAt: c.CreatedAt,
+		UpdatedAt: c.UpdatedAt,
+		Content:   c.Content,
+		UserID:    c.UserID,
+		PostID:    c.PostID,
+	}
+}
+
+// Present 在 legacy 为 true 时把帖子、评论相关的响应转换为旧字段名，其他类型原样返回
+func Present(data interface{}, legacy bool) interface{} {
+	if !legacy {
+		return data
+	}
+	switch v := data.(type) {
+	case PostResponse:
+		return v.Legacy()
+	case []PostResponse:
+		res := make([]LegacyPostResponse, len(v))
+		for i := range v {
+			res[i] = v[i].Legacy()
+		}
+		return res
+	case CommentResponse:
+		return v.Legacy()
+	case []CommentResponse:
+		res := make([]LegacyCommentResponse, len(v))
+		for i := range v {
+			res[i] = v[i].Legacy()
+		}
+		return res
+	}
+	return data
+}
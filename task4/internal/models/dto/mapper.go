@@ -0,0 +1,112 @@
+package dto
+
+import "github.com/miffyG/golearn/task4/internal/models/entity"
+
+// 实体到响应结构的映射，所有接口都应通过这里构造响应数据，避免直接返回 entity 或 gorm.Model 的内部字段。
+
+func NewUserResponse(u *entity.User) *UserResponse {
+	if u == nil || u.ID == 0 {
+		return nil
+	}
+	return &UserResponse{
+		ID:       u.ID,
+		Username: u.UserName,
+		Email:    u.Email,
+		Phone:    u.Phone,
+	}
+}
+
+func NewPostResponse(p *entity.Post) PostResponse {
+	res := PostResponse{
+		ID:        p.ID,
+		Title:     p.Title,
+		Slug:      p.Slug,
+		Status:    p.Status,
+		Content:   p.Content,
+		UserID:    p.UserID,
+		Version:   p.Version,
+		CreatedAt: p.CreatedAt,
+		UpdatedAt: p.UpdatedAt,
+		User:      NewUserResponse(p.User),
+	}
+	if len(p.Comments) > 0 {
+		res.Comments = NewCommentResponses(p.Comments)
+	}
+	if len(p.Tags) > 0 {
+		res.Tags = TagNames(p.Tags)
+	}
+	return res
+}
+
+func TagNames(tags []entity.Tag) []string {
+	names := make([]string, len(tags))
+	for i, t := range tags {
+		names[i] = t.Name
+	}
+	return names
+}
+
+func NewPostResponses(posts []entity.Post) []PostResponse {
+	res := make([]PostResponse, len(posts))
+	for i := range posts {
+		res[i] = NewPostResponse(&posts[i])
+	}
+	return res
+}
+
+func NewCommentResponse(c *entity.Comment) CommentResponse {
+	return CommentResponse{
+		ID:        c.ID,
+		Content:   c.Content,
+		UserID:    c.UserID,
+		PostID:    c.PostID,
+		Status:    c.Status,
+		CreatedAt: c.CreatedAt,
+		UpdatedAt: c.UpdatedAt,
+	}
+}
+
+func NewCommentResponses(comments []entity.Comment) []CommentResponse {
+	res := make([]CommentResponse, len(comments))
+	for i := range comments {
+		res[i] = NewCommentResponse(&comments[i])
+	}
+	return res
+}
+
+// NewRevisionResponse 构造修订记录的响应，withContent 为 false 时省略正文（用于列表）
+func NewRevisionResponse(r *entity.PostRevision, withContent bool) RevisionResponse {
+	res := RevisionResponse{
+		Rev:       r.Rev,
+		PostID:    r.PostID,
+		EditorID:  r.EditorID,
+		Title:     r.Title,
+		CreatedAt: r.CreatedAt,
+	}
+	if withContent {
+		res.Content = r.Content
+	}
+	return res
+}
+
+func NewRevisionResponses(revisions []entity.PostRevision) []RevisionResponse {
+	res := make([]RevisionResponse, len(revisions))
+	for i := range revisions {
+		res[i] = NewRevisionResponse(&revisions[i], false)
+	}
+	return res
+}
+
+func NewTrashResponse(posts []entity.Post, total int64) TrashResponse {
+	res := TrashResponse{Total: total, Posts: make([]TrashPostResponse, len(posts))}
+	for i, p := range posts {
+		res.Posts[i] = TrashPostResponse{
+			ID:        p.ID,
+			Title:     p.Title,
+			Slug:      p.Slug,
+			CreatedAt: p.CreatedAt,
+			DeletedAt: p.DeletedAt.Time,
+		}
+	}
+	return res
+}
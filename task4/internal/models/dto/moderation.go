@@ -0,0 +1,53 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/miffyG/golearn/task4/internal/models/entity"
+)
+
+type ReportResponse struct {
+	ID uint `json:"id"`
+	// 过滤器拦截产生的记录为 0
+	ReporterID uint       `json:"reporter_id"`
+	TargetType string     `json:"target_type"`
+	TargetID   uint       `json:"target_id"`
+	Reason     string     `json:"reason"`
+	Status     string     `json:"status"`
+	HandledBy  uint       `json:"handled_by,omitempty"`
+	HandledAt  *time.Time `json:"handled_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// ModerationTargetResponse 是被举报或被拦截的内容
+type ModerationTargetResponse struct {
+	AuthorID uint   `json:"author_id"`
+	Title    string `json:"title,omitempty"`
+	Content  string `json:"content"`
+	Status   string `json:"status"`
+}
+
+// ModerationItemResponse 是审核队列中的一条记录，内容已被删除时 Target 为空
+type ModerationItemResponse struct {
+	Report ReportResponse            `json:"report"`
+	Target *ModerationTargetResponse `json:"target,omitempty"`
+}
+
+type ModerationQueueResponse struct {
+	Total int64                    `json:"total"`
+	Items []ModerationItemResponse `json:"items"`
+}
+
+func NewReportResponse(r *entity.Report) ReportResponse {
+	return ReportResponse{
+		ID:         r.ID,
+		ReporterID: r.ReporterID,
+		TargetType: r.TargetType,
+		TargetID:   r.TargetID,
+		Reason:     r.Reason,
+		Status:     r.Status,
+		HandledBy:  r.HandledBy,
+		HandledAt:  r.HandledAt,
+		CreatedAt:  r.CreatedAt,
+	}
+}
@@ -1,5 +1,7 @@
 package dto
 
+import "time"
+
 type Response struct {
 	Code    int         `json:"code"`
 	Message string      `json:"message,omitempty"`
@@ -19,15 +21,56 @@ type UserResponse struct {
 }
 
 type PostResponse struct {
-	Title    string
-	Content  string
-	UserID   uint
-	User     *UserResponse     `json:"User,omitempty"`
-	Comments []CommentResponse `json:"Comments,omitempty"`
+	ID        uint              `json:"id"`
+	Title     string            `json:"title"`
+	Slug      string            `json:"slug,omitempty"`
+	Status    string            `json:"status,omitempty"`
+	Content   string            `json:"content"`
+	UserID    uint              `json:"user_id"`
+	Version   uint              `json:"version"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+	User      *UserResponse     `json:"user,omitempty"`
+	Comments  []CommentResponse `json:"comments,omitempty"`
+	Tags      []string          `json:"tags,omitempty"`
 }
 
 type CommentResponse struct {
-	Content string
-	UserID  uint `json:"UserID,omitempty"`
-	PostID  uint `json:"PostID,omitempty"`
+	ID        uint      `json:"id"`
+	Content   string    `json:"content"`
+	UserID    uint      `json:"user_id"`
+	PostID    uint      `json:"post_id"`
+	Status    string    `json:"status,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type RevisionResponse struct {
+	Rev       uint      `json:"rev"`
+	PostID    uint      `json:"post_id"`
+	EditorID  uint      `json:"editor_id"`
+	Title     string    `json:"title"`
+	Content   string    `json:"content,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type RevisionDiffResponse struct {
+	PostID uint   `json:"post_id"`
+	Base   uint   `json:"base"`
+	Rev    uint   `json:"rev"`
+	Diff   string `json:"diff"`
+}
+
+// TrashPostResponse 是回收站中的文章，不包含正文
+type TrashPostResponse struct {
+	ID        uint      `json:"id"`
+	Title     string    `json:"title"`
+	Slug      string    `json:"slug,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+type TrashResponse struct {
+	Total int64               `json:"total"`
+	Posts []TrashPostResponse `json:"posts"`
 }
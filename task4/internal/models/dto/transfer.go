@@ -0,0 +1,83 @@
+package dto
+
+import "time"
+
+// TransferVersion 是导出数据格式的版本号
+const TransferVersion = 1
+
+// 导出数据的格式：ndjson 每行一条记录，json 为单个 JSON 文档
+const (
+	TransferFormatNDJSON = "ndjson"
+	TransferFormatJSON   = "json"
+)
+
+// NDJSON 中每行记录的类型，依次为 meta、user、post、comment
+const (
+	TransferTypeMeta    = "meta"
+	TransferTypeUser    = "user"
+	TransferTypePost    = "post"
+	TransferTypeComment = "comment"
+)
+
+// TransferMeta 是导出数据的头信息，NDJSON 中位于第一行
+type TransferMeta struct {
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exported_at"`
+}
+
+// TransferRecord 是 NDJSON 中的一行
+type TransferRecord[T any] struct {
+	Type string `json:"type"`
+	Data T      `json:"data"`
+}
+
+// TransferUser 是导出的用户，导入时按 username 匹配。ID 为源库中的 ID，只用于识别记录
+type TransferUser struct {
+	ID           uint   `json:"id"`
+	Username     string `json:"username"`
+	Email        string `json:"email"`
+	Phone        string `json:"phone,omitempty"`
+	Role         string `json:"role"`
+	Banned       bool   `json:"banned,omitempty"`
+	PasswordHash string `json:"password_hash,omitempty"`
+	// Deleted 为 true 时用户已被删除，导出是为了保留他们发表的文章和评论
+	Deleted   bool      `json:"deleted,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TransferPost 是导出的文章，导入时按作者、标题和创建时间匹配
+type TransferPost struct {
+	ID        uint      `json:"id"`
+	Author    string    `json:"author"`
+	Title     string    `json:"title"`
+	Content   string    `json:"content"`
+	Status    string    `json:"status,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TransferComment 是导出的评论，PostID 为源库中的文章 ID，导入时映射为目标库中的文章；
+// 按文章、作者和创建时间匹配
+type TransferComment struct {
+	ID        uint      `json:"id"`
+	PostID    uint      `json:"post_id"`
+	Author    string    `json:"author"`
+	Content   string    `json:"content"`
+	Status    string    `json:"status,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TransferCount 是导入时某类记录的新建和更新数量
+type TransferCount struct {
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+}
+
+// ImportResult 是导入的结果，DryRun 为 true 时数据库没有被修改
+type ImportResult struct {
+	DryRun   bool          `json:"dry_run"`
+	Users    TransferCount `json:"users"`
+	Posts    TransferCount `json:"posts"`
+	Comments TransferCount `json:"comments"`
+}
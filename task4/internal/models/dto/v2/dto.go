@@ -0,0 +1,155 @@
+// Package v2 定义 /api/v2 的请求和响应结构。
+// 与 v1 相比，v2 不再使用 {code,message,data} 包装：成功时用 HTTP 状态码表达结果并把资源放在 data 中，
+// 失败时返回 {"error":{"code","message"}}，列表接口附带分页信息。
+package v2
+
+import (
+	"time"
+
+	"github.com/miffyG/golearn/task4/internal/models/entity"
+)
+
+// MediaType 是 v2 响应的媒体类型，请求头 Accept 中携带它即可在 v1 路径上协商到 v2
+const MediaType = "application/vnd.golearn.v2+json"
+
+type DataResponse struct {
+	Data interface{} `json:"data"`
+}
+
+type ListResponse struct {
+	Data       interface{} `json:"data"`
+	Pagination Pagination  `json:"pagination"`
+}
+
+type Pagination struct {
+	Page     int   `json:"page"`
+	PageSize int   `json:"page_size"`
+	Total    int64 `json:"total"`
+}
+
+type ErrorResponse struct {
+	Error ErrorBody `json:"error"`
+}
+
+type ErrorBody struct {
+	Code    string      `json:"code"`
+	Message string      `json:"message"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+type RegisterRequest struct {
+	Username string `json:"username" binding:"required,min=3,max=26,alphanum"`
+	Password string `json:"password" binding:"required,min=6,max=26"`
+	Email    string `json:"email" binding:"required,email"`
+	Phone    string `json:"phone" binding:"phone"`
+}
+
+type LoginRequest struct {
+	Username string `json:"username" binding:"required,min=3,max=26,alphanum"`
+	Password string `json:"password" binding:"required,min=6,max=26"`
+}
+
+type PostRequest struct {
+	Title   string `json:"title" binding:"required"`
+	Content string `json:"content" binding:"required"`
+}
+
+type CommentRequest struct {
+	Content string `json:"content" binding:"required"`
+}
+
+type Token struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+}
+
+type User struct {
+	ID        uint      `json:"id"`
+	Username  string    `json:"username"`
+	Email     string    `json:"email,omitempty"`
+	Phone     string    `json:"phone,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Author struct {
+	ID       uint   `json:"id"`
+	Username string `json:"username"`
+}
+
+type Post struct {
+	ID        uint      `json:"id"`
+	Title     string    `json:"title"`
+	Slug      string    `json:"slug,omitempty"`
+	Status    string    `json:"status,omitempty"`
+	Content   string    `json:"content"`
+	Version   uint      `json:"version"`
+	Author    Author    `json:"author"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type Comment struct {
+	ID        uint      `json:"id"`
+	PostID    uint      `json:"post_id"`
+	AuthorID  uint      `json:"author_id"`
+	Content   string    `json:"content"`
+	Status    string    `json:"status,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func NewUser(u *entity.User) User {
+	return User{
+		ID:        u.ID,
+		Username:  u.UserName,
+		Email:     u.Email,
+		Phone:     u.Phone,
+		CreatedAt: u.CreatedAt,
+	}
+}
+
+func NewPost(p *entity.Post) Post {
+	res := Post{
+		ID:        p.ID,
+		Title:     p.Title,
+		Slug:      p.Slug,
+		Status:    p.Status,
+		Content:   p.Content,
+		Version:   p.Version,
+		Author:    Author{ID: p.UserID},
+		CreatedAt: p.CreatedAt,
+		UpdatedAt: p.UpdatedAt,
+	}
+	if p.User != nil {
+		res.Author.Username = p.User.UserName
+	}
+	return res
+}
+
+func NewPosts(posts []entity.Post) []Post {
+	res := make([]Post, len(posts))
+	for i := range posts {
+		res[i] = NewPost(&posts[i])
+	}
+	return res
+}
+
+func NewComment(c *entity.Comment) Comment {
+	return Comment{
+		ID:        c.ID,
+		PostID:    c.PostID,
+		AuthorID:  c.UserID,
+		Content:   c.Content,
+		Status:    c.Status,
+		CreatedAt: c.CreatedAt,
+		UpdatedAt: c.UpdatedAt,
+	}
+}
+
+func NewComments(comments []entity.Comment) []Comment {
+	res := make([]Comment, len(comments))
+	for i := range comments {
+		res[i] = NewComment(&comments[i])
+	}
+	return res
+}
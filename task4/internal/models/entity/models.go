@@ -1,17 +1,45 @@
 package entity
 
 import (
+	"crypto/rand"
+	"errors"
+	"time"
+
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
-// users 表：存储用户信息，包括 id 、 username 、 password 、 email 等字段。
+// 用户角色，moderator 可以处理举报和待审核的内容
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+// ValidRole 判断 role 是否为已知的角色
+func ValidRole(role string) bool {
+	return role == RoleUser || role == RoleModerator || role == RoleAdmin
+}
+
+// 文章和评论的审核状态，只有 published 的内容对外可见
+const (
+	StatusPublished = "published"
+	// 被内容过滤器拦截，等待审核员处理
+	StatusPending = "pending"
+	// 被审核员移除
+	StatusRemoved = "removed"
+)
+
+// users 表：存储用户信息，包括 id 、 username 、 password 、 email 、 role （user、moderator 或 admin）、
+// banned （被封禁的用户不能登录）等字段。
 type User struct {
 	gorm.Model
 	UserName string
 	Password string
 	Email    string `json:"Email,omitempty"`
 	Phone    string `json:"Phone,omitempty"`
+	Role     string `gorm:"size:16;not null;default:user"`
+	Banned   bool   `gorm:"not null;default:false"`
 	Posts    []Post `json:"Posts,omitempty"`
 }
 
@@ -24,26 +52,167 @@ func (u *User) SetPassword(pw string) error {
 	return nil
 }
 
+// SetUnusablePassword 把密码设置为一个随机值的哈希，没有人知道这个密码，用户只能由管理员重置密码或通过第三方账号登录。
+// 密码本身不会被使用，用最低的 cost 计算，批量创建用户时不会太慢
+func (u *User) SetUnusablePassword() error {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return err
+	}
+	hash, err := bcrypt.GenerateFromPassword(b, bcrypt.MinCost)
+	if err != nil {
+		return err
+	}
+	u.Password = string(hash)
+	return nil
+}
+
 func (u *User) CheckPassword(password string) error {
 	return bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password))
 }
 
 // posts 表：存储博客文章信息，包括 id 、 title 、 content 、 user_id （关联 users 表的 id）、
-// created_at 、 updated_at 等字段。
+// slug （由标题生成的 URL 标识）、 status （审核状态）、 version （乐观锁版本号，每次更新加 1）、 created_at 、 updated_at 等字段。
 type Post struct {
 	gorm.Model
 	Title    string
 	Content  string
 	UserID   uint
+	Slug     string    `gorm:"size:191;index"`
+	Status   string    `gorm:"size:16;not null;default:published;index"`
+	Version  uint      `gorm:"not null;default:1"`
 	User     *User     `gorm:"foreignKey:UserID" json:"User,omitempty"`
 	Comments []Comment `json:"Comments,omitempty"`
+	Tags     []Tag     `gorm:"many2many:post_tags" json:"Tags,omitempty"`
+}
+
+// post_slugs 表：存储文章用过的全部 slug ，包括 post_id 、 slug （全局唯一）、 created_at 等字段。
+// 修改标题会生成新的 slug ，旧的 slug 保留在这里，用于重定向到文章当前的 slug 。
+type PostSlug struct {
+	ID        uint   `gorm:"primarykey"`
+	PostID    uint   `gorm:"index"`
+	Slug      string `gorm:"size:191;uniqueIndex"`
+	CreatedAt time.Time
+}
+
+// tags 表：存储文章标签，包括 id 、 name （唯一）、 created_at 等字段，与 posts 表通过 post_tags 表多对多关联。
+type Tag struct {
+	ID        uint   `gorm:"primarykey"`
+	Name      string `gorm:"size:64;uniqueIndex"`
+	CreatedAt time.Time
 }
 
 // comments 表：存储文章评论信息，包括 id 、 content 、 user_id （关联 users 表的 id）、
-// post_id （关联 posts 表的 id ）、 created_at 等字段。
+// post_id （关联 posts 表的 id ）、 status （审核状态）、 created_at 等字段。
 type Comment struct {
 	gorm.Model
 	Content string
 	UserID  uint
 	PostID  uint
+	Status  string `gorm:"size:16;not null;default:published;index"`
+}
+
+// 举报针对的内容类型
+const (
+	ReportTargetPost    = "post"
+	ReportTargetComment = "comment"
+)
+
+// 举报的处理状态
+const (
+	ReportOpen     = "open"
+	ReportApproved = "approved"
+	ReportRemoved  = "removed"
+)
+
+// reports 表：存储用户举报和内容过滤器拦截的记录，包括 id 、 reporter_id （举报人，过滤器拦截时为 0）、
+// target_type （post 或 comment）、 target_id 、 reason 、 status （open、approved 或 removed）、
+// handled_by （处理的审核员）、 handled_at 、 created_at 等字段。
+type Report struct {
+	ID         uint   `gorm:"primarykey"`
+	ReporterID uint   `gorm:"index"`
+	TargetType string `gorm:"size:16;not null;index:idx_report_target"`
+	TargetID   uint   `gorm:"not null;index:idx_report_target"`
+	Reason     string `gorm:"size:500"`
+	Status     string `gorm:"size:16;not null;default:open;index"`
+	HandledBy  uint
+	HandledAt  *time.Time
+	CreatedAt  time.Time
+}
+
+// post_revisions 表：存储文章的历史版本，包括 post_id 、 rev （文章内递增的版本号）、 editor_id 、
+// title 、 content 、 created_at 等字段。每次创建或更新文章都会追加一条记录，记录写入后不可修改。
+type PostRevision struct {
+	ID        uint `gorm:"primarykey"`
+	PostID    uint `gorm:"uniqueIndex:idx_post_rev"`
+	Rev       uint `gorm:"uniqueIndex:idx_post_rev"`
+	EditorID  uint
+	Title     string
+	Content   string
+	CreatedAt time.Time
+}
+
+func (r *PostRevision) BeforeUpdate(tx *gorm.DB) error {
+	return errors.New("post revision is immutable")
+}
+
+// audit_logs 表：只追加的审计日志，包括 id 、 created_at 、 request_id 、 actor_id （操作人，匿名或系统操作为 0）、
+// ip 、 user_agent 、 action （如 post.delete）、 target_type 、 target_id 、 before / after （操作前后的 JSON 摘要）、
+// detail 、 prev_hash / hash （开启哈希链时，hash 由上一条记录的 hash 和本条记录的内容计算）等字段。记录写入后不可修改或删除。
+type AuditLog struct {
+	ID         uint      `gorm:"primarykey"`
+	CreatedAt  time.Time `gorm:"index"`
+	RequestID  string    `gorm:"size:64;index"`
+	ActorID    uint      `gorm:"index"`
+	IP         string    `gorm:"size:64"`
+	UserAgent  string    `gorm:"size:255"`
+	Action     string    `gorm:"size:64;index"`
+	TargetType string    `gorm:"size:16;index:idx_audit_target"`
+	TargetID   uint      `gorm:"index:idx_audit_target"`
+	Before     string    `gorm:"type:text"`
+	After      string    `gorm:"type:text"`
+	Detail     string    `gorm:"size:255"`
+	PrevHash   string    `gorm:"size:64"`
+	Hash       string    `gorm:"size:64"`
+}
+
+func (l *AuditLog) BeforeUpdate(tx *gorm.DB) error {
+	return errors.New("audit log is append-only")
+}
+
+func (l *AuditLog) BeforeDelete(tx *gorm.DB) error {
+	return errors.New("audit log is append-only")
+}
+
+// audit_chain_heads 表：只有一行，保存哈希链最后一条记录的 hash 。写入审计日志时对这一行加锁，
+// 保证多个实例并发写入时哈希链不会分叉
+type AuditChainHead struct {
+	ID     uint `gorm:"primarykey"`
+	LastID uint
+	Hash   string `gorm:"size:64"`
+}
+
+// identities 表：用户在第三方登录提供方的账号，包括 id 、 user_id （关联 users 表的 id）、 provider （提供方名称）、
+// subject （用户在提供方的唯一标识，与 provider 一起唯一）、 email 、 created_at 等字段。一个用户可以关联多个外部账号。
+type Identity struct {
+	ID        uint   `gorm:"primarykey"`
+	UserID    uint   `gorm:"not null;index"`
+	Provider  string `gorm:"size:32;not null;uniqueIndex:idx_identity_subject"`
+	Subject   string `gorm:"size:191;not null;uniqueIndex:idx_identity_subject"`
+	Email     string `gorm:"size:255"`
+	CreatedAt time.Time
+}
+
+// oidc_flows 表：进行中的第三方登录，包括 state_hash （回调参数 state 的 SHA-256，唯一）、 provider 、 nonce 、
+// verifier （PKCE 的 code_verifier）、 link_user_id （登录状态下发起时为当前用户，回调后关联外部账号）、 expires_at 等字段。
+// 回调时取出并删除，每条记录只能使用一次。
+type OIDCFlow struct {
+	ID         uint   `gorm:"primarykey"`
+	StateHash  string `gorm:"size:64;not null;uniqueIndex"`
+	Provider   string `gorm:"size:32;not null"`
+	Nonce      string `gorm:"size:64;not null"`
+	Verifier   string `gorm:"size:64;not null"`
+	LinkUserID uint
+	ExpiresAt  time.Time `gorm:"index"`
+	CreatedAt  time.Time
 }
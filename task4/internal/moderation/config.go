@@ -0,0 +1,50 @@
+package moderation
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/miffyG/golearn/task4/pkg/config"
+)
+
+// NewChain 按配置组装过滤器链，没有配置任何规则时返回空链，所有内容直接发布
+func NewChain(cfg *config.Moderation) (Chain, error) {
+	var action Action
+	switch cfg.BlockAction {
+	case "hold":
+		action = Hold
+	case "reject":
+		action = Reject
+	default:
+		return nil, fmt.Errorf("未知的屏蔽处理方式 %q", cfg.BlockAction)
+	}
+
+	words := cfg.BlockWords
+	var patterns []string
+	if cfg.BlocklistFile != "" {
+		f, err := os.Open(cfg.BlocklistFile)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		fileWords, filePatterns, err := ParseBlocklist(f)
+		if err != nil {
+			return nil, err
+		}
+		words = append(words, fileWords...)
+		patterns = filePatterns
+	}
+
+	var chain Chain
+	if len(words) > 0 || len(patterns) > 0 {
+		kf, err := NewKeywordFilter(words, patterns, action)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, kf)
+	}
+	if cfg.MaxLinks > 0 {
+		chain = append(chain, &LinkFilter{MaxLinks: cfg.MaxLinks})
+	}
+	return chain, nil
+}
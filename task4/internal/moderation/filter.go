@@ -0,0 +1,61 @@
+// Package moderation 实现发布文章和评论时执行的内容过滤器链
+package moderation
+
+// Action 是过滤器对内容的处理结论，数值越大越严格
+type Action int
+
+const (
+	// Allow 直接发布
+	Allow Action = iota
+	// Hold 暂不发布，转人工审核
+	Hold
+	// Reject 拒绝发布
+	Reject
+)
+
+func (a Action) String() string {
+	switch a {
+	case Hold:
+		return "hold"
+	case Reject:
+		return "reject"
+	}
+	return "allow"
+}
+
+// Content 是待检查的内容，评论的 Title 为空
+type Content struct {
+	// Kind 为 post 或 comment
+	Kind     string
+	AuthorID uint
+	Title    string
+	Body     string
+}
+
+// Decision 是过滤器的检查结果，Reason 说明拦截原因，会写入举报记录展示给审核员
+type Decision struct {
+	Action Action
+	Reason string
+}
+
+// ContentFilter 检查一条内容，放行时返回 Action 为 Allow 的 Decision
+type ContentFilter interface {
+	Check(c *Content) Decision
+}
+
+// Chain 依次执行多个过滤器，返回最严格的结论，遇到 Reject 时不再执行后面的过滤器
+type Chain []ContentFilter
+
+func (ch Chain) Check(c *Content) Decision {
+	var res Decision
+	for _, f := range ch {
+		d := f.Check(c)
+		if d.Action > res.Action {
+			res = d
+		}
+		if res.Action == Reject {
+			break
+		}
+	}
+	return res
+}
@@ -0,0 +1,67 @@
+package moderation
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// KeywordFilter 在标题和正文中查找屏蔽词或匹配屏蔽规则，命中时按 action 处理。
+// 屏蔽词不区分大小写
+type KeywordFilter struct {
+	words    []string
+	patterns []*regexp.Regexp
+	action   Action
+}
+
+func NewKeywordFilter(words, patterns []string, action Action) (*KeywordFilter, error) {
+	f := &KeywordFilter{action: action}
+	for _, w := range words {
+		if w = strings.TrimSpace(w); w != "" {
+			f.words = append(f.words, strings.ToLower(w))
+		}
+	}
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("屏蔽规则 %q 无效: %w", p, err)
+		}
+		f.patterns = append(f.patterns, re)
+	}
+	return f, nil
+}
+
+// ParseBlocklist 读取屏蔽词列表，每行一条，以 re: 开头的行是正则表达式，空行和 # 开头的行会被忽略
+func ParseBlocklist(r io.Reader) (words, patterns []string, err error) {
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if p, ok := strings.CutPrefix(line, "re:"); ok {
+			patterns = append(patterns, p)
+		} else {
+			words = append(words, line)
+		}
+	}
+	return words, patterns, sc.Err()
+}
+
+func (f *KeywordFilter) Check(c *Content) Decision {
+	text := c.Title + "\n" + c.Body
+	lower := strings.ToLower(text)
+	for _, w := range f.words {
+		if strings.Contains(lower, w) {
+			return Decision{Action: f.action, Reason: fmt.Sprintf("包含屏蔽词 %q", w)}
+		}
+	}
+	for _, re := range f.patterns {
+		if re.MatchString(text) {
+			return Decision{Action: f.action, Reason: fmt.Sprintf("匹配屏蔽规则 %q", re.String())}
+		}
+	}
+	return Decision{}
+}
@@ -0,0 +1,21 @@
+package moderation
+
+import (
+	"fmt"
+	"regexp"
+)
+
+var linkPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)\S+`)
+
+// LinkFilter 是垃圾内容的简单判断：链接数超过 MaxLinks 的内容转人工审核
+type LinkFilter struct {
+	MaxLinks int
+}
+
+func (f *LinkFilter) Check(c *Content) Decision {
+	n := len(linkPattern.FindAllStringIndex(c.Title+"\n"+c.Body, -1))
+	if n > f.MaxLinks {
+		return Decision{Action: Hold, Reason: fmt.Sprintf("包含 %d 个链接，超过上限 %d", n, f.MaxLinks)}
+	}
+	return Decision{}
+}
@@ -0,0 +1,36 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+)
+
+// Flow 是一次登录的随机参数，跳转前由 NewFlow 生成并由调用方保存，回调时交回 Exchange
+type Flow struct {
+	// State 防止回调被伪造，回调参数中的 state 必须与保存的一致
+	State string
+	// Nonce 写入 ID token，防止 ID token 被重放
+	Nonce string
+	// Verifier 是 PKCE 的 code_verifier，只有发起登录的一方知道，授权码被截获也无法使用
+	Verifier string
+}
+
+// NewFlow 生成新的 state、nonce 和 code_verifier，各包含 256 位随机数
+func NewFlow() (*Flow, error) {
+	var values [3]string
+	for i := range values {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		values[i] = base64.RawURLEncoding.EncodeToString(b)
+	}
+	return &Flow{State: values[0], Nonce: values[1], Verifier: values[2]}, nil
+}
+
+// challenge 返回 S256 方法的 code_challenge
+func (f *Flow) challenge() string {
+	sum := sha256.Sum256([]byte(f.Verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
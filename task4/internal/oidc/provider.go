@@ -0,0 +1,232 @@
+// Package oidc 以依赖方（RP）的身份实现 OpenID Connect 的授权码登录。
+//
+// 跳转到提供方时带上 state、nonce 和 PKCE (RFC 7636) 的 S256 code_challenge；回调时用授权码和 code_verifier
+// 换取 ID token，按提供方公开的 JWKS 校验签名，并检查 iss、aud、azp、exp、iat 和 nonce。
+// 提供方的配置通过 <issuer>/.well-known/openid-configuration 在第一次使用时获取。
+// 保存 Flow、把 state 与浏览器绑定并保证它只使用一次由调用方负责。
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miffyG/golearn/task4/pkg/config"
+)
+
+var (
+	// ErrProvider 表示提供方不可用、返回了错误或不合规的响应
+	ErrProvider = errors.New("提供方响应错误")
+	// ErrInvalidIDToken 表示 ID token 的签名或声明不合法
+	ErrInvalidIDToken = errors.New("ID token 无效")
+)
+
+const (
+	// defaultTimeout 是请求提供方的超时时间
+	defaultTimeout = 10 * time.Second
+	// maxResponseSize 是提供方响应体的最大字节数
+	maxResponseSize = 1 << 20
+)
+
+// Registry 是配置中的全部提供方
+type Registry struct {
+	providers map[string]*Provider
+}
+
+// NewRegistry 按 cfg 创建提供方，client 为 nil 时使用超时为 10 秒的默认客户端
+func NewRegistry(cfg *config.Oidc, client *http.Client) *Registry {
+	if client == nil {
+		client = &http.Client{Timeout: defaultTimeout}
+	}
+	r := &Registry{providers: map[string]*Provider{}}
+	for name, issuer := range cfg.Providers {
+		r.providers[name] = &Provider{
+			Name:         name,
+			issuer:       strings.TrimRight(issuer, "/"),
+			clientID:     cfg.ClientIDs[name],
+			clientSecret: cfg.ClientSecrets[name],
+			scopes:       cfg.Scopes,
+			client:       client,
+		}
+	}
+	return r
+}
+
+// Get 按名称返回提供方
+func (r *Registry) Get(name string) (*Provider, bool) {
+	p, ok := r.providers[name]
+	return p, ok
+}
+
+// Names 按字母顺序返回全部提供方的名称
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Provider 是一个 OpenID Connect 提供方，可以并发使用
+type Provider struct {
+	Name         string
+	issuer       string
+	clientID     string
+	clientSecret string
+	scopes       []string
+	client       *http.Client
+
+	mu sync.Mutex
+	// meta 在第一次成功获取后缓存
+	meta *metadata
+	// keys 按 kid 缓存 JWKS 中的公钥，keysFetched 是上次获取的时间
+	keys        map[string]publicKey
+	keysFetched time.Time
+}
+
+// metadata 是 openid-configuration 中用到的字段
+type metadata struct {
+	Issuer                string   `json:"issuer"`
+	AuthorizationEndpoint string   `json:"authorization_endpoint"`
+	TokenEndpoint         string   `json:"token_endpoint"`
+	JWKSURI               string   `json:"jwks_uri"`
+	CodeChallengeMethods  []string `json:"code_challenge_methods_supported"`
+	SigningAlgorithms     []string `json:"id_token_signing_alg_values_supported"`
+	TokenAuthMethods      []string `json:"token_endpoint_auth_methods_supported"`
+}
+
+// discover 返回提供方的配置，第一次调用时从 openid-configuration 获取，issuer 必须与配置一致
+func (p *Provider) discover(ctx context.Context) (*metadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.meta != nil {
+		return p.meta, nil
+	}
+	var meta metadata
+	if err := p.getJSON(ctx, p.issuer+"/.well-known/openid-configuration", &meta); err != nil {
+		return nil, err
+	}
+	if meta.Issuer != p.issuer {
+		return nil, fmt.Errorf("%w: issuer 为 %q，与配置的 %q 不一致", ErrProvider, meta.Issuer, p.issuer)
+	}
+	if meta.AuthorizationEndpoint == "" || meta.TokenEndpoint == "" || meta.JWKSURI == "" {
+		return nil, fmt.Errorf("%w: openid-configuration 缺少 authorization_endpoint、token_endpoint 或 jwks_uri", ErrProvider)
+	}
+	if len(meta.CodeChallengeMethods) > 0 && !slices.Contains(meta.CodeChallengeMethods, "S256") {
+		return nil, fmt.Errorf("%w: 提供方不支持 S256 的 PKCE", ErrProvider)
+	}
+	p.meta = &meta
+	return p.meta, nil
+}
+
+// AuthCodeURL 返回跳转到提供方授权页面的地址，loginHint 不为空时作为 login_hint 提示提供方要登录的账号
+func (p *Provider) AuthCodeURL(ctx context.Context, flow *Flow, redirectURI, loginHint string) (string, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	u, err := url.Parse(meta.AuthorizationEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("%w: authorization_endpoint 不合法: %v", ErrProvider, err)
+	}
+	q := u.Query()
+	q.Set("response_type", "code")
+	q.Set("client_id", p.clientID)
+	q.Set("redirect_uri", redirectURI)
+	q.Set("scope", strings.Join(p.scopes, " "))
+	q.Set("state", flow.State)
+	q.Set("nonce", flow.Nonce)
+	q.Set("code_challenge", flow.challenge())
+	q.Set("code_challenge_method", "S256")
+	if loginHint != "" {
+		q.Set("login_hint", loginHint)
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// tokenResponse 是令牌端点的响应，失败时只有 error 和 error_description
+type tokenResponse struct {
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// Exchange 用授权码和 flow 的 code_verifier 换取 ID token，校验通过后返回其中的用户信息。
+// redirectURI 必须与 AuthCodeURL 使用的一致
+func (p *Provider) Exchange(ctx context.Context, code, redirectURI string, flow *Flow) (*Identity, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {redirectURI},
+		"code_verifier": {flow.Verifier},
+	}
+	// 有 client secret 时默认使用 client_secret_basic，提供方只支持 client_secret_post 时放在表单中
+	basic := p.clientSecret != "" && (len(meta.TokenAuthMethods) == 0 || slices.Contains(meta.TokenAuthMethods, "client_secret_basic"))
+	if !basic {
+		form.Set("client_id", p.clientID)
+		if p.clientSecret != "" {
+			form.Set("client_secret", p.clientSecret)
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if basic {
+		req.SetBasicAuth(url.QueryEscape(p.clientID), url.QueryEscape(p.clientSecret))
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrProvider, err)
+	}
+	defer resp.Body.Close()
+	var tr tokenResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(&tr); err != nil {
+		return nil, fmt.Errorf("%w: 令牌端点返回 %d，响应无法解析: %v", ErrProvider, resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK || tr.Error != "" {
+		return nil, fmt.Errorf("%w: 令牌端点返回 %d: %s %s", ErrProvider, resp.StatusCode, tr.Error, tr.ErrorDescription)
+	}
+	if tr.IDToken == "" {
+		return nil, fmt.Errorf("%w: 令牌端点没有返回 id_token", ErrProvider)
+	}
+	return p.verify(ctx, meta, tr.IDToken, flow.Nonce)
+}
+
+// getJSON 请求 url 并把 JSON 响应解码到 out
+func (p *Provider) getJSON(ctx context.Context, url string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrProvider, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: GET %s 返回 %d", ErrProvider, url, resp.StatusCode)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(out); err != nil {
+		return fmt.Errorf("%w: GET %s 的响应无法解析: %v", ErrProvider, url, err)
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/miffyG/golearn/task4/internal/tokens"
+)
+
+// supportedAlgorithms 是接受的 ID token 签名算法。不接受 none 和 HS256 等对称算法，
+// 提供方在 id_token_signing_alg_values_supported 中列出时取交集
+var supportedAlgorithms = []string{"RS256", "PS256", "ES256", "EdDSA"}
+
+const (
+	// leeway 是校验 exp、iat、nbf 时允许的时钟偏差
+	leeway = time.Minute
+	// jwksRefreshInterval 是遇到未知的 kid 时重新获取 JWKS 的最短间隔，避免伪造的 token 让服务反复请求提供方
+	jwksRefreshInterval = time.Minute
+)
+
+// Identity 是 ID token 中与用户有关的声明
+type Identity struct {
+	Provider string
+	// Subject 是用户在提供方的唯一标识，与 Provider 一起确定一个外部账号
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	Name              string
+	PreferredUsername string
+}
+
+// idClaims 是 ID token 中校验和读取的声明
+type idClaims struct {
+	jwt.RegisteredClaims
+	Nonce             string   `json:"nonce"`
+	AuthorizedParty   string   `json:"azp"`
+	Email             string   `json:"email"`
+	EmailVerified     flexBool `json:"email_verified"`
+	Name              string   `json:"name"`
+	PreferredUsername string   `json:"preferred_username"`
+}
+
+// flexBool 兼容把 email_verified 写成字符串 "true" 的提供方
+type flexBool bool
+
+func (b *flexBool) UnmarshalJSON(data []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*b = v == true || v == "true"
+	return nil
+}
+
+// publicKey 是 JWKS 中的一个公钥，alg 为空表示提供方没有限定算法
+type publicKey struct {
+	alg string
+	key interface{}
+}
+
+// verify 校验 ID token 的签名和声明，nonce 必须与发起登录时的一致
+func (p *Provider) verify(ctx context.Context, meta *metadata, raw, nonce string) (*Identity, error) {
+	algorithms := supportedAlgorithms
+	if len(meta.SigningAlgorithms) > 0 {
+		algorithms = slices.DeleteFunc(slices.Clone(supportedAlgorithms), func(alg string) bool {
+			return !slices.Contains(meta.SigningAlgorithms, alg)
+		})
+	}
+	claims := &idClaims{}
+	parser := jwt.NewParser(jwt.WithValidMethods(algorithms), jwt.WithoutClaimsValidation())
+	_, err := parser.ParseWithClaims(raw, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.key(ctx, meta, kid, token.Method.Alg())
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+	if err := p.validate(claims, nonce); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+	return &Identity{
+		Provider:          p.Name,
+		Subject:           claims.Subject,
+		Email:             claims.Email,
+		EmailVerified:     bool(claims.EmailVerified),
+		Name:              claims.Name,
+		PreferredUsername: claims.PreferredUsername,
+	}, nil
+}
+
+// validate 按 OpenID Connect Core 3.1.3.7 检查 ID token 的声明
+func (p *Provider) validate(c *idClaims, nonce string) error {
+	now := time.Now()
+	if c.Issuer != p.issuer {
+		return fmt.Errorf("iss 为 %q", c.Issuer)
+	}
+	if !slices.Contains(c.Audience, p.clientID) {
+		return errors.New("aud 不包含本站的 client ID")
+	}
+	if (len(c.Audience) > 1 || c.AuthorizedParty != "") && c.AuthorizedParty != p.clientID {
+		return errors.New("azp 不是本站的 client ID")
+	}
+	if c.ExpiresAt == nil || !now.Before(c.ExpiresAt.Add(leeway)) {
+		return errors.New("已过期或缺少 exp")
+	}
+	if c.IssuedAt == nil || now.Add(leeway).Before(c.IssuedAt.Time) {
+		return errors.New("缺少 iat 或签发时间晚于当前时间")
+	}
+	if c.NotBefore != nil && now.Add(leeway).Before(c.NotBefore.Time) {
+		return errors.New("尚未生效")
+	}
+	if subtle.ConstantTimeCompare([]byte(c.Nonce), []byte(nonce)) != 1 {
+		return errors.New("nonce 不一致")
+	}
+	if c.Subject == "" {
+		return errors.New("缺少 sub")
+	}
+	return nil
+}
+
+// key 返回 kid 对应的公钥，缓存中没有时重新获取 JWKS。token 没有 kid 时，JWKS 中必须只有一个算法匹配的公钥
+func (p *Provider) key(ctx context.Context, meta *metadata, kid, alg string) (interface{}, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	key, ok := p.lookup(kid, alg)
+	if !ok && time.Since(p.keysFetched) >= jwksRefreshInterval {
+		if err := p.fetchKeys(ctx, meta); err != nil {
+			return nil, err
+		}
+		key, ok = p.lookup(kid, alg)
+	}
+	if !ok {
+		return nil, fmt.Errorf("JWKS 中没有 kid 为 %q、算法为 %s 的公钥", kid, alg)
+	}
+	return key, nil
+}
+
+// lookup 在缓存的 JWKS 中查找公钥，调用方持有 p.mu
+func (p *Provider) lookup(kid, alg string) (interface{}, bool) {
+	if kid != "" {
+		k, ok := p.keys[kid]
+		if !ok || !k.accepts(alg) {
+			return nil, false
+		}
+		return k.key, true
+	}
+	var found interface{}
+	for _, k := range p.keys {
+		if k.accepts(alg) {
+			if found != nil {
+				return nil, false
+			}
+			found = k.key
+		}
+	}
+	return found, found != nil
+}
+
+// fetchKeys 重新获取 JWKS，跳过用于加密和无法解析的公钥，调用方持有 p.mu
+func (p *Provider) fetchKeys(ctx context.Context, meta *metadata) error {
+	var set tokens.JWKS
+	if err := p.getJSON(ctx, meta.JWKSURI, &set); err != nil {
+		return err
+	}
+	keys := make(map[string]publicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.PublicKey()
+		if err != nil {
+			continue
+		}
+		keys[jwk.Kid] = publicKey{alg: jwk.Alg, key: key}
+	}
+	p.keys, p.keysFetched = keys, time.Now()
+	return nil
+}
+
+// accepts 判断公钥能否校验 alg 算法的签名：JWK 声明了算法时必须一致，密钥类型必须与算法匹配
+func (k publicKey) accepts(alg string) bool {
+	if k.alg != "" && k.alg != alg {
+		return false
+	}
+	switch key := k.key.(type) {
+	case *rsa.PublicKey:
+		return alg == "RS256" || alg == "PS256"
+	case *ecdsa.PublicKey:
+		return alg == "ES256" && key.Curve == elliptic.P256()
+	case ed25519.PublicKey:
+		return alg == "EdDSA"
+	}
+	return false
+}
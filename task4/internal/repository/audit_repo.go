@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/miffyG/golearn/task4/internal/audit"
+	"github.com/miffyG/golearn/task4/internal/models/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type AuditRepository struct{ db *gorm.DB }
+
+func NewAuditRepository(db *gorm.DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+// Append 追加一条审计日志。chain 为 true 时在事务中锁定哈希链头，计算并写入 prev_hash 和 hash
+func (r *AuditRepository) Append(ctx context.Context, log *entity.AuditLog, chain bool) error {
+	// 数据库只保存到毫秒，先截断，保证写入前后计算出的 hash 一致
+	log.CreatedAt = time.Now().Truncate(time.Millisecond)
+	if !chain {
+		return r.db.WithContext(ctx).Create(log).Error
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		head := entity.AuditChainHead{ID: 1}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&head).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&head, 1).Error; err != nil {
+			return err
+		}
+		log.PrevHash = head.Hash
+		log.Hash = audit.ChainHash(head.Hash, log)
+		if err := tx.Create(log).Error; err != nil {
+			return err
+		}
+		return tx.Model(&head).Updates(map[string]interface{}{"last_id": log.ID, "hash": log.Hash}).Error
+	})
+}
+
+// AuditFilter 是查询审计日志的过滤条件，零值字段不过滤
+type AuditFilter struct {
+	ActorID    uint
+	Action     string
+	TargetType string
+	TargetID   uint
+	RequestID  string
+	Since      time.Time
+	Until      time.Time
+}
+
+// Search 按 ID 倒序分页查询审计日志，同时返回符合条件的总数
+func (r *AuditRepository) Search(ctx context.Context, filter AuditFilter, offset, limit int) ([]entity.AuditLog, int64, error) {
+	query := r.db.WithContext(ctx).Model(&entity.AuditLog{}).Scopes(replica)
+	if filter.ActorID != 0 {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.TargetType != "" {
+		query = query.Where("target_type = ?", filter.TargetType)
+	}
+	if filter.TargetID != 0 {
+		query = query.Where("target_id = ?", filter.TargetID)
+	}
+	if filter.RequestID != "" {
+		query = query.Where("request_id = ?", filter.RequestID)
+	}
+	if !filter.Since.IsZero() {
+		query = query.Where("created_at >= ?", filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		query = query.Where("created_at < ?", filter.Until)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var logs []entity.AuditLog
+	if err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&logs).Error; err != nil {
+		return nil, 0, err
+	}
+	return logs, total, nil
+}
+
+// Each 按 ID 顺序分批读取全部审计日志
+func (r *AuditRepository) Each(ctx context.Context, batch int, fn func([]entity.AuditLog) error) error {
+	var lastID uint
+	for {
+		var logs []entity.AuditLog
+		if err := r.db.WithContext(ctx).Where("id > ?", lastID).Order("id").Limit(batch).Find(&logs).Error; err != nil {
+			return err
+		}
+		if len(logs) == 0 {
+			return nil
+		}
+		if err := fn(logs); err != nil {
+			return err
+		}
+		lastID = logs[len(logs)-1].ID
+	}
+}
+
+// ChainHead 返回哈希链头，还没有写入过带 hash 的记录时返回零值
+func (r *AuditRepository) ChainHead(ctx context.Context) (*entity.AuditChainHead, error) {
+	var heads []entity.AuditChainHead
+	if err := r.db.WithContext(ctx).Where("id = 1").Limit(1).Find(&heads).Error; err != nil {
+		return nil, err
+	}
+	if len(heads) == 0 {
+		return &entity.AuditChainHead{}, nil
+	}
+	return &heads[0], nil
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"github.com/miffyG/golearn/task4/internal/models/entity"
 	"gorm.io/gorm"
 )
@@ -11,14 +12,67 @@ func NewCommentRepository(db *gorm.DB) *CommentRepository {
 	return &CommentRepository{db: db}
 }
 
-func (r *CommentRepository) Create(comment *entity.Comment) error {
-	return r.db.Create(comment).Error
+func (r *CommentRepository) Create(ctx context.Context, comment *entity.Comment) error {
+	return r.db.WithContext(ctx).Create(comment).Error
 }
 
-func (r *CommentRepository) GetByPostId(postId uint) ([]entity.Comment, error) {
+// livePosts 过滤掉所属文章已删除的评论，兼容删除文章还不会级联删除评论时留下的数据
+func (r *CommentRepository) livePosts(db *gorm.DB) *gorm.DB {
+	return db.Where("post_id IN (?)", r.db.Model(&entity.Post{}).Select("id"))
+}
+
+// visible 只保留已发布、所属文章未删除的评论
+func (r *CommentRepository) visible(db *gorm.DB) *gorm.DB {
+	return db.Scopes(published, r.livePosts)
+}
+
+func (r *CommentRepository) GetByPostId(ctx context.Context, postId uint) ([]entity.Comment, error) {
+	var comments []entity.Comment
+	if err := r.db.WithContext(ctx).Scopes(replica, r.visible).Where("post_id = ?", postId).Find(&comments).Error; err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+// GetByPostIds 一次查询多篇文章的评论，用于批量加载。
+// 每篇文章最多返回最早的 limit 条，按文章分组编号后在数据库中截断
+func (r *CommentRepository) GetByPostIds(ctx context.Context, postIds []uint, limit int) ([]entity.Comment, error) {
+	ranked := r.db.Model(&entity.Comment{}).Scopes(published).
+		Select("id, ROW_NUMBER() OVER (PARTITION BY post_id ORDER BY id) AS rn").
+		Where("post_id IN ?", postIds)
+	top := r.db.Table("(?) AS ranked", ranked).Select("id").Where("rn <= ?", limit)
 	var comments []entity.Comment
-	if err := r.db.Where("post_id = ?", postId).Find(&comments).Error; err != nil {
+	if err := r.db.WithContext(ctx).Scopes(replica).Where("id IN (?)", top).Order("id").Find(&comments).Error; err != nil {
 		return nil, err
 	}
 	return comments, nil
 }
+
+// ListByPostId 分页查询文章的评论，同时返回评论总数
+func (r *CommentRepository) ListByPostId(ctx context.Context, postId uint, offset, limit int) ([]entity.Comment, int64, error) {
+	var total int64
+	if err := r.db.WithContext(ctx).Model(&entity.Comment{}).Scopes(replica, r.visible).Where("post_id = ?", postId).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var comments []entity.Comment
+	if err := r.db.WithContext(ctx).Scopes(replica, r.visible).Where("post_id = ?", postId).Order("id").Offset(offset).Limit(limit).Find(&comments).Error; err != nil {
+		return nil, 0, err
+	}
+	return comments, total, nil
+}
+
+func (r *CommentRepository) GetByID(ctx context.Context, id uint) (*entity.Comment, error) {
+	var comment entity.Comment
+	if err := r.db.WithContext(ctx).First(&comment, id).Error; err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+func (r *CommentRepository) Update(ctx context.Context, comment *entity.Comment) error {
+	return r.db.WithContext(ctx).Model(comment).Update("content", comment.Content).Error
+}
+
+func (r *CommentRepository) Delete(ctx context.Context, comment *entity.Comment) error {
+	return r.db.WithContext(ctx).Delete(comment).Error
+}
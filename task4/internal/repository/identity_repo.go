@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/miffyG/golearn/task4/internal/models/entity"
+	"gorm.io/gorm"
+)
+
+type IdentityRepository struct{ db *gorm.DB }
+
+func NewIdentityRepository(db *gorm.DB) *IdentityRepository {
+	return &IdentityRepository{db: db}
+}
+
+// Get 按提供方和外部账号查找关联，不存在时返回 gorm.ErrRecordNotFound
+func (r *IdentityRepository) Get(ctx context.Context, provider, subject string) (*entity.Identity, error) {
+	var identity entity.Identity
+	if err := r.db.WithContext(ctx).Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error; err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+func (r *IdentityRepository) Create(ctx context.Context, identity *entity.Identity) error {
+	return r.db.WithContext(ctx).Create(identity).Error
+}
+
+// CreateFlow 保存新的登录流程，同时删除已经过期的流程
+func (r *IdentityRepository) CreateFlow(ctx context.Context, flow *entity.OIDCFlow) error {
+	db := r.db.WithContext(ctx)
+	if err := db.Where("expires_at < ?", time.Now()).Delete(&entity.OIDCFlow{}).Error; err != nil {
+		return err
+	}
+	return db.Create(flow).Error
+}
+
+// TakeFlow 取出并删除未过期的登录流程，并发回调时只有一个能取到，不存在或已过期时返回 gorm.ErrRecordNotFound
+func (r *IdentityRepository) TakeFlow(ctx context.Context, stateHash string) (*entity.OIDCFlow, error) {
+	var flow entity.OIDCFlow
+	db := r.db.WithContext(ctx)
+	if err := db.Where("state_hash = ?", stateHash).First(&flow).Error; err != nil {
+		return nil, err
+	}
+	res := db.Delete(&entity.OIDCFlow{}, flow.ID)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 || !time.Now().Before(flow.ExpiresAt) {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &flow, nil
+}
@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/miffyG/golearn/task4/internal/models/entity"
+	"gorm.io/gorm"
+)
+
+// published 只保留已发布的文章或评论，对外的列表查询都需要加上这个条件
+func published(db *gorm.DB) *gorm.DB {
+	return db.Where("status = ?", entity.StatusPublished)
+}
+
+// ErrReportClosed 表示举报在处理前已经被关闭
+var ErrReportClosed = errors.New("report closed")
+
+type ModerationRepository struct{ db *gorm.DB }
+
+func NewModerationRepository(db *gorm.DB) *ModerationRepository {
+	return &ModerationRepository{db: db}
+}
+
+func (r *ModerationRepository) CreateReport(ctx context.Context, report *entity.Report) error {
+	return r.db.WithContext(ctx).Create(report).Error
+}
+
+func (r *ModerationRepository) GetReport(ctx context.Context, id uint) (*entity.Report, error) {
+	var report entity.Report
+	if err := r.db.WithContext(ctx).First(&report, id).Error; err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// GetUser 返回用户，包括已删除的用户
+func (r *ModerationRepository) GetUser(ctx context.Context, id uint) (*entity.User, error) {
+	var user entity.User
+	if err := r.db.WithContext(ctx).Unscoped().Take(&user, id).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// FindOpenReport 查找用户对同一内容尚未处理的举报，不存在时返回 nil
+func (r *ModerationRepository) FindOpenReport(ctx context.Context, reporterId uint, targetType string, targetId uint) (*entity.Report, error) {
+	var reports []entity.Report
+	if err := r.db.WithContext(ctx).Where("reporter_id = ? AND target_type = ? AND target_id = ? AND status = ?",
+		reporterId, targetType, targetId, entity.ReportOpen).Limit(1).Find(&reports).Error; err != nil {
+		return nil, err
+	}
+	if len(reports) == 0 {
+		return nil, nil
+	}
+	return &reports[0], nil
+}
+
+// ListOpen 按时间顺序分页返回未处理的举报，同时返回总数
+func (r *ModerationRepository) ListOpen(ctx context.Context, offset, limit int) ([]entity.Report, int64, error) {
+	query := r.db.WithContext(ctx).Model(&entity.Report{}).Where("status = ?", entity.ReportOpen)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var reports []entity.Report
+	if err := query.Order("id").Offset(offset).Limit(limit).Find(&reports).Error; err != nil {
+		return nil, 0, err
+	}
+	return reports, total, nil
+}
+
+// ModerationTarget 是被举报或被过滤器拦截的文章或评论，评论的 Title 为空
+type ModerationTarget struct {
+	Type     string
+	ID       uint
+	AuthorID uint
+	Title    string
+	Content  string
+	Status   string
+}
+
+// TargetKey 唯一标识一条文章或评论
+type TargetKey struct {
+	Type string
+	ID   uint
+}
+
+func targetModel(targetType string) interface{} {
+	if targetType == entity.ReportTargetPost {
+		return &entity.Post{}
+	}
+	return &entity.Comment{}
+}
+
+// GetTarget 查询未删除的文章或评论，包括待审核和已移除的内容
+func (r *ModerationRepository) GetTarget(ctx context.Context, targetType string, id uint) (*ModerationTarget, error) {
+	targets, err := r.GetTargets(ctx, []TargetKey{{Type: targetType, ID: id}})
+	if err != nil {
+		return nil, err
+	}
+	t, ok := targets[TargetKey{Type: targetType, ID: id}]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &t, nil
+}
+
+// GetTargets 批量查询举报针对的内容，已删除的内容不在返回结果中
+func (r *ModerationRepository) GetTargets(ctx context.Context, keys []TargetKey) (map[TargetKey]ModerationTarget, error) {
+	var postIds, commentIds []uint
+	for _, k := range keys {
+		if k.Type == entity.ReportTargetPost {
+			postIds = append(postIds, k.ID)
+		} else {
+			commentIds = append(commentIds, k.ID)
+		}
+	}
+
+	res := make(map[TargetKey]ModerationTarget, len(keys))
+	if len(postIds) > 0 {
+		var posts []entity.Post
+		if err := r.db.WithContext(ctx).Select("id", "user_id", "title", "content", "status").
+			Where("id IN ?", postIds).Find(&posts).Error; err != nil {
+			return nil, err
+		}
+		for _, p := range posts {
+			res[TargetKey{entity.ReportTargetPost, p.ID}] = ModerationTarget{
+				Type: entity.ReportTargetPost, ID: p.ID, AuthorID: p.UserID, Title: p.Title, Content: p.Content, Status: p.Status,
+			}
+		}
+	}
+	if len(commentIds) > 0 {
+		var comments []entity.Comment
+		if err := r.db.WithContext(ctx).Select("id", "user_id", "content", "status").
+			Where("id IN ?", commentIds).Find(&comments).Error; err != nil {
+			return nil, err
+		}
+		for _, c := range comments {
+			res[TargetKey{entity.ReportTargetComment, c.ID}] = ModerationTarget{
+				Type: entity.ReportTargetComment, ID: c.ID, AuthorID: c.UserID, Content: c.Content, Status: c.Status,
+			}
+		}
+	}
+	return res, nil
+}
+
+// Resolve 在一个事务中以 reportStatus 关闭举报 reportId 和这条内容其他未处理的举报，把内容的审核状态改为 contentStatus；
+// banUserId 不为 0 时同时封禁该用户。举报 reportId 以 status = open 为条件更新，更新后行锁保持到事务结束，
+// 两个审核员同时处理同一条举报时只有先更新的一方成功，另一方得到 ErrReportClosed，内容状态和封禁都不会被改动
+func (r *ModerationRepository) Resolve(ctx context.Context, reportId uint, target TargetKey, contentStatus, reportStatus string, moderatorId, banUserId uint) error {
+	now := time.Now()
+	handled := map[string]interface{}{"status": reportStatus, "handled_by": moderatorId, "handled_at": now}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&entity.Report{}).Where("id = ? AND status = ?", reportId, entity.ReportOpen).Updates(handled)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrReportClosed
+		}
+		if err := tx.Model(&entity.Report{}).
+			Where("target_type = ? AND target_id = ? AND status = ?", target.Type, target.ID, entity.ReportOpen).
+			Updates(handled).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(targetModel(target.Type)).Where("id = ?", target.ID).
+			UpdateColumn("status", contentStatus).Error; err != nil {
+			return err
+		}
+		if banUserId != 0 {
+			return tx.Model(&entity.User{}).Where("id = ?", banUserId).UpdateColumn("banned", true).Error
+		}
+		return nil
+	})
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/miffyG/golearn/task4/internal/models/entity"
+)
+
+// TestResolveOnce 模拟两个审核员都在举报仍未处理时读到它，随后先后提交：后提交的一方得到 ErrReportClosed，
+// 内容状态、举报状态和作者的封禁状态都保持先提交一方的结果
+func TestResolveOnce(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	author := &entity.User{UserName: "writer", Password: "x", Email: "writer@example.com"}
+	if err := db.Create(author).Error; err != nil {
+		t.Fatal(err)
+	}
+	post := &entity.Post{Title: "待审核", Content: "正文", UserID: author.ID, Status: entity.StatusPending}
+	if err := db.Create(post).Error; err != nil {
+		t.Fatal(err)
+	}
+	report := &entity.Report{TargetType: entity.ReportTargetPost, TargetID: post.ID, Reason: "hold", Status: entity.ReportOpen}
+	if err := db.Create(report).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	repo := NewModerationRepository(db)
+	key := TargetKey{Type: entity.ReportTargetPost, ID: post.ID}
+	if err := repo.Resolve(ctx, report.ID, key, entity.StatusPublished, entity.ReportApproved, 1, 0); err != nil {
+		t.Fatal(err)
+	}
+	err := repo.Resolve(ctx, report.ID, key, entity.StatusRemoved, entity.ReportRemoved, 2, author.ID)
+	if !errors.Is(err, ErrReportClosed) {
+		t.Fatalf("第二次处理返回 %v，期望 ErrReportClosed", err)
+	}
+
+	var p entity.Post
+	var r entity.Report
+	var u entity.User
+	if err := db.First(&p, post.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.First(&r, report.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.First(&u, author.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if p.Status != entity.StatusPublished || r.Status != entity.ReportApproved || u.Banned {
+		t.Errorf("文章为 %s、举报为 %s、作者封禁状态为 %v，期望保持第一次处理的结果", p.Status, r.Status, u.Banned)
+	}
+}
@@ -1,23 +1,47 @@
 package repository
 
 import (
+	"context"
+	"errors"
+	"time"
+
 	"github.com/miffyG/golearn/task4/internal/models/entity"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ErrVersionConflict 表示条件更新时文章版本已被其他请求修改
+var ErrVersionConflict = errors.New("version conflict")
+
 type PostRepository struct{ db *gorm.DB }
 
 func NewPostRepository(db *gorm.DB) *PostRepository {
 	return &PostRepository{db: db}
 }
 
-func (r *PostRepository) Create(post *entity.Post) error {
-	return r.db.Create(post).Error
+// Create 创建文章，生成 slug 并写入第一条修订记录
+func (r *PostRepository) Create(ctx context.Context, post *entity.Post) error {
+	post.Version = 1
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(post).Error; err != nil {
+			return err
+		}
+		if err := assignSlug(tx, post); err != nil {
+			return err
+		}
+		return tx.Create(&entity.PostRevision{
+			PostID:   post.ID,
+			Rev:      1,
+			EditorID: post.UserID,
+			Title:    post.Title,
+			Content:  post.Content,
+		}).Error
+	})
 }
 
-func (r *PostRepository) GetAll() ([]entity.Post, error) {
+func (r *PostRepository) GetAll(ctx context.Context) ([]entity.Post, error) {
 	var posts []entity.Post
-	if err := r.db.Preload("User", func(db *gorm.DB) *gorm.DB {
+	if err := r.db.WithContext(ctx).Scopes(replica, published).Preload("User", func(db *gorm.DB) *gorm.DB {
 		return db.Select("id", "user_name")
 	}).Find(&posts).Error; err != nil {
 		return nil, err
@@ -25,22 +49,345 @@ func (r *PostRepository) GetAll() ([]entity.Post, error) {
 	return posts, nil
 }
 
-func (r *PostRepository) GetByID(id uint) (*entity.Post, error) {
+// List 按创建时间倒序分页查询文章，同时返回文章总数
+func (r *PostRepository) List(ctx context.Context, offset, limit int) ([]entity.Post, int64, error) {
+	var total int64
+	if err := r.db.WithContext(ctx).Model(&entity.Post{}).Scopes(replica, published).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var posts []entity.Post
+	if err := r.db.WithContext(ctx).Scopes(replica, published).Preload("User", func(db *gorm.DB) *gorm.DB {
+		return db.Select("id", "user_name")
+	}).Order("id DESC").Offset(offset).Limit(limit).Find(&posts).Error; err != nil {
+		return nil, 0, err
+	}
+	return posts, total, nil
+}
+
+// GetByIDs 一次查询多篇已发布的文章（不预加载关联），用于批量加载
+func (r *PostRepository) GetByIDs(ctx context.Context, ids []uint) ([]entity.Post, error) {
+	var posts []entity.Post
+	if err := r.db.WithContext(ctx).Scopes(replica, published).Where("id IN ?", ids).Find(&posts).Error; err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
+
+// GetByUserIDs 一次查询多个用户已发布的文章（不预加载关联），用于批量加载。
+// 每个用户最多返回最新的 limit 篇，按用户分组编号后在数据库中截断
+func (r *PostRepository) GetByUserIDs(ctx context.Context, userIds []uint, limit int) ([]entity.Post, error) {
+	ranked := r.db.Model(&entity.Post{}).Scopes(published).
+		Select("id, ROW_NUMBER() OVER (PARTITION BY user_id ORDER BY id DESC) AS rn").
+		Where("user_id IN ?", userIds)
+	top := r.db.Table("(?) AS ranked", ranked).Select("id").Where("rn <= ?", limit)
+	var posts []entity.Post
+	if err := r.db.WithContext(ctx).Scopes(replica).Where("id IN (?)", top).Order("id DESC").Find(&posts).Error; err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
+
+func (r *PostRepository) GetByID(ctx context.Context, id uint) (*entity.Post, error) {
+	return r.get(r.db.WithContext(ctx), id)
+}
+
+// GetDetail 与 GetByID 相同，但本进程最近没有写入时从只读副本读取，只用于对外的文章详情。
+// 需要读到最新数据的校验和写入之后的读取使用 GetByID
+func (r *PostRepository) GetDetail(ctx context.Context, id uint) (*entity.Post, error) {
+	return r.get(r.db.WithContext(ctx).Scopes(recentReplica), id)
+}
+
+// GetForUpdate 与 GetByID 相同，同时对文章行加排他锁直到事务结束，需要在事务中调用
+func (r *PostRepository) GetForUpdate(ctx context.Context, id uint) (*entity.Post, error) {
+	return r.get(r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}), id)
+}
+
+func (r *PostRepository) get(db *gorm.DB, id uint) (*entity.Post, error) {
 	var post entity.Post
-	if err := r.db.Preload("User", func(db *gorm.DB) *gorm.DB {
+	if err := db.Preload("User", func(db *gorm.DB) *gorm.DB {
 		return db.Select("id", "user_name")
 	}).Preload("Comments", func(db *gorm.DB) *gorm.DB {
-		return db.Select("id", "content", "user_id", "post_id")
-	}).First(&post, id).Error; err != nil {
+		return db.Scopes(published).Select("id", "content", "user_id", "post_id", "status", "created_at", "updated_at")
+	}).Preload("Tags").First(&post, id).Error; err != nil {
+		return nil, err
+	}
+	return &post, nil
+}
+
+// Update 以 post.Version 为条件更新文章，并在同一事务中追加一条由 editorId 编辑的修订记录。
+// columns 指定需要写入的列（title、content），为空时两列都写入。
+// 版本号不一致时不做任何修改并返回 ErrVersionConflict，成功后 post.Version 加 1。
+// 修改标题时会重新生成 slug ，旧的 slug 仍然可以查到文章。
+func (r *PostRepository) Update(ctx context.Context, post *entity.Post, editorId uint, columns ...string) error {
+	if len(columns) == 0 {
+		columns = []string{"title", "content"}
+	}
+	now := time.Now()
+	values := map[string]interface{}{"version": gorm.Expr("version + 1"), "updated_at": now}
+	for _, col := range columns {
+		switch col {
+		case "title":
+			values[col] = post.Title
+		case "content":
+			values[col] = post.Content
+		}
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 先锁住文章行，并发的编辑在这里排队，下面读到的最大修订号不会被两个事务同时使用
+		var old entity.Post
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "title", "content", "user_id").First(&old, post.ID).Error; err != nil {
+			return err
+		}
+		var lastRev uint
+		if err := tx.Model(&entity.PostRevision{}).Where("post_id = ?", post.ID).
+			Select("COALESCE(MAX(rev), 0)").Scan(&lastRev).Error; err != nil {
+			return err
+		}
+		// 引入修订记录之前创建的文章没有历史版本，先把修改前的内容补记为第一个版本
+		if lastRev == 0 {
+			lastRev++
+			if err := tx.Create(&entity.PostRevision{
+				PostID:   old.ID,
+				Rev:      lastRev,
+				EditorID: old.UserID,
+				Title:    old.Title,
+				Content:  old.Content,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		res := tx.Model(&entity.Post{}).Where("id = ? AND version = ?", post.ID, post.Version).Updates(values)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrVersionConflict
+		}
+		post.Version++
+		post.UpdatedAt = now
+		if values["title"] != nil {
+			if err := tx.Model(&entity.Post{}).Select("slug").Where("id = ?", post.ID).Scan(&post.Slug).Error; err != nil {
+				return err
+			}
+			if err := assignSlug(tx, post); err != nil {
+				return err
+			}
+		}
+		return tx.Create(&entity.PostRevision{
+			PostID:   post.ID,
+			Rev:      lastRev + 1,
+			EditorID: editorId,
+			Title:    post.Title,
+			Content:  post.Content,
+		}).Error
+	})
+}
+
+// Delete 软删除文章，并用同一个删除时间软删除文章下的评论，恢复时据此区分哪些评论是随文章一起删除的
+func (r *PostRepository) Delete(ctx context.Context, post *entity.Post) error {
+	now := time.Now()
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&entity.Post{}).Where("id = ?", post.ID).UpdateColumn("deleted_at", now)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Model(&entity.Comment{}).Where("post_id = ?", post.ID).UpdateColumn("deleted_at", now).Error
+	})
+}
+
+// GetDeleted 查询已删除的文章，文章不存在或未被删除时返回 gorm.ErrRecordNotFound
+func (r *PostRepository) GetDeleted(ctx context.Context, id uint) (*entity.Post, error) {
+	var post entity.Post
+	if err := r.db.WithContext(ctx).Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).Take(&post).Error; err != nil {
 		return nil, err
 	}
 	return &post, nil
 }
 
-func (r *PostRepository) Update(post *entity.Post) error {
-	return r.db.Save(post).Error
+// ReplaceTags 把文章的标签替换为 names，不存在的标签会被创建
+func (r *PostRepository) ReplaceTags(ctx context.Context, post *entity.Post, names []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		tags := make([]entity.Tag, len(names))
+		for i, name := range names {
+			if err := tx.Where(entity.Tag{Name: name}).FirstOrCreate(&tags[i]).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(post).Association("Tags").Replace(tags); err != nil {
+			return err
+		}
+		post.Tags = tags
+		return nil
+	})
+}
+
+func (r *PostRepository) GetTag(ctx context.Context, name string) (*entity.Tag, error) {
+	var tag entity.Tag
+	if err := r.db.WithContext(ctx).Where("name = ?", name).First(&tag).Error; err != nil {
+		return nil, err
+	}
+	return &tag, nil
 }
 
-func (r *PostRepository) Delete(post *entity.Post) error {
-	return r.db.Delete(post).Error
+// FeedFilter 是订阅源的过滤条件，UserID、TagID 为 0 时不过滤
+type FeedFilter struct {
+	UserID uint
+	TagID  uint
+}
+
+// ListForFeed 按创建时间倒序返回最新的 limit 篇文章，预加载作者和标签
+func (r *PostRepository) ListForFeed(ctx context.Context, filter FeedFilter, limit int) ([]entity.Post, error) {
+	query := r.db.WithContext(ctx).Model(&entity.Post{}).Scopes(replica, published)
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.TagID != 0 {
+		query = query.Where("id IN (?)", r.db.Table("post_tags").Select("post_id").Where("tag_id = ?", filter.TagID))
+	}
+	var posts []entity.Post
+	if err := query.Preload("User", func(db *gorm.DB) *gorm.DB {
+		return db.Select("id", "user_name")
+	}).Preload("Tags").Order("created_at DESC, id DESC").Limit(limit).Find(&posts).Error; err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
+
+// PostFilter 是管理端查询文章的过滤条件
+type PostFilter struct {
+	UserID uint
+	// Deleted 为 true 时只查询已删除的文章
+	Deleted bool
+}
+
+// Search 按过滤条件倒序分页查询文章，同时返回符合条件的文章总数
+func (r *PostRepository) Search(ctx context.Context, filter PostFilter, offset, limit int) ([]entity.Post, int64, error) {
+	query := r.db.WithContext(ctx).Model(&entity.Post{})
+	if filter.Deleted {
+		query = query.Unscoped().Where("deleted_at IS NOT NULL")
+	}
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var posts []entity.Post
+	if err := query.Preload("User", func(db *gorm.DB) *gorm.DB {
+		return db.Unscoped().Select("id", "user_name")
+	}).Order("id DESC").Offset(offset).Limit(limit).Find(&posts).Error; err != nil {
+		return nil, 0, err
+	}
+	return posts, total, nil
+}
+
+// Restore 恢复已删除的文章以及随文章一起删除的评论，之前单独删除的评论保持删除状态。
+// 文章不存在或未被删除时返回 gorm.ErrRecordNotFound
+func (r *PostRepository) Restore(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var post entity.Post
+		if err := tx.Unscoped().Select("id", "deleted_at").
+			Where("id = ? AND deleted_at IS NOT NULL", id).Take(&post).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&entity.Comment{}).
+			Where("post_id = ? AND deleted_at = ?", id, post.DeletedAt).UpdateColumn("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Model(&entity.Post{}).Where("id = ?", id).UpdateColumn("deleted_at", nil).Error
+	})
+}
+
+// PurgeResult 是一次清理中彻底删除的文章和评论数
+type PurgeResult struct {
+	Posts    int64 `json:"posts"`
+	Comments int64 `json:"comments"`
+}
+
+// Purge 彻底删除删除时间早于 before 的文章和评论。文章连同它的全部评论、修订记录、slug 和标签关联一起删除，
+// 每个事务最多处理 batch 篇文章，避免长时间锁表
+func (r *PostRepository) Purge(ctx context.Context, before time.Time, batch int) (PurgeResult, error) {
+	var result PurgeResult
+	for {
+		var res PurgeResult
+		err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			var err error
+			res, err = NewPostRepository(tx).PurgeBatch(ctx, before, batch)
+			return err
+		})
+		if err != nil {
+			return result, err
+		}
+		if res.Posts == 0 {
+			break
+		}
+		result.Posts += res.Posts
+		result.Comments += res.Comments
+	}
+	n, err := r.PurgeComments(ctx, before)
+	result.Comments += n
+	return result, err
+}
+
+// PurgeBatch 彻底删除最多 batch 篇删除时间早于 before 的文章，连同它们的全部评论、修订记录、slug 和标签关联。
+// 没有需要清理的文章时返回零值。需要在事务中调用，中途出错时由调用方回滚
+func (r *PostRepository) PurgeBatch(ctx context.Context, before time.Time, batch int) (PurgeResult, error) {
+	var result PurgeResult
+	db := r.db.WithContext(ctx)
+	var ids []uint
+	if err := db.Unscoped().Model(&entity.Post{}).
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", before).
+		Order("id").Limit(batch).Pluck("id", &ids).Error; err != nil {
+		return result, err
+	}
+	if len(ids) == 0 {
+		return result, nil
+	}
+	res := db.Unscoped().Where("post_id IN ?", ids).Delete(&entity.Comment{})
+	if res.Error != nil {
+		return result, res.Error
+	}
+	result.Comments = res.RowsAffected
+	if err := db.Where("post_id IN ?", ids).Delete(&entity.PostRevision{}).Error; err != nil {
+		return result, err
+	}
+	if err := db.Where("post_id IN ?", ids).Delete(&entity.PostSlug{}).Error; err != nil {
+		return result, err
+	}
+	if err := db.Exec("DELETE FROM post_tags WHERE post_id IN ?", ids).Error; err != nil {
+		return result, err
+	}
+	res = db.Unscoped().Where("id IN ?", ids).Delete(&entity.Post{})
+	result.Posts = res.RowsAffected
+	return result, res.Error
+}
+
+// PurgeComments 彻底删除单独删除、删除时间早于 before 的评论，返回删除的条数
+func (r *PostRepository) PurgeComments(ctx context.Context, before time.Time) (int64, error) {
+	res := r.db.WithContext(ctx).Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", before).Delete(&entity.Comment{})
+	return res.RowsAffected, res.Error
+}
+
+// GetRevisions 按版本号升序返回文章的全部修订记录
+func (r *PostRepository) GetRevisions(ctx context.Context, postId uint) ([]entity.PostRevision, error) {
+	var revisions []entity.PostRevision
+	if err := r.db.WithContext(ctx).Scopes(replica).Where("post_id = ?", postId).Order("rev").Find(&revisions).Error; err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+func (r *PostRepository) GetRevision(ctx context.Context, postId, rev uint) (*entity.PostRevision, error) {
+	var revision entity.PostRevision
+	if err := r.db.WithContext(ctx).Where("post_id = ? AND rev = ?", postId, rev).First(&revision).Error; err != nil {
+		return nil, err
+	}
+	return &revision, nil
 }
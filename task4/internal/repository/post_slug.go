@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/miffyG/golearn/task4/internal/models/entity"
+	"github.com/miffyG/golearn/task4/internal/utils"
+	"gorm.io/gorm"
+)
+
+// assignSlug 根据标题为文章生成 slug ，写入 posts.slug 并记录到 post_slugs ，需要在事务中调用。
+// 标题转换不出 slug 时使用 post-<ID>；与其他文章重复时追加 -2、-3 等后缀，使用最小的可用后缀；
+// 文章以前用过的 slug 会被直接复用
+func assignSlug(tx *gorm.DB, post *entity.Post) error {
+	base := utils.Slugify(post.Title)
+	if base == "" {
+		base = fmt.Sprintf("post-%d", post.ID)
+	}
+
+	slug := base
+	for n := 2; ; n++ {
+		taken, err := claimSlug(tx, post.ID, slug)
+		if err != nil {
+			return err
+		}
+		if !taken {
+			break
+		}
+		slug = fmt.Sprintf("%s-%d", base, n)
+	}
+	if slug == post.Slug {
+		return nil
+	}
+
+	if err := tx.Model(&entity.Post{}).Where("id = ?", post.ID).UpdateColumn("slug", slug).Error; err != nil {
+		return err
+	}
+	post.Slug = slug
+	return nil
+}
+
+// claimSlug 为文章占用 slug ，slug 已属于其他文章时返回 true。
+// 另一个事务可能在查询之后抢先写入同一个 slug ，插入在保存点中执行，唯一索引冲突时回滚到保存点并视为已被占用
+func claimSlug(tx *gorm.DB, postID uint, slug string) (bool, error) {
+	var owner entity.PostSlug
+	err := tx.Where("slug = ?", slug).Take(&owner).Error
+	if err == nil {
+		return owner.PostID != postID, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, err
+	}
+	err = tx.Transaction(func(tx *gorm.DB) error {
+		return tx.Create(&entity.PostSlug{PostID: postID, Slug: slug}).Error
+	})
+	if isDuplicateKey(err) {
+		return true, nil
+	}
+	return false, err
+}
+
+// isDuplicateKey 判断 err 是否为唯一索引冲突，MySQL 为错误码 1062，SQLite 为 UNIQUE constraint failed
+func isDuplicateKey(err error) bool {
+	if err == nil {
+		return false
+	}
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == 1062
+	}
+	return errors.Is(err, gorm.ErrDuplicatedKey) || strings.Contains(err.Error(), "UNIQUE constraint failed")
+}
+
+// GetBySlug 按当前或历史 slug 查找文章，调用方可以比较返回文章的 Slug 判断是否需要重定向。
+// 与 GetDetail 一样，本进程最近没有写入时从只读副本读取
+func (r *PostRepository) GetBySlug(ctx context.Context, slug string) (*entity.Post, error) {
+	var ps entity.PostSlug
+	if err := r.db.WithContext(ctx).Scopes(recentReplica).Where("slug = ?", slug).Take(&ps).Error; err != nil {
+		return nil, err
+	}
+	return r.GetDetail(ctx, ps.PostID)
+}
+
+// BackfillSlugs 为引入 slug 之前创建的文章生成 slug ，返回处理的文章数
+func (r *PostRepository) BackfillSlugs(ctx context.Context) (int, error) {
+	count := 0
+	for {
+		var posts []entity.Post
+		if err := r.db.WithContext(ctx).Select("id", "title", "slug").Where("slug = '' OR slug IS NULL").
+			Order("id").Limit(100).Find(&posts).Error; err != nil {
+			return count, err
+		}
+		if len(posts) == 0 {
+			return count, nil
+		}
+		for i := range posts {
+			if err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+				return assignSlug(tx, &posts[i])
+			}); err != nil {
+				return count, err
+			}
+			count++
+		}
+	}
+}
+
+// SitemapEntry 是站点地图中的一篇文章
+type SitemapEntry struct {
+	ID        uint
+	Slug      string
+	UpdatedAt time.Time
+}
+
+func (r *PostRepository) CountForSitemap(ctx context.Context) (int64, error) {
+	var total int64
+	err := r.db.WithContext(ctx).Model(&entity.Post{}).Scopes(replica, published).Count(&total).Error
+	return total, err
+}
+
+// ListForSitemap 按 ID 顺序分页返回已发布文章的 slug 和更新时间
+func (r *PostRepository) ListForSitemap(ctx context.Context, offset, limit int) ([]SitemapEntry, error) {
+	var entries []SitemapEntry
+	if err := r.db.WithContext(ctx).Model(&entity.Post{}).Scopes(replica, published).Select("id", "slug", "updated_at").
+		Order("id").Offset(offset).Limit(limit).Scan(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
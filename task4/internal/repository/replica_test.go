@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/miffyG/golearn/task4/internal/models/entity"
+	pkgdb "github.com/miffyG/golearn/task4/pkg/db"
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+// TestReplicaRouting 用一个空的数据库充当落后的只读副本：只有列表查询读副本，
+// 写入后的读取、slug 和用户名的唯一性检查都读主库
+func TestReplicaRouting(t *testing.T) {
+	primary := openTestDB(t)
+	// 副本上有表但没有数据，相当于复制还没有追上主库
+	replicaDSN := fmt.Sprintf("file:repo%d?mode=memory&cache=shared", databases.Add(1))
+	openTestDBAt(t, replicaDSN)
+	if err := primary.Use(dbresolver.Register(dbresolver.Config{
+		Replicas: []gorm.Dialector{sqlite.Open(replicaDSN)},
+	}, pkgdb.ReplicaResolver)); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	users := NewUserRepository(primary)
+	user := &entity.User{UserName: "writer", Password: "x", Email: "writer@example.com"}
+	if err := users.Create(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := users.GetByUsername(ctx, "writer"); err != nil {
+		t.Errorf("注册后检查用户名读到了副本: %v", err)
+	}
+
+	posts := NewPostRepository(primary)
+	post := &entity.Post{Title: "Replica", Content: "正文", UserID: user.ID, Status: entity.StatusPublished}
+	if err := posts.Create(ctx, post); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := posts.GetByID(ctx, post.ID); err != nil {
+		t.Errorf("创建后读取文章读到了副本: %v", err)
+	}
+	// 同名文章的 slug 唯一性检查必须看到主库中已有的 slug
+	again := &entity.Post{Title: "Replica", Content: "正文", UserID: user.ID, Status: entity.StatusPublished}
+	if err := posts.Create(ctx, again); err != nil {
+		t.Fatal(err)
+	}
+	if again.Slug != "replica-2" {
+		t.Errorf("第二篇同名文章的 slug 为 %q，期望 replica-2", again.Slug)
+	}
+
+	list, total, err := posts.List(ctx, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 0 || total != 0 {
+		t.Errorf("列表查询返回 %d 篇（total %d），期望读副本得到 0 篇", len(list), total)
+	}
+}
+
+// TestReadYourWrites 检查文章详情在本进程最近写过主库时读主库，没有开启保护时读副本
+func TestReadYourWrites(t *testing.T) {
+	tests := []struct {
+		name   string
+		window time.Duration
+		// fresh 为 true 表示期望读到主库中刚写入的文章
+		fresh bool
+	}{
+		{"without-window", 0, false},
+		{"within-window", time.Hour, true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			primary := openTestDB(t)
+			replicaDSN := fmt.Sprintf("file:repo%d?mode=memory&cache=shared", databases.Add(1))
+			openTestDBAt(t, replicaDSN)
+			if err := primary.Use(pkgdb.NewWriteTracker(tc.window)); err != nil {
+				t.Fatal(err)
+			}
+			if err := primary.Use(dbresolver.Register(dbresolver.Config{
+				Replicas: []gorm.Dialector{sqlite.Open(replicaDSN)},
+			}, pkgdb.ReplicaResolver)); err != nil {
+				t.Fatal(err)
+			}
+
+			ctx := context.Background()
+			user := &entity.User{UserName: "writer", Password: "x", Email: "writer@example.com"}
+			if err := NewUserRepository(primary).Create(ctx, user); err != nil {
+				t.Fatal(err)
+			}
+			posts := NewPostRepository(primary)
+			post := &entity.Post{Title: "Fresh", Content: "正文", UserID: user.ID, Status: entity.StatusPublished}
+			if err := posts.Create(ctx, post); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := posts.GetDetail(ctx, post.ID)
+			if fresh := err == nil; fresh != tc.fresh {
+				t.Errorf("写入后读取文章详情返回 %v，期望读到主库: %v", err, tc.fresh)
+			}
+			_, err = posts.GetBySlug(ctx, post.Slug)
+			if fresh := err == nil; fresh != tc.fresh {
+				t.Errorf("写入后按 slug 读取文章返回 %v，期望读到主库: %v", err, tc.fresh)
+			}
+			// 需要最新数据的读取不受窗口影响，始终读主库
+			if _, err := posts.GetByID(ctx, post.ID); err != nil {
+				t.Errorf("GetByID 读到了副本: %v", err)
+			}
+		})
+	}
+}
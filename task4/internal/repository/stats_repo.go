@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+	"github.com/miffyG/golearn/task4/internal/models/entity"
+	"gorm.io/gorm"
+)
+
+// Stats 是站点的数据统计
+type Stats struct {
+	Users        int64 `json:"users"`
+	AdminUsers   int64 `json:"admin_users"`
+	BannedUsers  int64 `json:"banned_users"`
+	Posts        int64 `json:"posts"`
+	DeletedPosts int64 `json:"deleted_posts"`
+	Comments     int64 `json:"comments"`
+	Revisions    int64 `json:"revisions"`
+}
+
+type StatsRepository struct{ db *gorm.DB }
+
+func NewStatsRepository(db *gorm.DB) *StatsRepository {
+	return &StatsRepository{db: db}
+}
+
+func (r *StatsRepository) Get(ctx context.Context) (*Stats, error) {
+	var s Stats
+	db := r.db.WithContext(ctx).Scopes(replica)
+	counts := []struct {
+		query *gorm.DB
+		dest  *int64
+	}{
+		{db.Model(&entity.User{}), &s.Users},
+		{db.Model(&entity.User{}).Where("role = ?", entity.RoleAdmin), &s.AdminUsers},
+		{db.Model(&entity.User{}).Where("banned = ?", true), &s.BannedUsers},
+		{db.Model(&entity.Post{}), &s.Posts},
+		{db.Model(&entity.Post{}).Unscoped().Where("deleted_at IS NOT NULL"), &s.DeletedPosts},
+		{db.Model(&entity.Comment{}), &s.Comments},
+		{db.Model(&entity.PostRevision{}), &s.Revisions},
+	}
+	for _, c := range counts {
+		if err := c.query.Count(c.dest).Error; err != nil {
+			return nil, err
+		}
+	}
+	return &s, nil
+}
@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/miffyG/golearn/task4/internal/models/entity"
+	"gorm.io/gorm"
+)
+
+// TransferRepository 提供导出时的分批读取和导入时按自然键的查找与写入
+type TransferRepository struct{ db *gorm.DB }
+
+func NewTransferRepository(db *gorm.DB) *TransferRepository {
+	return &TransferRepository{db: db}
+}
+
+// Transaction 在事务中执行 fn，fn 中需要使用传入的 repository
+func (r *TransferRepository) Transaction(ctx context.Context, fn func(tx *TransferRepository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&TransferRepository{db: tx})
+	})
+}
+
+// naturalKeyTolerance 是按创建时间匹配记录时允许的误差。不同数据库保存时间的精度不同，
+// 如 MySQL 的 DATETIME(3) 只保留毫秒、DATETIME 会四舍五入到秒，导出文件中的时间可能比库中的更精确
+const naturalKeyTolerance = time.Second
+
+// PostRow 是带作者用户名的文章
+type PostRow struct {
+	entity.Post
+	Author string
+}
+
+// CommentRow 是带作者用户名的评论
+type CommentRow struct {
+	entity.Comment
+	Author string
+}
+
+// EachUser 按 ID 顺序分批读取用户，包括已删除的用户，他们发表的文章和评论仍会被导出
+func (r *TransferRepository) EachUser(ctx context.Context, batch int, fn func([]entity.User) error) error {
+	var lastID uint
+	for {
+		var users []entity.User
+		if err := r.db.WithContext(ctx).Unscoped().Where("id > ?", lastID).Order("id").Limit(batch).Find(&users).Error; err != nil {
+			return err
+		}
+		if len(users) == 0 {
+			return nil
+		}
+		if err := fn(users); err != nil {
+			return err
+		}
+		lastID = users[len(users)-1].ID
+	}
+}
+
+// EachPost 按 ID 顺序分批读取未删除的文章，作者已被删除时同样读取，作者记录已不存在的文章无法导入，不会读取
+func (r *TransferRepository) EachPost(ctx context.Context, batch int, fn func([]PostRow) error) error {
+	var lastID uint
+	for {
+		var rows []PostRow
+		if err := r.db.WithContext(ctx).Model(&entity.Post{}).
+			Select("posts.*, users.user_name AS author").
+			Joins("JOIN users ON users.id = posts.user_id").
+			Where("posts.id > ?", lastID).Order("posts.id").Limit(batch).
+			Scan(&rows).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		if err := fn(rows); err != nil {
+			return err
+		}
+		lastID = rows[len(rows)-1].ID
+	}
+}
+
+// EachComment 按 ID 顺序分批读取未删除文章下未删除的评论，作者的处理与 EachPost 相同
+func (r *TransferRepository) EachComment(ctx context.Context, batch int, fn func([]CommentRow) error) error {
+	var lastID uint
+	for {
+		var rows []CommentRow
+		if err := r.db.WithContext(ctx).Model(&entity.Comment{}).
+			Select("comments.*, users.user_name AS author").
+			Joins("JOIN users ON users.id = comments.user_id").
+			Joins("JOIN posts ON posts.id = comments.post_id AND posts.deleted_at IS NULL").
+			Where("comments.id > ?", lastID).Order("comments.id").Limit(batch).
+			Scan(&rows).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		if err := fn(rows); err != nil {
+			return err
+		}
+		lastID = rows[len(rows)-1].ID
+	}
+}
+
+// FindUser 按用户名查找用户，优先返回未删除的用户，不存在时返回 nil
+func (r *TransferRepository) FindUser(ctx context.Context, username string) (*entity.User, error) {
+	var user entity.User
+	err := r.db.WithContext(ctx).Where("user_name = ?", username).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = r.db.WithContext(ctx).Unscoped().Where("user_name = ?", username).Order("id DESC").First(&user).Error
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *TransferRepository) CreateUser(ctx context.Context, user *entity.User) error {
+	return r.db.WithContext(ctx).Create(user).Error
+}
+
+// UpdateUser 更新用户的资料、角色、状态和删除时间，password 不为空时同时更新密码哈希
+func (r *TransferRepository) UpdateUser(ctx context.Context, user *entity.User) error {
+	values := map[string]interface{}{
+		"email":      user.Email,
+		"phone":      user.Phone,
+		"role":       user.Role,
+		"banned":     user.Banned,
+		"deleted_at": user.DeletedAt,
+	}
+	if user.Password != "" {
+		values["password"] = user.Password
+	}
+	return r.db.WithContext(ctx).Unscoped().Model(&entity.User{}).Where("id = ?", user.ID).Updates(values).Error
+}
+
+// FindPost 按作者、标题和创建时间查找文章，创建时间允许 naturalKeyTolerance 的误差，不存在时返回 nil
+func (r *TransferRepository) FindPost(ctx context.Context, userId uint, title string, createdAt time.Time) (*entity.Post, error) {
+	var posts []entity.Post
+	if err := r.db.WithContext(ctx).Where("user_id = ? AND title = ? AND created_at BETWEEN ? AND ?",
+		userId, title, createdAt.Add(-naturalKeyTolerance), createdAt.Add(naturalKeyTolerance)).
+		Order("id").Find(&posts).Error; err != nil {
+		return nil, err
+	}
+	return nearest(posts, createdAt, func(p *entity.Post) time.Time { return p.CreatedAt }), nil
+}
+
+// Posts 返回使用同一连接（或事务）的文章 repository，导入的文章和普通文章一样记录修订历史
+func (r *TransferRepository) Posts() *PostRepository {
+	return NewPostRepository(r.db)
+}
+
+// FindComment 按文章、作者和创建时间查找评论，创建时间的误差与 FindPost 相同，不存在时返回 nil
+func (r *TransferRepository) FindComment(ctx context.Context, postId, userId uint, createdAt time.Time) (*entity.Comment, error) {
+	var comments []entity.Comment
+	if err := r.db.WithContext(ctx).Where("post_id = ? AND user_id = ? AND created_at BETWEEN ? AND ?",
+		postId, userId, createdAt.Add(-naturalKeyTolerance), createdAt.Add(naturalKeyTolerance)).
+		Order("id").Find(&comments).Error; err != nil {
+		return nil, err
+	}
+	return nearest(comments, createdAt, func(c *entity.Comment) time.Time { return c.CreatedAt }), nil
+}
+
+// nearest 返回创建时间与 t 最接近的记录，rows 为空时返回 nil
+func nearest[T any](rows []T, t time.Time, createdAt func(*T) time.Time) *T {
+	var best *T
+	var bestDiff time.Duration
+	for i := range rows {
+		diff := createdAt(&rows[i]).Sub(t).Abs()
+		if best == nil || diff < bestDiff {
+			best, bestDiff = &rows[i], diff
+		}
+	}
+	return best
+}
+
+func (r *TransferRepository) CreateComment(ctx context.Context, comment *entity.Comment) error {
+	return r.db.WithContext(ctx).Create(comment).Error
+}
+
+func (r *TransferRepository) UpdateComment(ctx context.Context, comment *entity.Comment) error {
+	return r.db.WithContext(ctx).Model(comment).Update("content", comment.Content).Error
+}
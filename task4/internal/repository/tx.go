@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+
+	pkgdb "github.com/miffyG/golearn/task4/pkg/db"
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+// UnitOfWork 是绑定到同一个事务的一组 repository，事务函数中的读写都应该通过它完成
+type UnitOfWork struct {
+	Users      *UserRepo
+	Posts      *PostRepository
+	Comments   *CommentRepository
+	Moderation *ModerationRepository
+	Audit      *AuditRepository
+	Identities *IdentityRepository
+}
+
+func newUnitOfWork(tx *gorm.DB) *UnitOfWork {
+	return &UnitOfWork{
+		Users:      NewUserRepository(tx),
+		Posts:      NewPostRepository(tx),
+		Comments:   NewCommentRepository(tx),
+		Moderation: NewModerationRepository(tx),
+		Audit:      NewAuditRepository(tx),
+		Identities: NewIdentityRepository(tx),
+	}
+}
+
+type txKey struct{}
+
+// TxManager 在事务中执行跨 repository 的操作
+type TxManager struct{ db *gorm.DB }
+
+func NewTxManager(db *gorm.DB) *TxManager {
+	return &TxManager{db: db}
+}
+
+// Transaction 开启事务并用绑定到事务的 UnitOfWork 执行 fn，fn 返回错误或 panic 时回滚。
+// fn 收到的 ctx 携带当前事务，用它再次调用 Transaction 时不会开启新事务，而是在当前事务中创建保存点，
+// 内层返回错误只回滚到保存点，由外层决定是否继续。事务中的语句随 ctx 取消而中止
+func (m *TxManager) Transaction(ctx context.Context, fn func(ctx context.Context, uow *UnitOfWork) error) error {
+	db := m.db
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		db = tx
+	}
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txKey{}, tx), newUnitOfWork(tx))
+	})
+}
+
+// replica 把事务外的查询分配到只读副本，只用于可以接受复制延迟的列表查询，文章详情使用 recentReplica。
+// 写入后立即读取、唯一性检查等需要读到最新数据的查询不能使用
+func replica(db *gorm.DB) *gorm.DB {
+	return db.Clauses(dbresolver.Use(pkgdb.ReplicaResolver), dbresolver.Read)
+}
+
+// recentReplica 与 replica 相同，但本进程在 DB_READ_YOUR_WRITES_WINDOW 内写过主库时改读主库，
+// 用于客户端写入后通常会立即读取的文章详情
+func recentReplica(db *gorm.DB) *gorm.DB {
+	if pkgdb.RecentlyWritten(db) {
+		return db
+	}
+	return replica(db)
+}
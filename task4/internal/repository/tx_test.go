@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/miffyG/golearn/task4/internal/models/entity"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+var databases atomic.Int64
+
+// openTestDB 打开一个独立的内存数据库并建好表
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	return openTestDBAt(t, fmt.Sprintf("file:repo%d?mode=memory&cache=shared&_pragma=foreign_keys(1)", databases.Add(1)))
+}
+
+func openTestDBAt(t *testing.T, dsn string) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: gormlogger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := db.AutoMigrate(&entity.User{}, &entity.Post{}, &entity.Comment{}, &entity.Tag{}, &entity.PostRevision{}, &entity.PostSlug{}, &entity.Report{}); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// counts 返回各表的行数，包括软删除的记录
+func counts(t *testing.T, db *gorm.DB) map[string]int64 {
+	t.Helper()
+	n := make(map[string]int64)
+	for name, model := range map[string]interface{}{
+		"posts":          &entity.Post{},
+		"post_revisions": &entity.PostRevision{},
+		"post_slugs":     &entity.PostSlug{},
+		"reports":        &entity.Report{},
+	} {
+		var c int64
+		if db.Migrator().HasTable(model) {
+			if err := db.Unscoped().Model(model).Count(&c).Error; err != nil {
+				t.Fatal(err)
+			}
+		}
+		n[name] = c
+	}
+	return n
+}
+
+// holdPost 为文章创建一条待处理的举报，与 PostService.Create 拦截文章时相同
+func holdPost(ctx context.Context, uow *UnitOfWork, post *entity.Post) error {
+	return uow.Moderation.CreateReport(ctx, &entity.Report{
+		TargetType: entity.ReportTargetPost, TargetID: post.ID, Reason: "hold", Status: entity.ReportOpen,
+	})
+}
+
+// TestTransactionRollback 在文章写入之后让事务的某一步失败，检查所有表都没有留下记录
+func TestTransactionRollback(t *testing.T) {
+	errStep := errors.New("第三步失败")
+	tests := []struct {
+		name string
+		// setup 在事务之前修改数据库，让某一步失败
+		setup func(t *testing.T, db *gorm.DB)
+		// last 在事务中创建文章之后执行
+		last func(ctx context.Context, uow *UnitOfWork, post *entity.Post) error
+		want error
+	}{
+		{
+			name: "step-returns-error",
+			last: func(ctx context.Context, uow *UnitOfWork, post *entity.Post) error {
+				if err := holdPost(ctx, uow, post); err != nil {
+					return err
+				}
+				return errStep
+			},
+			want: errStep,
+		},
+		{
+			name: "report-insert-fails",
+			setup: func(t *testing.T, db *gorm.DB) {
+				if err := db.Migrator().DropTable(&entity.Report{}); err != nil {
+					t.Fatal(err)
+				}
+			},
+			last: holdPost,
+		},
+		{
+			name: "panic",
+			last: func(ctx context.Context, uow *UnitOfWork, post *entity.Post) error {
+				if err := holdPost(ctx, uow, post); err != nil {
+					return err
+				}
+				panic(errStep)
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			db := openTestDB(t)
+			user := &entity.User{UserName: "writer", Password: "x", Email: "writer@example.com"}
+			if err := db.Create(user).Error; err != nil {
+				t.Fatal(err)
+			}
+			if tc.setup != nil {
+				tc.setup(t, db)
+			}
+
+			var err error
+			func() {
+				defer func() {
+					if p := recover(); p != nil {
+						err = fmt.Errorf("panic: %v", p)
+					}
+				}()
+				err = NewTxManager(db).Transaction(context.Background(), func(ctx context.Context, uow *UnitOfWork) error {
+					post := &entity.Post{Title: "Rollback", Content: "正文", UserID: user.ID, Status: entity.StatusPublished}
+					if err := uow.Posts.Create(ctx, post); err != nil {
+						return err
+					}
+					return tc.last(ctx, uow, post)
+				})
+			}()
+			if err == nil {
+				t.Fatal("事务没有返回错误")
+			}
+			if tc.want != nil && !errors.Is(err, tc.want) {
+				t.Errorf("事务返回 %v，期望 %v", err, tc.want)
+			}
+			for table, n := range counts(t, db) {
+				if n != 0 {
+					t.Errorf("回滚后 %s 还有 %d 行", table, n)
+				}
+			}
+		})
+	}
+}
+
+// TestTransactionSavepoint 检查嵌套的 Transaction 只回滚到保存点，外层事务提交后保留内层之前的写入
+func TestTransactionSavepoint(t *testing.T) {
+	db := openTestDB(t)
+	user := &entity.User{UserName: "writer", Password: "x", Email: "writer@example.com"}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatal(err)
+	}
+	tm := NewTxManager(db)
+	errInner := errors.New("内层失败")
+	err := tm.Transaction(context.Background(), func(ctx context.Context, uow *UnitOfWork) error {
+		post := &entity.Post{Title: "Savepoint", Content: "正文", UserID: user.ID, Status: entity.StatusPublished}
+		if err := uow.Posts.Create(ctx, post); err != nil {
+			return err
+		}
+		err := tm.Transaction(ctx, func(ctx context.Context, uow *UnitOfWork) error {
+			if err := holdPost(ctx, uow, post); err != nil {
+				return err
+			}
+			return errInner
+		})
+		if !errors.Is(err, errInner) {
+			t.Errorf("内层事务返回 %v，期望 %v", err, errInner)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int64{"posts": 1, "post_revisions": 1, "post_slugs": 1, "reports": 0}
+	for table, n := range counts(t, db) {
+		if n != want[table] {
+			t.Errorf("%s 有 %d 行，期望 %d", table, n, want[table])
+		}
+	}
+}
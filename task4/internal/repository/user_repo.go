@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"github.com/miffyG/golearn/task4/internal/models/entity"
 	"gorm.io/gorm"
 )
@@ -11,14 +12,56 @@ func NewUserRepository(db *gorm.DB) *UserRepo {
 	return &UserRepo{db: db}
 }
 
-func (r *UserRepo) Create(user *entity.User) error {
-	return r.db.Create(user).Error
+func (r *UserRepo) Create(ctx context.Context, user *entity.User) error {
+	return r.db.WithContext(ctx).Create(user).Error
 }
 
-func (r *UserRepo) GetByUsername(username string) (*entity.User, error) {
+func (r *UserRepo) GetByUsername(ctx context.Context, username string) (*entity.User, error) {
 	var user entity.User
-	if err := r.db.Where("user_name = ?", username).First(&user).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("user_name = ?", username).First(&user).Error; err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
+
+func (r *UserRepo) GetByID(ctx context.Context, id uint) (*entity.User, error) {
+	var user entity.User
+	if err := r.db.WithContext(ctx).First(&user, id).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetByIDs 一次查询多个用户，用于批量加载
+func (r *UserRepo) GetByIDs(ctx context.Context, ids []uint) ([]entity.User, error) {
+	var users []entity.User
+	if err := r.db.WithContext(ctx).Scopes(replica).Where("id IN ?", ids).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// List 按 ID 分页查询用户，同时返回用户总数
+func (r *UserRepo) List(ctx context.Context, offset, limit int) ([]entity.User, int64, error) {
+	var total int64
+	if err := r.db.WithContext(ctx).Model(&entity.User{}).Scopes(replica).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var users []entity.User
+	if err := r.db.WithContext(ctx).Scopes(replica).Order("id").Offset(offset).Limit(limit).Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+// UpdateColumns 更新用户的指定字段，用户不存在时返回 gorm.ErrRecordNotFound
+func (r *UserRepo) UpdateColumns(ctx context.Context, id uint, values map[string]interface{}) error {
+	res := r.db.WithContext(ctx).Model(&entity.User{}).Where("id = ?", id).Updates(values)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
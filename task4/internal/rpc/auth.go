@@ -0,0 +1,82 @@
+package rpc
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"github.com/miffyG/golearn/task4/internal/audit"
+	"github.com/miffyG/golearn/task4/internal/tokens"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+type userIDKey struct{}
+
+// AuthInterceptor 从 metadata 的 authorization: Bearer <token> 中用 keys 解析 JWT，通过后把用户 ID 写入上下文。
+// protected 中的方法必须携带合法 token，其余方法携带合法 token 时同样写入用户 ID。
+// 审计日志需要的请求 ID、客户端地址和 UA 也在这里写入上下文
+func AuthInterceptor(keys *tokens.KeySet, protected map[string]bool) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx = audit.WithMeta(ctx, requestMeta(ctx))
+		userId, err := authenticate(ctx, keys)
+		if err != nil && protected[info.FullMethod] {
+			return nil, err
+		}
+		if userId != 0 {
+			ctx = context.WithValue(ctx, userIDKey{}, userId)
+			ctx = audit.WithActor(ctx, userId)
+		}
+		return handler(ctx, req)
+	}
+}
+
+// requestMeta 读取 metadata 中的 x-request-id 和 user-agent，没有请求 ID 时生成一个
+func requestMeta(ctx context.Context) audit.Meta {
+	md, _ := metadata.FromIncomingContext(ctx)
+	meta := audit.Meta{RequestID: first(md.Get("x-request-id"))}
+	if meta.RequestID == "" {
+		meta.RequestID = audit.NewRequestID()
+	}
+	meta.UserAgent = first(md.Get("user-agent"))
+	if p, ok := peer.FromContext(ctx); ok {
+		meta.IP = p.Addr.String()
+		if host, _, err := net.SplitHostPort(meta.IP); err == nil {
+			meta.IP = host
+		}
+	}
+	return meta
+}
+
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func authenticate(ctx context.Context, keys *tokens.KeySet) (uint, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return 0, status.Error(codes.Unauthenticated, "未授权")
+	}
+	parts := strings.SplitN(values[0], " ", 2)
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		return 0, status.Error(codes.Unauthenticated, "未授权")
+	}
+	claims, err := keys.Parse(parts[1])
+	if err != nil {
+		return 0, status.Error(codes.Unauthenticated, "token无效")
+	}
+	return claims.UserID, nil
+}
+
+// UserIDFrom 返回拦截器写入的当前用户 ID，未登录时为 0
+func UserIDFrom(ctx context.Context) uint {
+	id, _ := ctx.Value(userIDKey{}).(uint)
+	return id
+}
@@ -0,0 +1,52 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin/binding"
+	blogv1 "github.com/miffyG/golearn/task4/api/blog/v1"
+	dto "github.com/miffyG/golearn/task4/internal/models/dto/v2"
+	"github.com/miffyG/golearn/task4/internal/models/entity"
+	"github.com/miffyG/golearn/task4/internal/service"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type commentServer struct {
+	errorMapper
+	blogv1.UnimplementedCommentServiceServer
+	commentService *service.CommentService
+}
+
+func (s *commentServer) ListComments(ctx context.Context, req *blogv1.ListCommentsRequest) (*blogv1.ListCommentsResponse, error) {
+	if req.GetPostId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "参数错误")
+	}
+	comments, err := s.commentService.GetByPostId(ctx, uint(req.GetPostId()))
+	if err != nil {
+		return nil, s.statusError(err, "获取评论")
+	}
+	res := &blogv1.ListCommentsResponse{Comments: make([]*blogv1.Comment, len(comments))}
+	for i := range comments {
+		res.Comments[i] = toComment(&comments[i])
+	}
+	return res, nil
+}
+
+func (s *commentServer) CreateComment(ctx context.Context, req *blogv1.CreateCommentRequest) (*blogv1.Comment, error) {
+	if req.GetPostId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "参数错误")
+	}
+	if err := binding.Validator.ValidateStruct(&dto.CommentRequest{Content: req.GetContent()}); err != nil {
+		return nil, s.invalidArgument(err)
+	}
+	comment := entity.Comment{
+		Content: req.GetContent(),
+		UserID:  UserIDFrom(ctx),
+		PostID:  uint(req.GetPostId()),
+	}
+	if err := s.commentService.Create(ctx, &comment); err != nil {
+		return nil, s.statusError(err, "创建评论")
+	}
+	return toComment(&comment), nil
+}
@@ -0,0 +1,58 @@
+package rpc
+
+import (
+	"time"
+
+	blogv1 "github.com/miffyG/golearn/task4/api/blog/v1"
+	"github.com/miffyG/golearn/task4/internal/models/entity"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func timestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+func toUser(u *entity.User) *blogv1.User {
+	if u == nil || u.ID == 0 {
+		return nil
+	}
+	return &blogv1.User{
+		Id:        uint64(u.ID),
+		Username:  u.UserName,
+		Email:     u.Email,
+		Phone:     u.Phone,
+		CreatedAt: timestamp(u.CreatedAt),
+	}
+}
+
+func toPost(p *entity.Post) *blogv1.Post {
+	comments := make([]*blogv1.Comment, len(p.Comments))
+	for i := range p.Comments {
+		comments[i] = toComment(&p.Comments[i])
+	}
+	return &blogv1.Post{
+		Id:        uint64(p.ID),
+		Title:     p.Title,
+		Content:   p.Content,
+		UserId:    uint64(p.UserID),
+		Version:   uint64(p.Version),
+		CreatedAt: timestamp(p.CreatedAt),
+		UpdatedAt: timestamp(p.UpdatedAt),
+		User:      toUser(p.User),
+		Comments:  comments,
+	}
+}
+
+func toComment(c *entity.Comment) *blogv1.Comment {
+	return &blogv1.Comment{
+		Id:        uint64(c.ID),
+		Content:   c.Content,
+		UserId:    uint64(c.UserID),
+		PostId:    uint64(c.PostID),
+		CreatedAt: timestamp(c.CreatedAt),
+		UpdatedAt: timestamp(c.UpdatedAt),
+	}
+}
@@ -0,0 +1,94 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin/binding"
+	blogv1 "github.com/miffyG/golearn/task4/api/blog/v1"
+	dto "github.com/miffyG/golearn/task4/internal/models/dto/v2"
+	"github.com/miffyG/golearn/task4/internal/models/entity"
+	"github.com/miffyG/golearn/task4/internal/service"
+	"github.com/miffyG/golearn/task4/internal/utils"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+type postServer struct {
+	errorMapper
+	blogv1.UnimplementedPostServiceServer
+	postService *service.PostService
+}
+
+func (s *postServer) ListPosts(ctx context.Context, req *blogv1.ListPostsRequest) (*blogv1.ListPostsResponse, error) {
+	page, pageSize := int(req.GetPage()), int(req.GetPageSize())
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = defaultPageSize
+	}
+	posts, total, err := s.postService.List(ctx, page, min(pageSize, maxPageSize))
+	if err != nil {
+		return nil, s.statusError(err, "获取帖子")
+	}
+	res := &blogv1.ListPostsResponse{Posts: make([]*blogv1.Post, len(posts)), Total: total}
+	for i := range posts {
+		res.Posts[i] = toPost(&posts[i])
+	}
+	return res, nil
+}
+
+func (s *postServer) GetPost(ctx context.Context, req *blogv1.GetPostRequest) (*blogv1.Post, error) {
+	if req.GetId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "参数错误")
+	}
+	p, err := s.postService.GetByID(ctx, uint(req.GetId()))
+	if err != nil {
+		return nil, s.statusError(err, "获取帖子")
+	}
+	return toPost(p), nil
+}
+
+func (s *postServer) CreatePost(ctx context.Context, req *blogv1.CreatePostRequest) (*blogv1.Post, error) {
+	if err := binding.Validator.ValidateStruct(&dto.PostRequest{Title: req.GetTitle(), Content: req.GetContent()}); err != nil {
+		return nil, s.invalidArgument(err)
+	}
+	post := entity.Post{
+		Title:   req.GetTitle(),
+		Content: req.GetContent(),
+		UserID:  UserIDFrom(ctx),
+	}
+	if err := s.postService.Create(ctx, &post); err != nil {
+		return nil, s.statusError(err, "创建帖子")
+	}
+	return toPost(&post), nil
+}
+
+func (s *postServer) UpdatePost(ctx context.Context, req *blogv1.UpdatePostRequest) (*blogv1.Post, error) {
+	if req.GetId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "参数错误")
+	}
+	if err := binding.Validator.ValidateStruct(&dto.PostRequest{Title: req.GetTitle(), Content: req.GetContent()}); err != nil {
+		return nil, s.invalidArgument(err)
+	}
+	post := &entity.Post{
+		Title:   req.GetTitle(),
+		Content: req.GetContent(),
+	}
+	post.ID = uint(req.GetId())
+	if err := s.postService.Update(ctx, UserIDFrom(ctx), post, utils.ExpectVersion(uint(req.GetVersion()))); err != nil {
+		return nil, s.statusError(err, "更新帖子")
+	}
+	return toPost(post), nil
+}
+
+func (s *postServer) DeletePost(ctx context.Context, req *blogv1.DeletePostRequest) (*emptypb.Empty, error) {
+	if req.GetId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "参数错误")
+	}
+	if err := s.postService.Delete(ctx, UserIDFrom(ctx), uint(req.GetId())); err != nil {
+		return nil, s.statusError(err, "删除帖子")
+	}
+	return &emptypb.Empty{}, nil
+}
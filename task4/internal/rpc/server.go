@@ -0,0 +1,82 @@
+// Package rpc 实现 gRPC 接口，与 Gin 的 REST 接口共用 service 层，错误按 REST 的状态码映射为 gRPC 状态码。
+package rpc
+
+import (
+	"context"
+	"errors"
+
+	blogv1 "github.com/miffyG/golearn/task4/api/blog/v1"
+	"github.com/miffyG/golearn/task4/internal/repository"
+	"github.com/miffyG/golearn/task4/internal/service"
+	"github.com/miffyG/golearn/task4/internal/tokens"
+	"github.com/miffyG/golearn/task4/internal/utils"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// NewServer 创建注册了全部服务和反射服务的 gRPC server，用 keys 校验 token，内部错误输出到 log
+func NewServer(userService *service.UserService, postService *service.PostService, commentService *service.CommentService, keys *tokens.KeySet, log *zap.SugaredLogger) *grpc.Server {
+	utils.RegisterValidators()
+	s := grpc.NewServer(grpc.UnaryInterceptor(AuthInterceptor(keys, protectedMethods)))
+	errs := errorMapper{log: log}
+	blogv1.RegisterUserServiceServer(s, &userServer{errorMapper: errs, userService: userService})
+	blogv1.RegisterPostServiceServer(s, &postServer{errorMapper: errs, postService: postService})
+	blogv1.RegisterCommentServiceServer(s, &commentServer{errorMapper: errs, commentService: commentService})
+	reflection.Register(s)
+	return s
+}
+
+// protectedMethods 是需要登录才能调用的方法，与 REST 中挂了 JWT 中间件的路由一致
+var protectedMethods = map[string]bool{
+	blogv1.PostService_CreatePost_FullMethodName:       true,
+	blogv1.PostService_UpdatePost_FullMethodName:       true,
+	blogv1.PostService_DeletePost_FullMethodName:       true,
+	blogv1.CommentService_CreateComment_FullMethodName: true,
+}
+
+// errorMapper 由各服务嵌入，把错误转换为 gRPC 状态并记录内部错误
+type errorMapper struct {
+	log *zap.SugaredLogger
+}
+
+// statusError 把 service 层返回的错误映射为 gRPC 状态码
+func (m errorMapper) statusError(err error, action string) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return status.Error(codes.NotFound, "资源不存在")
+	case errors.Is(err, bcrypt.ErrMismatchedHashAndPassword):
+		return status.Error(codes.Unauthenticated, "用户不存在或密码错误")
+	case errors.Is(err, service.ErrUserBanned):
+		return status.Error(codes.PermissionDenied, "用户已被封禁")
+	case errors.Is(err, service.ErrPreconditionFailed):
+		return status.Error(codes.FailedPrecondition, "版本不匹配")
+	case errors.Is(err, service.ErrContentRejected):
+		return status.Error(codes.InvalidArgument, "内容未通过审核")
+	case errors.Is(err, repository.ErrVersionConflict):
+		return status.Error(codes.Aborted, "资源已被其他人修改")
+	case err.Error() == "unauthorized":
+		return status.Error(codes.PermissionDenied, "没有权限")
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, "请求超时")
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, "请求已取消")
+	default:
+		m.log.Errorf("%s失败: %v", action, err)
+		return status.Error(codes.Internal, action+"失败")
+	}
+}
+
+func (m errorMapper) invalidArgument(err error) error {
+	m.log.Errorf("参数错误: %v", err)
+	return status.Error(codes.InvalidArgument, "参数错误")
+}
@@ -0,0 +1,62 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gin-gonic/gin/binding"
+	blogv1 "github.com/miffyG/golearn/task4/api/blog/v1"
+	dto "github.com/miffyG/golearn/task4/internal/models/dto/v2"
+	"github.com/miffyG/golearn/task4/internal/models/entity"
+	"github.com/miffyG/golearn/task4/internal/service"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+type userServer struct {
+	errorMapper
+	blogv1.UnimplementedUserServiceServer
+	userService *service.UserService
+}
+
+func (s *userServer) Register(ctx context.Context, req *blogv1.RegisterRequest) (*blogv1.User, error) {
+	// 复用 REST 请求结构上的校验规则，保证两边的参数要求一致
+	if err := binding.Validator.ValidateStruct(&dto.RegisterRequest{
+		Username: req.GetUsername(),
+		Password: req.GetPassword(),
+		Email:    req.GetEmail(),
+		Phone:    req.GetPhone(),
+	}); err != nil {
+		return nil, s.invalidArgument(err)
+	}
+
+	user := entity.User{
+		UserName: req.GetUsername(),
+		Password: req.GetPassword(),
+		Email:    req.GetEmail(),
+		Phone:    req.GetPhone(),
+	}
+	if err := s.userService.Register(ctx, &user); err != nil {
+		return nil, s.statusError(err, "注册")
+	}
+	return toUser(&user), nil
+}
+
+func (s *userServer) Login(ctx context.Context, req *blogv1.LoginRequest) (*blogv1.LoginResponse, error) {
+	if err := binding.Validator.ValidateStruct(&dto.LoginRequest{
+		Username: req.GetUsername(),
+		Password: req.GetPassword(),
+	}); err != nil {
+		return nil, s.invalidArgument(err)
+	}
+
+	token, user, err := s.userService.Login(ctx, req.GetUsername(), req.GetPassword())
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && user == nil) {
+		return nil, status.Error(codes.Unauthenticated, "用户不存在或密码错误")
+	}
+	if err != nil {
+		return nil, s.statusError(err, "登录")
+	}
+	return &blogv1.LoginResponse{Token: token, User: toUser(user)}, nil
+}
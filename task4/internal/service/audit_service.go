@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/miffyG/golearn/task4/internal/audit"
+	"github.com/miffyG/golearn/task4/internal/models/entity"
+	"github.com/miffyG/golearn/task4/internal/repository"
+	"go.uber.org/zap"
+)
+
+type AuditService struct {
+	repo  *repository.AuditRepository
+	chain bool
+	log   *zap.SugaredLogger
+}
+
+// NewAuditService 创建审计服务，chain 为 true 时每条记录都带上哈希链，可以用 Verify 检查记录是否被篡改。
+// 写入失败的审计日志输出到 log
+func NewAuditService(repo *repository.AuditRepository, chain bool, log *zap.SugaredLogger) *AuditService {
+	return &AuditService{repo: repo, chain: chain, log: log}
+}
+
+// AuditEntry 是一条待写入的审计日志，ActorID 为 0 时使用 ctx 中的操作人；
+// Before、After 会被编码为 JSON 摘要
+type AuditEntry struct {
+	Action     string
+	TargetType string
+	TargetID   uint
+	ActorID    uint
+	Before     interface{}
+	After      interface{}
+	Detail     string
+}
+
+// Record 写入一条审计日志，请求 ID、IP、UA 取自 ctx。s 为 nil 时不记录；
+// 写入失败不影响业务操作，只输出错误日志
+func (s *AuditService) Record(ctx context.Context, e AuditEntry) {
+	if s == nil {
+		return
+	}
+	// 客户端断开或请求超时后，已经完成的操作仍然需要留下记录
+	if err := s.append(s.repo, context.WithoutCancel(ctx), e); err != nil {
+		s.log.Errorf("写入审计日志失败: %v, action=%s target=%s/%d", err, e.Action, e.TargetType, e.TargetID)
+	}
+}
+
+// RecordTx 与 Record 相同，但在 uow 的事务中写入，审计日志随业务操作一起提交或回滚。
+// 写入失败时返回错误，调用方应当返回它让事务回滚，不留下没有审计日志的操作。s 为 nil 时返回 nil
+func (s *AuditService) RecordTx(ctx context.Context, uow *repository.UnitOfWork, e AuditEntry) error {
+	if s == nil {
+		return nil
+	}
+	if err := s.append(uow.Audit, ctx, e); err != nil {
+		return fmt.Errorf("写入审计日志失败: %w", err)
+	}
+	return nil
+}
+
+func (s *AuditService) append(repo *repository.AuditRepository, ctx context.Context, e AuditEntry) error {
+	meta := audit.MetaFrom(ctx)
+	log := &entity.AuditLog{
+		RequestID:  meta.RequestID,
+		ActorID:    e.ActorID,
+		IP:         meta.IP,
+		UserAgent:  truncate(meta.UserAgent, 255),
+		Action:     e.Action,
+		TargetType: e.TargetType,
+		TargetID:   e.TargetID,
+		Before:     auditJSON(e.Before),
+		After:      auditJSON(e.After),
+		Detail:     truncate(e.Detail, 255),
+	}
+	if log.ActorID == 0 {
+		log.ActorID = meta.ActorID
+	}
+	return repo.Append(ctx, log, s.chain)
+}
+
+// Search 分页查询审计日志，page 从 1 开始
+func (s *AuditService) Search(ctx context.Context, filter repository.AuditFilter, page, pageSize int) ([]entity.AuditLog, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	return s.repo.Search(ctx, filter, (page-1)*pageSize, pageSize)
+}
+
+// AuditVerifyResult 是哈希链的校验结果，Valid 为 false 时 BrokenAt 是第一条校验失败的记录
+type AuditVerifyResult struct {
+	Valid    bool   `json:"valid"`
+	Checked  int    `json:"checked"`
+	BrokenAt uint   `json:"broken_at,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// Verify 按顺序重新计算全部带 hash 的记录，检查记录是否被修改、删除或插入。
+// 没有 hash 的记录（关闭哈希链时写入）不参与校验，校验开始后新写入的记录也不参与校验
+func (s *AuditService) Verify(ctx context.Context) (*AuditVerifyResult, error) {
+	// 先读取链头，末尾的记录被删除时链本身仍然连续，需要和链头比较
+	head, err := s.repo.ChainHead(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &AuditVerifyResult{Valid: true}
+	prev := ""
+	var lastID uint
+	err = s.repo.Each(ctx, 500, func(logs []entity.AuditLog) error {
+		for i := range logs {
+			l := &logs[i]
+			if l.Hash == "" || l.ID > head.LastID {
+				continue
+			}
+			res.Checked++
+			lastID = l.ID
+			switch {
+			case l.PrevHash != prev:
+				res.Valid, res.BrokenAt, res.Reason = false, l.ID, "prev_hash 与上一条记录不一致"
+			case audit.ChainHash(l.PrevHash, l) != l.Hash:
+				res.Valid, res.BrokenAt, res.Reason = false, l.ID, "记录内容与 hash 不一致"
+			}
+			if !res.Valid {
+				return errStopVerify
+			}
+			prev = l.Hash
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errStopVerify) {
+		return nil, err
+	}
+	if !res.Valid {
+		return res, nil
+	}
+	if head.Hash != prev || head.LastID != lastID {
+		res.Valid, res.Reason = false, "最后一条记录与哈希链头不一致"
+		res.BrokenAt = head.LastID
+	}
+	return res, nil
+}
+
+var errStopVerify = errors.New("stop verify")
+
+func auditJSON(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n])
+}
+
+// postSummary 是审计日志中文章的摘要，不包含正文
+func postSummary(p *entity.Post) map[string]interface{} {
+	return map[string]interface{}{
+		"title":          p.Title,
+		"version":        p.Version,
+		"status":         p.Status,
+		"content_length": len([]rune(p.Content)),
+	}
+}
+
+func commentSummary(c *entity.Comment) map[string]interface{} {
+	return map[string]interface{}{
+		"post_id": c.PostID,
+		"content": truncate(c.Content, 200),
+		"status":  c.Status,
+	}
+}
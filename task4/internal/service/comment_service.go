@@ -1,22 +1,131 @@
 package service
 
 import (
+	"context"
+	"errors"
+
+	"github.com/miffyG/golearn/task4/internal/audit"
 	"github.com/miffyG/golearn/task4/internal/models/entity"
+	"github.com/miffyG/golearn/task4/internal/moderation"
 	"github.com/miffyG/golearn/task4/internal/repository"
+	"gorm.io/gorm"
 )
 
 type CommentService struct {
-	repo *repository.CommentRepository
+	repo       *repository.CommentRepository
+	tx         *repository.TxManager
+	moderation *ModerationService
+	audit      *AuditService
+}
+
+// NewCommentService 创建评论服务，moderation 为 nil 时发布评论不经过内容过滤，audit 为 nil 时不记录审计日志
+func NewCommentService(repo *repository.CommentRepository, tx *repository.TxManager, moderation *ModerationService, audit *AuditService) *CommentService {
+	return &CommentService{repo: repo, tx: tx, moderation: moderation, audit: audit}
+}
+
+// Create 创建评论，内容过滤的处理与 PostService.Create 相同
+func (s *CommentService) Create(ctx context.Context, comment *entity.Comment) error {
+	hold, reason, err := s.moderation.screen(&moderation.Content{
+		Kind:     entity.ReportTargetComment,
+		AuthorID: comment.UserID,
+		Body:     comment.Content,
+	})
+	if err != nil {
+		return err
+	}
+	comment.Status = entity.StatusPublished
+	if hold {
+		comment.Status = entity.StatusPending
+	}
+	return s.tx.Transaction(ctx, func(ctx context.Context, uow *repository.UnitOfWork) error {
+		if err := uow.Comments.Create(ctx, comment); err != nil {
+			return err
+		}
+		if hold {
+			return s.moderation.hold(ctx, uow.Moderation, entity.ReportTargetComment, comment.ID, reason)
+		}
+		return nil
+	})
+}
+
+func (s *CommentService) GetByPostId(ctx context.Context, postId uint) ([]entity.Comment, error) {
+	return s.repo.GetByPostId(ctx, postId)
+}
+
+// GetByPostIds 返回每篇文章最早的 limit 条评论
+func (s *CommentService) GetByPostIds(ctx context.Context, postIds []uint, limit int) ([]entity.Comment, error) {
+	return s.repo.GetByPostIds(ctx, postIds, limit)
+}
+
+// List 分页查询文章的评论，page 从 1 开始
+func (s *CommentService) List(ctx context.Context, postId uint, page, pageSize int) ([]entity.Comment, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	return s.repo.ListByPostId(ctx, postId, (page-1)*pageSize, pageSize)
 }
 
-func NewCommentService(repo *repository.CommentRepository) *CommentService {
-	return &CommentService{repo: repo}
+// GetByID 返回已发布的评论，待审核和已移除的评论按不存在处理
+func (s *CommentService) GetByID(ctx context.Context, id uint) (*entity.Comment, error) {
+	comment, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if comment.Status != entity.StatusPublished {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return comment, nil
 }
 
-func (s *CommentService) Create(comment *entity.Comment) error {
-	return s.repo.Create(comment)
+// Update 修改评论内容，只有评论作者可以修改
+func (s *CommentService) Update(ctx context.Context, userId, commentId uint, content string) (*entity.Comment, error) {
+	var comment *entity.Comment
+	err := s.tx.Transaction(ctx, func(ctx context.Context, uow *repository.UnitOfWork) error {
+		var err error
+		comment, err = uow.Comments.GetByID(ctx, commentId)
+		if err != nil {
+			return err
+		}
+		if comment.UserID != userId {
+			return errors.New("unauthorized")
+		}
+		before := commentSummary(comment)
+		comment.Content = content
+		if err := uow.Comments.Update(ctx, comment); err != nil {
+			return err
+		}
+		return s.audit.RecordTx(ctx, uow, AuditEntry{
+			Action:     audit.ActionCommentUpdate,
+			TargetType: audit.TargetComment,
+			TargetID:   comment.ID,
+			Before:     before,
+			After:      commentSummary(comment),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return comment, nil
 }
 
-func (s *CommentService) GetByPostId(postId uint) ([]entity.Comment, error) {
-	return s.repo.GetByPostId(postId)
+// Delete 删除评论，只有评论作者可以删除
+func (s *CommentService) Delete(ctx context.Context, userId, commentId uint) error {
+	return s.tx.Transaction(ctx, func(ctx context.Context, uow *repository.UnitOfWork) error {
+		comment, err := uow.Comments.GetByID(ctx, commentId)
+		if err != nil {
+			return err
+		}
+		if comment.UserID != userId {
+			return errors.New("unauthorized")
+		}
+		if err := uow.Comments.Delete(ctx, comment); err != nil {
+			return err
+		}
+		return s.audit.RecordTx(ctx, uow, AuditEntry{
+			Action:     audit.ActionCommentDelete,
+			TargetType: audit.TargetComment,
+			TargetID:   comment.ID,
+			Before:     commentSummary(comment),
+		})
+	})
 }
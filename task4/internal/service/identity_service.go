@@ -0,0 +1,222 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/miffyG/golearn/task4/internal/audit"
+	"github.com/miffyG/golearn/task4/internal/models/entity"
+	"github.com/miffyG/golearn/task4/internal/oidc"
+	"github.com/miffyG/golearn/task4/internal/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrFlowNotFound 表示第三方登录的流程不存在、已经使用过或已过期
+	ErrFlowNotFound = errors.New("oidc flow not found")
+	// ErrIdentityLinked 表示外部账号已经关联到其他用户
+	ErrIdentityLinked = errors.New("identity linked to another user")
+)
+
+// IdentityLogin 是一次第三方登录的结果
+type IdentityLogin struct {
+	Token string
+	User  *entity.User
+	// Created 表示本次登录创建了新用户，Linked 表示本次登录把外部账号关联到了已登录的用户
+	Created bool
+	Linked  bool
+}
+
+// IdentityService 处理第三方登录：保存进行中的登录流程，按外部账号找到或创建用户并签发 token
+type IdentityService struct {
+	repo  *repository.IdentityRepository
+	tx    *repository.TxManager
+	users *UserService
+	audit *AuditService
+}
+
+func NewIdentityService(r *repository.IdentityRepository, tx *repository.TxManager, users *UserService, audit *AuditService) *IdentityService {
+	return &IdentityService{
+		repo:  r,
+		tx:    tx,
+		users: users,
+		audit: audit,
+	}
+}
+
+// StartFlow 保存一次登录流程，有效期为 ttl。state 只保存哈希；linkUserID 不为 0 时，回调后把外部账号关联到该用户
+func (s *IdentityService) StartFlow(ctx context.Context, provider string, flow *oidc.Flow, linkUserID uint, ttl time.Duration) error {
+	return s.repo.CreateFlow(ctx, &entity.OIDCFlow{
+		StateHash:  hashState(flow.State),
+		Provider:   provider,
+		Nonce:      flow.Nonce,
+		Verifier:   flow.Verifier,
+		LinkUserID: linkUserID,
+		ExpiresAt:  time.Now().Add(ttl),
+	})
+}
+
+// TakeFlow 取出 state 对应的登录流程，每个流程只能取出一次，提供方必须与发起时的一致
+func (s *IdentityService) TakeFlow(ctx context.Context, provider, state string) (*oidc.Flow, uint, error) {
+	f, err := s.repo.TakeFlow(ctx, hashState(state))
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && f.Provider != provider) {
+		return nil, 0, ErrFlowNotFound
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	return &oidc.Flow{State: state, Nonce: f.Nonce, Verifier: f.Verifier}, f.LinkUserID, nil
+}
+
+// Login 用已校验的外部账号登录。外部账号已关联时登录关联的用户；未关联且 linkUserID 不为 0 时关联到该用户；
+// 否则创建新用户，用户名取自账号信息，密码是随机的，只能通过第三方登录。
+// 不按邮箱自动关联已有用户，避免提供方没有验证邮箱时账号被冒用
+func (s *IdentityService) Login(ctx context.Context, id *oidc.Identity, linkUserID uint) (*IdentityLogin, error) {
+	result := &IdentityLogin{}
+	err := s.tx.Transaction(ctx, func(ctx context.Context, uow *repository.UnitOfWork) error {
+		identity, err := uow.Identities.Get(ctx, id.Provider, id.Subject)
+		if err == nil {
+			if linkUserID != 0 && identity.UserID != linkUserID {
+				return ErrIdentityLinked
+			}
+			result.User, err = uow.Users.GetByID(ctx, identity.UserID)
+			if err != nil {
+				return err
+			}
+			return checkBanned(result.User)
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		if linkUserID != 0 {
+			if result.User, err = uow.Users.GetByID(ctx, linkUserID); err != nil {
+				return err
+			}
+			if err := checkBanned(result.User); err != nil {
+				return err
+			}
+			result.Linked = true
+		} else {
+			if result.User, err = s.createUser(ctx, uow, id); err != nil {
+				return err
+			}
+			result.Created = true
+		}
+		identity = &entity.Identity{UserID: result.User.ID, Provider: id.Provider, Subject: id.Subject, Email: id.Email}
+		if err := uow.Identities.Create(ctx, identity); err != nil {
+			return err
+		}
+		return s.audit.RecordTx(ctx, uow, AuditEntry{
+			Action:     audit.ActionIdentityLink,
+			TargetType: audit.TargetUser,
+			TargetID:   result.User.ID,
+			ActorID:    result.User.ID,
+			After:      map[string]interface{}{"provider": id.Provider, "subject": id.Subject},
+		})
+	})
+	if errors.Is(err, ErrUserBanned) {
+		s.users.loginFailed(ctx, result.User.ID, result.User.UserName, "用户已被封禁")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Token, err = s.users.IssueToken(result.User); err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, AuditEntry{
+		Action:     audit.ActionLogin,
+		TargetType: audit.TargetUser,
+		TargetID:   result.User.ID,
+		ActorID:    result.User.ID,
+		Detail:     result.User.UserName + " (" + id.Provider + ")",
+	})
+	return result, nil
+}
+
+// createUser 为外部账号创建用户，提供方验证过的邮箱才会写入
+func (s *IdentityService) createUser(ctx context.Context, uow *repository.UnitOfWork, id *oidc.Identity) (*entity.User, error) {
+	username, err := uniqueUsername(ctx, uow.Users, usernameBase(id))
+	if err != nil {
+		return nil, err
+	}
+	user := &entity.User{UserName: username}
+	if id.EmailVerified {
+		user.Email = id.Email
+	}
+	if err := user.SetUnusablePassword(); err != nil {
+		return nil, err
+	}
+	if err := uow.Users.Create(ctx, user); err != nil {
+		return nil, err
+	}
+	if err := s.audit.RecordTx(ctx, uow, AuditEntry{
+		Action:     audit.ActionRegister,
+		TargetType: audit.TargetUser,
+		TargetID:   user.ID,
+		ActorID:    user.ID,
+		After:      map[string]interface{}{"username": user.UserName, "role": user.Role, "provider": id.Provider},
+	}); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func checkBanned(user *entity.User) error {
+	if user.Banned {
+		return ErrUserBanned
+	}
+	return nil
+}
+
+// maxUsernameBase 是由账号信息生成的用户名的最大长度，后面还可能加上 6 位数字，不超过注册接口的 26 位
+const maxUsernameBase = 20
+
+// usernameBase 依次尝试 preferred_username、邮箱 @ 之前的部分和姓名，只保留 ASCII 字母和数字，
+// 取第一个不少于 3 位的结果，都不满足时使用 user
+func usernameBase(id *oidc.Identity) string {
+	local, _, _ := strings.Cut(id.Email, "@")
+	for _, s := range []string{id.PreferredUsername, local, id.Name} {
+		name := strings.Map(func(r rune) rune {
+			if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+				return r
+			}
+			return -1
+		}, s)
+		if len(name) > maxUsernameBase {
+			name = name[:maxUsernameBase]
+		}
+		if len(name) >= 3 {
+			return name
+		}
+	}
+	return "user"
+}
+
+// uniqueUsername 返回未被使用的用户名，base 已被使用时在后面加上随机的 6 位数字
+func uniqueUsername(ctx context.Context, users *repository.UserRepo, base string) (string, error) {
+	name := base
+	for i := 0; i < 5; i++ {
+		_, err := users.GetByUsername(ctx, name)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return name, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		name = fmt.Sprintf("%s%06d", base, rand.IntN(1000000))
+	}
+	return "", fmt.Errorf("无法为 %s 生成未使用的用户名", base)
+}
+
+func hashState(state string) string {
+	sum := sha256.Sum256([]byte(state))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/miffyG/golearn/task4/internal/audit"
+	"github.com/miffyG/golearn/task4/internal/models/entity"
+	"github.com/miffyG/golearn/task4/internal/moderation"
+	"github.com/miffyG/golearn/task4/internal/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrContentRejected 表示内容被过滤器拒绝发布
+	ErrContentRejected = errors.New("content rejected")
+	// ErrReportClosed 表示举报已经被处理过，包括与其他审核员同时处理而后提交的一方
+	ErrReportClosed = repository.ErrReportClosed
+	// ErrBanForbidden 表示内容的作者是管理员、审核员或处理举报的审核员本人，不能被封禁
+	ErrBanForbidden = errors.New("ban forbidden")
+)
+
+type ModerationService struct {
+	repo   *repository.ModerationRepository
+	tx     *repository.TxManager
+	filter moderation.ContentFilter
+	audit  *AuditService
+}
+
+// NewModerationService 创建审核服务，filter 为 nil 时所有内容直接发布，audit 为 nil 时不记录审计日志。
+// 处理举报的结果与审计日志在 tx 开启的同一个事务中写入
+func NewModerationService(repo *repository.ModerationRepository, tx *repository.TxManager, filter moderation.ContentFilter, audit *AuditService) *ModerationService {
+	return &ModerationService{repo: repo, tx: tx, filter: filter, audit: audit}
+}
+
+// screen 在发布前检查内容，s 为 nil 时直接放行。被拒绝时返回包装了 ErrContentRejected 的错误，
+// 需要人工审核时返回 true 和拦截原因
+func (s *ModerationService) screen(c *moderation.Content) (hold bool, reason string, err error) {
+	if s == nil || s.filter == nil {
+		return false, "", nil
+	}
+	d := s.filter.Check(c)
+	switch d.Action {
+	case moderation.Reject:
+		return false, "", fmt.Errorf("%w: %s", ErrContentRejected, d.Reason)
+	case moderation.Hold:
+		return true, d.Reason, nil
+	}
+	return false, "", nil
+}
+
+// hold 为被过滤器拦截的内容创建一条系统举报，放入审核队列。repo 为绑定到创建内容的事务的 repository，
+// 内容和举报一起提交或回滚，不会出现没有举报、永远不会被审核的待审核内容
+func (s *ModerationService) hold(ctx context.Context, repo *repository.ModerationRepository, targetType string, targetId uint, reason string) error {
+	return repo.CreateReport(ctx, &entity.Report{
+		TargetType: targetType,
+		TargetID:   targetId,
+		Reason:     reason,
+		Status:     entity.ReportOpen,
+	})
+}
+
+// canModerate 判断用户是否为审核员或管理员，s 为 nil 时返回 false
+func (s *ModerationService) canModerate(ctx context.Context, userId uint) (bool, error) {
+	if s == nil || userId == 0 {
+		return false, nil
+	}
+	user, err := s.repo.GetUser(ctx, userId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return !user.Banned && (user.Role == entity.RoleModerator || user.Role == entity.RoleAdmin), nil
+}
+
+// Report 举报一条已发布的文章或评论，同一用户对同一内容重复举报时返回之前尚未处理的举报
+func (s *ModerationService) Report(ctx context.Context, reporterId uint, targetType string, targetId uint, reason string) (*entity.Report, error) {
+	target, err := s.repo.GetTarget(ctx, targetType, targetId)
+	if err != nil {
+		return nil, err
+	}
+	if target.Status != entity.StatusPublished {
+		return nil, gorm.ErrRecordNotFound
+	}
+	existing, err := s.repo.FindOpenReport(ctx, reporterId, targetType, targetId)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return existing, nil
+	}
+	report := &entity.Report{
+		ReporterID: reporterId,
+		TargetType: targetType,
+		TargetID:   targetId,
+		Reason:     reason,
+		Status:     entity.ReportOpen,
+	}
+	if err := s.repo.CreateReport(ctx, report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// QueueItem 是审核队列中的一条举报，Target 为 nil 表示内容已被删除
+type QueueItem struct {
+	Report entity.Report
+	Target *repository.ModerationTarget
+}
+
+// Queue 分页返回未处理的举报及其针对的内容，page 从 1 开始
+func (s *ModerationService) Queue(ctx context.Context, page, pageSize int) ([]QueueItem, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	reports, total, err := s.repo.ListOpen(ctx, (page-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	keys := make([]repository.TargetKey, len(reports))
+	for i, r := range reports {
+		keys[i] = repository.TargetKey{Type: r.TargetType, ID: r.TargetID}
+	}
+	targets, err := s.repo.GetTargets(ctx, keys)
+	if err != nil {
+		return nil, 0, err
+	}
+	items := make([]QueueItem, len(reports))
+	for i, r := range reports {
+		items[i].Report = r
+		if t, ok := targets[keys[i]]; ok {
+			items[i].Target = &t
+		}
+	}
+	return items, total, nil
+}
+
+// Approve 保留内容：待审核的内容被发布，这条内容的全部举报以 approved 关闭
+func (s *ModerationService) Approve(ctx context.Context, moderatorId, reportId uint) (*entity.Report, error) {
+	return s.resolve(ctx, audit.ActionReportApprove, moderatorId, reportId, entity.StatusPublished, entity.ReportApproved, false)
+}
+
+// Remove 移除内容，这条内容的全部举报以 removed 关闭
+func (s *ModerationService) Remove(ctx context.Context, moderatorId, reportId uint) (*entity.Report, error) {
+	return s.resolve(ctx, audit.ActionReportRemove, moderatorId, reportId, entity.StatusRemoved, entity.ReportRemoved, false)
+}
+
+// BanAuthor 移除内容并封禁内容的作者。作者是管理员、审核员或审核员本人时返回 ErrBanForbidden，内容和举报都保持不变
+func (s *ModerationService) BanAuthor(ctx context.Context, moderatorId, reportId uint) (*entity.Report, error) {
+	return s.resolve(ctx, audit.ActionReportBanOwner, moderatorId, reportId, entity.StatusRemoved, entity.ReportRemoved, true)
+}
+
+func (s *ModerationService) resolve(ctx context.Context, action string, moderatorId, reportId uint, contentStatus, reportStatus string, ban bool) (*entity.Report, error) {
+	report, err := s.repo.GetReport(ctx, reportId)
+	if err != nil {
+		return nil, err
+	}
+	if report.Status != entity.ReportOpen {
+		return report, ErrReportClosed
+	}
+	target, err := s.repo.GetTarget(ctx, report.TargetType, report.TargetID)
+	if err != nil {
+		return nil, err
+	}
+	var banUserId uint
+	if ban {
+		if err := s.checkBan(ctx, moderatorId, target.AuthorID); err != nil {
+			return nil, err
+		}
+		banUserId = target.AuthorID
+	}
+	key := repository.TargetKey{Type: report.TargetType, ID: report.TargetID}
+	after := map[string]interface{}{"status": contentStatus}
+	if ban {
+		after["banned_user_id"] = banUserId
+	}
+	err = s.tx.Transaction(ctx, func(ctx context.Context, uow *repository.UnitOfWork) error {
+		if err := uow.Moderation.Resolve(ctx, report.ID, key, contentStatus, reportStatus, moderatorId, banUserId); err != nil {
+			return err
+		}
+		return s.audit.RecordTx(ctx, uow, AuditEntry{
+			Action:     action,
+			TargetType: report.TargetType,
+			TargetID:   report.TargetID,
+			ActorID:    moderatorId,
+			Before:     map[string]interface{}{"status": target.Status, "author_id": target.AuthorID},
+			After:      after,
+			Detail:     fmt.Sprintf("report %d: %s", report.ID, truncate(report.Reason, 200)),
+		})
+	})
+	if errors.Is(err, ErrReportClosed) {
+		// 检查之后被其他审核员关闭，返回当前的处理结果
+		if closed, getErr := s.repo.GetReport(ctx, reportId); getErr == nil {
+			report = closed
+		}
+		return report, err
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetReport(ctx, reportId)
+}
+
+// checkBan 检查审核员能否封禁作者：不能封禁自己，也不能封禁管理员和其他审核员
+func (s *ModerationService) checkBan(ctx context.Context, moderatorId, authorId uint) error {
+	if authorId == moderatorId {
+		return ErrBanForbidden
+	}
+	author, err := s.repo.GetUser(ctx, authorId)
+	if err != nil {
+		return err
+	}
+	if author.Role == entity.RoleAdmin || author.Role == entity.RoleModerator {
+		return ErrBanForbidden
+	}
+	return nil
+}
@@ -1,52 +1,479 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"strings"
+	"time"
 
+	"github.com/miffyG/golearn/task4/internal/audit"
 	"github.com/miffyG/golearn/task4/internal/models/entity"
+	"github.com/miffyG/golearn/task4/internal/moderation"
 	"github.com/miffyG/golearn/task4/internal/repository"
+	"github.com/miffyG/golearn/task4/internal/utils"
+	"gorm.io/gorm"
 )
 
+// ErrPreconditionFailed 表示客户端通过 If-Match 指定的版本与文章当前版本不一致
+var ErrPreconditionFailed = errors.New("precondition failed")
+
 type PostService struct {
-	repo *repository.PostRepository
+	repo       *repository.PostRepository
+	tx         *repository.TxManager
+	moderation *ModerationService
+	audit      *AuditService
+}
+
+// NewPostService 创建文章服务，moderation 为 nil 时发布文章不经过内容过滤，audit 为 nil 时不记录审计日志
+func NewPostService(repo *repository.PostRepository, tx *repository.TxManager, moderation *ModerationService, audit *AuditService) *PostService {
+	return &PostService{repo: repo, tx: tx, moderation: moderation, audit: audit}
+}
+
+func postAuditEntry(action string, postId uint, before, after *entity.Post) AuditEntry {
+	e := AuditEntry{Action: action, TargetType: audit.TargetPost, TargetID: postId}
+	if before != nil {
+		e.Before = postSummary(before)
+	}
+	if after != nil {
+		e.After = postSummary(after)
+	}
+	return e
 }
 
-func NewPostService(repo *repository.PostRepository) *PostService {
-	return &PostService{repo: repo}
+// Create 创建文章。发布前经过内容过滤器检查，被拒绝时返回 ErrContentRejected；
+// 需要人工审核时文章以 pending 状态保存并进入审核队列，审核通过前不对外展示。文章和审核队列中的举报在同一个事务中写入
+func (s *PostService) Create(ctx context.Context, post *entity.Post) error {
+	hold, reason, err := s.moderation.screen(&moderation.Content{
+		Kind:     entity.ReportTargetPost,
+		AuthorID: post.UserID,
+		Title:    post.Title,
+		Body:     post.Content,
+	})
+	if err != nil {
+		return err
+	}
+	post.Status = entity.StatusPublished
+	if hold {
+		post.Status = entity.StatusPending
+	}
+	return s.tx.Transaction(ctx, func(ctx context.Context, uow *repository.UnitOfWork) error {
+		if err := uow.Posts.Create(ctx, post); err != nil {
+			return err
+		}
+		if hold {
+			return s.moderation.hold(ctx, uow.Moderation, entity.ReportTargetPost, post.ID, reason)
+		}
+		return nil
+	})
 }
 
-func (s *PostService) Create(post *entity.Post) error {
-	return s.repo.Create(post)
+func (s *PostService) GetAll(ctx context.Context) ([]entity.Post, error) {
+	return s.repo.GetAll(ctx)
 }
 
-func (s *PostService) GetAll() ([]entity.Post, error) {
-	return s.repo.GetAll()
+// List 分页查询文章，page 从 1 开始
+func (s *PostService) List(ctx context.Context, page, pageSize int) ([]entity.Post, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	return s.repo.List(ctx, (page-1)*pageSize, pageSize)
 }
 
-func (s *PostService) GetByID(id uint) (*entity.Post, error) {
-	return s.repo.GetByID(id)
+func (s *PostService) GetByIDs(ctx context.Context, ids []uint) ([]entity.Post, error) {
+	return s.repo.GetByIDs(ctx, ids)
 }
 
-func (s *PostService) Update(userId uint, post *entity.Post) error {
-	p, err := s.repo.GetByID(post.ID)
+// GetByUserIDs 返回每个用户最新的 limit 篇已发布文章
+func (s *PostService) GetByUserIDs(ctx context.Context, userIds []uint, limit int) ([]entity.Post, error) {
+	return s.repo.GetByUserIDs(ctx, userIds, limit)
+}
+
+// GetByID 返回已发布的文章，待审核和已移除的文章按不存在处理。配置了只读副本时可能从副本读取
+func (s *PostService) GetByID(ctx context.Context, id uint) (*entity.Post, error) {
+	return published(s.repo.GetDetail(ctx, id))
+}
+
+func published(p *entity.Post, err error) (*entity.Post, error) {
 	if err != nil {
+		return nil, err
+	}
+	if p.Status != entity.StatusPublished {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return p, nil
+}
+
+// Update 更新文章的标题和内容，成功后 post 中会回填最新的版本号。
+// 当前版本不满足 expected 时返回 ErrPreconditionFailed；
+// 读取之后文章被其他请求修改时返回 repository.ErrVersionConflict。
+func (s *PostService) Update(ctx context.Context, userId uint, post *entity.Post, expected utils.IfMatch) error {
+	// 读取、校验和写入在同一个事务中完成，文章行加锁后其他请求的修改需要等待本次提交，
+	// 不会在校验之后、写入之前被覆盖
+	var p *entity.Post
+	err := s.tx.Transaction(ctx, func(ctx context.Context, uow *repository.UnitOfWork) error {
+		var err error
+		p, err = uow.Posts.GetForUpdate(ctx, post.ID)
+		if err != nil {
+			return err
+		}
+		if p.UserID != userId {
+			return errors.New("unauthorized")
+		}
+		if !expected.Matches(p.Version) {
+			post.Version = p.Version
+			return ErrPreconditionFailed
+		}
+		before := *p
+		p.Title = post.Title
+		p.Content = post.Content
+		if err := uow.Posts.Update(ctx, p, userId); err != nil {
+			return err
+		}
+		return s.audit.RecordTx(ctx, uow, postAuditEntry(audit.ActionPostUpdate, p.ID, &before, p))
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrVersionConflict) {
+			if cur, getErr := s.repo.GetByID(ctx, post.ID); getErr == nil {
+				post.Version = cur.Version
+			}
+		}
 		return err
 	}
+	post.UserID = p.UserID
+	post.Version = p.Version
+	post.Slug = p.Slug
+	post.CreatedAt = p.CreatedAt
+	post.UpdatedAt = p.UpdatedAt
+	return nil
+}
+
+// PostPatch 描述对文章的部分修改，字段为 nil 表示保持不变
+type PostPatch struct {
+	Title   *string
+	Content *string
+}
+
+// Patch 只写入 patch 中给出且与当前值不同的列，没有实际变化时不写库也不产生修订记录。
+// expected 的含义与 Update 相同；返回 ErrPreconditionFailed 或 repository.ErrVersionConflict 时，
+// 返回的文章中带有当前的版本号。
+func (s *PostService) Patch(ctx context.Context, userId, postId uint, patch PostPatch, expected utils.IfMatch) (*entity.Post, error) {
+	return s.PatchWith(ctx, userId, postId, func(*entity.Post) (PostPatch, error) { return patch, nil }, expected)
+}
+
+// PatchWith 与 Patch 相同，只是修改内容由 build 根据加锁读取的当前文章计算，适用于 JSON Patch 这类依赖当前内容的补丁。
+// build 只在作者校验和版本校验通过之后调用，它返回的错误原样返回
+func (s *PostService) PatchWith(ctx context.Context, userId, postId uint, build func(cur *entity.Post) (PostPatch, error), expected utils.IfMatch) (*entity.Post, error) {
+	var p *entity.Post
+	err := s.tx.Transaction(ctx, func(ctx context.Context, uow *repository.UnitOfWork) error {
+		var err error
+		p, err = uow.Posts.GetForUpdate(ctx, postId)
+		if err != nil {
+			return err
+		}
+		if p.UserID != userId {
+			return errors.New("unauthorized")
+		}
+		if !expected.Matches(p.Version) {
+			return ErrPreconditionFailed
+		}
+		patch, err := build(p)
+		if err != nil {
+			return err
+		}
+
+		before := *p
+		var columns []string
+		if patch.Title != nil && *patch.Title != p.Title {
+			p.Title = *patch.Title
+			columns = append(columns, "title")
+		}
+		if patch.Content != nil && *patch.Content != p.Content {
+			p.Content = *patch.Content
+			columns = append(columns, "content")
+		}
+		if len(columns) == 0 {
+			return nil
+		}
+		if err := uow.Posts.Update(ctx, p, userId, columns...); err != nil {
+			return err
+		}
+		return s.audit.RecordTx(ctx, uow, postAuditEntry(audit.ActionPostUpdate, p.ID, &before, p))
+	})
+	switch {
+	case err == nil, errors.Is(err, ErrPreconditionFailed):
+		return p, err
+	case errors.Is(err, repository.ErrVersionConflict):
+		if cur, getErr := s.repo.GetByID(ctx, postId); getErr == nil {
+			p.Version = cur.Version
+		}
+		return p, err
+	default:
+		return nil, err
+	}
+}
+
+func (s *PostService) Delete(ctx context.Context, userId, postId uint) error {
+	return s.tx.Transaction(ctx, func(ctx context.Context, uow *repository.UnitOfWork) error {
+		p, err := uow.Posts.GetForUpdate(ctx, postId)
+		if err != nil {
+			return err
+		}
+		if p.UserID != userId {
+			return errors.New("unauthorized")
+		}
+		if err := uow.Posts.Delete(ctx, p); err != nil {
+			return err
+		}
+		return s.audit.RecordTx(ctx, uow, postAuditEntry(audit.ActionPostDelete, p.ID, p, nil))
+	})
+}
+
+// SetTags 替换文章的标签，只有作者可以修改。标签名去除首尾空白并去重
+func (s *PostService) SetTags(ctx context.Context, userId, postId uint, names []string) (*entity.Post, error) {
+	p, err := s.repo.GetByID(ctx, postId)
+	if err != nil {
+		return nil, err
+	}
 	if p.UserID != userId {
-		return errors.New("unauthorized")
+		return nil, errors.New("unauthorized")
+	}
+	seen := make(map[string]bool, len(names))
+	unique := make([]string, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		unique = append(unique, name)
+	}
+	if err := s.repo.ReplaceTags(ctx, p, unique); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Feed 返回订阅源使用的最新文章，authorId 为 0、tag 为空时不过滤，标签不存在时返回 gorm.ErrRecordNotFound
+func (s *PostService) Feed(ctx context.Context, authorId uint, tag string, limit int) ([]entity.Post, error) {
+	filter := repository.FeedFilter{UserID: authorId}
+	if tag != "" {
+		t, err := s.repo.GetTag(ctx, tag)
+		if err != nil {
+			return nil, err
+		}
+		filter.TagID = t.ID
 	}
-	p.Title = post.Title
-	p.Content = post.Content
-	return s.repo.Update(p)
+	return s.repo.ListForFeed(ctx, filter, limit)
 }
 
-func (s *PostService) Delete(userId, postId uint) error {
-	p, err := s.repo.GetByID(postId)
+// GetBySlug 按当前或历史 slug 查找已发布的文章
+func (s *PostService) GetBySlug(ctx context.Context, slug string) (*entity.Post, error) {
+	return published(s.repo.GetBySlug(ctx, slug))
+}
+
+// BackfillSlugs 为没有 slug 的文章生成 slug
+func (s *PostService) BackfillSlugs(ctx context.Context) (int, error) {
+	return s.repo.BackfillSlugs(ctx)
+}
+
+func (s *PostService) SitemapCount(ctx context.Context) (int64, error) {
+	return s.repo.CountForSitemap(ctx)
+}
+
+// SitemapPage 返回站点地图第 page 页（从 1 开始）的文章
+func (s *PostService) SitemapPage(ctx context.Context, page, pageSize int) ([]repository.SitemapEntry, error) {
+	return s.repo.ListForSitemap(ctx, (page-1)*pageSize, pageSize)
+}
+
+// Search 供管理端使用，按过滤条件分页查询文章，可以查询已删除的文章
+func (s *PostService) Search(ctx context.Context, filter repository.PostFilter, page, pageSize int) ([]entity.Post, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	return s.repo.Search(ctx, filter, (page-1)*pageSize, pageSize)
+}
+
+// Remove 供管理端使用，不校验作者直接删除文章
+func (s *PostService) Remove(ctx context.Context, postId uint) error {
+	return s.tx.Transaction(ctx, func(ctx context.Context, uow *repository.UnitOfWork) error {
+		p, err := uow.Posts.GetForUpdate(ctx, postId)
+		if err != nil {
+			return err
+		}
+		if err := uow.Posts.Delete(ctx, p); err != nil {
+			return err
+		}
+		return s.audit.RecordTx(ctx, uow, postAuditEntry(audit.ActionPostDelete, p.ID, p, nil))
+	})
+}
+
+// Restore 供管理端使用，恢复已删除的文章
+func (s *PostService) Restore(ctx context.Context, postId uint) error {
+	return s.tx.Transaction(ctx, func(ctx context.Context, uow *repository.UnitOfWork) error {
+		if err := uow.Posts.Restore(ctx, postId); err != nil {
+			return err
+		}
+		return s.audit.RecordTx(ctx, uow, postAuditEntry(audit.ActionPostRestore, postId, nil, nil))
+	})
+}
+
+// Trash 分页返回用户已删除、尚未被彻底清理的文章
+func (s *PostService) Trash(ctx context.Context, userId uint, page, pageSize int) ([]entity.Post, int64, error) {
+	return s.Search(ctx, repository.PostFilter{UserID: userId, Deleted: true}, page, pageSize)
+}
+
+// RestoreByAuthor 恢复自己删除的文章，文章不在回收站中时返回 gorm.ErrRecordNotFound
+func (s *PostService) RestoreByAuthor(ctx context.Context, userId, postId uint) (*entity.Post, error) {
+	err := s.tx.Transaction(ctx, func(ctx context.Context, uow *repository.UnitOfWork) error {
+		p, err := uow.Posts.GetDeleted(ctx, postId)
+		if err != nil {
+			return err
+		}
+		if p.UserID != userId {
+			return errors.New("unauthorized")
+		}
+		if err := uow.Posts.Restore(ctx, postId); err != nil {
+			return err
+		}
+		return s.audit.RecordTx(ctx, uow, postAuditEntry(audit.ActionPostRestore, postId, nil, p))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, postId)
+}
+
+// purgeBatchSize 是清理时每个事务最多删除的文章数
+const purgeBatchSize = 500
+
+// Purge 彻底删除删除时间超过 retention 的文章和评论。每批文章和它的审计日志在同一个事务中提交，
+// 出错时已提交的批次保留，返回其中删除的数量
+func (s *PostService) Purge(ctx context.Context, retention time.Duration) (repository.PurgeResult, error) {
+	var total repository.PurgeResult
+	before := time.Now().Add(-retention)
+	entry := func(res repository.PurgeResult) AuditEntry {
+		return AuditEntry{Action: audit.ActionPostPurge, After: res, Detail: "retention " + retention.String()}
+	}
+	for {
+		var res repository.PurgeResult
+		err := s.tx.Transaction(ctx, func(ctx context.Context, uow *repository.UnitOfWork) error {
+			var err error
+			if res, err = uow.Posts.PurgeBatch(ctx, before, purgeBatchSize); err != nil || res.Posts == 0 {
+				return err
+			}
+			return s.audit.RecordTx(ctx, uow, entry(res))
+		})
+		if err != nil {
+			return total, err
+		}
+		if res.Posts == 0 {
+			break
+		}
+		total.Posts += res.Posts
+		total.Comments += res.Comments
+	}
+
+	err := s.tx.Transaction(ctx, func(ctx context.Context, uow *repository.UnitOfWork) error {
+		n, err := uow.Posts.PurgeComments(ctx, before)
+		if err != nil || n == 0 {
+			return err
+		}
+		if err := s.audit.RecordTx(ctx, uow, entry(repository.PurgeResult{Comments: n})); err != nil {
+			return err
+		}
+		total.Comments += n
+		return nil
+	})
+	return total, err
+}
+
+// visible 检查 viewerId 能否查看文章：已发布的文章对所有人可见，待审核和已移除的文章只对作者、审核员和管理员可见，
+// 其他人按不存在处理。viewerId 为 0 表示匿名访问
+func (s *PostService) visible(ctx context.Context, postId, viewerId uint) error {
+	p, err := s.repo.GetByID(ctx, postId)
 	if err != nil {
 		return err
 	}
-	if p.UserID != userId {
-		return errors.New("unauthorized")
+	if p.Status == entity.StatusPublished || (viewerId != 0 && p.UserID == viewerId) {
+		return nil
+	}
+	ok, err := s.moderation.canModerate(ctx, viewerId)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetRevisions 返回文章的修订记录，可见性与 visible 相同
+func (s *PostService) GetRevisions(ctx context.Context, viewerId, postId uint) ([]entity.PostRevision, error) {
+	if err := s.visible(ctx, postId, viewerId); err != nil {
+		return nil, err
+	}
+	return s.repo.GetRevisions(ctx, postId)
+}
+
+func (s *PostService) GetRevision(ctx context.Context, viewerId, postId, rev uint) (*entity.PostRevision, error) {
+	if err := s.visible(ctx, postId, viewerId); err != nil {
+		return nil, err
+	}
+	return s.repo.GetRevision(ctx, postId, rev)
+}
+
+// DiffRevisions 返回文章从版本 base 到版本 rev 的逐行 unified diff，标题作为第一行参与比较
+func (s *PostService) DiffRevisions(ctx context.Context, viewerId, postId, base, rev uint) (string, error) {
+	if err := s.visible(ctx, postId, viewerId); err != nil {
+		return "", err
+	}
+	from, err := s.repo.GetRevision(ctx, postId, base)
+	if err != nil {
+		return "", err
+	}
+	to, err := s.repo.GetRevision(ctx, postId, rev)
+	if err != nil {
+		return "", err
+	}
+	return utils.UnifiedDiff(
+		fmt.Sprintf("post/%d@%d", postId, base),
+		fmt.Sprintf("post/%d@%d", postId, rev),
+		from.Title+"\n\n"+from.Content,
+		to.Title+"\n\n"+to.Content,
+		3,
+	)
+}
+
+// RestoreRevision 将文章内容恢复为指定版本，恢复操作本身也会产生一条新的修订记录，只有作者可以恢复。
+// 与 Update 一样在事务中锁定文章行后读取、写入并记录审计日志
+func (s *PostService) RestoreRevision(ctx context.Context, userId, postId, rev uint) (*entity.Post, error) {
+	var p *entity.Post
+	err := s.tx.Transaction(ctx, func(ctx context.Context, uow *repository.UnitOfWork) error {
+		var err error
+		p, err = uow.Posts.GetForUpdate(ctx, postId)
+		if err != nil {
+			return err
+		}
+		if p.UserID != userId {
+			return errors.New("unauthorized")
+		}
+		revision, err := uow.Posts.GetRevision(ctx, postId, rev)
+		if err != nil {
+			return err
+		}
+		before := *p
+		p.Title = revision.Title
+		p.Content = revision.Content
+		if err := uow.Posts.Update(ctx, p, userId); err != nil {
+			return err
+		}
+		e := postAuditEntry(audit.ActionPostUpdate, p.ID, &before, p)
+		e.Detail = fmt.Sprintf("restore revision %d", rev)
+		return s.audit.RecordTx(ctx, uow, e)
+	})
+	if err != nil {
+		return nil, err
 	}
-	return s.repo.Delete(p)
+	return p, nil
 }
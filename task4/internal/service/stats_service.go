@@ -0,0 +1,19 @@
+package service
+
+import (
+	"context"
+
+	"github.com/miffyG/golearn/task4/internal/repository"
+)
+
+type StatsService struct {
+	repo *repository.StatsRepository
+}
+
+func NewStatsService(repo *repository.StatsRepository) *StatsService {
+	return &StatsService{repo: repo}
+}
+
+func (s *StatsService) Get(ctx context.Context) (*repository.Stats, error) {
+	return s.repo.Get(ctx)
+}
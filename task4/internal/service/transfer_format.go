@@ -0,0 +1,313 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/miffyG/golearn/task4/internal/models/dto"
+)
+
+// transferWriter 按格式写出导出数据，记录必须按 meta、user、post、comment 的顺序写入
+type transferWriter interface {
+	meta(m dto.TransferMeta) error
+	write(typ string, record interface{}) error
+	close() error
+}
+
+func newTransferWriter(w io.Writer, format string) (transferWriter, error) {
+	switch format {
+	case dto.TransferFormatNDJSON, "":
+		return &ndjsonWriter{enc: json.NewEncoder(w)}, nil
+	case dto.TransferFormatJSON:
+		return &archiveWriter{w: w}, nil
+	}
+	return nil, fmt.Errorf("不支持的格式 %q", format)
+}
+
+type ndjsonWriter struct {
+	enc *json.Encoder
+}
+
+func (nw *ndjsonWriter) meta(m dto.TransferMeta) error {
+	return nw.write(dto.TransferTypeMeta, m)
+}
+
+func (nw *ndjsonWriter) write(typ string, record interface{}) error {
+	return nw.enc.Encode(dto.TransferRecord[interface{}]{Type: typ, Data: record})
+}
+
+func (nw *ndjsonWriter) close() error { return nil }
+
+// archiveWriter 边写边输出单个 JSON 文档：{"version":1,"exported_at":...,"users":[...],"posts":[...],"comments":[...]}
+type archiveWriter struct {
+	w io.Writer
+	// section 是当前正在写入的字段在 archiveSections 中的下标，-1 表示尚未开始
+	section int
+	count   int
+	err     error
+}
+
+// archiveSections 是记录类型在 JSON 文档中对应的字段，按写入顺序排列
+var archiveSections = []struct{ typ, key string }{
+	{dto.TransferTypeUser, "users"},
+	{dto.TransferTypePost, "posts"},
+	{dto.TransferTypeComment, "comments"},
+}
+
+func (aw *archiveWriter) print(s string) {
+	if aw.err == nil {
+		_, aw.err = io.WriteString(aw.w, s)
+	}
+}
+
+func (aw *archiveWriter) meta(m dto.TransferMeta) error {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	// 去掉结尾的 }，后面继续追加各类记录
+	aw.print(string(b[:len(b)-1]))
+	aw.section = -1
+	return aw.err
+}
+
+// advance 依次关闭当前字段、打开后续字段，直到下标为 target 的字段，没有记录的字段输出为空数组
+func (aw *archiveWriter) advance(target int) {
+	for aw.section < target {
+		if aw.section >= 0 {
+			aw.print("]")
+		}
+		aw.section++
+		aw.count = 0
+		aw.print(fmt.Sprintf(",%q:[", archiveSections[aw.section].key))
+	}
+}
+
+func (aw *archiveWriter) write(typ string, record interface{}) error {
+	target := -1
+	for i, s := range archiveSections {
+		if s.typ == typ {
+			target = i
+		}
+	}
+	if target < aw.section || target < 0 {
+		return fmt.Errorf("记录类型 %q 的顺序错误", typ)
+	}
+	aw.advance(target)
+
+	b, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if aw.count > 0 {
+		aw.print(",")
+	}
+	aw.print(string(b))
+	aw.count++
+	return aw.err
+}
+
+func (aw *archiveWriter) close() error {
+	aw.advance(len(archiveSections) - 1)
+	aw.print("]}\n")
+	return aw.err
+}
+
+// transferReader 按格式逐条读取导入数据，读完时返回 io.EOF
+type transferReader interface {
+	next() (typ string, record interface{}, err error)
+}
+
+func newTransferReader(r io.Reader, format string) (transferReader, error) {
+	switch format {
+	case dto.TransferFormatNDJSON, "":
+		src := &errReader{r: r}
+		return &ndjsonReader{scanner: newLineScanner(src), src: src}, nil
+	case dto.TransferFormatJSON:
+		return &archiveReader{dec: json.NewDecoder(r)}, nil
+	}
+	return nil, fmt.Errorf("不支持的格式 %q", format)
+}
+
+// newRecord 返回记录类型对应的空结构，用于解码
+func newRecord(typ string) (interface{}, error) {
+	switch typ {
+	case dto.TransferTypeUser:
+		return &dto.TransferUser{}, nil
+	case dto.TransferTypePost:
+		return &dto.TransferPost{}, nil
+	case dto.TransferTypeComment:
+		return &dto.TransferComment{}, nil
+	}
+	return nil, fmt.Errorf("%w: 未知的记录类型 %q", ErrInvalidTransferData, typ)
+}
+
+func checkVersion(m dto.TransferMeta) error {
+	if m.Version != dto.TransferVersion {
+		return fmt.Errorf("%w: 不支持的数据版本 %d", ErrInvalidTransferData, m.Version)
+	}
+	return nil
+}
+
+func newLineScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	// 单条记录（文章内容）可能较长
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	return scanner
+}
+
+// errReader 记录读取 r 时遇到的错误
+type errReader struct {
+	r   io.Reader
+	err error
+}
+
+func (er *errReader) Read(p []byte) (int, error) {
+	n, err := er.r.Read(p)
+	if err != nil && err != io.EOF {
+		er.err = err
+	}
+	return n, err
+}
+
+type ndjsonReader struct {
+	scanner *bufio.Scanner
+	src     *errReader
+	line    int
+}
+
+// invalid 返回第 line 行格式错误。读取出错时 bufio.Scanner 仍会把已读到的半行作为最后一行返回，
+// 这时报告读取错误，如请求体超过大小限制
+func (nr *ndjsonReader) invalid(err error) error {
+	if nr.src.err != nil {
+		return nr.src.err
+	}
+	return fmt.Errorf("%w: 第 %d 行: %v", ErrInvalidTransferData, nr.line, err)
+}
+
+func (nr *ndjsonReader) next() (string, interface{}, error) {
+	for nr.scanner.Scan() {
+		nr.line++
+		line := bytes.TrimSpace(nr.scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var raw dto.TransferRecord[json.RawMessage]
+		if err := json.Unmarshal(line, &raw); err != nil {
+			return "", nil, nr.invalid(err)
+		}
+		if raw.Type == dto.TransferTypeMeta {
+			var m dto.TransferMeta
+			if err := json.Unmarshal(raw.Data, &m); err != nil {
+				return "", nil, nr.invalid(err)
+			}
+			if err := checkVersion(m); err != nil {
+				return "", nil, err
+			}
+			continue
+		}
+		record, err := newRecord(raw.Type)
+		if err != nil {
+			return "", nil, fmt.Errorf("第 %d 行: %w", nr.line, err)
+		}
+		if err := json.Unmarshal(raw.Data, record); err != nil {
+			return "", nil, nr.invalid(err)
+		}
+		return raw.Type, record, nil
+	}
+	if err := nr.scanner.Err(); err != nil {
+		return "", nil, err
+	}
+	return "", nil, io.EOF
+}
+
+// archiveReader 用 json.Decoder 逐个读取 JSON 文档中数组的元素，不会把整个文档读入内存
+type archiveReader struct {
+	dec     *json.Decoder
+	started bool
+	// typ 为当前正在读取的数组对应的记录类型，为空表示不在数组中
+	typ  string
+	done bool
+}
+
+func (ar *archiveReader) next() (string, interface{}, error) {
+	if ar.done {
+		return "", nil, io.EOF
+	}
+	if !ar.started {
+		if err := ar.expectDelim('{'); err != nil {
+			return "", nil, err
+		}
+		ar.started = true
+	}
+	for {
+		if ar.typ != "" {
+			if ar.dec.More() {
+				record, err := newRecord(ar.typ)
+				if err != nil {
+					return "", nil, err
+				}
+				if err := ar.dec.Decode(record); err != nil {
+					return "", nil, fmt.Errorf("%w: %w", ErrInvalidTransferData, err)
+				}
+				return ar.typ, record, nil
+			}
+			if err := ar.expectDelim(']'); err != nil {
+				return "", nil, err
+			}
+			ar.typ = ""
+		}
+
+		if !ar.dec.More() {
+			if err := ar.expectDelim('}'); err != nil {
+				return "", nil, err
+			}
+			ar.done = true
+			return "", nil, io.EOF
+		}
+		tok, err := ar.dec.Token()
+		if err != nil {
+			return "", nil, fmt.Errorf("%w: %w", ErrInvalidTransferData, err)
+		}
+		key, _ := tok.(string)
+		switch key {
+		case "version":
+			var version int
+			if err := ar.dec.Decode(&version); err != nil {
+				return "", nil, fmt.Errorf("%w: %w", ErrInvalidTransferData, err)
+			}
+			if err := checkVersion(dto.TransferMeta{Version: version}); err != nil {
+				return "", nil, err
+			}
+		case "users", "posts", "comments":
+			if err := ar.expectDelim('['); err != nil {
+				return "", nil, err
+			}
+			for _, s := range archiveSections {
+				if s.key == key {
+					ar.typ = s.typ
+				}
+			}
+		default:
+			// 忽略其他字段
+			var skip json.RawMessage
+			if err := ar.dec.Decode(&skip); err != nil {
+				return "", nil, fmt.Errorf("%w: %w", ErrInvalidTransferData, err)
+			}
+		}
+	}
+}
+
+func (ar *archiveReader) expectDelim(d json.Delim) error {
+	tok, err := ar.dec.Token()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidTransferData, err)
+	}
+	if tok != d {
+		return fmt.Errorf("%w: 期望 %v，实际为 %v", ErrInvalidTransferData, d, tok)
+	}
+	return nil
+}
@@ -0,0 +1,352 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/miffyG/golearn/task4/internal/models/dto"
+	"github.com/miffyG/golearn/task4/internal/models/entity"
+	"github.com/miffyG/golearn/task4/internal/repository"
+	"gorm.io/gorm"
+)
+
+const transferBatchSize = 500
+
+// ErrInvalidTransferData 表示导入的数据格式错误或引用了不存在的记录
+var ErrInvalidTransferData = errors.New("invalid transfer data")
+
+// errDryRun 用于在试运行结束时回滚事务
+var errDryRun = errors.New("dry run")
+
+type ExportOptions struct {
+	// Format 为 dto.TransferFormatNDJSON 或 dto.TransferFormatJSON
+	Format string
+	// IncludePasswords 为 true 时导出用户的密码哈希
+	IncludePasswords bool
+}
+
+type ImportOptions struct {
+	Format string
+	// DryRun 为 true 时完整执行导入后回滚，只返回统计结果
+	DryRun bool
+	// Progress 不为空时每处理 ProgressEvery 条记录以及导入结束时调用一次
+	Progress      func(processed int)
+	ProgressEvery int
+}
+
+// TransferService 负责在不同环境间导出、导入用户、文章和评论。
+// 导入在一个事务中完成：用户按用户名、文章按作者+标题+创建时间、评论按文章+作者+创建时间匹配，
+// 匹配到的记录被更新，否则新建；评论引用的文章 ID 会映射为目标库中的 ID。
+type TransferService struct {
+	repo *repository.TransferRepository
+}
+
+func NewTransferService(repo *repository.TransferRepository) *TransferService {
+	return &TransferService{repo: repo}
+}
+
+// Export 把未删除的用户、文章和评论按顺序流式写入 w
+func (s *TransferService) Export(ctx context.Context, w io.Writer, opts ExportOptions) error {
+	ew, err := newTransferWriter(w, opts.Format)
+	if err != nil {
+		return err
+	}
+	if err := ew.meta(dto.TransferMeta{Version: dto.TransferVersion, ExportedAt: time.Now().UTC()}); err != nil {
+		return err
+	}
+
+	if err := s.repo.EachUser(ctx, transferBatchSize, func(users []entity.User) error {
+		for _, u := range users {
+			record := dto.TransferUser{
+				ID:        u.ID,
+				Username:  u.UserName,
+				Email:     u.Email,
+				Phone:     u.Phone,
+				Role:      u.Role,
+				Banned:    u.Banned,
+				Deleted:   u.DeletedAt.Valid,
+				CreatedAt: u.CreatedAt,
+			}
+			if opts.IncludePasswords {
+				record.PasswordHash = u.Password
+			}
+			if err := ew.write(dto.TransferTypeUser, record); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	if err := s.repo.EachPost(ctx, transferBatchSize, func(rows []repository.PostRow) error {
+		for _, p := range rows {
+			if err := ew.write(dto.TransferTypePost, dto.TransferPost{
+				ID:        p.ID,
+				Author:    p.Author,
+				Title:     p.Title,
+				Content:   p.Content,
+				Status:    p.Status,
+				CreatedAt: p.CreatedAt,
+				UpdatedAt: p.UpdatedAt,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	if err := s.repo.EachComment(ctx, transferBatchSize, func(rows []repository.CommentRow) error {
+		for _, c := range rows {
+			if err := ew.write(dto.TransferTypeComment, dto.TransferComment{
+				ID:        c.ID,
+				PostID:    c.PostID,
+				Author:    c.Author,
+				Content:   c.Content,
+				Status:    c.Status,
+				CreatedAt: c.CreatedAt,
+				UpdatedAt: c.UpdatedAt,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	return ew.close()
+}
+
+// Import 从 r 流式读取导出的数据并写入数据库，任何一条记录出错都会回滚整个导入
+func (s *TransferService) Import(ctx context.Context, r io.Reader, opts ImportOptions) (*dto.ImportResult, error) {
+	tr, err := newTransferReader(r, opts.Format)
+	if err != nil {
+		return nil, err
+	}
+	if opts.ProgressEvery <= 0 {
+		opts.ProgressEvery = transferBatchSize
+	}
+
+	result := &dto.ImportResult{DryRun: opts.DryRun}
+	err = s.repo.Transaction(ctx, func(tx *repository.TransferRepository) error {
+		imp := &importer{
+			ctx:     ctx,
+			repo:    tx,
+			result:  result,
+			userIDs: make(map[string]uint),
+			postIDs: make(map[uint]uint),
+		}
+		processed := 0
+		for {
+			typ, data, err := tr.next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			if err := imp.apply(typ, data); err != nil {
+				return err
+			}
+			processed++
+			if opts.Progress != nil && processed%opts.ProgressEvery == 0 {
+				opts.Progress(processed)
+			}
+		}
+		if opts.Progress != nil {
+			opts.Progress(processed)
+		}
+		if opts.DryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		return nil, err
+	}
+	return result, nil
+}
+
+type importer struct {
+	ctx    context.Context
+	repo   *repository.TransferRepository
+	result *dto.ImportResult
+	// userIDs 缓存用户名到目标库用户 ID 的映射，postIDs 是源库文章 ID 到目标库文章 ID 的映射
+	userIDs map[string]uint
+	postIDs map[uint]uint
+}
+
+func (imp *importer) apply(typ string, data interface{}) error {
+	switch v := data.(type) {
+	case *dto.TransferUser:
+		return imp.user(v)
+	case *dto.TransferPost:
+		return imp.post(v)
+	case *dto.TransferComment:
+		return imp.comment(v)
+	}
+	return fmt.Errorf("%w: 未知的记录类型 %q", ErrInvalidTransferData, typ)
+}
+
+func (imp *importer) user(u *dto.TransferUser) error {
+	if u.Username == "" {
+		return fmt.Errorf("%w: 用户 %d 缺少用户名", ErrInvalidTransferData, u.ID)
+	}
+	role := u.Role
+	if role == "" {
+		role = entity.RoleUser
+	}
+	if !entity.ValidRole(role) {
+		return fmt.Errorf("%w: 用户 %s 的角色 %q 无效", ErrInvalidTransferData, u.Username, role)
+	}
+
+	existing, err := imp.repo.FindUser(imp.ctx, u.Username)
+	if err != nil {
+		return err
+	}
+	user := entity.User{
+		UserName: u.Username,
+		Password: u.PasswordHash,
+		Email:    u.Email,
+		Phone:    u.Phone,
+		Role:     role,
+		Banned:   u.Banned,
+	}
+	if existing != nil {
+		user.ID = existing.ID
+		user.DeletedAt = existing.DeletedAt
+		if u.Deleted && !existing.DeletedAt.Valid {
+			user.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+		} else if !u.Deleted {
+			user.DeletedAt = gorm.DeletedAt{}
+		}
+		changed := existing.Email != user.Email || existing.Phone != user.Phone || existing.Role != user.Role ||
+			existing.Banned != user.Banned || existing.DeletedAt.Valid != user.DeletedAt.Valid ||
+			(user.Password != "" && existing.Password != user.Password)
+		if changed {
+			if err := imp.repo.UpdateUser(imp.ctx, &user); err != nil {
+				return err
+			}
+			imp.result.Users.Updated++
+		}
+	} else {
+		// 没有导出密码哈希的新用户使用无人知道的随机密码，需要由管理员重置密码后才能登录
+		if user.Password == "" {
+			if err := user.SetUnusablePassword(); err != nil {
+				return err
+			}
+		}
+		if u.Deleted {
+			user.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+		}
+		user.CreatedAt = u.CreatedAt
+		if err := imp.repo.CreateUser(imp.ctx, &user); err != nil {
+			return err
+		}
+		imp.result.Users.Created++
+	}
+	imp.userIDs[u.Username] = user.ID
+	return nil
+}
+
+func (imp *importer) userID(username string) (uint, error) {
+	if id, ok := imp.userIDs[username]; ok {
+		return id, nil
+	}
+	u, err := imp.repo.FindUser(imp.ctx, username)
+	if err != nil {
+		return 0, err
+	}
+	if u == nil {
+		return 0, fmt.Errorf("%w: 用户 %q 不存在", ErrInvalidTransferData, username)
+	}
+	imp.userIDs[username] = u.ID
+	return u.ID, nil
+}
+
+// validStatus 判断导入数据中的审核状态是否有效，为空时使用默认的 published
+func validStatus(status string) bool {
+	switch status {
+	case "", entity.StatusPublished, entity.StatusPending, entity.StatusRemoved:
+		return true
+	}
+	return false
+}
+
+func (imp *importer) post(p *dto.TransferPost) error {
+	if !validStatus(p.Status) {
+		return fmt.Errorf("%w: 文章 %d 的状态 %q 无效", ErrInvalidTransferData, p.ID, p.Status)
+	}
+	userId, err := imp.userID(p.Author)
+	if err != nil {
+		return err
+	}
+	existing, err := imp.repo.FindPost(imp.ctx, userId, p.Title, p.CreatedAt)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		post := entity.Post{Title: p.Title, Content: p.Content, UserID: userId, Status: p.Status}
+		post.CreatedAt = p.CreatedAt
+		post.UpdatedAt = p.UpdatedAt
+		if err := imp.repo.Posts().Create(imp.ctx, &post); err != nil {
+			return err
+		}
+		imp.result.Posts.Created++
+		imp.postIDs[p.ID] = post.ID
+		return nil
+	}
+
+	imp.postIDs[p.ID] = existing.ID
+	if existing.Content == p.Content {
+		return nil
+	}
+	existing.Content = p.Content
+	if err := imp.repo.Posts().Update(imp.ctx, existing, userId, "content"); err != nil {
+		return err
+	}
+	imp.result.Posts.Updated++
+	return nil
+}
+
+func (imp *importer) comment(c *dto.TransferComment) error {
+	postId, ok := imp.postIDs[c.PostID]
+	if !ok {
+		return fmt.Errorf("%w: 评论 %d 引用的文章 %d 不在导入数据中", ErrInvalidTransferData, c.ID, c.PostID)
+	}
+	if !validStatus(c.Status) {
+		return fmt.Errorf("%w: 评论 %d 的状态 %q 无效", ErrInvalidTransferData, c.ID, c.Status)
+	}
+	userId, err := imp.userID(c.Author)
+	if err != nil {
+		return err
+	}
+	existing, err := imp.repo.FindComment(imp.ctx, postId, userId, c.CreatedAt)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		comment := entity.Comment{Content: c.Content, UserID: userId, PostID: postId, Status: c.Status}
+		comment.CreatedAt = c.CreatedAt
+		comment.UpdatedAt = c.UpdatedAt
+		if err := imp.repo.CreateComment(imp.ctx, &comment); err != nil {
+			return err
+		}
+		imp.result.Comments.Created++
+		return nil
+	}
+	if existing.Content == c.Content {
+		return nil
+	}
+	existing.Content = c.Content
+	if err := imp.repo.UpdateComment(imp.ctx, existing); err != nil {
+		return err
+	}
+	imp.result.Comments.Updated++
+	return nil
+}
@@ -1,33 +1,62 @@
 package service
 
 import (
-	"time"
+	"context"
+	"errors"
+	"fmt"
 
+	"github.com/miffyG/golearn/task4/internal/audit"
 	"github.com/miffyG/golearn/task4/internal/models/entity"
 	"github.com/miffyG/golearn/task4/internal/repository"
-	"github.com/miffyG/golearn/task4/internal/utils"
-	"github.com/miffyG/golearn/task4/pkg/config"
+	"github.com/miffyG/golearn/task4/internal/tokens"
+	"gorm.io/gorm"
 )
 
+// ErrUserBanned 表示用户已被封禁，不能登录
+var ErrUserBanned = errors.New("user banned")
+
 type UserService struct {
-	repo *repository.UserRepo
+	repo  *repository.UserRepo
+	tx    *repository.TxManager
+	audit *AuditService
+	keys  *tokens.KeySet
 }
 
-func NewUserService(r *repository.UserRepo) *UserService {
+// NewUserService 创建用户服务，登录成功时用 keys 签发 token，audit 为 nil 时不记录审计日志。
+// 封禁、修改角色和重置密码与审计日志在 tx 开启的同一个事务中写入
+func NewUserService(r *repository.UserRepo, tx *repository.TxManager, audit *AuditService, keys *tokens.KeySet) *UserService {
 	return &UserService{
-		repo: r,
+		repo:  r,
+		tx:    tx,
+		audit: audit,
+		keys:  keys,
 	}
 }
 
-func (s *UserService) Register(user *entity.User) error {
+func (s *UserService) Register(ctx context.Context, user *entity.User) error {
 	if err := user.SetPassword(user.Password); err != nil {
 		return err
 	}
-	return s.repo.Create(user)
+	if err := s.repo.Create(ctx, user); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, AuditEntry{
+		Action:     audit.ActionRegister,
+		TargetType: audit.TargetUser,
+		TargetID:   user.ID,
+		ActorID:    user.ID,
+		After:      map[string]interface{}{"username": user.UserName, "role": user.Role},
+	})
+	return nil
 }
 
-func (s *UserService) Login(username, password string) (string, *entity.User, error) {
-	user, err := s.repo.GetByUsername(username)
+// Login 校验用户名和密码并签发 token ，成功和失败都会写入审计日志
+func (s *UserService) Login(ctx context.Context, username, password string) (string, *entity.User, error) {
+	user, err := s.repo.GetByUsername(ctx, username)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		s.loginFailed(ctx, 0, username, "用户不存在")
+		return "", nil, err
+	}
 	if err != nil {
 		return "", nil, err
 	}
@@ -35,11 +64,120 @@ func (s *UserService) Login(username, password string) (string, *entity.User, er
 		return "", nil, nil
 	}
 	if err := user.CheckPassword(password); err != nil {
+		s.loginFailed(ctx, user.ID, username, "密码错误")
 		return "", nil, err
 	}
-	token, err := utils.GenerateJWTToken(config.GetSecretConfig().JwtSecret, user.ID, user.UserName, time.Hour*24)
+	if user.Banned {
+		s.loginFailed(ctx, user.ID, username, "用户已被封禁")
+		return "", nil, ErrUserBanned
+	}
+	token, err := s.IssueToken(user)
 	if err != nil {
 		return "", nil, err
 	}
+	s.audit.Record(ctx, AuditEntry{
+		Action:     audit.ActionLogin,
+		TargetType: audit.TargetUser,
+		TargetID:   user.ID,
+		ActorID:    user.ID,
+		Detail:     username,
+	})
 	return token, user, nil
 }
+
+// IssueToken 为用户签发访问 token
+func (s *UserService) IssueToken(user *entity.User) (string, error) {
+	return s.keys.Issue(user.ID, user.UserName)
+}
+
+func (s *UserService) loginFailed(ctx context.Context, userId uint, username, reason string) {
+	s.audit.Record(ctx, AuditEntry{
+		Action:     audit.ActionLoginFailed,
+		TargetType: audit.TargetUser,
+		TargetID:   userId,
+		Detail:     username + ": " + reason,
+	})
+}
+
+func (s *UserService) GetByID(ctx context.Context, id uint) (*entity.User, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *UserService) GetByIDs(ctx context.Context, ids []uint) ([]entity.User, error) {
+	return s.repo.GetByIDs(ctx, ids)
+}
+
+// List 分页查询用户，page 从 1 开始
+func (s *UserService) List(ctx context.Context, page, pageSize int) ([]entity.User, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	return s.repo.List(ctx, (page-1)*pageSize, pageSize)
+}
+
+func (s *UserService) GetByUsername(ctx context.Context, username string) (*entity.User, error) {
+	return s.repo.GetByUsername(ctx, username)
+}
+
+// SetBanned 封禁或解封用户。已签发的 token 在过期前仍然有效
+func (s *UserService) SetBanned(ctx context.Context, id uint, banned bool) error {
+	return s.tx.Transaction(ctx, func(ctx context.Context, uow *repository.UnitOfWork) error {
+		u, err := uow.Users.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := uow.Users.UpdateColumns(ctx, id, map[string]interface{}{"banned": banned}); err != nil {
+			return err
+		}
+		action := audit.ActionBan
+		if !banned {
+			action = audit.ActionUnban
+		}
+		return s.audit.RecordTx(ctx, uow, AuditEntry{
+			Action:     action,
+			TargetType: audit.TargetUser,
+			TargetID:   id,
+			Before:     map[string]interface{}{"banned": u.Banned},
+			After:      map[string]interface{}{"banned": banned},
+		})
+	})
+}
+
+func (s *UserService) SetRole(ctx context.Context, id uint, role string) error {
+	if !entity.ValidRole(role) {
+		return fmt.Errorf("unknown role %q", role)
+	}
+	return s.tx.Transaction(ctx, func(ctx context.Context, uow *repository.UnitOfWork) error {
+		u, err := uow.Users.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := uow.Users.UpdateColumns(ctx, id, map[string]interface{}{"role": role}); err != nil {
+			return err
+		}
+		return s.audit.RecordTx(ctx, uow, AuditEntry{
+			Action:     audit.ActionRoleChange,
+			TargetType: audit.TargetUser,
+			TargetID:   id,
+			Before:     map[string]interface{}{"role": u.Role},
+			After:      map[string]interface{}{"role": role},
+		})
+	})
+}
+
+func (s *UserService) ResetPassword(ctx context.Context, id uint, password string) error {
+	var u entity.User
+	if err := u.SetPassword(password); err != nil {
+		return err
+	}
+	return s.tx.Transaction(ctx, func(ctx context.Context, uow *repository.UnitOfWork) error {
+		if err := uow.Users.UpdateColumns(ctx, id, map[string]interface{}{"password": u.Password}); err != nil {
+			return err
+		}
+		return s.audit.RecordTx(ctx, uow, AuditEntry{
+			Action:     audit.ActionPasswordReset,
+			TargetType: audit.TargetUser,
+			TargetID:   id,
+		})
+	})
+}
@@ -0,0 +1,207 @@
+package tokens
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+)
+
+const (
+	HS256 = "HS256"
+	RS256 = "RS256"
+	EdDSA = "EdDSA"
+)
+
+// minRSABits 是 RSA 密钥的最小长度
+const minRSABits = 2048
+
+// Key 是一把签名或校验密钥，非对称密钥的 ID 是公钥的 JWK 指纹 (RFC 7638)
+type Key struct {
+	ID        string
+	Algorithm string
+	// signer 为 nil 时只能用于校验
+	signer interface{}
+	public interface{}
+}
+
+// CanSign 判断密钥是否包含私钥
+func (k *Key) CanSign() bool {
+	return k.signer != nil
+}
+
+// hmacKey 用 JWT_SECRET 创建 HS256 密钥，签名和校验使用同一个密钥，不出现在 JWKS 中
+func hmacKey(secret string) *Key {
+	return &Key{ID: "hs256", Algorithm: HS256, signer: []byte(secret), public: []byte(secret)}
+}
+
+// LoadKeyFile 读取 PEM 格式的密钥文件，支持 PKCS#8 和 PKCS#1 格式的私钥以及 PKIX 格式的公钥，
+// RSA 密钥用于 RS256，Ed25519 密钥用于 EdDSA
+func LoadKeyFile(path string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取密钥文件失败: %w", err)
+	}
+	key, err := ParseKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return key, nil
+}
+
+// ParseKey 解析 PEM 格式的密钥
+func ParseKey(data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("不是 PEM 格式的密钥")
+	}
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("不支持的 PEM 类型 %q", block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("解析密钥失败: %w", err)
+	}
+
+	key := &Key{}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Algorithm, key.signer, key.public = RS256, k, &k.PublicKey
+	case *rsa.PublicKey:
+		key.Algorithm, key.public = RS256, k
+	case ed25519.PrivateKey:
+		key.Algorithm, key.signer, key.public = EdDSA, k, k.Public()
+	case ed25519.PublicKey:
+		key.Algorithm, key.public = EdDSA, k
+	default:
+		return nil, fmt.Errorf("不支持的密钥类型 %T，只支持 RSA 和 Ed25519", parsed)
+	}
+	if pub, ok := key.public.(*rsa.PublicKey); ok && pub.N.BitLen() < minRSABits {
+		return nil, fmt.Errorf("RSA 密钥至少需要 %d 位", minRSABits)
+	}
+	key.ID = key.JWK().thumbprint()
+	return key, nil
+}
+
+// JWK 是 JSON Web Key (RFC 7517) 中公钥的字段
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	Kid string `json:"kid,omitempty"`
+	// RSA 公钥
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Ed25519 和 EC 公钥，EC 公钥还有 Y
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKS 是 /.well-known/jwks.json 返回的密钥集合
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWK 返回密钥的公钥部分，HMAC 密钥没有公钥，返回零值
+func (k *Key) JWK() JWK {
+	enc := base64.RawURLEncoding
+	switch pub := k.public.(type) {
+	case *rsa.PublicKey:
+		return JWK{Kty: "RSA", Use: "sig", Alg: RS256, Kid: k.ID,
+			N: enc.EncodeToString(pub.N.Bytes()),
+			E: enc.EncodeToString(big.NewInt(int64(pub.E)).Bytes())}
+	case ed25519.PublicKey:
+		return JWK{Kty: "OKP", Use: "sig", Alg: EdDSA, Kid: k.ID, Crv: "Ed25519", X: enc.EncodeToString(pub)}
+	}
+	return JWK{}
+}
+
+// PublicKey 把 JWK 转为校验签名用的公钥，支持 RSA、EC（P-256、P-384、P-521）和 Ed25519，
+// 用于校验第三方签发的 token
+func (j JWK) PublicKey() (interface{}, error) {
+	dec := base64.RawURLEncoding
+	switch j.Kty {
+	case "RSA":
+		n, err1 := dec.DecodeString(j.N)
+		e, err2 := dec.DecodeString(j.E)
+		if err := errors.Join(err1, err2); err != nil || len(n) == 0 || len(e) == 0 || len(e) > 4 {
+			return nil, errors.New("RSA 公钥的 n 或 e 不合法")
+		}
+		pub := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		if pub.N.BitLen() < minRSABits {
+			return nil, fmt.Errorf("RSA 密钥至少需要 %d 位", minRSABits)
+		}
+		return pub, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch j.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("不支持的 EC 曲线 %q", j.Crv)
+		}
+		x, err1 := dec.DecodeString(j.X)
+		y, err2 := dec.DecodeString(j.Y)
+		if err := errors.Join(err1, err2); err != nil {
+			return nil, errors.New("EC 公钥的 x 或 y 不合法")
+		}
+		pub := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if _, err := pub.ECDH(); err != nil {
+			return nil, errors.New("EC 公钥不在曲线上")
+		}
+		return pub, nil
+	case "OKP":
+		x, err := dec.DecodeString(j.X)
+		if j.Crv != "Ed25519" || err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("只支持 Ed25519 的 OKP 公钥")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("不支持的密钥类型 %q", j.Kty)
+}
+
+// thumbprint 按 RFC 7638 计算 JWK 指纹：只取必需的字段，按字段名排序后做 SHA-256
+func (j JWK) thumbprint() string {
+	var fields interface{}
+	switch j.Kty {
+	case "RSA":
+		fields = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{j.E, j.Kty, j.N}
+	case "OKP":
+		fields = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{j.Crv, j.Kty, j.X}
+	default:
+		return ""
+	}
+	b, _ := json.Marshal(fields)
+	sum := sha256.Sum256(b)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,221 @@
+// Package tokens 签发和校验访问 token。
+//
+// token 是 JWT，签名算法为 HS256、RS256 或 EdDSA，非对称签名的 token 在头部带有密钥 ID (kid)，
+// 公钥通过 JWKS 公开。校验时只接受配置允许的算法，并且算法必须与 kid 对应的密钥一致，
+// 防止把公钥当作 HMAC 密钥或使用 alg=none 伪造 token；iss、aud、exp、nbf、iat 都会检查。
+// 升级前签发的 HS256 token 没有 iss、aud、sub，只在配置的 JWT_LEGACY_UNTIL 之前接受。
+package tokens
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/miffyG/golearn/task4/pkg/config"
+)
+
+var (
+	// ErrInvalid 表示 token 格式、签名或声明不合法，具体原因包含在错误信息中
+	ErrInvalid = errors.New("token 无效")
+	// ErrExpired 表示 token 已过期
+	ErrExpired = errors.New("token 已过期")
+)
+
+// Claims 是 token 中的声明，sub 是用户 ID 的十进制字符串，user_id 保留给只读取它的旧客户端
+type Claims struct {
+	UserID   uint   `json:"user_id"`
+	Username string `json:"username"`
+	jwt.RegisteredClaims
+}
+
+// KeySet 保存签名密钥和校验密钥，创建后只读，可以并发使用
+type KeySet struct {
+	signing *Key
+	// keys 按 kid 索引全部校验密钥
+	keys       map[string]*Key
+	algorithms []string
+	issuer     string
+	audience   string
+	ttl        time.Duration
+	leeway     time.Duration
+	// legacyUntil 之前接受升级前签发的 token，零值表示不接受
+	legacyUntil time.Time
+}
+
+// NewKeySet 按 cfg 读取 JWT_KEYS 中的密钥，secret 不为空且允许 HS256 时作为 HS256 密钥。
+// JWT_ALGORITHM 为 HS256 时用 secret 签名，否则用 JWT_KEYS 的第一个密钥签名
+func NewKeySet(cfg *config.Jwt, secret string) (*KeySet, error) {
+	ks := &KeySet{
+		keys:        map[string]*Key{},
+		algorithms:  cfg.Algorithms(),
+		issuer:      cfg.Issuer,
+		audience:    cfg.Audience,
+		ttl:         cfg.TTL,
+		leeway:      cfg.Leeway,
+		legacyUntil: cfg.LegacyUntil,
+	}
+	if secret != "" && slices.Contains(ks.algorithms, HS256) {
+		key := hmacKey(secret)
+		ks.keys[key.ID] = key
+		if cfg.Algorithm == HS256 {
+			ks.signing = key
+		}
+	}
+	for i, path := range cfg.Keys {
+		key, err := LoadKeyFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(ks.algorithms, key.Algorithm) {
+			return nil, fmt.Errorf("%s: 密钥算法 %s 不在 JWT_ALLOWED_ALGORITHMS 中", path, key.Algorithm)
+		}
+		if _, ok := ks.keys[key.ID]; ok {
+			return nil, fmt.Errorf("%s: 密钥重复", path)
+		}
+		ks.keys[key.ID] = key
+		if i == 0 && cfg.Algorithm != HS256 {
+			if key.Algorithm != cfg.Algorithm {
+				return nil, fmt.Errorf("%s: 签名密钥的算法是 %s，与 JWT_ALGORITHM %s 不一致", path, key.Algorithm, cfg.Algorithm)
+			}
+			if !key.CanSign() {
+				return nil, fmt.Errorf("%s: JWT_KEYS 的第一个密钥用于签名，必须是私钥", path)
+			}
+			ks.signing = key
+		}
+	}
+	if ks.signing == nil {
+		return nil, fmt.Errorf("没有 %s 签名密钥", cfg.Algorithm)
+	}
+	return ks, nil
+}
+
+// TTL 返回签发的 token 的有效期
+func (ks *KeySet) TTL() time.Duration {
+	return ks.ttl
+}
+
+// Issue 为用户签发有效期为 TTL 的 token
+func (ks *KeySet) Issue(userID uint, username string) (string, error) {
+	return ks.IssueWithTTL(userID, username, ks.ttl)
+}
+
+// IssueWithTTL 为用户签发指定有效期的 token，供 blogctl token issue 使用
+func (ks *KeySet) IssueWithTTL(userID uint, username string, ttl time.Duration) (string, error) {
+	now := time.Now().Truncate(time.Second)
+	claims := &Claims{
+		UserID:   userID,
+		Username: username,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    ks.issuer,
+			Subject:   strconv.FormatUint(uint64(userID), 10),
+			Audience:  jwt.ClaimStrings{ks.audience},
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			NotBefore: jwt.NewNumericDate(now),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(ks.signing.Algorithm), claims)
+	if ks.signing.Algorithm != HS256 {
+		token.Header["kid"] = ks.signing.ID
+	}
+	return token.SignedString(ks.signing.signer)
+}
+
+// Parse 校验 token 的算法、签名和声明，返回其中的声明
+func (ks *KeySet) Parse(tokenStr string) (*Claims, error) {
+	claims := &Claims{}
+	parser := jwt.NewParser(jwt.WithValidMethods(ks.algorithms), jwt.WithoutClaimsValidation())
+	token, err := parser.ParseWithClaims(tokenStr, claims, ks.keyFunc)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	if err := ks.validate(claims, ks.legacy(token, claims)); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// keyFunc 按 kid 选择校验密钥，并要求头部的算法与密钥的算法一致。没有 kid 时只能是 HS256
+func (ks *KeySet) keyFunc(token *jwt.Token) (interface{}, error) {
+	alg, _ := token.Header["alg"].(string)
+	id := hmacKey("").ID
+	if kid, ok := token.Header["kid"]; ok {
+		if id, ok = kid.(string); !ok {
+			return nil, errors.New("kid 必须是字符串")
+		}
+	}
+	key, ok := ks.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("未知的密钥 %q", id)
+	}
+	if key.Algorithm != alg || token.Method.Alg() != alg {
+		return nil, fmt.Errorf("算法 %s 与密钥 %q 的算法 %s 不一致", alg, id, key.Algorithm)
+	}
+	return key.public, nil
+}
+
+// legacy 判断 token 是否是升级前签发的：用 JWT_SECRET 以 HS256 签名、没有 kid，也没有 iss、aud、sub。
+// 过了 legacyUntil 后一律返回 false，这些 token 按缺少 iss 处理
+func (ks *KeySet) legacy(token *jwt.Token, c *Claims) bool {
+	if !time.Now().Before(ks.legacyUntil) || token.Method.Alg() != HS256 {
+		return false
+	}
+	_, hasKid := token.Header["kid"]
+	return !hasKid && c.Issuer == "" && len(c.Audience) == 0 && c.Subject == ""
+}
+
+// validate 检查声明：exp 必须存在，iss、aud 必须与配置一致，时间允许 leeway 的偏差。
+// legacy 为 true 时不检查 iss、aud、sub，只要求 user_id 不为 0
+func (ks *KeySet) validate(c *Claims, legacy bool) error {
+	now := time.Now()
+	if c.ExpiresAt == nil {
+		return fmt.Errorf("%w: 缺少 exp", ErrInvalid)
+	}
+	if !now.Before(c.ExpiresAt.Add(ks.leeway)) {
+		return ErrExpired
+	}
+	if c.NotBefore != nil && now.Add(ks.leeway).Before(c.NotBefore.Time) {
+		return fmt.Errorf("%w: 尚未生效", ErrInvalid)
+	}
+	if c.IssuedAt != nil && now.Add(ks.leeway).Before(c.IssuedAt.Time) {
+		return fmt.Errorf("%w: 签发时间晚于当前时间", ErrInvalid)
+	}
+	if legacy {
+		if c.UserID == 0 {
+			return fmt.Errorf("%w: 缺少 user_id", ErrInvalid)
+		}
+		return nil
+	}
+	if c.Issuer != ks.issuer {
+		return fmt.Errorf("%w: 签发方 %q 不正确", ErrInvalid, c.Issuer)
+	}
+	if !slices.Contains(c.Audience, ks.audience) {
+		return fmt.Errorf("%w: 接收方不正确", ErrInvalid)
+	}
+	if c.Subject != strconv.FormatUint(uint64(c.UserID), 10) {
+		return fmt.Errorf("%w: sub 与 user_id 不一致", ErrInvalid)
+	}
+	return nil
+}
+
+// JWKS 返回全部非对称密钥的公钥，签名密钥排在第一个，HS256 密钥不会公开
+func (ks *KeySet) JWKS() JWKS {
+	set := JWKS{Keys: []JWK{}}
+	if ks.signing.Algorithm != HS256 {
+		set.Keys = append(set.Keys, ks.signing.JWK())
+	}
+	ids := make([]string, 0, len(ks.keys))
+	for id, key := range ks.keys {
+		if key != ks.signing && key.Algorithm != HS256 {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	for _, id := range ids {
+		set.Keys = append(set.Keys, ks.keys[id].JWK())
+	}
+	return set
+}
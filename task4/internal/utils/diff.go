@@ -0,0 +1,164 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// MaxDiffEdits 是 UnifiedDiff 支持的最大编辑距离（删除和新增的总行数）。
+// 回溯需要保留每一步的 V 数组，内存占用与编辑距离的平方成正比
+const MaxDiffEdits = 1000
+
+// ErrDiffTooLarge 表示两段文本的差异超过 MaxDiffEdits
+var ErrDiffTooLarge = errors.New("diff too large")
+
+type diffOp struct {
+	kind byte // ' ' 相同, '-' 删除, '+' 新增
+	line string
+}
+
+// UnifiedDiff 按行比较 a 和 b，返回 unified diff 格式的文本。
+// context 为每个变更块前后保留的上下文行数，两段文本相同时返回空字符串。
+// 差异超过 MaxDiffEdits 行时返回 ErrDiffTooLarge。
+func UnifiedDiff(fromName, toName, a, b string, context int) (string, error) {
+	ops, err := diffLines(splitLines(a), splitLines(b))
+	if err != nil {
+		return "", err
+	}
+
+	// aPos[i]、bPos[i] 为 ops[i] 之前 a、b 已经消耗的行数
+	aPos := make([]int, len(ops)+1)
+	bPos := make([]int, len(ops)+1)
+	for i, op := range ops {
+		aPos[i+1], bPos[i+1] = aPos[i], bPos[i]
+		if op.kind != '+' {
+			aPos[i+1]++
+		}
+		if op.kind != '-' {
+			bPos[i+1]++
+		}
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(ops); i++ {
+		if ops[i].kind == ' ' {
+			continue
+		}
+		// 找到当前变更块的结束位置：相邻变更之间的相同行不超过 2*context 时合并为一个块
+		lastChange := i
+		for j := i + 1; j < len(ops) && j-lastChange <= 2*context; j++ {
+			if ops[j].kind != ' ' {
+				lastChange = j
+			}
+		}
+		start := max(0, i-context)
+		end := min(len(ops), lastChange+context+1)
+
+		if sb.Len() == 0 {
+			fmt.Fprintf(&sb, "--- %s\n+++ %s\n", fromName, toName)
+		}
+		aLen, bLen := aPos[end]-aPos[start], bPos[end]-bPos[start]
+		aStart, bStart := aPos[start], bPos[start]
+		if aLen > 0 {
+			aStart++
+		}
+		if bLen > 0 {
+			bStart++
+		}
+		fmt.Fprintf(&sb, "@@ -%d,%d +%d,%d @@\n", aStart, aLen, bStart, bLen)
+		for _, op := range ops[start:end] {
+			sb.WriteByte(op.kind)
+			sb.WriteString(op.line)
+			sb.WriteByte('\n')
+		}
+		i = end - 1
+	}
+	return sb.String(), nil
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines 使用 Myers 算法计算 a 到 b 的最短编辑序列，编辑距离超过 MaxDiffEdits 时返回 ErrDiffTooLarge
+func diffLines(a, b []string) ([]diffOp, error) {
+	n, m := len(a), len(b)
+	maxD := min(n+m, MaxDiffEdits)
+	offset := maxD + 1
+	v := make([]int, 2*maxD+3)
+	// trace[d] 是第 d 步开始前 V 在 k ∈ [-d, d] 上的值，下标为 k+d，回溯只会用到这一段
+	var trace [][]int
+	found := false
+
+search:
+	for d := 0; d <= maxD; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				found = true
+				break search
+			}
+		}
+	}
+	if !found {
+		return nil, ErrDiffTooLarge
+	}
+
+	// 从终点回溯，得到逆序的编辑操作
+	ops := make([]diffOp, 0, n+m)
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		v := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[d+k-1] < v[d+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[d+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			ops = append(ops, diffOp{' ', a[x-1]})
+			x--
+			y--
+		}
+		if x == prevX {
+			ops = append(ops, diffOp{'+', b[y-1]})
+			y--
+		} else {
+			ops = append(ops, diffOp{'-', a[x-1]})
+			x--
+		}
+	}
+	for x > 0 && y > 0 {
+		ops = append(ops, diffOp{' ', a[x-1]})
+		x--
+		y--
+	}
+
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops, nil
+}
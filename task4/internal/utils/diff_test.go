@@ -0,0 +1,49 @@
+package utils
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	got, err := UnifiedDiff("a", "b", "标题\n\n第一行\n第二行\n第三行\n", "标题\n\n第一行\n第二行改\n第三行\n第四行\n", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `--- a
++++ b
+@@ -3,3 +3,4 @@
+ 第一行
+-第二行
++第二行改
+ 第三行
++第四行
+`
+	if got != want {
+		t.Errorf("diff 为\n%s期望\n%s", got, want)
+	}
+
+	if got, err := UnifiedDiff("a", "b", "相同\n", "相同\n", 3); err != nil || got != "" {
+		t.Errorf("相同文本的 diff 为 %q, %v，期望为空", got, err)
+	}
+}
+
+func TestUnifiedDiffTooLarge(t *testing.T) {
+	lines := func(prefix string, n int) string {
+		var sb strings.Builder
+		for i := 0; i < n; i++ {
+			sb.WriteString(prefix + strconv.Itoa(i) + "\n")
+		}
+		return sb.String()
+	}
+	// 两段文本没有相同的行，编辑距离等于总行数
+	half := MaxDiffEdits / 2
+	if _, err := UnifiedDiff("a", "b", lines("a", half), lines("b", half), 3); err != nil {
+		t.Errorf("编辑距离为 %d 时返回 %v", MaxDiffEdits, err)
+	}
+	if _, err := UnifiedDiff("a", "b", lines("a", half+1), lines("b", half), 3); !errors.Is(err, ErrDiffTooLarge) {
+		t.Errorf("编辑距离超过 %d 时返回 %v，期望 ErrDiffTooLarge", MaxDiffEdits, err)
+	}
+}
@@ -0,0 +1,116 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// VersionETag 将版本号格式化为强校验的 ETag
+func VersionETag(version uint) string {
+	return fmt.Sprintf("%q", strconv.FormatUint(uint64(version), 10))
+}
+
+// IfMatch 是解析后的 If-Match 条件，零值表示未携带 If-Match 或为 *，任何版本都满足
+type IfMatch struct {
+	// checked 为 true 时版本必须在 versions 中
+	checked bool
+	// versions 是列表中强校验 ETag 对应的版本号，弱校验和不是版本号的 ETag 不会匹配任何版本
+	versions []uint
+}
+
+// ExpectVersion 返回只允许 version 的条件，version 为 0 时不检查，用于请求中直接给出版本号的接口
+func ExpectVersion(version uint) IfMatch {
+	if version == 0 {
+		return IfMatch{}
+	}
+	return IfMatch{checked: true, versions: []uint{version}}
+}
+
+// Matches 判断当前版本是否满足条件
+func (m IfMatch) Matches(version uint) bool {
+	return !m.checked || slices.Contains(m.versions, version)
+}
+
+// ParseIfMatch 解析 If-Match 请求头，支持逗号分隔的多个 ETag，当前版本与其中任意一个相同即满足条件。
+// If-Match 要求强比较，W/ 开头的弱校验 ETag 以及不是版本号的 ETag 语法正确但不会匹配；
+// 只有语法错误（缺少引号、* 与其他 ETag 混用、列表为空）时返回 false
+func ParseIfMatch(header string) (IfMatch, bool) {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return IfMatch{}, true
+	}
+	m := IfMatch{checked: true}
+	empty := true
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+		empty = false
+		weak := strings.HasPrefix(tag, "W/")
+		opaque, ok := opaqueTag(strings.TrimPrefix(tag, "W/"))
+		if !ok {
+			return IfMatch{}, false
+		}
+		if weak {
+			continue
+		}
+		if version, err := strconv.ParseUint(opaque, 10, 64); err == nil && version != 0 {
+			m.versions = append(m.versions, uint(version))
+		}
+	}
+	if empty {
+		return IfMatch{}, false
+	}
+	return m, true
+}
+
+// opaqueTag 返回带引号的 entity-tag 中引号之间的部分，其中只能是除空白、双引号和 DEL 以外的可见字符
+func opaqueTag(tag string) (string, bool) {
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return "", false
+	}
+	opaque := tag[1 : len(tag)-1]
+	for i := 0; i < len(opaque); i++ {
+		if c := opaque[i]; c <= ' ' || c == '"' || c == 0x7f {
+			return "", false
+		}
+	}
+	return opaque, true
+}
+
+// ContentETag 按响应体的内容生成强校验的 ETag
+func ContentETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// MatchesETag 判断 If-None-Match 请求头是否包含 etag，比较时忽略弱校验前缀 W/
+func MatchesETag(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// NotModifiedSince 判断资源在 If-Modified-Since 请求头给出的时间之后是否没有修改。
+// HTTP 日期只精确到秒，比较前截掉 modified 的亚秒部分；请求头无法解析或 modified 为零值时返回 false
+func NotModifiedSince(header string, modified time.Time) bool {
+	if header == "" || modified.IsZero() {
+		return false
+	}
+	since, err := http.ParseTime(header)
+	if err != nil {
+		return false
+	}
+	return !modified.Truncate(time.Second).After(since)
+}
@@ -0,0 +1,60 @@
+package utils
+
+import "testing"
+
+func TestParseIfMatch(t *testing.T) {
+	tests := []struct {
+		header string
+		ok     bool
+		// matches 和 rejects 分别是满足和不满足条件的版本号
+		matches []uint
+		rejects []uint
+	}{
+		{header: "", ok: true, matches: []uint{1, 3}},
+		{header: " * ", ok: true, matches: []uint{1, 3}},
+		{header: `"3"`, ok: true, matches: []uint{3}, rejects: []uint{1, 4}},
+		{header: `"1", "3"`, ok: true, matches: []uint{1, 3}, rejects: []uint{2}},
+		{header: `"1","3",`, ok: true, matches: []uint{1, 3}, rejects: []uint{2}},
+		// If-Match 使用强比较，弱校验的 ETag 不匹配任何版本
+		{header: `W/"3"`, ok: true, rejects: []uint{3}},
+		{header: `W/"3", "4"`, ok: true, matches: []uint{4}, rejects: []uint{3}},
+		// 语法正确但不是版本号的 ETag 同样不匹配
+		{header: `"abc"`, ok: true, rejects: []uint{1}},
+		{header: `"0"`, ok: true, rejects: []uint{0, 1}},
+		{header: `"abc", "2"`, ok: true, matches: []uint{2}, rejects: []uint{1}},
+		{header: `3`, ok: false},
+		{header: `"3`, ok: false},
+		{header: `""3""`, ok: false},
+		{header: `"3 4"`, ok: false},
+		{header: `w/"3"`, ok: false},
+		{header: `"1", 2`, ok: false},
+		{header: `*, "1"`, ok: false},
+		{header: `,`, ok: false},
+	}
+	for _, tc := range tests {
+		m, ok := ParseIfMatch(tc.header)
+		if ok != tc.ok {
+			t.Errorf("ParseIfMatch(%q) 返回 %v，期望 %v", tc.header, ok, tc.ok)
+			continue
+		}
+		for _, v := range tc.matches {
+			if !m.Matches(v) {
+				t.Errorf("If-Match %q 应当匹配版本 %d", tc.header, v)
+			}
+		}
+		for _, v := range tc.rejects {
+			if m.Matches(v) {
+				t.Errorf("If-Match %q 不应匹配版本 %d", tc.header, v)
+			}
+		}
+	}
+}
+
+func TestExpectVersion(t *testing.T) {
+	if !ExpectVersion(0).Matches(7) {
+		t.Error("版本号为 0 时不应检查版本")
+	}
+	if m := ExpectVersion(3); !m.Matches(3) || m.Matches(4) {
+		t.Error("ExpectVersion(3) 应当只匹配版本 3")
+	}
+}
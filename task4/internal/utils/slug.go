@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/mozillazg/go-pinyin"
+)
+
+// MaxSlugLength 是 Slugify 生成的 slug 的最大长度
+const MaxSlugLength = 80
+
+var pinyinArgs = pinyin.NewArgs()
+
+// Slugify 把标题转换为只包含小写字母、数字和连字符的 slug，汉字转换为不带声调的拼音，
+// 其他字符作为分隔符。无法转换出任何字母或数字时返回空字符串
+func Slugify(title string) string {
+	var words []string
+	var word strings.Builder
+	flush := func() {
+		if word.Len() > 0 {
+			words = append(words, word.String())
+			word.Reset()
+		}
+	}
+	for _, r := range title {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			word.WriteRune(unicode.ToLower(r))
+		case unicode.Is(unicode.Han, r):
+			flush()
+			if py := pinyin.SinglePinyin(r, pinyinArgs); len(py) > 0 {
+				words = append(words, py[0])
+			}
+		default:
+			flush()
+		}
+	}
+	flush()
+
+	slug := strings.Join(words, "-")
+	if len(slug) > MaxSlugLength {
+		slug = strings.TrimRight(slug[:MaxSlugLength], "-")
+		// 不在单词中间截断
+		if i := strings.LastIndex(slug, "-"); i > MaxSlugLength/2 {
+			slug = slug[:i]
+		}
+	}
+	return slug
+}
@@ -0,0 +1,25 @@
+package utils
+
+import (
+	"regexp"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+var phoneRegexp = regexp.MustCompile(`^(\+86)?1[3-9]\d{9}$`)
+
+var phone validator.Func = func(fl validator.FieldLevel) bool {
+	phone := fl.Field().String()
+	if phone == "" {
+		return true // 空值通过
+	}
+	return phoneRegexp.MatchString(phone)
+}
+
+// RegisterValidators 向 gin 的默认校验器注册自定义校验规则（phone），重复调用是安全的
+func RegisterValidators() {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterValidation("phone", phone)
+	}
+}
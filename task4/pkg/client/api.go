@@ -0,0 +1,208 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Register 注册用户，返回新用户的 ID
+func (c *Client) Register(ctx context.Context, req RegisterRequest) (uint, error) {
+	var data struct {
+		UserID uint `json:"user_id"`
+	}
+	_, err := c.call(ctx, request{ep: epRegister, body: req}, &data)
+	return data.UserID, err
+}
+
+// Login 登录并保存 token，不保存密码。token 过期后需要自动重新登录时使用 WithLogin 或 WithTokenSource
+func (c *Client) Login(ctx context.Context, username, password string) (string, error) {
+	token, err := c.login(ctx, username, password)
+	if err != nil {
+		return "", err
+	}
+	c.tokens.SetToken(token)
+	return token, nil
+}
+
+func (c *Client) login(ctx context.Context, username, password string) (string, error) {
+	var data struct {
+		Token string `json:"token"`
+	}
+	if _, err := c.call(ctx, request{ep: epLogin, body: LoginRequest{Username: username, Password: password}}, &data); err != nil {
+		return "", err
+	}
+	return data.Token, nil
+}
+
+// Logout 清除保存的 token，服务端的 token 无状态，不需要调用接口
+func (c *Client) Logout() {
+	c.tokens.SetToken("")
+}
+
+// ListPosts 返回全部已发布的帖子
+func (c *Client) ListPosts(ctx context.Context) ([]Post, error) {
+	var posts []Post
+	_, err := c.call(ctx, request{ep: epListPosts}, &posts)
+	return posts, err
+}
+
+func (c *Client) GetPost(ctx context.Context, id uint) (*Post, error) {
+	var post Post
+	if _, err := c.call(ctx, request{ep: epGetPost, params: []interface{}{id}, auth: true}, &post); err != nil {
+		return nil, err
+	}
+	return &post, nil
+}
+
+func (c *Client) GetPostBySlug(ctx context.Context, slug string) (*Post, error) {
+	var post Post
+	if _, err := c.call(ctx, request{ep: epGetPostBySlug, params: []interface{}{slug}, auth: true}, &post); err != nil {
+		return nil, err
+	}
+	return &post, nil
+}
+
+func (c *Client) CreatePost(ctx context.Context, req PostRequest) (*Post, error) {
+	var post Post
+	if _, err := c.call(ctx, request{ep: epCreatePost, body: req, auth: true}, &post); err != nil {
+		return nil, err
+	}
+	return &post, nil
+}
+
+// UpdatePost 更新帖子，version 不为 0 时通过 If-Match 要求帖子仍是该版本，
+// 否则返回 ErrPreconditionFailed
+func (c *Client) UpdatePost(ctx context.Context, id uint, req PostRequest, version uint) (*Post, error) {
+	r := request{ep: epUpdatePost, params: []interface{}{id}, body: req, auth: true}
+	if version != 0 {
+		r.header = http.Header{"If-Match": {strconv.Quote(strconv.FormatUint(uint64(version), 10))}}
+	}
+	var post Post
+	if _, err := c.call(ctx, r, &post); err != nil {
+		return nil, err
+	}
+	return &post, nil
+}
+
+// DeletePost 把帖子移入回收站
+func (c *Client) DeletePost(ctx context.Context, id uint) error {
+	_, err := c.call(ctx, request{ep: epDeletePost, params: []interface{}{id}, auth: true}, nil)
+	return err
+}
+
+// RestorePost 从回收站恢复帖子
+func (c *Client) RestorePost(ctx context.Context, id uint) (*Post, error) {
+	var post Post
+	if _, err := c.call(ctx, request{ep: epRestorePost, params: []interface{}{id}, auth: true}, &post); err != nil {
+		return nil, err
+	}
+	return &post, nil
+}
+
+// SetTags 用 tags 替换帖子的全部标签
+func (c *Client) SetTags(ctx context.Context, id uint, tags []string) (*Post, error) {
+	var post Post
+	if _, err := c.call(ctx, request{ep: epSetTags, params: []interface{}{id}, body: TagsRequest{Tags: tags}, auth: true}, &post); err != nil {
+		return nil, err
+	}
+	return &post, nil
+}
+
+func (c *Client) ListRevisions(ctx context.Context, postID uint) ([]Revision, error) {
+	var revs []Revision
+	_, err := c.call(ctx, request{ep: epListRevisions, params: []interface{}{postID}, auth: true}, &revs)
+	return revs, err
+}
+
+func (c *Client) GetRevision(ctx context.Context, postID, rev uint) (*Revision, error) {
+	var r Revision
+	if _, err := c.call(ctx, request{ep: epGetRevision, params: []interface{}{postID, rev}, auth: true}, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func (c *Client) ListComments(ctx context.Context, postID uint) ([]Comment, error) {
+	var comments []Comment
+	_, err := c.call(ctx, request{ep: epListComments, params: []interface{}{postID}}, &comments)
+	return comments, err
+}
+
+func (c *Client) CreateComment(ctx context.Context, postID uint, content string) (*Comment, error) {
+	var comment Comment
+	r := request{ep: epCreateComment, params: []interface{}{postID}, body: CommentRequest{Content: content}, auth: true}
+	if _, err := c.call(ctx, r, &comment); err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+func (c *Client) CreateReport(ctx context.Context, req ReportRequest) (*Report, error) {
+	var report Report
+	if _, err := c.call(ctx, request{ep: epCreateReport, body: req, auth: true}, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// ApproveReport 保留举报针对的内容，这条内容的全部举报一起关闭
+func (c *Client) ApproveReport(ctx context.Context, id uint) (*Report, error) {
+	return c.handleReport(ctx, epApproveReport, id)
+}
+
+// RemoveReport 移除举报针对的内容
+func (c *Client) RemoveReport(ctx context.Context, id uint) (*Report, error) {
+	return c.handleReport(ctx, epRemoveReport, id)
+}
+
+// BanAuthor 移除举报针对的内容并封禁内容的作者
+func (c *Client) BanAuthor(ctx context.Context, id uint) (*Report, error) {
+	return c.handleReport(ctx, epBanReport, id)
+}
+
+func (c *Client) handleReport(ctx context.Context, ep Endpoint, id uint) (*Report, error) {
+	var report Report
+	if _, err := c.call(ctx, request{ep: ep, params: []interface{}{id}, auth: true}, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// VerifyAuditLogs 校验审计日志的哈希链
+func (c *Client) VerifyAuditLogs(ctx context.Context) (*AuditVerifyResult, error) {
+	var result AuditVerifyResult
+	if _, err := c.call(ctx, request{ep: epVerifyAudit, auth: true}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// values 把审计日志的过滤条件转换为查询参数
+func (f AuditFilter) values() url.Values {
+	q := url.Values{}
+	if f.ActorID != 0 {
+		q.Set("actor_id", strconv.FormatUint(uint64(f.ActorID), 10))
+	}
+	if f.Action != "" {
+		q.Set("action", f.Action)
+	}
+	if f.TargetType != "" {
+		q.Set("target_type", f.TargetType)
+	}
+	if f.TargetID != 0 {
+		q.Set("target_id", strconv.FormatUint(uint64(f.TargetID), 10))
+	}
+	if f.RequestID != "" {
+		q.Set("request_id", f.RequestID)
+	}
+	if !f.Since.IsZero() {
+		q.Set("since", f.Since.Format(time.RFC3339))
+	}
+	if !f.Until.IsZero() {
+		q.Set("until", f.Until.Format(time.RFC3339))
+	}
+	return q
+}
@@ -0,0 +1,306 @@
+// Package client 是 v1 接口的 Go 客户端。
+//
+// 客户端保存登录得到的 token 并在需要认证的请求中携带，没有 token 或 token 过期时向调用方提供的
+// TokenSource 获取新的 token，客户端不保存密码；
+// GET、PUT、DELETE 等幂等请求在网络错误或 429、502、503、504 时按退避间隔重试；
+// 服务端返回的错误统一为 *APIError，可用 errors.Is 与 ErrNotFound 等比较。
+//
+// 客户端调用的接口列在 endpoints.go 中，修改接口后运行 go generate ./pkg/client
+// 与 docs/swagger.json 对照，避免客户端与服务端不一致。
+package client
+
+//go:generate go run ../../cmd/clientcheck -spec ../../docs/swagger.json
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TokenStore 保存登录得到的 token，实现需要并发安全
+type TokenStore interface {
+	Token() string
+	SetToken(token string)
+}
+
+// MemoryTokenStore 是默认的 TokenStore，只在内存中保存
+type MemoryTokenStore struct {
+	mu    sync.RWMutex
+	token string
+}
+
+func (s *MemoryTokenStore) Token() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.token
+}
+
+func (s *MemoryTokenStore) SetToken(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = token
+}
+
+// TokenSource 在客户端没有 token 或 token 被服务端拒绝（401）时提供新的 token，实现需要并发安全。
+// 例如从密钥管理服务读取长期有效的服务账号 token，或在需要时提示用户输入密码后调用 Login
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// TokenSourceFunc 把函数适配为 TokenSource
+type TokenSourceFunc func(ctx context.Context) (string, error)
+
+func (f TokenSourceFunc) Token(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+// Client 是 v1 接口的客户端，可以在多个 goroutine 中共用
+type Client struct {
+	base    string
+	http    *http.Client
+	tokens  TokenStore
+	source  TokenSource
+	retries int
+	backoff time.Duration
+}
+
+type Option func(*Client)
+
+// WithHTTPClient 使用自定义的 http.Client，例如设置超时或代理
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.http = hc }
+}
+
+// WithTokenStore 使用自定义的 token 存储，例如保存到文件以便下次启动时复用
+func WithTokenStore(s TokenStore) Option {
+	return func(c *Client) { c.tokens = s }
+}
+
+// WithRetry 设置幂等请求的最大重试次数和首次重试的等待时间，之后每次翻倍，max 为 0 时不重试
+func WithRetry(max int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.retries = max
+		c.backoff = backoff
+	}
+}
+
+// WithTokenSource 设置 token 为空或过期时获取新 token 的来源，为 nil 时不自动获取，需要认证的请求直接返回 401
+func WithTokenSource(s TokenSource) Option {
+	return func(c *Client) { c.source = s }
+}
+
+// WithLogin 在需要新 token 时调用 credentials 获取账号并登录，例如提示用户输入密码或从密钥管理服务读取。
+// 账号只在登录时使用，客户端不保存密码
+func WithLogin(credentials func(ctx context.Context) (username, password string, err error)) Option {
+	return func(c *Client) {
+		c.source = TokenSourceFunc(func(ctx context.Context) (string, error) {
+			username, password, err := credentials(ctx)
+			if err != nil {
+				return "", err
+			}
+			return c.login(ctx, username, password)
+		})
+	}
+}
+
+// New 创建客户端，baseURL 是服务的地址，例如 http://localhost:8080，不含 /api/v1
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		base:    strings.TrimRight(baseURL, "/"),
+		http:    http.DefaultClient,
+		tokens:  &MemoryTokenStore{},
+		retries: 3,
+		backoff: 200 * time.Millisecond,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Token 返回当前保存的 token
+func (c *Client) Token() string {
+	return c.tokens.Token()
+}
+
+// SetToken 直接设置 token，用于复用之前登录得到的 token
+func (c *Client) SetToken(token string) {
+	c.tokens.SetToken(token)
+}
+
+// request 是一次接口调用
+type request struct {
+	ep     Endpoint
+	params []interface{}
+	query  url.Values
+	header http.Header
+	body   interface{}
+	// auth 为 true 时携带 token，401 时从 TokenSource 获取新 token 后再试一次
+	auth bool
+}
+
+// envelope 是 v1 响应的外层结构，错误响应没有 data
+type envelope struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// call 发送请求并把响应的 data 解码到 out，out 为 nil 时忽略 data
+func (c *Client) call(ctx context.Context, r request, out interface{}) (http.Header, error) {
+	header, err := c.send(ctx, r, out)
+	if r.auth && errors.Is(err, ErrUnauthorized) && c.source != nil {
+		if err := c.refresh(ctx); err != nil {
+			return nil, err
+		}
+		return c.send(ctx, r, out)
+	}
+	return header, err
+}
+
+func (c *Client) send(ctx context.Context, r request, out interface{}) (http.Header, error) {
+	if r.auth && c.tokens.Token() == "" && c.source != nil {
+		if err := c.refresh(ctx); err != nil {
+			return nil, err
+		}
+	}
+	target, err := c.url(r)
+	if err != nil {
+		return nil, err
+	}
+	var payload []byte
+	if r.body != nil {
+		if payload, err = json.Marshal(r.body); err != nil {
+			return nil, err
+		}
+	}
+
+	attempts := 1
+	if idempotent(r.ep.Method) {
+		attempts += c.retries
+	}
+	wait := c.backoff
+	for attempt := 1; ; attempt++ {
+		resp, err := c.do(ctx, r, target, payload)
+		if err == nil && (attempt == attempts || !retryableStatus(resp.StatusCode)) {
+			defer resp.Body.Close()
+			return resp.Header, decode(resp, out)
+		}
+		if err != nil && (attempt == attempts || ctx.Err() != nil) {
+			return nil, err
+		}
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+}
+
+func (c *Client) do(ctx context.Context, r request, target string, payload []byte) (*http.Response, error) {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, r.ep.Method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range r.header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if r.auth {
+		if token := c.tokens.Token(); token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+	return c.http.Do(req)
+}
+
+// url 用 params 依次替换 Path 中的路径参数并拼接查询参数
+func (c *Client) url(r request) (string, error) {
+	path := r.ep.Path
+	for _, p := range r.params {
+		start := strings.Index(path, "{")
+		end := strings.Index(path, "}")
+		if start < 0 || end < start {
+			return "", fmt.Errorf("client: %s 的路径参数过多", r.ep.Path)
+		}
+		path = path[:start] + url.PathEscape(fmt.Sprint(p)) + path[end+1:]
+	}
+	if strings.Contains(path, "{") {
+		return "", fmt.Errorf("client: %s 缺少路径参数", r.ep.Path)
+	}
+	target := c.base + BasePath + path
+	if len(r.query) > 0 {
+		target += "?" + r.query.Encode()
+	}
+	return target, nil
+}
+
+// decode 把 2xx 响应的 data 解码到 out，其余状态码转换为 *APIError
+func decode(resp *http.Response, out interface{}) error {
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	var env envelope
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &env); err != nil && resp.StatusCode < 300 {
+			return fmt.Errorf("client: 解析响应失败: %w", err)
+		}
+	}
+	if resp.StatusCode >= 300 {
+		return &APIError{StatusCode: resp.StatusCode, Code: env.Code, Message: env.Message}
+	}
+	if out == nil || len(env.Data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(env.Data, out); err != nil {
+		return fmt.Errorf("client: 解析响应失败: %w", err)
+	}
+	return nil
+}
+
+// refresh 从 TokenSource 获取新的 token 并保存
+func (c *Client) refresh(ctx context.Context) error {
+	token, err := c.source.Token(ctx)
+	if err != nil {
+		return fmt.Errorf("client: 获取 token 失败: %w", err)
+	}
+	c.tokens.SetToken(token)
+	return nil
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+func retryableStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,251 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// respond 按 v1 的响应格式写出 data
+func respond(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{"code": status, "message": http.StatusText(status), "data": data})
+}
+
+func TestRetry(t *testing.T) {
+	tests := []struct {
+		name string
+		// statuses 是服务端依次返回的状态码，用完后返回 200
+		statuses []int
+		call     func(ctx context.Context, c *Client) error
+		want     error
+		requests int32
+	}{
+		{"get-recovers", []int{503, 502}, listPosts, nil, 3},
+		{"get-gives-up", []int{503, 503, 503}, listPosts, &APIError{StatusCode: 503}, 3},
+		{"get-not-retryable", []int{404}, listPosts, ErrNotFound, 1},
+		{"post-not-retried", []int{503}, func(ctx context.Context, c *Client) error {
+			_, err := c.CreatePost(ctx, PostRequest{Title: "t", Content: "c"})
+			return err
+		}, &APIError{StatusCode: 503}, 1},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var requests atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := int(requests.Add(1))
+				if n <= len(tc.statuses) {
+					respond(w, tc.statuses[n-1], nil)
+					return
+				}
+				respond(w, http.StatusOK, []Post{})
+			}))
+			defer srv.Close()
+			c := New(srv.URL, WithRetry(2, time.Millisecond))
+			c.SetToken("token")
+
+			err := tc.call(context.Background(), c)
+			if tc.want == nil && err != nil || tc.want != nil && !errors.Is(err, tc.want) {
+				t.Errorf("返回 %v，期望 %v", err, tc.want)
+			}
+			if got := requests.Load(); got != tc.requests {
+				t.Errorf("发送了 %d 个请求，期望 %d 个", got, tc.requests)
+			}
+		})
+	}
+
+	t.Run("context-cancelled", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			respond(w, http.StatusServiceUnavailable, nil)
+		}))
+		defer srv.Close()
+		c := New(srv.URL, WithRetry(5, time.Hour))
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		if err := listPosts(ctx, c); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("返回 %v，期望 context.DeadlineExceeded", err)
+		}
+	})
+}
+
+func listPosts(ctx context.Context, c *Client) error {
+	_, err := c.ListPosts(ctx)
+	return err
+}
+
+// authServer 只接受 token 为 valid 的请求，登录成功时签发 valid
+func authServer(t *testing.T, valid string) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var logins atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case BasePath + "/auth/login":
+			logins.Add(1)
+			var req LoginRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			if req.Password != "secret" {
+				respond(w, http.StatusUnauthorized, nil)
+				return
+			}
+			respond(w, http.StatusOK, map[string]string{"token": valid})
+		default:
+			if r.Header.Get("Authorization") != "Bearer "+valid {
+				respond(w, http.StatusUnauthorized, nil)
+				return
+			}
+			respond(w, http.StatusOK, []Revision{})
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &logins
+}
+
+func TestTokenSource(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("expired", func(t *testing.T) {
+		srv, _ := authServer(t, "fresh")
+		var calls atomic.Int32
+		c := New(srv.URL, WithTokenSource(TokenSourceFunc(func(ctx context.Context) (string, error) {
+			calls.Add(1)
+			return "fresh", nil
+		})))
+		c.SetToken("expired")
+		if _, err := c.ListRevisions(ctx, 1); err != nil {
+			t.Fatal(err)
+		}
+		if calls.Load() != 1 || c.Token() != "fresh" {
+			t.Errorf("TokenSource 调用了 %d 次，token 为 %q", calls.Load(), c.Token())
+		}
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		srv, _ := authServer(t, "fresh")
+		c := New(srv.URL, WithTokenSource(TokenSourceFunc(func(ctx context.Context) (string, error) {
+			return "fresh", nil
+		})))
+		if _, err := c.ListRevisions(ctx, 1); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("source-error", func(t *testing.T) {
+		srv, _ := authServer(t, "fresh")
+		errSource := errors.New("没有可用的账号")
+		c := New(srv.URL, WithTokenSource(TokenSourceFunc(func(ctx context.Context) (string, error) {
+			return "", errSource
+		})))
+		if _, err := c.ListRevisions(ctx, 1); !errors.Is(err, errSource) {
+			t.Errorf("返回 %v，期望 %v", err, errSource)
+		}
+	})
+
+	t.Run("no-source", func(t *testing.T) {
+		srv, logins := authServer(t, "fresh")
+		c := New(srv.URL)
+		if _, err := c.Login(ctx, "alice", "secret"); err != nil {
+			t.Fatal(err)
+		}
+		// 登录后 token 过期，客户端没有保存密码，不会自动重新登录
+		c.SetToken("expired")
+		if _, err := c.ListRevisions(ctx, 1); !errors.Is(err, ErrUnauthorized) {
+			t.Errorf("返回 %v，期望 ErrUnauthorized", err)
+		}
+		if logins.Load() != 1 {
+			t.Errorf("登录了 %d 次，期望 1 次", logins.Load())
+		}
+	})
+
+	t.Run("login", func(t *testing.T) {
+		srv, logins := authServer(t, "fresh")
+		var asked atomic.Int32
+		c := New(srv.URL, WithLogin(func(ctx context.Context) (string, string, error) {
+			asked.Add(1)
+			return "alice", "secret", nil
+		}))
+		c.SetToken("expired")
+		if _, err := c.ListRevisions(ctx, 1); err != nil {
+			t.Fatal(err)
+		}
+		if asked.Load() != 1 || logins.Load() != 1 {
+			t.Errorf("获取了 %d 次账号、登录了 %d 次，期望各 1 次", asked.Load(), logins.Load())
+		}
+	})
+}
+
+// pageServer 分页返回 total 条回收站记录，fail 不为 0 时请求第 fail 页返回 500
+func pageServer(t *testing.T, total, fail int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		size, _ := strconv.Atoi(r.URL.Query().Get("page_size"))
+		if page == fail {
+			respond(w, http.StatusInternalServerError, nil)
+			return
+		}
+		posts := []TrashPost{}
+		for id := (page-1)*size + 1; id <= min(page*size, total); id++ {
+			posts = append(posts, TrashPost{ID: uint(id)})
+		}
+		respond(w, http.StatusOK, TrashPage{Posts: posts, Total: int64(total)})
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+func TestPaginate(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name     string
+		total    int
+		fail     int
+		stop     int
+		wantIDs  int
+		wantErr  bool
+		requests int32
+	}{
+		{name: "all-pages", total: 5, wantIDs: 5, requests: 3},
+		{name: "exact-pages", total: 4, wantIDs: 4, requests: 2},
+		{name: "empty", total: 0, wantIDs: 0, requests: 1},
+		{name: "break-early", total: 5, stop: 3, wantIDs: 3, requests: 2},
+		{name: "error", total: 5, fail: 2, wantIDs: 2, wantErr: true, requests: 2},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			srv, requests := pageServer(t, tc.total, tc.fail)
+			c := New(srv.URL, WithRetry(0, 0))
+			c.SetToken("token")
+
+			var ids []uint
+			var gotErr error
+			for post, err := range c.Trash(ctx, 2) {
+				if err != nil {
+					gotErr = err
+					continue
+				}
+				if post.ID != uint(len(ids)+1) {
+					t.Errorf("第 %d 条的 ID 为 %d", len(ids)+1, post.ID)
+				}
+				ids = append(ids, post.ID)
+				if len(ids) == tc.stop {
+					break
+				}
+			}
+			if len(ids) != tc.wantIDs || (gotErr != nil) != tc.wantErr {
+				t.Errorf("返回 %d 条，错误 %v", len(ids), gotErr)
+			}
+			if got := requests.Load(); got != tc.requests {
+				t.Errorf("请求了 %d 页，期望 %d 页", got, tc.requests)
+			}
+		})
+	}
+}
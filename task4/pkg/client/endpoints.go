@@ -0,0 +1,67 @@
+package client
+
+// BasePath 是 v1 接口的路径前缀，与 swagger 的 basePath 一致
+const BasePath = "/api/v1"
+
+// Endpoint 描述客户端调用的一个接口。客户端的方法只通过这张表拼接路径，
+// cmd/clientcheck 用同一张表与 docs/swagger.json 对照，接口的路径、参数或字段变化时检查失败
+type Endpoint struct {
+	Method string
+	// Path 相对于 BasePath，路径参数的写法与 swagger 相同，如 /posts/{post_id}
+	Path string
+	// Query 是客户端会发送的查询参数
+	Query []string
+	// Body 是请求体类型的零值，BodySchema 是它在 swagger definitions 中的名称，没有请求体时都为空
+	Body       interface{}
+	BodySchema string
+	// Data 是成功响应中 data 字段类型的零值，DataSchema 是它（为切片时是元素）在 definitions 中的名称，
+	// swagger 没有描述 data 时都为空
+	Data       interface{}
+	DataSchema string
+}
+
+var pageQuery = []string{"page", "page_size"}
+
+var (
+	epRegister = Endpoint{Method: "POST", Path: "/auth/register", Body: RegisterRequest{}, BodySchema: "handler.RegisterRequest"}
+	epLogin    = Endpoint{Method: "POST", Path: "/auth/login", Body: LoginRequest{}, BodySchema: "handler.LoginRequest"}
+
+	epListPosts     = Endpoint{Method: "GET", Path: "/posts", Data: []Post{}, DataSchema: "dto.PostResponse"}
+	epGetPost       = Endpoint{Method: "GET", Path: "/posts/{post_id}", Data: Post{}, DataSchema: "dto.PostResponse"}
+	epGetPostBySlug = Endpoint{Method: "GET", Path: "/posts/by-slug/{slug}", Data: Post{}, DataSchema: "dto.PostResponse"}
+	epCreatePost    = Endpoint{Method: "POST", Path: "/posts", Body: PostRequest{}, BodySchema: "handler.CreatePostRequest", Data: Post{}, DataSchema: "dto.PostResponse"}
+	epUpdatePost    = Endpoint{Method: "PUT", Path: "/posts/{post_id}", Body: PostRequest{}, BodySchema: "handler.CreatePostRequest", Data: Post{}, DataSchema: "dto.PostResponse"}
+	epDeletePost    = Endpoint{Method: "DELETE", Path: "/posts/{post_id}"}
+	epRestorePost   = Endpoint{Method: "POST", Path: "/posts/{post_id}/restore", Data: Post{}, DataSchema: "dto.PostResponse"}
+	epSetTags       = Endpoint{Method: "PUT", Path: "/posts/{post_id}/tags", Body: TagsRequest{}, BodySchema: "handler.SetTagsRequest", Data: Post{}, DataSchema: "dto.PostResponse"}
+	epTrash         = Endpoint{Method: "GET", Path: "/me/trash", Query: pageQuery, Data: TrashPage{}, DataSchema: "dto.TrashResponse"}
+
+	epListRevisions = Endpoint{Method: "GET", Path: "/posts/{post_id}/revisions", Data: []Revision{}, DataSchema: "dto.RevisionResponse"}
+	epGetRevision   = Endpoint{Method: "GET", Path: "/posts/{post_id}/revisions/{rev}", Data: Revision{}, DataSchema: "dto.RevisionResponse"}
+
+	epListComments  = Endpoint{Method: "GET", Path: "/posts/{post_id}/comments", Data: []Comment{}, DataSchema: "dto.CommentResponse"}
+	epCreateComment = Endpoint{Method: "POST", Path: "/posts/{post_id}/comments", Body: CommentRequest{}, BodySchema: "handler.CreateCommentRequest", Data: Comment{}, DataSchema: "dto.CommentResponse"}
+
+	epCreateReport    = Endpoint{Method: "POST", Path: "/reports", Body: ReportRequest{}, BodySchema: "handler.CreateReportRequest", Data: Report{}, DataSchema: "dto.ReportResponse"}
+	epModerationQueue = Endpoint{Method: "GET", Path: "/moderation/queue", Query: pageQuery, Data: ModerationPage{}, DataSchema: "dto.ModerationQueueResponse"}
+	epApproveReport   = Endpoint{Method: "POST", Path: "/moderation/reports/{report_id}/approve", Data: Report{}, DataSchema: "dto.ReportResponse"}
+	epRemoveReport    = Endpoint{Method: "POST", Path: "/moderation/reports/{report_id}/remove", Data: Report{}, DataSchema: "dto.ReportResponse"}
+	epBanReport       = Endpoint{Method: "POST", Path: "/moderation/reports/{report_id}/ban", Data: Report{}, DataSchema: "dto.ReportResponse"}
+
+	epAuditLogs = Endpoint{Method: "GET", Path: "/admin/audit",
+		Query: []string{"actor_id", "action", "target_type", "target_id", "request_id", "since", "until", "page", "page_size"},
+		Data:  AuditLogPage{}, DataSchema: "dto.AuditLogListResponse"}
+	epVerifyAudit = Endpoint{Method: "GET", Path: "/admin/audit/verify", Data: AuditVerifyResult{}, DataSchema: "service.AuditVerifyResult"}
+)
+
+// Endpoints 返回客户端调用的全部接口，供 cmd/clientcheck 检查
+func Endpoints() []Endpoint {
+	return []Endpoint{
+		epRegister, epLogin,
+		epListPosts, epGetPost, epGetPostBySlug, epCreatePost, epUpdatePost, epDeletePost, epRestorePost, epSetTags, epTrash,
+		epListRevisions, epGetRevision,
+		epListComments, epCreateComment,
+		epCreateReport, epModerationQueue, epApproveReport, epRemoveReport, epBanReport,
+		epAuditLogs, epVerifyAudit,
+	}
+}
@@ -0,0 +1,38 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// APIError 是服务端返回的错误响应，可以用 errors.Is 与 ErrNotFound 等按状态码比较
+type APIError struct {
+	StatusCode int
+	// Code、Message 取自响应体的 {"code": ..., "message": ...}
+	Code    int
+	Message string
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("blog api: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("blog api: %d %s", e.StatusCode, e.Message)
+}
+
+// Is 在状态码相同时认为两个 APIError 相等，使 errors.Is(err, client.ErrNotFound) 可用
+func (e *APIError) Is(target error) bool {
+	t, ok := target.(*APIError)
+	return ok && t.StatusCode == e.StatusCode
+}
+
+var (
+	ErrBadRequest         = &APIError{StatusCode: http.StatusBadRequest}
+	ErrUnauthorized       = &APIError{StatusCode: http.StatusUnauthorized}
+	ErrForbidden          = &APIError{StatusCode: http.StatusForbidden}
+	ErrNotFound           = &APIError{StatusCode: http.StatusNotFound}
+	ErrConflict           = &APIError{StatusCode: http.StatusConflict}
+	ErrPreconditionFailed = &APIError{StatusCode: http.StatusPreconditionFailed}
+	ErrContentRejected    = &APIError{StatusCode: http.StatusUnprocessableEntity}
+	ErrTimeout            = &APIError{StatusCode: http.StatusGatewayTimeout}
+)
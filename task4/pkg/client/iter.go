@@ -0,0 +1,67 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/url"
+	"strconv"
+)
+
+// DefaultPageSize 是分页迭代器在 pageSize 不大于 0 时使用的每页数量
+const DefaultPageSize = 20
+
+// Trash 逐条返回当前用户回收站中的帖子，按需请求下一页，出错时返回错误并结束
+func (c *Client) Trash(ctx context.Context, pageSize int) iter.Seq2[TrashPost, error] {
+	return paginate(ctx, c, epTrash, url.Values{}, pageSize, func(p TrashPage) ([]TrashPost, int64) {
+		return p.Posts, p.Total
+	})
+}
+
+// AuditLogs 逐条返回满足 filter 的审计日志
+func (c *Client) AuditLogs(ctx context.Context, filter AuditFilter, pageSize int) iter.Seq2[AuditLog, error] {
+	return paginate(ctx, c, epAuditLogs, filter.values(), pageSize, func(p AuditLogPage) ([]AuditLog, int64) {
+		return p.Logs, p.Total
+	})
+}
+
+// ModerationQueue 逐条返回审核队列中待处理的举报
+func (c *Client) ModerationQueue(ctx context.Context, pageSize int) iter.Seq2[ModerationItem, error] {
+	return paginate(ctx, c, epModerationQueue, url.Values{}, pageSize, func(p ModerationPage) ([]ModerationItem, int64) {
+		return p.Items, p.Total
+	})
+}
+
+// paginate 用 page、page_size 查询参数依次请求每一页，直到取完 total 条或某页为空
+func paginate[P, T any](ctx context.Context, c *Client, ep Endpoint, query url.Values, pageSize int, items func(P) ([]T, int64)) iter.Seq2[T, error] {
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	return func(yield func(T, error) bool) {
+		var seen int64
+		for page := 1; ; page++ {
+			q := url.Values{}
+			for k, v := range query {
+				q[k] = v
+			}
+			q.Set("page", strconv.Itoa(page))
+			q.Set("page_size", strconv.Itoa(pageSize))
+
+			var p P
+			if _, err := c.call(ctx, request{ep: ep, query: q, auth: true}, &p); err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			list, total := items(p)
+			for _, item := range list {
+				if !yield(item, nil) {
+					return
+				}
+			}
+			seen += int64(len(list))
+			if len(list) == 0 || seen >= total {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package client
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// 以下类型与 docs/swagger.json 中的同名定义一一对应，由 cmd/clientcheck 检查字段是否一致
+
+type User struct {
+	ID       uint   `json:"id"`
+	Username string `json:"username"`
+	Email    string `json:"email,omitempty"`
+	Phone    string `json:"phone,omitempty"`
+}
+
+type Post struct {
+	ID        uint      `json:"id"`
+	Title     string    `json:"title"`
+	Content   string    `json:"content"`
+	Slug      string    `json:"slug"`
+	Status    string    `json:"status"`
+	Version   uint      `json:"version"`
+	Tags      []string  `json:"tags,omitempty"`
+	UserID    uint      `json:"user_id"`
+	User      *User     `json:"user,omitempty"`
+	Comments  []Comment `json:"comments,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type Comment struct {
+	ID        uint      `json:"id"`
+	Content   string    `json:"content"`
+	Status    string    `json:"status"`
+	PostID    uint      `json:"post_id"`
+	UserID    uint      `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type Revision struct {
+	PostID    uint      `json:"post_id"`
+	Rev       uint      `json:"rev"`
+	Title     string    `json:"title"`
+	Content   string    `json:"content"`
+	EditorID  uint      `json:"editor_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type TrashPost struct {
+	ID        uint      `json:"id"`
+	Title     string    `json:"title"`
+	Slug      string    `json:"slug"`
+	CreatedAt time.Time `json:"created_at"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+type TrashPage struct {
+	Posts []TrashPost `json:"posts"`
+	Total int64       `json:"total"`
+}
+
+type Report struct {
+	ID         uint       `json:"id"`
+	ReporterID uint       `json:"reporter_id"`
+	TargetType string     `json:"target_type"`
+	TargetID   uint       `json:"target_id"`
+	Reason     string     `json:"reason"`
+	Status     string     `json:"status"`
+	HandledBy  uint       `json:"handled_by,omitempty"`
+	HandledAt  *time.Time `json:"handled_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// ModerationTarget 是被举报的内容，评论没有标题
+type ModerationTarget struct {
+	AuthorID uint   `json:"author_id"`
+	Title    string `json:"title,omitempty"`
+	Content  string `json:"content"`
+	Status   string `json:"status"`
+}
+
+// ModerationItem 是审核队列中的一条举报，Target 为 nil 表示内容已被删除
+type ModerationItem struct {
+	Report Report            `json:"report"`
+	Target *ModerationTarget `json:"target,omitempty"`
+}
+
+type ModerationPage struct {
+	Items []ModerationItem `json:"items"`
+	Total int64            `json:"total"`
+}
+
+type AuditLog struct {
+	ID         uint            `json:"id"`
+	RequestID  string          `json:"request_id"`
+	ActorID    uint            `json:"actor_id"`
+	IP         string          `json:"ip"`
+	UserAgent  string          `json:"user_agent"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"target_type"`
+	TargetID   uint            `json:"target_id"`
+	Before     json.RawMessage `json:"before,omitempty"`
+	After      json.RawMessage `json:"after,omitempty"`
+	Detail     string          `json:"detail,omitempty"`
+	Hash       string          `json:"hash,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+type AuditLogPage struct {
+	Logs  []AuditLog `json:"logs"`
+	Total int64      `json:"total"`
+}
+
+// AuditVerifyResult 是审计日志哈希链的校验结果，Valid 为 false 时 BrokenAt 是第一条校验失败的日志 ID
+type AuditVerifyResult struct {
+	Valid    bool   `json:"valid"`
+	Checked  int64  `json:"checked"`
+	BrokenAt uint   `json:"broken_at,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// AuditFilter 是查询审计日志的条件，零值的字段不参与过滤
+type AuditFilter struct {
+	ActorID    uint
+	Action     string
+	TargetType string
+	TargetID   uint
+	RequestID  string
+	Since      time.Time
+	Until      time.Time
+}
+
+type RegisterRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Email    string `json:"email"`
+	Phone    string `json:"phone,omitempty"`
+}
+
+type LoginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type PostRequest struct {
+	Title   string `json:"title"`
+	Content string `json:"content"`
+}
+
+type CommentRequest struct {
+	Content string `json:"content"`
+}
+
+type TagsRequest struct {
+	Tags []string `json:"tags"`
+}
+
+type ReportRequest struct {
+	TargetType string `json:"target_type"`
+	TargetID   uint   `json:"target_id"`
+	Reason     string `json:"reason"`
+}
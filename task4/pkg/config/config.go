@@ -1,44 +1,231 @@
+// Package config 定义服务端和 blogctl 共用的配置。
+//
+// 配置按以下顺序加载，后加载的覆盖先加载的：字段的默认值、YAML 或 TOML 配置文件、.env 文件、环境变量。
+// 配置文件中的键是字段的 yaml 标签，如 db.host、site.url；.env 和环境变量使用字段的 env 标签，如 DB_HOST。
+// APP_PROFILE 选择运行环境（dev、test、prod），未指定配置文件时读取 configs/<profile>.yaml，
+// prod 环境的校验更严格。
 package config
 
 import (
-	"fmt"
+	"time"
 
-	"github.com/caarlos0/env/v11"
-	"github.com/joho/godotenv"
 	"github.com/miffyG/golearn/task4/pkg/db"
 )
 
+const (
+	ProfileDev  = "dev"
+	ProfileTest = "test"
+	ProfileProd = "prod"
+)
+
+// Config 是全部配置，由 Load 在启动时加载一次，再通过构造函数传给需要的组件
+type Config struct {
+	// Profile 是运行环境，决定默认读取的配置文件和校验规则
+	Profile    string      `env:"APP_PROFILE" envDefault:"dev" yaml:"profile"`
+	Http       Http        `yaml:"http"`
+	Db         db.DbConfig `yaml:"db"`
+	Secret     Secret      `yaml:"secret"`
+	Jwt        Jwt         `yaml:"jwt"`
+	Api        Api         `yaml:"api"`
+	Graphql    Graphql     `yaml:"graphql"`
+	Grpc       Grpc        `yaml:"grpc"`
+	Site       Site        `yaml:"site"`
+	Trash      Trash       `yaml:"trash"`
+	Moderation Moderation  `yaml:"moderation"`
+	Audit      Audit       `yaml:"audit"`
+	Timeout    Timeout     `yaml:"timeout"`
+	Cors       Cors        `yaml:"cors"`
+	// Headers 是所有响应都会带上的安全响应头
+	Headers    Headers    `yaml:"headers"`
+	AuthCookie AuthCookie `yaml:"auth_cookie"`
+	Oidc       Oidc       `yaml:"oidc"`
+
+	// file 是实际读取的配置文件，没有读取时为空
+	file string
+}
+
+// File 返回加载时读取的配置文件路径，没有读取配置文件时返回空字符串
+func (c *Config) File() string {
+	return c.file
+}
+
+type Http struct {
+	// HTTP 服务的监听地址，端口为 0 时随机选择
+	Addr string `env:"HTTP_ADDR" envDefault:":8080" yaml:"addr"`
+	// 收到退出信号后等待进行中的请求完成的最长时间
+	ShutdownTimeout time.Duration `env:"HTTP_SHUTDOWN_TIMEOUT" envDefault:"10s" yaml:"shutdown_timeout"`
+	// 逗号分隔的反向代理 IP 或 CIDR，只有来自这些地址的请求才按 X-Forwarded-For 取客户端 IP。
+	// 为空时不信任任何代理，审计日志使用连接的对端地址
+	TrustedProxies []string `env:"HTTP_TRUSTED_PROXIES" envSeparator:"," yaml:"trusted_proxies"`
+}
+
 type Secret struct {
-	JwtSecret string `env:"JWT_SECRET" unset:"true"`
+	// HS256 的 JWT 密钥，prod 环境至少 MinProdJwtSecretLength 字节。JWT_ALGORITHM 为 HS256 时必须配置，
+	// 改用非对称签名后可以保留，并把 HS256 留在 JWT_ALLOWED_ALGORITHMS 中，让已签发的 token 在过期前继续可用
+	JwtSecret string `env:"JWT_SECRET" unset:"true" yaml:"jwt_secret" secret:"true"`
 }
 
-func LoadConfig() {
+// Jwt 是 token 的签发和校验规则。非对称签名时公钥通过 /.well-known/jwks.json 公开，
+// 其他服务可以直接校验 token。轮换密钥时把新密钥放在 JWT_KEYS 的第一个，旧密钥保留到它签发的 token 全部过期
+type Jwt struct {
+	// 签名算法：HS256、RS256 或 EdDSA
+	Algorithm string `env:"JWT_ALGORITHM" envDefault:"HS256" yaml:"algorithm"`
+	// 逗号分隔的 PEM 密钥文件，第一个必须是私钥，用于签名，其余的只用于校验，可以是公钥
+	Keys []string `env:"JWT_KEYS" envSeparator:"," yaml:"keys"`
+	// 接受的签名算法，为空时只接受 JWT_ALGORITHM，不在其中的 token 一律拒绝
+	AllowedAlgorithms []string `env:"JWT_ALLOWED_ALGORITHMS" envSeparator:"," yaml:"allowed_algorithms"`
+	// 写入并校验 token 的 iss 和 aud
+	Issuer   string `env:"JWT_ISSUER" envDefault:"golearn-blog" yaml:"issuer"`
+	Audience string `env:"JWT_AUDIENCE" envDefault:"golearn-blog-api" yaml:"audience"`
+	// token 的有效期
+	TTL time.Duration `env:"JWT_TTL" envDefault:"24h" yaml:"ttl"`
+	// 校验 exp、nbf、iat 时允许的时钟偏差
+	Leeway time.Duration `env:"JWT_LEEWAY" envDefault:"30s" yaml:"leeway"`
+	// 在此时间（RFC 3339）之前继续接受升级前用 JWT_SECRET 签发、没有 iss、aud、sub 的 HS256 token，
+	// 为空时不接受，这些 token 的用户需要重新登录
+	LegacyUntil time.Time `env:"JWT_LEGACY_UNTIL" yaml:"legacy_until"`
+}
 
-	if err := godotenv.Load(".env"); err != nil {
-		fmt.Printf("no .env loaded: %v", err)
+// Algorithms 返回接受的签名算法，未配置 JWT_ALLOWED_ALGORITHMS 时只有 JWT_ALGORITHM
+func (j *Jwt) Algorithms() []string {
+	if len(j.AllowedAlgorithms) == 0 {
+		return []string{j.Algorithm}
 	}
-	fmt.Println("加载环境变量成功")
+	return j.AllowedAlgorithms
 }
 
-func GetDbConfig() *db.DbConfig {
-	// 将环境变量映射到结构体（自动类型转换 + 默认值）
-	var cfg db.DbConfig
-	if err := env.Parse(&cfg); err != nil {
-		fmt.Printf("解析配置失败: %v\n", err)
-		return nil
-	}
+type Api struct {
+	// 弃用期内为 true 时，帖子和评论接口默认返回旧的 PascalCase 字段名
+	LegacyFieldNames bool `env:"LEGACY_FIELD_NAMES" envDefault:"false" yaml:"legacy_field_names"`
+	// v1 的弃用时间和下线时间（RFC 3339），写入 v1 响应的 Deprecation、Sunset 头
+	V1DeprecatedAt time.Time `env:"API_V1_DEPRECATED_AT" envDefault:"2026-10-19T00:00:00Z" yaml:"v1_deprecated_at"`
+	V1Sunset       time.Time `env:"API_V1_SUNSET" envDefault:"2027-04-30T00:00:00Z" yaml:"v1_sunset"`
+	// 按 docs/openapi.json 校验 v1 请求的参数和请求体，不符合时返回 400
+	ValidateRequests bool `env:"API_VALIDATE_REQUESTS" envDefault:"true" yaml:"validate_requests"`
+	// 按 docs/openapi.json 校验 v1 响应，不一致时返回 500。需要缓存完整的响应，test 环境始终开启
+	ValidateResponses bool `env:"API_VALIDATE_RESPONSES" envDefault:"false" yaml:"validate_responses"`
+	// POST /admin/import 请求体的最大字节数，超过时返回 413
+	ImportMaxBytes int64 `env:"API_IMPORT_MAX_BYTES" envDefault:"104857600" yaml:"import_max_bytes"`
+}
+
+type Graphql struct {
+	// 查询的最大嵌套深度和最大复杂度，0 表示不限制
+	MaxDepth      int `env:"GRAPHQL_MAX_DEPTH" envDefault:"8" yaml:"max_depth"`
+	MaxComplexity int `env:"GRAPHQL_MAX_COMPLEXITY" envDefault:"1000" yaml:"max_complexity"`
+}
+
+type Grpc struct {
+	// gRPC 服务的监听地址，为空时不启动
+	Addr string `env:"GRPC_ADDR" envDefault:":9090" yaml:"addr"`
+}
 
-	fmt.Printf("数据库host：%s, 端口：%s, 用户：%s, 密码：%s, 数据库名：%s\n",
-		cfg.DBHost, cfg.DBPort, cfg.DBUser, cfg.DBPassword, cfg.DBName)
+type Site struct {
+	// 站点的对外地址，用于生成订阅源等处的绝对链接
+	URL      string `env:"SITE_URL" envDefault:"http://localhost:8080" yaml:"url"`
+	Title    string `env:"SITE_TITLE" envDefault:"golearn 博客" yaml:"title"`
+	FeedSize int    `env:"FEED_SIZE" envDefault:"20" yaml:"feed_size"`
+	// 单个 sitemap 文件最多列出的文章数，超过时 /sitemap.xml 改为索引文件，协议允许的最大值为 50000
+	SitemapPageSize int `env:"SITEMAP_PAGE_SIZE" envDefault:"50000" yaml:"sitemap_page_size"`
+}
+
+type Trash struct {
+	// 已删除的文章和评论在回收站中保留的时长，超过后由清理任务彻底删除
+	Retention time.Duration `env:"TRASH_RETENTION" envDefault:"720h" yaml:"retention"`
+	// 服务内清理任务的执行间隔，0 表示不运行，可以改用 blogctl post purge 定时清理
+	PurgeInterval time.Duration `env:"TRASH_PURGE_INTERVAL" envDefault:"1h" yaml:"purge_interval"`
+}
+
+type Moderation struct {
+	// 逗号分隔的屏蔽词，不区分大小写
+	BlockWords []string `env:"MODERATION_BLOCK_WORDS" envSeparator:"," yaml:"block_words"`
+	// 屏蔽词文件，每行一条，以 re: 开头的行按正则表达式匹配
+	BlocklistFile string `env:"MODERATION_BLOCKLIST_FILE" yaml:"blocklist_file"`
+	// 命中屏蔽词时的处理方式：hold 转人工审核，reject 直接拒绝
+	BlockAction string `env:"MODERATION_BLOCK_ACTION" envDefault:"hold" yaml:"block_action"`
+	// 单条内容最多允许的链接数，超过时转人工审核，0 表示不检查
+	MaxLinks int `env:"MODERATION_MAX_LINKS" envDefault:"3" yaml:"max_links"`
+}
+
+type Audit struct {
+	// 开启后每条审计日志都记录上一条的哈希，可以用 blogctl audit verify 检查日志是否被篡改
+	HashChain bool `env:"AUDIT_HASH_CHAIN" envDefault:"false" yaml:"hash_chain"`
+}
+
+type Timeout struct {
+	// 请求的默认超时时间，超时后数据库查询被取消并返回 504，0 表示不限制
+	Default time.Duration `env:"REQUEST_TIMEOUT" envDefault:"10s" yaml:"default"`
+	// 按路由覆盖默认值，格式为 "方法 路由=时长"，多条用逗号分隔，路由使用注册时的模板，
+	// 如 "GET /api/v1/posts/:post_id=3s"。默认不限制导出和导入，设置后会替换默认值，需要时一并写上。
+	// 配置文件中写成以 "方法 路由" 为键的映射
+	Routes map[string]time.Duration `env:"REQUEST_TIMEOUT_ROUTES" envSeparator:"," envKeyValSeparator:"=" envDefault:"GET /api/v1/admin/export=0,POST /api/v1/admin/import=0" yaml:"routes"`
+}
+
+type Cors struct {
+	// 允许跨域访问的来源，如 https://app.example.com，* 表示任意来源，为空时不处理跨域请求
+	AllowedOrigins []string `env:"CORS_ALLOWED_ORIGINS" envSeparator:"," yaml:"allowed_origins"`
+	AllowedMethods []string `env:"CORS_ALLOWED_METHODS" envSeparator:"," envDefault:"GET,POST,PUT,PATCH,DELETE" yaml:"allowed_methods"`
+	AllowedHeaders []string `env:"CORS_ALLOWED_HEADERS" envSeparator:"," envDefault:"Authorization,Content-Type,If-Match,X-Request-ID,X-CSRF-Token,X-API-Compat" yaml:"allowed_headers"`
+	// 允许前端读取的响应头
+	ExposedHeaders []string `env:"CORS_EXPOSED_HEADERS" envSeparator:"," envDefault:"ETag,X-Request-ID,Deprecation,Sunset,Link,Warning" yaml:"exposed_headers"`
+	// 为 true 时允许跨域请求携带 cookie，此时 CORS_ALLOWED_ORIGINS 不能包含 *
+	AllowCredentials bool `env:"CORS_ALLOW_CREDENTIALS" envDefault:"false" yaml:"allow_credentials"`
+	// 浏览器缓存预检请求结果的时长
+	MaxAge time.Duration `env:"CORS_MAX_AGE" envDefault:"10m" yaml:"max_age"`
+}
+
+type Headers struct {
+	// Strict-Transport-Security 的 max-age，只在 HTTPS 请求（含 X-Forwarded-Proto: https）的响应中发送，0 表示不发送
+	HSTSMaxAge            time.Duration `env:"HEADERS_HSTS_MAX_AGE" envDefault:"8760h" yaml:"hsts_max_age"`
+	HSTSIncludeSubdomains bool          `env:"HEADERS_HSTS_INCLUDE_SUBDOMAINS" envDefault:"false" yaml:"hsts_include_subdomains"`
+	// Content-Security-Policy，接口只返回数据，默认禁止加载任何资源；swagger 页面使用单独的策略
+	CSP string `env:"HEADERS_CSP" envDefault:"default-src 'none'; frame-ancestors 'none'" yaml:"csp"`
+	// X-Frame-Options，为空时不发送
+	FrameOptions   string `env:"HEADERS_FRAME_OPTIONS" envDefault:"DENY" yaml:"frame_options"`
+	ReferrerPolicy string `env:"HEADERS_REFERRER_POLICY" envDefault:"no-referrer" yaml:"referrer_policy"`
+}
+
+// AuthCookie 是 Authorization 头之外的另一种认证方式：登录时把 token 写入 HttpOnly cookie，
+// 并下发 CSRF token，携带 cookie 的写请求必须在请求头中带上相同的 CSRF token（双重提交）。
+// 前端与接口不同源时需要 AUTH_COOKIE_SAMESITE=none，并开启 CORS_ALLOW_CREDENTIALS
+type AuthCookie struct {
+	Enabled bool   `env:"AUTH_COOKIE_ENABLED" envDefault:"false" yaml:"enabled"`
+	Name    string `env:"AUTH_COOKIE_NAME" envDefault:"blog_token" yaml:"name"`
+	// CSRF token 的 cookie 名称和请求头名称
+	CSRFName   string `env:"AUTH_CSRF_COOKIE_NAME" envDefault:"blog_csrf" yaml:"csrf_name"`
+	CSRFHeader string `env:"AUTH_CSRF_HEADER" envDefault:"X-CSRF-Token" yaml:"csrf_header"`
+	Domain     string `env:"AUTH_COOKIE_DOMAIN" yaml:"domain"`
+	// 为 true 时 cookie 只通过 HTTPS 发送，prod 环境必须开启
+	Secure bool `env:"AUTH_COOKIE_SECURE" envDefault:"true" yaml:"secure"`
+	// SameSite 属性：lax、strict 或 none，none 要求 AUTH_COOKIE_SECURE=true
+	SameSite string `env:"AUTH_COOKIE_SAMESITE" envDefault:"lax" yaml:"same_site"`
+	// cookie 的有效期，与 token 的有效期一致
+	MaxAge time.Duration `env:"AUTH_COOKIE_MAX_AGE" envDefault:"24h" yaml:"max_age"`
+}
 
-	return &cfg
+// Oidc 是第三方登录（OpenID Connect）的提供方。以提供方名称为键，在提供方处登记的回调地址为
+// SITE_URL/auth/oidc/<名称>/callback，登录流程见 internal/oidc
+type Oidc struct {
+	// 提供方的 issuer 地址，格式为 "名称=issuer"，多个用逗号分隔，如 "google=https://accounts.google.com"，为空时不开启
+	Providers map[string]string `env:"OIDC_PROVIDERS" envSeparator:"," envKeyValSeparator:"=" yaml:"providers"`
+	// 各提供方分配的 client ID 和 client secret，格式同上。没有 client secret 时作为公开客户端，只依靠 PKCE
+	ClientIDs     map[string]string `env:"OIDC_CLIENT_IDS" envSeparator:"," envKeyValSeparator:"=" yaml:"client_ids"`
+	ClientSecrets map[string]string `env:"OIDC_CLIENT_SECRETS" envSeparator:"," envKeyValSeparator:"=" yaml:"client_secrets" secret:"true"`
+	// 向提供方申请的 scope，必须包含 openid
+	Scopes []string `env:"OIDC_SCOPES" envSeparator:"," envDefault:"openid,email,profile" yaml:"scopes"`
+	// 从跳转到提供方到回调完成允许的最长时间
+	FlowTTL time.Duration `env:"OIDC_FLOW_TTL" envDefault:"10m" yaml:"flow_ttl"`
+	// 登录成功后跳转的前端地址，只在开启 AUTH_COOKIE_ENABLED 时使用，token 已写入 cookie；为空时回调返回 JSON
+	SuccessURL string `env:"OIDC_SUCCESS_URL" yaml:"success_url"`
 }
 
-func GetSecretConfig() *Secret {
-	var secret Secret
-	if err := env.Parse(&secret); err != nil {
-		fmt.Printf("解析JWT密钥失败: %v\n", err)
-		return nil
+// Load 加载并校验配置
+func Load(opts Options) (*Config, error) {
+	cfg, err := Parse(opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
 	}
-	return &secret
+	return cfg, nil
 }
@@ -0,0 +1,340 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// isolate 切换到一个空的临时目录，并清除全部配置项对应的环境变量，测试结束后恢复。
+// 返回临时目录，Parse 按相对路径查找的 configs 目录和 .env 都在其中
+func isolate(t *testing.T) string {
+	t.Helper()
+	for _, f := range fieldsOf() {
+		unsetenv(t, f.Env)
+	}
+	unsetenv(t, "CONFIG_FILE")
+
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	return dir
+}
+
+// unsetenv 删除环境变量，t.Setenv 负责在测试结束后恢复原来的值
+func unsetenv(t *testing.T, key string) {
+	t.Helper()
+	t.Setenv(key, "")
+	os.Unsetenv(key)
+}
+
+// writeFiles 在当前目录下写入文件，键是相对路径
+func writeFiles(t *testing.T, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// TestParsePrecedence 检查同一个配置项在默认值、配置文件、.env、环境变量中依次覆盖
+func TestParsePrecedence(t *testing.T) {
+	const file = "db:\n  host: file-host\n"
+	tests := []struct {
+		name   string
+		file   string
+		dotenv string
+		env    string
+		want   string
+	}{
+		{name: "default", want: "localhost"},
+		{name: "file", file: file, want: "file-host"},
+		{name: "dotenv-over-file", file: file, dotenv: "DB_HOST=dotenv-host\n", want: "dotenv-host"},
+		{name: "env-over-dotenv", file: file, dotenv: "DB_HOST=dotenv-host\n", env: "env-host", want: "env-host"},
+		{name: "env-over-file", file: file, env: "env-host", want: "env-host"},
+		{name: "dotenv-without-file", dotenv: "DB_HOST=dotenv-host\n", want: "dotenv-host"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			isolate(t)
+			opts := Options{Profile: ProfileDev}
+			if tc.file != "" {
+				writeFiles(t, map[string]string{"app.yaml": tc.file})
+				opts.File = "app.yaml"
+			}
+			if tc.dotenv != "" {
+				writeFiles(t, map[string]string{".env": tc.dotenv})
+			}
+			if tc.env != "" {
+				t.Setenv("DB_HOST", tc.env)
+			}
+			cfg, err := Parse(opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Db.DBHost != tc.want {
+				t.Errorf("DB_HOST 为 %q，期望 %q", cfg.Db.DBHost, tc.want)
+			}
+			// 其他配置项不受影响，仍为默认值
+			if cfg.Db.DBPort != "3306" {
+				t.Errorf("DB_PORT 为 %q，期望默认值 3306", cfg.Db.DBPort)
+			}
+		})
+	}
+}
+
+// TestParseFileFormats 检查 YAML 和 TOML 配置文件中的列表、映射和时长与环境变量的写法得到相同的结果
+func TestParseFileFormats(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{"yaml", "app.yaml", `
+cors:
+  allowed_origins: [https://a.example.com, https://b.example.com]
+timeout:
+  default: 3s
+  routes:
+    GET /api/v1/posts: 1s
+    POST /api/v1/admin/import: 0s
+`},
+		{"toml", "app.toml", `
+[cors]
+allowed_origins = ["https://a.example.com", "https://b.example.com"]
+[timeout]
+default = "3s"
+[timeout.routes]
+"GET /api/v1/posts" = "1s"
+"POST /api/v1/admin/import" = "0s"
+`},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			isolate(t)
+			writeFiles(t, map[string]string{tc.file: tc.content})
+			cfg, err := Parse(Options{Profile: ProfileDev, File: tc.file})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := []string{"https://a.example.com", "https://b.example.com"}; !slices.Equal(cfg.Cors.AllowedOrigins, want) {
+				t.Errorf("CORS_ALLOWED_ORIGINS 为 %v，期望 %v", cfg.Cors.AllowedOrigins, want)
+			}
+			if cfg.Timeout.Default != 3*time.Second {
+				t.Errorf("REQUEST_TIMEOUT 为 %v，期望 3s", cfg.Timeout.Default)
+			}
+			want := map[string]time.Duration{"GET /api/v1/posts": time.Second, "POST /api/v1/admin/import": 0}
+			if len(cfg.Timeout.Routes) != len(want) {
+				t.Errorf("REQUEST_TIMEOUT_ROUTES 为 %v，期望 %v", cfg.Timeout.Routes, want)
+			}
+			for route, d := range want {
+				if got, ok := cfg.Timeout.Routes[route]; !ok || got != d {
+					t.Errorf("REQUEST_TIMEOUT_ROUTES 中 %s 为 %v，期望 %v", route, got, d)
+				}
+			}
+		})
+	}
+}
+
+// TestProfileFile 检查未指定配置文件时按 profile 选择 configs 下的文件，以及 profile 和配置文件的指定方式的优先级
+func TestProfileFile(t *testing.T) {
+	files := map[string]string{
+		"configs/test.yaml": "site:\n  title: test-yaml\n",
+		"configs/test.toml": "[site]\ntitle = \"test-toml\"\n",
+		"configs/prod.toml": "[site]\ntitle = \"prod-toml\"\n",
+		"other.yaml":        "site:\n  title: other\n",
+	}
+	tests := []struct {
+		name        string
+		opts        Options
+		dotenv      string
+		env         map[string]string
+		wantProfile string
+		wantFile    string
+		wantTitle   string
+	}{
+		{
+			name: "no-profile-file", opts: Options{Profile: ProfileDev},
+			wantProfile: ProfileDev, wantFile: "", wantTitle: "golearn 博客",
+		},
+		{
+			// 同名的 .yaml 和 .toml 都存在时优先读取 .yaml
+			name: "yaml-before-toml", opts: Options{Profile: ProfileTest},
+			wantProfile: ProfileTest, wantFile: "configs/test.yaml", wantTitle: "test-yaml",
+		},
+		{
+			name: "profile-from-dotenv", dotenv: "APP_PROFILE=prod\n",
+			wantProfile: ProfileProd, wantFile: "configs/prod.toml", wantTitle: "prod-toml",
+		},
+		{
+			name: "env-profile-over-dotenv", dotenv: "APP_PROFILE=prod\n", env: map[string]string{"APP_PROFILE": ProfileTest},
+			wantProfile: ProfileTest, wantFile: "configs/test.yaml", wantTitle: "test-yaml",
+		},
+		{
+			name: "option-profile-over-env", opts: Options{Profile: ProfileTest}, env: map[string]string{"APP_PROFILE": ProfileProd},
+			wantProfile: ProfileTest, wantFile: "configs/test.yaml", wantTitle: "test-yaml",
+		},
+		{
+			name: "config-file-env", opts: Options{Profile: ProfileTest}, env: map[string]string{"CONFIG_FILE": "other.yaml"},
+			wantProfile: ProfileTest, wantFile: "other.yaml", wantTitle: "other",
+		},
+		{
+			name: "file-option-over-env", opts: Options{Profile: ProfileTest, File: "other.yaml"}, env: map[string]string{"CONFIG_FILE": "configs/prod.toml"},
+			wantProfile: ProfileTest, wantFile: "other.yaml", wantTitle: "other",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			isolate(t)
+			writeFiles(t, files)
+			if tc.dotenv != "" {
+				writeFiles(t, map[string]string{".env": tc.dotenv})
+			}
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+			cfg, err := Parse(tc.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Profile != tc.wantProfile || cfg.File() != filepath.FromSlash(tc.wantFile) || cfg.Site.Title != tc.wantTitle {
+				t.Errorf("profile %q，配置文件 %q，标题 %q；期望 %q、%q、%q",
+					cfg.Profile, cfg.File(), cfg.Site.Title, tc.wantProfile, tc.wantFile, tc.wantTitle)
+			}
+		})
+	}
+}
+
+// TestUnknownKeys 检查配置文件中拼写错误或不存在的配置项导致加载失败
+func TestUnknownKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		// want 为空表示应当加载成功
+		want string
+	}{
+		{"known", "app.yaml", "profile: dev\ndb:\n  host: db\n", ""},
+		{"unknown-section-key", "app.yaml", "db:\n  hots: db\n", "db.hots"},
+		{"unknown-top-level", "app.yaml", "sitee:\n  url: http://example.com\n", "sitee.url"},
+		{"unknown-scalar", "app.yaml", "debug: true\n", "debug"},
+		{"sorted", "app.yaml", "site:\n  titel: x\ndb:\n  hots: db\n", "db.hots, site.titel"},
+		{"unknown-toml", "app.toml", "[site]\ntitel = \"x\"\n", "site.titel"},
+		{"unsupported-format", "app.json", "{}", "不支持的配置文件格式"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			isolate(t)
+			writeFiles(t, map[string]string{tc.file: tc.content})
+			_, err := Parse(Options{Profile: ProfileDev, File: tc.file})
+			if tc.want == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("返回 %v，期望包含 %q", err, tc.want)
+			}
+		})
+	}
+}
+
+// TestValidateJwtSecret 检查 prod 环境 JWT_SECRET 的最小长度，其他环境不限制长度
+func TestValidateJwtSecret(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile string
+		secret  string
+		want    string
+	}{
+		{"prod-too-short", ProfileProd, strings.Repeat("x", MinProdJwtSecretLength-1), "prod 环境的 JWT_SECRET 至少需要 32 字节"},
+		{"prod-minimum", ProfileProd, strings.Repeat("x", MinProdJwtSecretLength), ""},
+		{"prod-missing", ProfileProd, "", "未配置 JWT_SECRET"},
+		{"dev-short", ProfileDev, "short", ""},
+		{"test-short", ProfileTest, "short", ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := Default(tc.profile)
+			cfg.Db.DBPassword = "db-password"
+			cfg.Secret.JwtSecret = tc.secret
+			err := cfg.Validate()
+			if tc.want == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("返回 %v，期望包含 %q", err, tc.want)
+			}
+		})
+	}
+}
+
+// TestRedaction 检查 Entries、String、WriteEnv 和 WriteYAML 的输出中不出现密钥，未配置的密钥保持为空
+func TestRedaction(t *testing.T) {
+	cfg := Default(ProfileDev)
+	cfg.Secret.JwtSecret = "jwt-secret-value"
+	cfg.Db.Replicas = []string{"reader:replica-secret-value@tcp(replica:3306)/blog"}
+	cfg.Oidc.Providers = map[string]string{"google": "https://accounts.google.com"}
+	cfg.Oidc.ClientIDs = map[string]string{"google": "public-client-id"}
+	cfg.Oidc.ClientSecrets = map[string]string{"google": "oidc-secret-value"}
+	secrets := []string{"jwt-secret-value", "replica-secret-value", "oidc-secret-value"}
+
+	entries := map[string]string{}
+	for _, e := range cfg.Entries() {
+		entries[e.Env] = e.Value
+	}
+	for env, want := range map[string]string{
+		"JWT_SECRET":          Redacted,
+		"DB_REPLICAS":         Redacted,
+		"OIDC_CLIENT_SECRETS": Redacted,
+		// 未配置的密钥输出为空，可以看出没有配置
+		"DB_PASSWORD": "",
+		// 不是密钥的配置项原样输出
+		"OIDC_CLIENT_IDS": "google=public-client-id",
+	} {
+		if entries[env] != want {
+			t.Errorf("Entries 中 %s 为 %q，期望 %q", env, entries[env], want)
+		}
+	}
+
+	var env, yml bytes.Buffer
+	if err := cfg.WriteEnv(&env); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.WriteYAML(&yml); err != nil {
+		t.Fatal(err)
+	}
+	outputs := map[string]string{
+		"String":    cfg.String(),
+		"WriteEnv":  env.String(),
+		"WriteYAML": yml.String(),
+	}
+	for name, out := range outputs {
+		for _, secret := range secrets {
+			if strings.Contains(out, secret) {
+				t.Errorf("%s 的输出包含密钥 %q", name, secret)
+			}
+		}
+		if !strings.Contains(out, Redacted) {
+			t.Errorf("%s 的输出中没有 %s", name, Redacted)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Redacted 是打印配置时代替密钥的占位符
+const Redacted = "******"
+
+// Entry 是一个配置项及其当前值，密钥类配置项的值已替换为 Redacted
+type Entry struct {
+	Key   string `json:"key"`
+	Env   string `json:"env"`
+	Value string `json:"value"`
+}
+
+// Entries 按声明顺序返回全部配置项，值使用环境变量的写法，可以直接写回 .env 或配置文件
+func (c *Config) Entries() []Entry {
+	v := reflect.ValueOf(c).Elem()
+	fields := fieldsOf()
+	entries := make([]Entry, len(fields))
+	for i, f := range fields {
+		value := formatValue(v.FieldByIndex(f.index), f)
+		if f.Secret && value != "" {
+			value = Redacted
+		}
+		entries[i] = Entry{Key: f.Key, Env: f.Env, Value: value}
+	}
+	return entries
+}
+
+func formatValue(v reflect.Value, f field) string {
+	switch x := v.Interface().(type) {
+	case time.Duration:
+		return x.String()
+	case time.Time:
+		// 未设置的时间输出为空，重新读取时仍为零值
+		if x.IsZero() {
+			return ""
+		}
+		return x.Format(time.RFC3339)
+	}
+	switch v.Kind() {
+	case reflect.Slice:
+		parts := make([]string, v.Len())
+		for i := range parts {
+			parts[i] = formatValue(v.Index(i), f)
+		}
+		return strings.Join(parts, f.sep)
+	case reflect.Map:
+		parts := make([]string, 0, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			parts = append(parts, formatValue(iter.Key(), f)+f.kvSep+formatValue(iter.Value(), f))
+		}
+		sort.Strings(parts)
+		return strings.Join(parts, f.sep)
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// String 返回隐去密钥的单行摘要，用于启动日志
+func (c *Config) String() string {
+	parts := []string{"profile=" + c.Profile}
+	if c.file != "" {
+		parts = append(parts, "file="+c.file)
+	}
+	for _, e := range c.Entries() {
+		if e.Key == "profile" {
+			continue
+		}
+		parts = append(parts, e.Key+"="+e.Value)
+	}
+	return strings.Join(parts, " ")
+}
+
+// WriteEnv 以 .env 的格式输出配置，密钥被隐去
+func (c *Config) WriteEnv(w io.Writer) error {
+	for _, e := range c.Entries() {
+		value := e.Value
+		if value == "" || strings.ContainsAny(value, " #\"'\\") {
+			value = strconv.Quote(value)
+		}
+		if _, err := fmt.Fprintf(w, "%s=%s\n", e.Env, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteYAML 以配置文件的格式输出配置，密钥被隐去，列表和映射保留为环境变量的写法
+func (c *Config) WriteYAML(w io.Writer) error {
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	sections := map[string]*yaml.Node{}
+	for _, e := range c.Entries() {
+		parent := root
+		key := e.Key
+		if section, sub, ok := strings.Cut(e.Key, "."); ok {
+			if sections[section] == nil {
+				sections[section] = &yaml.Node{Kind: yaml.MappingNode}
+				root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: section}, sections[section])
+			}
+			parent, key = sections[section], sub
+		}
+		parent.Content = append(parent.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Value: key},
+			&yaml.Node{Kind: yaml.ScalarNode, Value: e.Value},
+		)
+	}
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(root); err != nil {
+		return err
+	}
+	return enc.Close()
+}
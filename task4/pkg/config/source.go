@@ -0,0 +1,255 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/caarlos0/env/v11"
+	"github.com/joho/godotenv"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Options 指定配置的来源，字段为空时使用括号中的默认值
+type Options struct {
+	// Profile 覆盖环境变量和 .env 中的 APP_PROFILE（dev）
+	Profile string
+	// File 是 YAML 或 TOML 配置文件，按扩展名判断格式
+	// （CONFIG_FILE，仍为空时依次尝试 configs/<profile>.yaml、.yml、.toml，都不存在时不读取）
+	File string
+	// EnvFile 是 .env 文件，文件不存在时忽略（.env）
+	EnvFile string
+}
+
+// ConfigDir 是按 profile 查找配置文件的目录
+const ConfigDir = "configs"
+
+// Parse 按默认值、配置文件、.env、环境变量的顺序读取配置，不做校验
+func Parse(opts Options) (*Config, error) {
+	envFile := opts.EnvFile
+	if envFile == "" {
+		envFile = ".env"
+	}
+	dotenv, err := godotenv.Read(envFile)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("读取 %s 失败: %w", envFile, err)
+		}
+		dotenv = map[string]string{}
+	}
+	environ := map[string]string{}
+	for _, kv := range os.Environ() {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			environ[k] = v
+		}
+	}
+	lookup := func(key string) string {
+		if v, ok := environ[key]; ok {
+			return v
+		}
+		return dotenv[key]
+	}
+
+	profile := opts.Profile
+	if profile == "" {
+		profile = lookup("APP_PROFILE")
+	}
+	if profile == "" {
+		profile = ProfileDev
+	}
+	file := opts.File
+	if file == "" {
+		file = lookup("CONFIG_FILE")
+	}
+	if file == "" {
+		file = profileFile(profile)
+	}
+
+	merged := map[string]string{}
+	if file != "" {
+		values, err := readFile(file)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range values {
+			merged[k] = v
+		}
+	}
+	for k, v := range dotenv {
+		merged[k] = v
+	}
+	for k, v := range environ {
+		merged[k] = v
+	}
+	if opts.Profile != "" {
+		merged["APP_PROFILE"] = opts.Profile
+	}
+
+	cfg := &Config{file: file}
+	if err := env.ParseWithOptions(cfg, env.Options{Environment: merged}); err != nil {
+		return nil, fmt.Errorf("解析配置失败: %w", err)
+	}
+	cfg.Site.URL = strings.TrimRight(cfg.Site.URL, "/")
+	return cfg, nil
+}
+
+// Default 返回只包含字段默认值的配置，不读取配置文件、.env 和环境变量，不做校验，供测试使用
+func Default(profile string) *Config {
+	cfg := &Config{}
+	if err := env.ParseWithOptions(cfg, env.Options{Environment: map[string]string{"APP_PROFILE": profile}}); err != nil {
+		// 默认值写在结构体标签中，解析失败属于程序错误
+		panic(fmt.Errorf("config: 解析默认配置失败: %w", err))
+	}
+	return cfg
+}
+
+// profileFile 返回 configs 目录下与 profile 同名的配置文件，不存在时返回空字符串
+func profileFile(profile string) string {
+	for _, ext := range []string{".yaml", ".yml", ".toml"} {
+		path := filepath.Join(ConfigDir, profile+ext)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// readFile 读取配置文件并转换为环境变量的形式，文件中出现未知的键时返回错误，避免拼写错误的配置被静默忽略
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取配置文件失败: %w", err)
+	}
+	raw := map[string]interface{}{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	default:
+		return nil, fmt.Errorf("不支持的配置文件格式: %s", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("解析配置文件 %s 失败: %w", path, err)
+	}
+
+	fields := map[string]field{}
+	for _, f := range fieldsOf() {
+		fields[f.Key] = f
+	}
+	values := map[string]string{}
+	var unknown []string
+	for key, v := range raw {
+		if section, ok := v.(map[string]interface{}); ok {
+			if _, isField := fields[key]; !isField {
+				for sub, sv := range section {
+					if f, ok := fields[key+"."+sub]; ok {
+						values[f.Env] = fileValue(sv, f)
+					} else {
+						unknown = append(unknown, key+"."+sub)
+					}
+				}
+				continue
+			}
+		}
+		if f, ok := fields[key]; ok {
+			values[f.Env] = fileValue(v, f)
+		} else {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("配置文件 %s 中有未知的配置项: %s", path, strings.Join(unknown, ", "))
+	}
+	return values, nil
+}
+
+// fileValue 把配置文件中的值转换为环境变量的写法，列表和映射按字段的 envSeparator、envKeyValSeparator 拼接
+func fileValue(v interface{}, f field) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case []interface{}:
+		parts := make([]string, len(v))
+		for i, item := range v {
+			parts[i] = fileValue(item, f)
+		}
+		return strings.Join(parts, f.sep)
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		parts := make([]string, len(keys))
+		for i, k := range keys {
+			parts[i] = k + f.kvSep + fileValue(v[k], f)
+		}
+		return strings.Join(parts, f.sep)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	}
+	return fmt.Sprint(v)
+}
+
+// field 描述一个配置项，Key 是配置文件中的键，Env 是环境变量名
+type field struct {
+	Key    string
+	Env    string
+	Secret bool
+	sep    string
+	kvSep  string
+	index  []int
+}
+
+// fieldsOf 按声明顺序列出 Config 中的全部配置项，只展开一层嵌套的结构体
+func fieldsOf() []field {
+	var fields []field
+	t := reflect.TypeOf(Config{})
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		if sf.Type.Kind() == reflect.Struct && sf.Tag.Get("env") == "" {
+			for j := 0; j < sf.Type.NumField(); j++ {
+				sub := sf.Type.Field(j)
+				if sub.Tag.Get("env") == "" {
+					continue
+				}
+				fields = append(fields, newField(sf.Tag.Get("yaml")+"."+sub.Tag.Get("yaml"), sub, []int{i, j}))
+			}
+			continue
+		}
+		if sf.Tag.Get("env") != "" {
+			fields = append(fields, newField(sf.Tag.Get("yaml"), sf, []int{i}))
+		}
+	}
+	return fields
+}
+
+func newField(key string, sf reflect.StructField, index []int) field {
+	f := field{
+		Key:    key,
+		Env:    strings.Split(sf.Tag.Get("env"), ",")[0],
+		Secret: sf.Tag.Get("secret") == "true",
+		sep:    sf.Tag.Get("envSeparator"),
+		kvSep:  sf.Tag.Get("envKeyValSeparator"),
+		index:  index,
+	}
+	if f.sep == "" {
+		f.sep = ","
+	}
+	if f.kvSep == "" {
+		f.kvSep = ":"
+	}
+	return f
+}
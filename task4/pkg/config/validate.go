@@ -0,0 +1,228 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/miffyG/golearn/task4/pkg/db"
+)
+
+// MinProdJwtSecretLength 是 prod 环境 JWT 密钥的最小字节数，HS256 的密钥不应短于哈希输出的长度
+const MinProdJwtSecretLength = 32
+
+// Validate 检查配置是否可以启动服务，返回的错误包含全部不合法的配置项
+func (c *Config) Validate() error {
+	var errs []error
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	prod := c.Profile == ProfileProd
+	switch c.Profile {
+	case ProfileDev, ProfileTest, ProfileProd:
+	default:
+		fail("APP_PROFILE 只能是 %s、%s 或 %s，当前为 %q", ProfileDev, ProfileTest, ProfileProd, c.Profile)
+	}
+
+	c.validateJwt(fail)
+
+	for _, f := range []struct{ env, value string }{
+		{"DB_HOST", c.Db.DBHost}, {"DB_PORT", c.Db.DBPort}, {"DB_USER", c.Db.DBUser}, {"DB_NAME", c.Db.DBName},
+	} {
+		if f.value == "" {
+			fail("%s 不能为空", f.env)
+		}
+	}
+	if prod && c.Db.DBPassword == "" {
+		fail("prod 环境必须配置 DB_PASSWORD")
+	}
+	if c.Db.MaxOpenConns < 0 || c.Db.MaxIdleConns < 0 || c.Db.ConnectRetries < 0 {
+		fail("DB_MAX_OPEN_CONNS、DB_MAX_IDLE_CONNS 和 DB_CONNECT_RETRIES 不能为负数")
+	}
+	if c.Db.MaxOpenConns > 0 && c.Db.MaxIdleConns > c.Db.MaxOpenConns {
+		fail("DB_MAX_IDLE_CONNS 不能大于 DB_MAX_OPEN_CONNS")
+	}
+	if _, err := db.ParseLogLevel(c.Db.LogLevel); err != nil {
+		fail("DB_LOG_LEVEL 只能是 silent、error、warn 或 info，当前为 %q", c.Db.LogLevel)
+	}
+
+	for _, proxy := range c.Http.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			fail("HTTP_TRUSTED_PROXIES 只能包含 IP 或 CIDR，%q 不合法", proxy)
+		}
+	}
+
+	if u, err := url.Parse(c.Site.URL); err != nil || u.Scheme == "" || u.Host == "" {
+		fail("SITE_URL 必须是包含协议和主机的绝对地址，当前为 %q", c.Site.URL)
+	}
+	if c.Site.FeedSize <= 0 {
+		fail("FEED_SIZE 必须大于 0")
+	}
+	if c.Site.SitemapPageSize <= 0 || c.Site.SitemapPageSize > 50000 {
+		fail("SITEMAP_PAGE_SIZE 必须在 1 到 50000 之间")
+	}
+	if !c.Api.V1Sunset.After(c.Api.V1DeprecatedAt) {
+		fail("API_V1_SUNSET 必须晚于 API_V1_DEPRECATED_AT")
+	}
+	if c.Api.ImportMaxBytes <= 0 {
+		fail("API_IMPORT_MAX_BYTES 必须大于 0")
+	}
+	if c.Graphql.MaxDepth < 0 || c.Graphql.MaxComplexity < 0 {
+		fail("GRAPHQL_MAX_DEPTH 和 GRAPHQL_MAX_COMPLEXITY 不能为负数")
+	}
+
+	switch c.Moderation.BlockAction {
+	case "hold", "reject":
+	default:
+		fail("MODERATION_BLOCK_ACTION 只能是 hold 或 reject，当前为 %q", c.Moderation.BlockAction)
+	}
+	if c.Moderation.MaxLinks < 0 {
+		fail("MODERATION_MAX_LINKS 不能为负数")
+	}
+
+	for _, f := range []struct {
+		env   string
+		value time.Duration
+	}{
+		{"DB_CONN_MAX_LIFETIME", c.Db.ConnMaxLifetime}, {"DB_CONN_MAX_IDLE_TIME", c.Db.ConnMaxIdleTime},
+		{"DB_CONNECT_BACKOFF", c.Db.ConnectBackoff}, {"DB_SLOW_THRESHOLD", c.Db.SlowThreshold},
+		{"DB_READ_YOUR_WRITES_WINDOW", c.Db.ReadYourWritesWindow},
+		{"HTTP_SHUTDOWN_TIMEOUT", c.Http.ShutdownTimeout},
+		{"TRASH_RETENTION", c.Trash.Retention}, {"TRASH_PURGE_INTERVAL", c.Trash.PurgeInterval}, {"REQUEST_TIMEOUT", c.Timeout.Default},
+		{"JWT_LEEWAY", c.Jwt.Leeway}, {"CORS_MAX_AGE", c.Cors.MaxAge}, {"HEADERS_HSTS_MAX_AGE", c.Headers.HSTSMaxAge}, {"AUTH_COOKIE_MAX_AGE", c.AuthCookie.MaxAge},
+	} {
+		if f.value < 0 {
+			fail("%s 不能为负数", f.env)
+		}
+	}
+	for route, d := range c.Timeout.Routes {
+		if d < 0 {
+			fail("REQUEST_TIMEOUT_ROUTES 中 %s 的超时时间不能为负数", route)
+		}
+	}
+
+	for _, origin := range c.Cors.AllowedOrigins {
+		if origin == "*" {
+			if c.Cors.AllowCredentials {
+				fail("开启 CORS_ALLOW_CREDENTIALS 时 CORS_ALLOWED_ORIGINS 不能包含 *")
+			}
+			continue
+		}
+		if u, err := url.Parse(origin); err != nil || u.Scheme == "" || u.Host == "" || (u.Path != "" && u.Path != "/") {
+			fail("CORS_ALLOWED_ORIGINS 中的 %q 必须是协议加主机，如 https://app.example.com", origin)
+		}
+	}
+	if c.AuthCookie.Enabled {
+		if c.AuthCookie.Name == "" || c.AuthCookie.CSRFName == "" || c.AuthCookie.CSRFHeader == "" {
+			fail("开启 AUTH_COOKIE_ENABLED 时 AUTH_COOKIE_NAME、AUTH_CSRF_COOKIE_NAME 和 AUTH_CSRF_HEADER 不能为空")
+		}
+		if prod && !c.AuthCookie.Secure {
+			fail("prod 环境开启 AUTH_COOKIE_ENABLED 时必须开启 AUTH_COOKIE_SECURE")
+		}
+	}
+	switch c.AuthCookie.SameSite {
+	case "lax", "strict":
+	case "none":
+		if !c.AuthCookie.Secure {
+			fail("AUTH_COOKIE_SAMESITE 为 none 时必须开启 AUTH_COOKIE_SECURE")
+		}
+	default:
+		fail("AUTH_COOKIE_SAMESITE 只能是 lax、strict 或 none，当前为 %q", c.AuthCookie.SameSite)
+	}
+
+	c.validateOidc(fail)
+
+	if len(errs) > 0 {
+		return fmt.Errorf("配置校验失败: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// validateJwt 检查签名算法、密钥和接受的算法是否匹配。密钥文件由 tokens.NewKeySet 在启动时读取和检查
+func (c *Config) validateJwt(fail func(format string, args ...interface{})) {
+	algorithms := c.Jwt.Algorithms()
+	hs256 := slices.Contains(algorithms, "HS256")
+	if c.Secret.JwtSecret == "" {
+		if hs256 {
+			fail("未配置 JWT_SECRET，JWT_ALGORITHM 或 JWT_ALLOWED_ALGORITHMS 包含 HS256 时必须配置")
+		}
+	} else if c.Profile == ProfileProd && len(c.Secret.JwtSecret) < MinProdJwtSecretLength {
+		fail("prod 环境的 JWT_SECRET 至少需要 %d 字节", MinProdJwtSecretLength)
+	}
+
+	switch c.Jwt.Algorithm {
+	case "HS256":
+	case "RS256", "EdDSA":
+		if len(c.Jwt.Keys) == 0 {
+			fail("JWT_ALGORITHM 为 %s 时必须配置 JWT_KEYS", c.Jwt.Algorithm)
+		}
+	default:
+		fail("JWT_ALGORITHM 只能是 HS256、RS256 或 EdDSA，当前为 %q", c.Jwt.Algorithm)
+	}
+	for _, alg := range algorithms {
+		switch alg {
+		case "HS256", "RS256", "EdDSA":
+		default:
+			fail("JWT_ALLOWED_ALGORITHMS 只能包含 HS256、RS256 或 EdDSA，当前包含 %q", alg)
+		}
+	}
+	if !slices.Contains(algorithms, c.Jwt.Algorithm) {
+		fail("JWT_ALLOWED_ALGORITHMS 必须包含 JWT_ALGORITHM %s", c.Jwt.Algorithm)
+	}
+	if c.Jwt.TTL <= 0 {
+		fail("JWT_TTL 必须大于 0")
+	}
+	if !c.Jwt.LegacyUntil.IsZero() && (!hs256 || c.Secret.JwtSecret == "") {
+		fail("配置 JWT_LEGACY_UNTIL 时 JWT_ALLOWED_ALGORITHMS 必须包含 HS256，并且必须配置 JWT_SECRET")
+	}
+}
+
+// oidcProviderName 限制提供方名称的字符，名称会出现在回调地址中
+var oidcProviderName = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,31}$`)
+
+// validateOidc 检查每个提供方都有合法的 issuer 和 client ID，prod 环境的 issuer 必须使用 HTTPS
+func (c *Config) validateOidc(fail func(format string, args ...interface{})) {
+	for name, issuer := range c.Oidc.Providers {
+		if !oidcProviderName.MatchString(name) {
+			fail("OIDC_PROVIDERS 中的提供方名称 %q 只能包含小写字母、数字和 -", name)
+		}
+		u, err := url.Parse(issuer)
+		if err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") {
+			fail("OIDC_PROVIDERS 中 %s 的 issuer 必须是绝对地址，当前为 %q", name, issuer)
+		} else if c.Profile == ProfileProd && u.Scheme != "https" {
+			fail("prod 环境中 %s 的 issuer 必须使用 https", name)
+		}
+		if c.Oidc.ClientIDs[name] == "" {
+			fail("OIDC_CLIENT_IDS 中缺少 %s 的 client ID", name)
+		}
+	}
+	for _, m := range []struct {
+		env    string
+		values map[string]string
+	}{{"OIDC_CLIENT_IDS", c.Oidc.ClientIDs}, {"OIDC_CLIENT_SECRETS", c.Oidc.ClientSecrets}} {
+		for name := range m.values {
+			if _, ok := c.Oidc.Providers[name]; !ok {
+				fail("%s 中的 %s 不在 OIDC_PROVIDERS 中", m.env, name)
+			}
+		}
+	}
+	if len(c.Oidc.Providers) == 0 {
+		return
+	}
+	if !slices.Contains(c.Oidc.Scopes, "openid") {
+		fail("OIDC_SCOPES 必须包含 openid")
+	}
+	if c.Oidc.FlowTTL <= 0 {
+		fail("OIDC_FLOW_TTL 必须大于 0")
+	}
+	if c.Oidc.SuccessURL != "" {
+		if u, err := url.Parse(c.Oidc.SuccessURL); err != nil || u.Scheme == "" || u.Host == "" {
+			fail("OIDC_SUCCESS_URL 必须是包含协议和主机的绝对地址，当前为 %q", c.Oidc.SuccessURL)
+		}
+	}
+}
@@ -1,43 +1,141 @@
 package db
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"time"
 
+	"go.uber.org/zap"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
 )
 
+// DbConfig 是 MySQL 的连接配置，由 config 包加载，prod 环境必须配置密码
 type DbConfig struct {
-	DBHost     string `env:"DB_HOST" required:"true" envdefault:"localhost"`
-	DBPort     string `env:"DB_PORT" required:"true" envdefault:"3306"`
-	DBUser     string `env:"DB_USER" required:"true" envdefault:"root"`
-	DBPassword string `env:"DB_PASSWORD" required:"true" envdefault:"password"`
-	DBName     string `env:"DB_NAME" required:"true" envdefault:"test"`
-}
+	DBHost     string `env:"DB_HOST" envDefault:"localhost" yaml:"host"`
+	DBPort     string `env:"DB_PORT" envDefault:"3306" yaml:"port"`
+	DBUser     string `env:"DB_USER" envDefault:"root" yaml:"user"`
+	DBPassword string `env:"DB_PASSWORD" yaml:"password" secret:"true"`
+	DBName     string `env:"DB_NAME" envDefault:"test" yaml:"name"`
+
+	// 连接池的最大连接数和最大空闲连接数，最大连接数为 0 表示不限制
+	MaxOpenConns int `env:"DB_MAX_OPEN_CONNS" envDefault:"25" yaml:"max_open_conns"`
+	MaxIdleConns int `env:"DB_MAX_IDLE_CONNS" envDefault:"10" yaml:"max_idle_conns"`
+	// 连接的最长使用时间和最长空闲时间，应短于 MySQL 的 wait_timeout，0 表示不限制
+	ConnMaxLifetime time.Duration `env:"DB_CONN_MAX_LIFETIME" envDefault:"30m" yaml:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `env:"DB_CONN_MAX_IDLE_TIME" envDefault:"5m" yaml:"conn_max_idle_time"`
 
-var GormDb *gorm.DB
+	// 启动时连接失败的重试次数，每次重试的等待时间从 ConnectBackoff 开始翻倍，最长 30s，全部失败后启动失败
+	ConnectRetries int           `env:"DB_CONNECT_RETRIES" envDefault:"5" yaml:"connect_retries"`
+	ConnectBackoff time.Duration `env:"DB_CONNECT_BACKOFF" envDefault:"1s" yaml:"connect_backoff"`
 
-func InitGormDb(cfg *DbConfig) {
-	dsn := fmt.Sprintf(
+	// 只读副本的 DSN，多个用逗号分隔，格式同 go-sql-driver/mysql，如
+	// "user:pass@tcp(replica:3306)/myblog?charset=utf8mb4&parseTime=true"。
+	// 配置后只有 repository 中显式使用 ReplicaResolver 的列表查询和对外的文章详情分配到副本，其余查询、写操作和事务都使用主库。
+	// 副本的复制延迟可能导致列表中暂时看不到刚写入的数据
+	Replicas []string `env:"DB_REPLICAS" envSeparator:"," yaml:"replicas" secret:"true"`
+	// 本进程写主库之后 ReadYourWritesWindow 内的文章详情仍读主库，应大于副本通常的复制延迟，0 表示不改读主库。
+	// 只记录本进程的写入，多实例部署时需要负载均衡按客户端保持会话，否则在其他实例上仍可能读到旧的详情
+	ReadYourWritesWindow time.Duration `env:"DB_READ_YOUR_WRITES_WINDOW" envDefault:"5s" yaml:"read_your_writes_window"`
+
+	// 执行时间超过 SlowThreshold 的 SQL 记录为慢查询，0 表示不记录
+	SlowThreshold time.Duration `env:"DB_SLOW_THRESHOLD" envDefault:"200ms" yaml:"slow_threshold"`
+	// SQL 日志级别：silent、error、warn 或 info，info 记录全部 SQL
+	LogLevel string `env:"DB_LOG_LEVEL" envDefault:"warn" yaml:"log_level"`
+}
+
+// DSN 返回主库的连接串
+func (cfg *DbConfig) DSN() string {
+	return fmt.Sprintf(
 		"%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=true",
 		cfg.DBUser,
 		cfg.DBPassword,
 		cfg.DBHost,
 		cfg.DBPort,
 		cfg.DBName)
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+}
+
+// maxConnectBackoff 是连接重试的最长等待时间
+const maxConnectBackoff = 30 * time.Second
+
+// ReplicaResolver 是只读副本在 dbresolver 中注册的名称。副本不作为默认的读库，
+// 查询需要同时加上 dbresolver.Use(ReplicaResolver) 和 dbresolver.Read 才会分配到副本，没有配置副本时仍使用主库
+const ReplicaResolver = "replica"
+
+// Open 连接主库并按配置设置连接池和只读副本，连接失败时按 ConnectRetries 重试，ctx 取消时停止重试。
+// SQL 日志写入 log，log 为 nil 时不输出
+func Open(ctx context.Context, cfg *DbConfig, log *zap.Logger) (*gorm.DB, error) {
+	if log == nil {
+		log = zap.NewNop()
+	}
+	level, err := ParseLogLevel(cfg.LogLevel)
 	if err != nil {
-		fmt.Println("gorm连接数据库失败:", err)
+		return nil, err
 	}
-	GormDb = db
-	fmt.Println("gorm连接数据库成功")
-}
+	gormConfig := &gorm.Config{Logger: NewGormLogger(log, level, cfg.SlowThreshold)}
 
-func CloseDBConnections() {
-	if GormDb != nil {
-		sqlDB, err := GormDb.DB()
+	var db *gorm.DB
+	backoff := cfg.ConnectBackoff
+	for attempt := 0; ; attempt++ {
+		// gorm.Open 会 ping 数据库，数据库未就绪时在这里返回错误
+		db, err = gorm.Open(mysql.Open(cfg.DSN()), gormConfig)
 		if err == nil {
-			sqlDB.Close()
+			break
+		}
+		if attempt >= cfg.ConnectRetries {
+			return nil, fmt.Errorf("连接数据库失败，已重试 %d 次: %w", attempt, err)
+		}
+		log.Sugar().Warnf("连接数据库失败，%s 后重试（%d/%d）: %v", backoff, attempt+1, cfg.ConnectRetries, err)
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("连接数据库失败，已取消: %w", errors.Join(ctx.Err(), err))
+		case <-timer.C:
 		}
+		backoff = min(backoff*2, maxConnectBackoff)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+
+	if len(cfg.Replicas) > 0 {
+		if err := db.Use(NewWriteTracker(cfg.ReadYourWritesWindow)); err != nil {
+			_ = sqlDB.Close()
+			return nil, err
+		}
+		replicas := make([]gorm.Dialector, len(cfg.Replicas))
+		for i, dsn := range cfg.Replicas {
+			replicas[i] = mysql.Open(dsn)
+		}
+		// 按名称注册，不设置全局的 resolver，未指定 ReplicaResolver 的查询不会被分配到副本
+		resolver := dbresolver.Register(dbresolver.Config{Replicas: replicas}, ReplicaResolver).
+			SetMaxOpenConns(cfg.MaxOpenConns).
+			SetMaxIdleConns(cfg.MaxIdleConns).
+			SetConnMaxLifetime(cfg.ConnMaxLifetime).
+			SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+		if err := db.Use(resolver); err != nil {
+			_ = sqlDB.Close()
+			return nil, fmt.Errorf("连接只读副本失败: %w", err)
+		}
+	}
+	log.Sugar().Infof("数据库连接成功，只读副本 %d 个", len(cfg.Replicas))
+	return db, nil
+}
+
+// Close 关闭主库的连接池，只读副本的连接由 dbresolver 持有，随进程退出释放
+func Close(db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
 	}
+	return sqlDB.Close()
 }
@@ -0,0 +1,26 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestOpenCancel 检查等待重试时 ctx 取消会立即返回，不等满退避时间
+func TestOpenCancel(t *testing.T) {
+	cfg := &DbConfig{
+		DBHost: "127.0.0.1", DBPort: "1", DBUser: "root", DBName: "test",
+		ConnectRetries: 3, ConnectBackoff: time.Hour, LogLevel: "silent",
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := Open(ctx, cfg, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Open 返回 %v，期望包含 context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("Open 在 ctx 取消后 %s 才返回", elapsed)
+	}
+}
@@ -0,0 +1,87 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+	"gorm.io/gorm/utils"
+)
+
+// ParseLogLevel 把 DB_LOG_LEVEL 转换为 GORM 的日志级别
+func ParseLogLevel(level string) (gormlogger.LogLevel, error) {
+	switch level {
+	case "silent":
+		return gormlogger.Silent, nil
+	case "error":
+		return gormlogger.Error, nil
+	case "warn", "":
+		return gormlogger.Warn, nil
+	case "info":
+		return gormlogger.Info, nil
+	}
+	return 0, fmt.Errorf("未知的 SQL 日志级别 %q", level)
+}
+
+// GormLogger 把 GORM 的日志写入 zap：执行出错的 SQL 记为 error，慢查询记为 warn，
+// info 级别下记录全部 SQL。查询不到记录是正常的业务结果，不记为错误。
+// zap 的调用位置会指向 GORM 内部，改为在 caller 字段记录发起查询的业务代码位置
+type GormLogger struct {
+	log           *zap.SugaredLogger
+	level         gormlogger.LogLevel
+	slowThreshold time.Duration
+}
+
+func NewGormLogger(log *zap.Logger, level gormlogger.LogLevel, slowThreshold time.Duration) *GormLogger {
+	return &GormLogger{
+		log:           log.WithOptions(zap.WithCaller(false)).Sugar(),
+		level:         level,
+		slowThreshold: slowThreshold,
+	}
+}
+
+func (l *GormLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	clone := *l
+	clone.level = level
+	return &clone
+}
+
+func (l *GormLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= gormlogger.Info {
+		l.log.Infof(msg, args...)
+	}
+}
+
+func (l *GormLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= gormlogger.Warn {
+		l.log.Warnf(msg, args...)
+	}
+}
+
+func (l *GormLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= gormlogger.Error {
+		l.log.Errorf(msg, args...)
+	}
+}
+
+func (l *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	if l.level <= gormlogger.Silent {
+		return
+	}
+	elapsed := time.Since(begin)
+	switch {
+	case err != nil && l.level >= gormlogger.Error && !errors.Is(err, gorm.ErrRecordNotFound):
+		sql, rows := fc()
+		l.log.Errorw("SQL 执行失败", "caller", utils.FileWithLineNum(), "error", err, "elapsed", elapsed, "rows", rows, "sql", sql)
+	case l.slowThreshold > 0 && elapsed > l.slowThreshold && l.level >= gormlogger.Warn:
+		sql, rows := fc()
+		l.log.Warnw("慢查询", "caller", utils.FileWithLineNum(), "elapsed", elapsed, "threshold", l.slowThreshold, "rows", rows, "sql", sql)
+	case l.level >= gormlogger.Info:
+		sql, rows := fc()
+		l.log.Infow("SQL", "caller", utils.FileWithLineNum(), "elapsed", elapsed, "rows", rows, "sql", sql)
+	}
+}
@@ -0,0 +1,61 @@
+package db
+
+import (
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// WriteTrackerName 是 WriteTracker 在 GORM 中注册的插件名称
+const WriteTrackerName = "write_tracker"
+
+// WriteTracker 是记录本进程最近一次写主库时间的 GORM 插件。配置了只读副本时，
+// 写入之后 window 内的详情查询改读主库，避免客户端刚写入就从复制落后的副本读到旧数据
+type WriteTracker struct {
+	window time.Duration
+	// last 是最近一次写入的时间（UnixNano），0 表示还没有写入
+	last atomic.Int64
+}
+
+// NewWriteTracker 创建 WriteTracker，window 为 0 时不改读主库
+func NewWriteTracker(window time.Duration) *WriteTracker {
+	return &WriteTracker{window: window}
+}
+
+func (w *WriteTracker) Name() string {
+	return WriteTrackerName
+}
+
+// Initialize 在创建、更新、删除和 Exec 执行的语句之后记录写入时间，执行失败和 DryRun 的语句不记录。
+// 事务中的写入在提交之前就会记录，之后回滚也不撤销，只会让读主库的时间变长
+func (w *WriteTracker) Initialize(db *gorm.DB) error {
+	mark := func(tx *gorm.DB) {
+		if tx.Error == nil && !tx.DryRun {
+			w.last.Store(time.Now().UnixNano())
+		}
+	}
+	cb := db.Callback()
+	if err := cb.Create().After("gorm:create").Register("write_tracker:create", mark); err != nil {
+		return err
+	}
+	if err := cb.Update().After("gorm:update").Register("write_tracker:update", mark); err != nil {
+		return err
+	}
+	if err := cb.Delete().After("gorm:delete").Register("write_tracker:delete", mark); err != nil {
+		return err
+	}
+	return cb.Raw().After("gorm:raw").Register("write_tracker:raw", mark)
+}
+
+// Recent 判断 window 内是否有过写入
+func (w *WriteTracker) Recent() bool {
+	last := w.last.Load()
+	return last != 0 && time.Since(time.Unix(0, last)) < w.window
+}
+
+// RecentlyWritten 判断 db 上注册的 WriteTracker 是否在 window 内记录过写入，没有注册时返回 false
+func RecentlyWritten(db *gorm.DB) bool {
+	w, ok := db.Config.Plugins[WriteTrackerName].(*WriteTracker)
+	return ok && w.Recent()
+}
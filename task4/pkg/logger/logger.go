@@ -4,24 +4,10 @@ import (
 	"go.uber.org/zap"
 )
 
-var (
-	Logger *zap.Logger
-	Sugar  *zap.SugaredLogger
-)
-
-func Init() error {
-	var err error
-	Logger, err = zap.NewProduction()
-	if err != nil {
-		return err
-	}
-	Sugar = Logger.Sugar()
-	return nil
-}
-
-func Sync() error {
-	if Logger != nil {
-		return Logger.Sync()
+// New 创建日志，prod 和 test 环境输出 JSON 格式的 info 及以上日志，dev 环境输出便于阅读的 debug 日志
+func New(profile string) (*zap.Logger, error) {
+	if profile == "dev" {
+		return zap.NewDevelopment()
 	}
-	return nil
+	return zap.NewProduction()
 }
@@ -0,0 +1,245 @@
+// Package openapi 读取 OpenAPI 3.1 文档，并按文档校验 HTTP 请求和响应。
+//
+// 只实现了本项目文档用到的部分：路径、查询和请求头参数，JSON 请求体和响应体，
+// schema 支持 type、enum、properties、required、additionalProperties、items、allOf、$ref
+// 以及长度、数量和数值范围的限制。
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Document 是 OpenAPI 3.1 文档
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Servers    []Server            `json:"servers,omitempty"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+type Server struct {
+	URL string `json:"url"`
+}
+
+// PathItem 以小写的 HTTP 方法为键
+type PathItem map[string]*Operation
+
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas,omitempty"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	Description  string `json:"description,omitempty"`
+}
+
+type Operation struct {
+	Tags        []string              `json:"tags,omitempty"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Parameters  []*Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Description string                `json:"description,omitempty"`
+	Required    bool                  `json:"required,omitempty"`
+	Content     map[string]*MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema  *Schema     `json:"schema,omitempty"`
+	Example interface{} `json:"example,omitempty"`
+}
+
+// Schema 是 JSON Schema 2020-12 的子集
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 Types              `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+
+	// never 为 true 时表示布尔 schema false，任何值都不满足，如 "additionalProperties": false
+	never bool
+}
+
+// False 返回布尔 schema false
+func False() *Schema {
+	return &Schema{never: true}
+}
+
+func (s *Schema) MarshalJSON() ([]byte, error) {
+	if s.never {
+		return []byte("false"), nil
+	}
+	type plain Schema
+	return json.Marshal((*plain)(s))
+}
+
+func (s *Schema) UnmarshalJSON(data []byte) error {
+	switch strings.TrimSpace(string(data)) {
+	case "false":
+		*s = Schema{never: true}
+		return nil
+	case "true":
+		*s = Schema{}
+		return nil
+	}
+	type plain Schema
+	return json.Unmarshal(data, (*plain)(s))
+}
+
+// Types 是 schema 的 type，只有一个类型时编码为字符串，如 "string"，可为空时为数组，如 ["string", "null"]
+type Types []string
+
+func (t Types) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+func (t *Types) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*t = Types{one}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(t))
+}
+
+// Load 解析 OpenAPI 文档并检查其中的 $ref 都能找到对应的定义
+func Load(data []byte) (*Document, error) {
+	var d Document
+	if err := json.Unmarshal(data, &d); err != nil {
+		return nil, fmt.Errorf("解析 OpenAPI 文档失败: %w", err)
+	}
+	if !strings.HasPrefix(d.OpenAPI, "3.") {
+		return nil, fmt.Errorf("不支持的 OpenAPI 版本 %q", d.OpenAPI)
+	}
+	for path, item := range d.Paths {
+		for method, op := range item {
+			if err := d.checkRefs(op); err != nil {
+				return nil, fmt.Errorf("%s %s: %w", strings.ToUpper(method), path, err)
+			}
+		}
+	}
+	return &d, nil
+}
+
+// BasePath 返回第一个 server 的路径，文档中的路径都相对于它
+func (d *Document) BasePath() string {
+	if len(d.Servers) == 0 {
+		return ""
+	}
+	url := d.Servers[0].URL
+	if i := strings.Index(url, "://"); i >= 0 {
+		url = url[i+3:]
+		if j := strings.Index(url, "/"); j >= 0 {
+			url = url[j:]
+		} else {
+			url = ""
+		}
+	}
+	return strings.TrimRight(url, "/")
+}
+
+// Operation 按方法和路径模板查找接口，路径使用文档中的写法，如 /posts/{post_id}
+func (d *Document) Operation(method, path string) *Operation {
+	return d.Paths[path][strings.ToLower(method)]
+}
+
+const refPrefix = "#/components/schemas/"
+
+// resolve 展开 $ref
+func (d *Document) resolve(s *Schema) (*Schema, error) {
+	for depth := 0; s != nil && s.Ref != ""; depth++ {
+		if depth > 32 {
+			return nil, fmt.Errorf("%s 的引用层数过多", s.Ref)
+		}
+		target, ok := d.Components.Schemas[strings.TrimPrefix(s.Ref, refPrefix)]
+		if !strings.HasPrefix(s.Ref, refPrefix) || !ok {
+			return nil, fmt.Errorf("找不到 %s", s.Ref)
+		}
+		s = target
+	}
+	return s, nil
+}
+
+func (d *Document) checkRefs(op *Operation) error {
+	var schemas []*Schema
+	for _, p := range op.Parameters {
+		schemas = append(schemas, p.Schema)
+	}
+	if op.RequestBody != nil {
+		for _, mt := range op.RequestBody.Content {
+			schemas = append(schemas, mt.Schema)
+		}
+	}
+	for _, resp := range op.Responses {
+		for _, mt := range resp.Content {
+			schemas = append(schemas, mt.Schema)
+		}
+	}
+	seen := make(map[*Schema]bool)
+	for len(schemas) > 0 {
+		s := schemas[len(schemas)-1]
+		schemas = schemas[:len(schemas)-1]
+		if s == nil || seen[s] {
+			continue
+		}
+		seen[s] = true
+		if s.Ref != "" {
+			target, err := d.resolve(s)
+			if err != nil {
+				return err
+			}
+			schemas = append(schemas, target)
+		}
+		for _, p := range s.Properties {
+			schemas = append(schemas, p)
+		}
+		schemas = append(schemas, s.AdditionalProperties, s.Items)
+		schemas = append(schemas, s.AllOf...)
+	}
+	return nil
+}
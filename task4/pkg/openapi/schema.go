@@ -0,0 +1,210 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ValidationError 是值不满足 schema 的原因，Pointer 是出错位置的 JSON Pointer，如 /data/tags/0
+type ValidationError struct {
+	Pointer string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Pointer == "" {
+		return e.Message
+	}
+	return e.Pointer + ": " + e.Message
+}
+
+// ValidateValue 检查 v 是否满足 schema，v 是 encoding/json 解码得到的值，数字为 json.Number 或 float64
+func (d *Document) ValidateValue(s *Schema, v interface{}) error {
+	return d.validate(s, v, "")
+}
+
+func (d *Document) validate(s *Schema, v interface{}, ptr string) error {
+	if s == nil {
+		return nil
+	}
+	s, err := d.resolve(s)
+	if err != nil {
+		return &ValidationError{Pointer: ptr, Message: err.Error()}
+	}
+	if s.never {
+		return &ValidationError{Pointer: ptr, Message: "不允许出现"}
+	}
+	for _, sub := range s.AllOf {
+		if err := d.validate(sub, v, ptr); err != nil {
+			return err
+		}
+	}
+	if len(s.Type) > 0 && !s.Type.match(v) {
+		return &ValidationError{Pointer: ptr, Message: fmt.Sprintf("类型应为 %s，实际为 %s", strings.Join(s.Type, " 或 "), typeOf(v))}
+	}
+	if len(s.Enum) > 0 && !inEnum(s.Enum, v) {
+		return &ValidationError{Pointer: ptr, Message: fmt.Sprintf("应为 %s 之一", formatEnum(s.Enum))}
+	}
+
+	switch x := v.(type) {
+	case string:
+		n := utf8.RuneCountInString(x)
+		if s.MinLength != nil && n < *s.MinLength {
+			return &ValidationError{Pointer: ptr, Message: fmt.Sprintf("长度不能小于 %d", *s.MinLength)}
+		}
+		if s.MaxLength != nil && n > *s.MaxLength {
+			return &ValidationError{Pointer: ptr, Message: fmt.Sprintf("长度不能大于 %d", *s.MaxLength)}
+		}
+	case json.Number, float64:
+		f, _ := toFloat(x)
+		if s.Minimum != nil && f < *s.Minimum {
+			return &ValidationError{Pointer: ptr, Message: fmt.Sprintf("不能小于 %v", *s.Minimum)}
+		}
+		if s.Maximum != nil && f > *s.Maximum {
+			return &ValidationError{Pointer: ptr, Message: fmt.Sprintf("不能大于 %v", *s.Maximum)}
+		}
+	case []interface{}:
+		if s.MinItems != nil && len(x) < *s.MinItems {
+			return &ValidationError{Pointer: ptr, Message: fmt.Sprintf("元素不能少于 %d 个", *s.MinItems)}
+		}
+		if s.MaxItems != nil && len(x) > *s.MaxItems {
+			return &ValidationError{Pointer: ptr, Message: fmt.Sprintf("元素不能多于 %d 个", *s.MaxItems)}
+		}
+		for i, item := range x {
+			if err := d.validate(s.Items, item, ptr+"/"+strconv.Itoa(i)); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := x[name]; !ok {
+				return &ValidationError{Pointer: ptr, Message: fmt.Sprintf("缺少必填字段 %s", name)}
+			}
+		}
+		// 按字段名顺序检查，保证同一个值每次报告相同的错误
+		keys := make([]string, 0, len(x))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			sub, ok := s.Properties[k]
+			if !ok {
+				if s.AdditionalProperties == nil {
+					continue
+				}
+				if s.AdditionalProperties.never {
+					return &ValidationError{Pointer: ptr, Message: fmt.Sprintf("不允许的字段 %s", k)}
+				}
+				sub = s.AdditionalProperties
+			}
+			if err := d.validate(sub, x[k], ptr+"/"+escapePointer(k)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (t Types) match(v interface{}) bool {
+	for _, name := range t {
+		switch name {
+		case "null":
+			if v == nil {
+				return true
+			}
+		case "boolean":
+			if _, ok := v.(bool); ok {
+				return true
+			}
+		case "string":
+			if _, ok := v.(string); ok {
+				return true
+			}
+		case "number":
+			if _, ok := toFloat(v); ok {
+				return true
+			}
+		case "integer":
+			if f, ok := toFloat(v); ok && f == math.Trunc(f) {
+				return true
+			}
+		case "array":
+			if _, ok := v.([]interface{}); ok {
+				return true
+			}
+		case "object":
+			if _, ok := v.(map[string]interface{}); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch x := v.(type) {
+	case json.Number:
+		f, err := x.Float64()
+		return f, err == nil
+	case float64:
+		return x, true
+	}
+	return 0, false
+}
+
+func typeOf(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number, float64:
+		if f, _ := toFloat(x); f == math.Trunc(f) {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// inEnum 比较时把数字统一为 float64，json.Number("1") 与文档中的 1 相等
+func inEnum(enum []interface{}, v interface{}) bool {
+	if f, ok := toFloat(v); ok {
+		v = f
+	}
+	for _, e := range enum {
+		if f, ok := toFloat(e); ok {
+			e = f
+		}
+		if reflect.DeepEqual(e, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func formatEnum(enum []interface{}) string {
+	parts := make([]string, len(enum))
+	for i, e := range enum {
+		b, _ := json.Marshal(e)
+		parts[i] = string(b)
+	}
+	return strings.Join(parts, "、")
+}
+
+func escapePointer(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~", "~0"), "/", "~1")
+}
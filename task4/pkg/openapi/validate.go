@@ -0,0 +1,194 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// RequestError 是请求不符合文档的原因，Status 是应返回的状态码：
+// Content-Type 不在文档中时为 415，其余为 400
+type RequestError struct {
+	Status int
+	// In 是出错的位置：path、query、header、body
+	In  string
+	Err error
+}
+
+func (e *RequestError) Error() string {
+	return e.In + ": " + e.Err.Error()
+}
+
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
+
+// ValidateRequest 按接口的定义校验参数和请求体，pathParams 由调用方的路由解析得到。
+// 只读取并校验 JSON 请求体，读取后 r.Body 被替换为相同内容的副本，其余类型的请求体只检查是否存在
+func (d *Document) ValidateRequest(op *Operation, r *http.Request, pathParams map[string]string) error {
+	query := r.URL.Query()
+	for _, p := range op.Parameters {
+		var values []string
+		switch p.In {
+		case "path":
+			if v, ok := pathParams[p.Name]; ok {
+				values = []string{v}
+			}
+		case "query":
+			values = query[p.Name]
+		case "header":
+			values = r.Header.Values(p.Name)
+		default:
+			continue
+		}
+		if len(values) == 0 {
+			if p.Required {
+				return &RequestError{Status: http.StatusBadRequest, In: p.In, Err: fmt.Errorf("缺少参数 %s", p.Name)}
+			}
+			continue
+		}
+		if err := d.validateParam(p, values); err != nil {
+			return &RequestError{Status: http.StatusBadRequest, In: p.In, Err: fmt.Errorf("参数 %s %w", p.Name, err)}
+		}
+	}
+
+	body := op.RequestBody
+	if body == nil {
+		return nil
+	}
+	if r.Body == nil || r.Body == http.NoBody || r.ContentLength == 0 {
+		if body.Required {
+			return &RequestError{Status: http.StatusBadRequest, In: "body", Err: errors.New("缺少请求体")}
+		}
+		return nil
+	}
+	mediaType, mt := body.mediaType(r.Header.Get("Content-Type"))
+	if mt == nil {
+		return &RequestError{Status: http.StatusUnsupportedMediaType, In: "body", Err: fmt.Errorf("Content-Type %q 不在文档中", mediaType)}
+	}
+	if mt.Schema == nil || !isJSON(mediaType) {
+		return nil
+	}
+	data, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(data))
+	if err != nil {
+		return &RequestError{Status: http.StatusBadRequest, In: "body", Err: err}
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		if body.Required {
+			return &RequestError{Status: http.StatusBadRequest, In: "body", Err: errors.New("缺少请求体")}
+		}
+		return nil
+	}
+	if err := d.validateJSON(mt.Schema, data); err != nil {
+		return &RequestError{Status: http.StatusBadRequest, In: "body", Err: err}
+	}
+	return nil
+}
+
+// ValidateResponse 检查状态码是否在文档中声明，并按该状态码的定义校验 JSON 响应体
+func (d *Document) ValidateResponse(op *Operation, status int, header http.Header, body []byte) error {
+	resp, ok := op.Responses[strconv.Itoa(status)]
+	if !ok {
+		if resp, ok = op.Responses["default"]; !ok {
+			return fmt.Errorf("状态码 %d 没有在文档中声明", status)
+		}
+	}
+	if len(body) == 0 {
+		return nil
+	}
+	mediaType := mediaTypeOf(header.Get("Content-Type"))
+	// 没有声明响应体时只检查 JSON，重定向等响应附带的简短 HTML 说明不算偏差
+	if len(resp.Content) == 0 {
+		if isJSON(mediaType) {
+			return fmt.Errorf("状态码 %d 在文档中没有响应体", status)
+		}
+		return nil
+	}
+	mt, ok := resp.Content[mediaType]
+	if !ok {
+		return fmt.Errorf("Content-Type %q 没有在文档中声明", mediaType)
+	}
+	if mt.Schema == nil || !isJSON(mediaType) {
+		return nil
+	}
+	return d.validateJSON(mt.Schema, body)
+}
+
+// validateParam 按 schema 的类型转换参数的字符串值后校验，数组类型的参数可以出现多次
+func (d *Document) validateParam(p *Parameter, values []string) error {
+	s, err := d.resolve(p.Schema)
+	if err != nil || s == nil {
+		return err
+	}
+	if len(s.Type) == 1 && s.Type[0] == "array" {
+		items := make([]interface{}, len(values))
+		for i, v := range values {
+			items[i] = d.paramValue(s.Items, v)
+		}
+		return d.ValidateValue(s, items)
+	}
+	return d.ValidateValue(s, d.paramValue(s, values[0]))
+}
+
+// paramValue 把字符串转换为 schema 要求的类型，无法转换时保留字符串，由校验报告类型错误
+func (d *Document) paramValue(s *Schema, v string) interface{} {
+	s, err := d.resolve(s)
+	if err != nil || s == nil || len(s.Type) == 0 {
+		return v
+	}
+	switch s.Type[0] {
+	case "integer", "number":
+		if _, err := strconv.ParseFloat(v, 64); err == nil {
+			return json.Number(v)
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	return v
+}
+
+func (d *Document) validateJSON(s *Schema, data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return fmt.Errorf("不是合法的 JSON: %w", err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return errors.New("JSON 之后有多余的内容")
+	}
+	return d.ValidateValue(s, v)
+}
+
+// mediaType 返回请求的媒体类型及其在文档中的定义，未携带 Content-Type 且文档只有一种类型时使用这一种
+func (b *RequestBody) mediaType(contentType string) (string, *MediaType) {
+	if contentType == "" && len(b.Content) == 1 {
+		for name, mt := range b.Content {
+			return name, mt
+		}
+	}
+	name := mediaTypeOf(contentType)
+	return name, b.Content[name]
+}
+
+func mediaTypeOf(contentType string) string {
+	name, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(contentType))
+	}
+	return name
+}
+
+func isJSON(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
@@ -0,0 +1,131 @@
+package e2e
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/miffyG/golearn/task4/internal/models/entity"
+	"github.com/miffyG/golearn/task4/pkg/client"
+	"github.com/miffyG/golearn/task4/pkg/config"
+)
+
+// TestAuditClientIP 检查审计日志中的 IP：默认忽略 X-Forwarded-For，请求来自可信代理时才采用
+func TestAuditClientIP(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		proxies []string
+		want    string
+	}{
+		{"untrusted", nil, "127.0.0.1"},
+		{"trusted", []string{"127.0.0.1"}, "203.0.113.7"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := startServer(t, func(cfg *config.Config) {
+				cfg.Http.TrustedProxies = tc.proxies
+			})
+			admin := s.newUser(t, "admin")
+			s.setRole(t, "admin", entity.RoleAdmin)
+
+			r := &runner{base: s.url}
+			resp, err := r.do(http.DefaultClient, http.MethodPost, "/api/v1/auth/login", http.Header{
+				"X-Forwarded-For": {"203.0.113.7"},
+				"X-Request-ID":    {"xff-" + tc.name},
+			}, map[string]string{"username": "admin", "password": "admin-password"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.Status != http.StatusOK {
+				t.Fatalf("登录返回 %d: %s", resp.Status, resp.Body)
+			}
+
+			found := false
+			for log, err := range admin.AuditLogs(context.Background(), client.AuditFilter{RequestID: "xff-" + tc.name}, 10) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				found = true
+				if log.IP != tc.want {
+					t.Errorf("审计日志的 IP 为 %q，期望 %q", log.IP, tc.want)
+				}
+			}
+			if !found {
+				t.Error("没有找到登录的审计日志")
+			}
+		})
+	}
+}
+
+// TestAuditFailureRollsBack 检查事务中的审计日志写入失败时业务操作一起回滚，事务外的审计日志写入失败不影响操作。
+// 更新、恢复、清理文章和处理举报都必须与审计日志一起提交
+func TestAuditFailureRollsBack(t *testing.T) {
+	s := startServer(t)
+	c := s.newUser(t, "writer")
+	mod := s.newUser(t, "mod")
+	s.setRole(t, "mod", entity.RoleModerator)
+	ctx := context.Background()
+	post, err := c.CreatePost(ctx, client.PostRequest{Title: "审计", Content: "原始正文"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	trashed, err := c.CreatePost(ctx, client.PostRequest{Title: "回收站", Content: "已删除"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.DeletePost(ctx, trashed.ID); err != nil {
+		t.Fatal(err)
+	}
+	report, err := mod.CreateReport(ctx, client.ReportRequest{TargetType: entity.ReportTargetPost, TargetID: post.ID, Reason: "审计"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.app.DB.Migrator().DropTable(&entity.AuditLog{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.UpdatePost(ctx, post.ID, client.PostRequest{Title: "审计", Content: "修改后的正文"}, post.Version); err == nil {
+		t.Fatal("审计日志写入失败时更新文章成功")
+	}
+	got, err := c.GetPost(ctx, post.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Content != post.Content || got.Version != post.Version {
+		t.Errorf("更新失败后文章为 %q@%d，期望保持 %q@%d", got.Content, got.Version, post.Content, post.Version)
+	}
+
+	if _, err := c.RestorePost(ctx, trashed.ID); err == nil {
+		t.Error("审计日志写入失败时恢复文章成功")
+	}
+	if _, err := s.app.Services.Posts.Purge(ctx, 0); err == nil {
+		t.Error("审计日志写入失败时清理文章成功")
+	}
+	var deleted entity.Post
+	if err := s.app.DB.Unscoped().First(&deleted, trashed.ID).Error; err != nil || !deleted.DeletedAt.Valid {
+		t.Errorf("恢复和清理失败后回收站中的文章为 %+v, %v，期望保持删除状态", deleted.DeletedAt, err)
+	}
+
+	if _, err := mod.BanAuthor(ctx, report.ID); err == nil {
+		t.Error("审计日志写入失败时封禁作者成功")
+	}
+	var r entity.Report
+	var author entity.User
+	if err := s.app.DB.First(&r, report.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := s.app.DB.Where("user_name = ?", "writer").First(&author).Error; err != nil {
+		t.Fatal(err)
+	}
+	if r.Status != entity.ReportOpen || author.Banned {
+		t.Errorf("封禁失败后举报为 %s、作者封禁状态为 %v，期望保持不变", r.Status, author.Banned)
+	}
+	if got, err := c.GetPost(ctx, post.ID); err != nil {
+		t.Errorf("封禁失败后文章不可见: %v", err)
+	} else if got.Content != post.Content {
+		t.Errorf("封禁失败后文章为 %q", got.Content)
+	}
+
+	if _, err := c.Login(ctx, "writer", "writer-password"); err != nil {
+		t.Errorf("审计日志写入失败时登录返回 %v", err)
+	}
+}
@@ -0,0 +1,348 @@
+package e2e
+
+import "net/http"
+
+var fixtureUsers = []fixtureUser{
+	{username: "alice", password: "alice123", email: "alice@example.com"},
+	{username: "bob", password: "bob12345", email: "bob@example.com"},
+}
+
+// cases 按顺序执行，数据库在每次运行时都是空的，newRunner 注册的 alice、bob 的 ID 分别为 1、2，
+// 后面创建的文章和评论的 ID 同样从 1 开始
+var cases = []testCase{
+	// 注册和登录
+	{name: "register", method: http.MethodPost, path: "/api/v1/auth/register",
+		body:   map[string]string{"username": "carol", "password": "carol123", "email": "carol@example.com"},
+		status: http.StatusOK, golden: true},
+	{name: "register-invalid", method: http.MethodPost, path: "/api/v1/auth/register",
+		body:   map[string]string{"username": "x", "password": "1", "email": "not-an-email"},
+		status: http.StatusBadRequest, golden: true},
+	{name: "login", method: http.MethodPost, path: "/api/v1/auth/login",
+		body:   map[string]string{"username": "carol", "password": "carol123"},
+		status: http.StatusOK, golden: true},
+	{name: "login-wrong-password", method: http.MethodPost, path: "/api/v1/auth/login",
+		body:   map[string]string{"username": "carol", "password": "wrong-password"},
+		status: http.StatusUnauthorized, golden: true},
+	{name: "login-unknown-user", method: http.MethodPost, path: "/api/v1/auth/login",
+		body:   map[string]string{"username": "nobody", "password": "whatever"},
+		status: http.StatusUnauthorized, golden: true},
+
+	// 文章
+	{name: "create-post-anonymous", method: http.MethodPost, path: "/api/v1/posts",
+		body:   map[string]string{"title": "你好", "content": "第一篇文章"},
+		status: http.StatusUnauthorized, golden: true},
+	{name: "create-post-bad-token", token: "not-a-jwt", method: http.MethodPost, path: "/api/v1/posts",
+		body:   map[string]string{"title": "你好", "content": "第一篇文章"},
+		status: http.StatusUnauthorized},
+	{name: "create-post-invalid", as: "alice", method: http.MethodPost, path: "/api/v1/posts",
+		body:   map[string]string{"title": "缺少正文"},
+		status: http.StatusBadRequest, golden: true},
+	{name: "create-post", as: "alice", method: http.MethodPost, path: "/api/v1/posts",
+		body:   map[string]string{"title": "Hello World", "content": "第一篇文章"},
+		status: http.StatusOK, golden: true},
+	{name: "list-posts", method: http.MethodGet, path: "/api/v1/posts",
+		status: http.StatusOK, golden: true},
+	{name: "get-post", method: http.MethodGet, path: "/api/v1/posts/1",
+		status: http.StatusOK, golden: true},
+	{name: "get-post-not-found", method: http.MethodGet, path: "/api/v1/posts/999",
+		status: http.StatusNotFound, golden: true},
+	{name: "update-post-other-user", as: "bob", method: http.MethodPut, path: "/api/v1/posts/1",
+		body:   map[string]string{"title": "被改了", "content": "bob 的修改"},
+		status: http.StatusForbidden, golden: true},
+	{name: "update-post", as: "alice", method: http.MethodPut, path: "/api/v1/posts/1",
+		body:   map[string]string{"title": "Hello Again", "content": "修改后的正文"},
+		status: http.StatusOK, golden: true},
+	{name: "get-post-updated", method: http.MethodGet, path: "/api/v1/posts/1",
+		status: http.StatusOK, golden: true},
+
+	// X-API-Compat: legacy 时帖子相关的响应使用旧的 PascalCase 字段名，并提醒客户端迁移
+	{name: "legacy-list-posts", method: http.MethodGet, path: "/api/v1/posts",
+		header:     map[string]string{"X-API-Compat": "legacy"},
+		status:     http.StatusOK,
+		wantHeader: map[string]string{"Warning": legacyWarning}, golden: true},
+	{name: "legacy-get-post", method: http.MethodGet, path: "/api/v1/posts/1",
+		header:     map[string]string{"X-API-Compat": "legacy"},
+		status:     http.StatusOK,
+		wantHeader: map[string]string{"Warning": legacyWarning}, golden: true},
+	// 错误响应不受字段命名影响
+	{name: "legacy-get-post-not-found", method: http.MethodGet, path: "/api/v1/posts/999",
+		header:     map[string]string{"X-API-Compat": "legacy"},
+		status:     http.StatusNotFound,
+		wantHeader: map[string]string{"Warning": legacyWarning}, golden: true},
+	{name: "current-get-post", method: http.MethodGet, path: "/api/v1/posts/1",
+		header:     map[string]string{"X-API-Compat": "current"},
+		status:     http.StatusOK,
+		wantHeader: map[string]string{"Warning": ""}},
+
+	// 按 OpenAPI 文档校验的参数和请求体
+	{name: "set-tags", as: "alice", method: http.MethodPut, path: "/api/v1/posts/1/tags",
+		body:   map[string][]string{"tags": {"go", "web"}},
+		status: http.StatusOK, golden: true},
+	{name: "set-tags-too-many", as: "alice", method: http.MethodPut, path: "/api/v1/posts/1/tags",
+		body:   map[string][]string{"tags": {"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k"}},
+		status: http.StatusBadRequest, golden: true},
+	{name: "patch-post-json-patch", as: "alice", method: http.MethodPatch, path: "/api/v1/posts/1",
+		contentType: "application/json-patch+json",
+		body:        []map[string]string{{"op": "replace", "path": "/content", "value": "打过补丁的正文"}},
+		status:      http.StatusOK, golden: true},
+	{name: "patch-post-invalid-op", as: "alice", method: http.MethodPatch, path: "/api/v1/posts/1",
+		contentType: "application/json-patch+json",
+		body:        []map[string]string{{"op": "rename", "path": "/content"}},
+		status:      http.StatusBadRequest, golden: true},
+	// 其他用户的补丁即使无效也返回 403，不会先应用和校验补丁
+	{name: "patch-post-other-user-invalid", as: "bob", method: http.MethodPatch, path: "/api/v1/posts/1",
+		contentType: "application/merge-patch+json",
+		body:        map[string]string{"title": ""},
+		status:      http.StatusForbidden, golden: true},
+	{name: "patch-post-other-user-bad-op", as: "bob", method: http.MethodPatch, path: "/api/v1/posts/1",
+		contentType: "application/json-patch+json",
+		body:        []map[string]string{{"op": "remove", "path": "/missing"}},
+		status:      http.StatusForbidden},
+	{name: "patch-post-unsupported-type", as: "alice", method: http.MethodPatch, path: "/api/v1/posts/1",
+		contentType: "text/plain", body: "title=x",
+		status: http.StatusUnsupportedMediaType, golden: true},
+	{name: "get-post-by-slug", method: http.MethodGet, path: "/api/v1/posts/by-slug/hello-again",
+		status: http.StatusOK, golden: true},
+	// 标题修改前的 slug 重定向到新的 slug
+	{name: "get-post-by-old-slug", method: http.MethodGet, path: "/api/v1/posts/by-slug/hello-world",
+		status: http.StatusOK},
+	{name: "list-revisions", method: http.MethodGet, path: "/api/v1/posts/1/revisions",
+		status: http.StatusOK, golden: true},
+	{name: "trash-invalid-page", as: "alice", method: http.MethodGet, path: "/api/v1/me/trash?page=abc",
+		status: http.StatusBadRequest, golden: true},
+
+	// 举报
+	{name: "create-report-invalid-type", as: "bob", method: http.MethodPost, path: "/api/v1/reports",
+		body:   map[string]interface{}{"target_type": "user", "target_id": 1, "reason": "广告"},
+		status: http.StatusBadRequest, golden: true},
+	{name: "create-report", as: "bob", method: http.MethodPost, path: "/api/v1/reports",
+		body:   map[string]interface{}{"target_type": "post", "target_id": 1, "reason": "广告"},
+		status: http.StatusOK, golden: true},
+
+	// 评论
+	{name: "create-comment-anonymous", method: http.MethodPost, path: "/api/v1/posts/1/comments",
+		body:   map[string]string{"content": "匿名评论"},
+		status: http.StatusUnauthorized},
+	{name: "create-comment-invalid", as: "bob", method: http.MethodPost, path: "/api/v1/posts/1/comments",
+		body:   map[string]string{},
+		status: http.StatusBadRequest, golden: true},
+	{name: "create-comment", as: "bob", method: http.MethodPost, path: "/api/v1/posts/1/comments",
+		body:   map[string]string{"content": "写得不错"},
+		status: http.StatusOK, golden: true},
+	{name: "list-comments", method: http.MethodGet, path: "/api/v1/posts/1/comments",
+		status: http.StatusOK, golden: true},
+	{name: "legacy-list-comments", method: http.MethodGet, path: "/api/v1/posts/1/comments",
+		header:     map[string]string{"X-API-Compat": "legacy"},
+		status:     http.StatusOK,
+		wantHeader: map[string]string{"Warning": legacyWarning}, golden: true},
+
+	// 权限
+	{name: "admin-forbidden", as: "alice", method: http.MethodGet, path: "/api/v1/admin/audit",
+		status: http.StatusForbidden, golden: true},
+	{name: "moderation-forbidden", as: "bob", method: http.MethodGet, path: "/api/v1/moderation/queue",
+		status: http.StatusForbidden},
+	// 认证在 OpenAPI 校验之前执行，参数或请求体不合法时仍然先返回 401、403
+	{name: "set-tags-anonymous-invalid", method: http.MethodPut, path: "/api/v1/posts/1/tags",
+		body:   map[string]string{"tags": "go"},
+		status: http.StatusUnauthorized},
+	{name: "audit-forbidden-invalid", as: "alice", method: http.MethodGet, path: "/api/v1/admin/audit?page=abc",
+		status: http.StatusForbidden},
+
+	// 删除文章后评论一并删除
+	{name: "delete-post-other-user", as: "bob", method: http.MethodDelete, path: "/api/v1/posts/1",
+		status: http.StatusForbidden, golden: true},
+	{name: "delete-post", as: "alice", method: http.MethodDelete, path: "/api/v1/posts/1",
+		status: http.StatusOK, golden: true},
+	{name: "get-post-deleted", method: http.MethodGet, path: "/api/v1/posts/1",
+		status: http.StatusNotFound},
+	{name: "list-comments-deleted-post", method: http.MethodGet, path: "/api/v1/posts/1/comments",
+		status: http.StatusOK, golden: true},
+	{name: "trash", as: "alice", method: http.MethodGet, path: "/api/v1/me/trash",
+		status: http.StatusOK, golden: true},
+	{name: "restore-post", as: "alice", method: http.MethodPost, path: "/api/v1/posts/1/restore",
+		status: http.StatusOK, golden: true},
+	{name: "list-comments-restored", method: http.MethodGet, path: "/api/v1/posts/1/comments",
+		status: http.StatusOK, golden: true},
+
+	// 安全响应头和跨域
+	{name: "security-headers", method: http.MethodGet, path: "/api/v1/posts/1",
+		status: http.StatusOK,
+		wantHeader: map[string]string{
+			"X-Content-Type-Options":    "nosniff",
+			"X-Frame-Options":           "DENY",
+			"Content-Security-Policy":   "default-src 'none'; frame-ancestors 'none'",
+			"Strict-Transport-Security": "",
+		}},
+	{name: "hsts-behind-proxy", method: http.MethodGet, path: "/api/v1/posts/1",
+		header:     map[string]string{"X-Forwarded-Proto": "https"},
+		status:     http.StatusOK,
+		wantHeader: map[string]string{"Strict-Transport-Security": "max-age=31536000"}},
+	{name: "cors-preflight", method: http.MethodOptions, path: "/api/v1/posts",
+		header: map[string]string{
+			"Origin":                         allowedOrigin,
+			"Access-Control-Request-Method":  "POST",
+			"Access-Control-Request-Headers": "Content-Type, X-CSRF-Token",
+		},
+		status: http.StatusNoContent,
+		wantHeader: map[string]string{
+			"Access-Control-Allow-Origin":      allowedOrigin,
+			"Access-Control-Allow-Credentials": "true",
+			"Access-Control-Allow-Methods":     "GET, POST, PUT, PATCH, DELETE",
+			"Access-Control-Max-Age":           "600",
+		}},
+	{name: "cors-preflight-disallowed-origin", method: http.MethodOptions, path: "/api/v1/posts",
+		header:     map[string]string{"Origin": "https://evil.example.com", "Access-Control-Request-Method": "POST"},
+		status:     http.StatusForbidden,
+		wantHeader: map[string]string{"Access-Control-Allow-Origin": ""}},
+	{name: "cors-simple-request", method: http.MethodGet, path: "/api/v1/posts",
+		header: map[string]string{"Origin": allowedOrigin},
+		status: http.StatusOK,
+		wantHeader: map[string]string{
+			"Access-Control-Allow-Origin":   allowedOrigin,
+			"Access-Control-Expose-Headers": "ETag, X-Request-ID, Deprecation, Sunset, Link, Warning",
+		}},
+
+	// cookie 认证，写请求需要 CSRF token
+	{name: "cookie-get-trash", as: "alice", cookie: true, method: http.MethodGet, path: "/api/v1/me/trash",
+		status: http.StatusOK},
+	{name: "cookie-create-post-without-csrf", as: "alice", cookie: true, method: http.MethodPost, path: "/api/v1/posts",
+		body:   map[string]string{"title": "没有 CSRF", "content": "应当被拒绝"},
+		status: http.StatusUnauthorized, golden: true},
+	{name: "cookie-create-post", as: "alice", cookie: true, csrf: true, method: http.MethodPost, path: "/api/v1/posts",
+		body:   map[string]string{"title": "Cookie Post", "content": "通过 cookie 认证创建"},
+		status: http.StatusOK, golden: true},
+	{name: "cookie-csrf-token", as: "alice", cookie: true, method: http.MethodGet, path: "/api/v1/auth/csrf",
+		status: http.StatusOK, golden: true},
+	{name: "logout", as: "alice", cookie: true, csrf: true, method: http.MethodPost, path: "/api/v1/auth/logout",
+		status: http.StatusOK, golden: true,
+		wantHeader: map[string]string{"Set-Cookie": "blog_token=; Path=/; Max-Age=0; HttpOnly; Secure; SameSite=Lax"}},
+
+	// token 的签名算法、密钥和声明
+	{name: "jwks", method: http.MethodGet, path: "/.well-known/jwks.json",
+		status: http.StatusOK, golden: true,
+		wantHeader: map[string]string{"Cache-Control": "public, max-age=300"}},
+	{name: "token-legacy-hs256", token: forged.legacyHS256, method: http.MethodGet, path: "/api/v1/me/trash",
+		status: http.StatusOK},
+	{name: "token-pre-upgrade", token: forged.preUpgrade, method: http.MethodGet, path: "/api/v1/me/trash",
+		status: http.StatusOK},
+	{name: "token-alg-none", token: forged.algNone, method: http.MethodGet, path: "/api/v1/me/trash",
+		status: http.StatusUnauthorized},
+	{name: "token-unknown-kid", token: forged.unknownKid, method: http.MethodGet, path: "/api/v1/me/trash",
+		status: http.StatusUnauthorized},
+	{name: "token-alg-mismatch", token: forged.algMismatch, method: http.MethodGet, path: "/api/v1/me/trash",
+		status: http.StatusUnauthorized},
+	{name: "token-wrong-audience", token: forged.wrongAudience, method: http.MethodGet, path: "/api/v1/me/trash",
+		status: http.StatusUnauthorized},
+	{name: "token-wrong-issuer", token: forged.wrongIssuer, method: http.MethodGet, path: "/api/v1/me/trash",
+		status: http.StatusUnauthorized},
+	{name: "token-expired", token: forged.expired, method: http.MethodGet, path: "/api/v1/me/trash",
+		status: http.StatusUnauthorized},
+
+	// 通过模拟的 OIDC 提供方登录，oidcCallback 只用于失败时的提示
+	{name: "oidc-providers", method: http.MethodGet, path: "/auth/oidc",
+		status: http.StatusOK, golden: true},
+	{name: "oidc-unknown-provider", method: http.MethodGet, path: "/auth/oidc/unknown/login",
+		status: http.StatusNotFound, golden: true},
+	{name: "oidc-login-new-user", method: http.MethodGet, path: oidcCallback,
+		oidc:   &oidcLogin{subject: "dave", saveAs: "dave"},
+		status: http.StatusOK, golden: true},
+	{name: "oidc-new-user-trash", as: "dave", method: http.MethodGet, path: "/api/v1/me/trash",
+		status: http.StatusOK},
+	{name: "oidc-login-returning", method: http.MethodGet, path: oidcCallback,
+		oidc:   &oidcLogin{subject: "dave"},
+		status: http.StatusOK, golden: true},
+	{name: "oidc-link-existing-user", as: "bob", method: http.MethodGet, path: oidcCallback,
+		oidc:   &oidcLogin{subject: "bob-at-idp"},
+		status: http.StatusOK, golden: true},
+	{name: "oidc-login-linked-user", method: http.MethodGet, path: oidcCallback,
+		oidc:   &oidcLogin{subject: "bob-at-idp"},
+		status: http.StatusOK, golden: true},
+	{name: "oidc-link-conflict", as: "alice", method: http.MethodGet, path: oidcCallback,
+		oidc:   &oidcLogin{subject: "dave"},
+		status: http.StatusConflict, golden: true},
+	{name: "oidc-state-mismatch", method: http.MethodGet, path: oidcCallback,
+		oidc:   &oidcLogin{subject: "dave", tamper: "state"},
+		status: http.StatusBadRequest, golden: true},
+	{name: "oidc-missing-cookie", method: http.MethodGet, path: oidcCallback,
+		oidc:   &oidcLogin{subject: "dave", tamper: "cookie"},
+		status: http.StatusBadRequest},
+	{name: "oidc-replay", method: http.MethodGet, path: oidcCallback,
+		oidc:   &oidcLogin{subject: "dave", tamper: "replay"},
+		status: http.StatusBadRequest, golden: true},
+	{name: "oidc-denied", method: http.MethodGet, path: oidcCallback,
+		oidc:   &oidcLogin{subject: "deny"},
+		status: http.StatusUnauthorized, golden: true},
+	{name: "oidc-bad-nonce", method: http.MethodGet, path: oidcCallback,
+		oidc:   &oidcLogin{subject: "dave", tamper: tamperNonce},
+		status: http.StatusUnauthorized, golden: true},
+	{name: "oidc-wrong-audience", method: http.MethodGet, path: oidcCallback,
+		oidc:   &oidcLogin{subject: "dave", tamper: tamperAudience},
+		status: http.StatusUnauthorized},
+	{name: "oidc-expired-id-token", method: http.MethodGet, path: oidcCallback,
+		oidc:   &oidcLogin{subject: "dave", tamper: tamperExpired},
+		status: http.StatusUnauthorized},
+	{name: "oidc-hs256-id-token", method: http.MethodGet, path: oidcCallback,
+		oidc:   &oidcLogin{subject: "dave", tamper: tamperHS256},
+		status: http.StatusUnauthorized},
+	// 关联只能登录后用 POST 发起，cookie 认证时必须带上 CSRF 请求头；GET 的登录入口忽略 token，按匿名登录处理
+	{name: "oidc-link-anonymous", method: http.MethodPost, path: "/auth/oidc/" + oidcProvider + "/link?login_hint=erin",
+		status: http.StatusUnauthorized},
+	{name: "oidc-link-cookie-without-csrf", as: "bob", cookie: true, method: http.MethodPost,
+		path:   "/auth/oidc/" + oidcProvider + "/link?login_hint=erin",
+		status: http.StatusUnauthorized},
+	{name: "oidc-link-cookie", as: "bob", cookie: true, csrf: true, method: http.MethodPost,
+		path:   "/auth/oidc/" + oidcProvider + "/link?login_hint=erin",
+		status: http.StatusOK},
+	{name: "oidc-login-ignores-token", as: "alice", method: http.MethodGet, path: oidcCallback,
+		oidc:   &oidcLogin{subject: "erin", get: true},
+		status: http.StatusOK, golden: true},
+
+	// v2 接口、v1 上的版本协商和弃用响应头
+	{name: "v2-list-posts", method: http.MethodGet, path: "/api/v2/posts",
+		status: http.StatusOK, golden: true,
+		wantHeader: map[string]string{"Content-Type": v2ContentType, "Deprecation": "", "Sunset": ""}},
+	{name: "v2-get-post", method: http.MethodGet, path: "/api/v2/posts/1",
+		status: http.StatusOK, golden: true},
+	{name: "v2-get-post-not-found", method: http.MethodGet, path: "/api/v2/posts/999",
+		status: http.StatusNotFound, golden: true},
+	{name: "v2-create-post-anonymous", method: http.MethodPost, path: "/api/v2/posts",
+		body:   map[string]string{"title": "v2", "content": "匿名"},
+		status: http.StatusUnauthorized, golden: true},
+	{name: "v2-create-post-invalid", as: "bob", method: http.MethodPost, path: "/api/v2/posts",
+		body:   map[string]string{"title": "缺少正文"},
+		status: http.StatusBadRequest, golden: true},
+	{name: "v2-create-post", as: "bob", method: http.MethodPost, path: "/api/v2/posts",
+		body:   map[string]string{"title": "V2 Post", "content": "通过 v2 创建"},
+		status: http.StatusCreated, golden: true},
+	{name: "v1-deprecation-headers", method: http.MethodGet, path: "/api/v1/posts/1",
+		status: http.StatusOK,
+		wantHeader: map[string]string{
+			"Deprecation": "@1792368000",
+			"Sunset":      "Fri, 30 Apr 2027 00:00:00 GMT",
+			"Link":        `</api/v2>; rel="successor-version"`,
+		}},
+	// 协商到 v2 的请求按 v2 的格式返回，不再带 v1 的弃用响应头
+	{name: "v1-negotiated-get-post", method: http.MethodGet, path: "/api/v1/posts/1",
+		header: map[string]string{"Accept": v2MediaType},
+		status: http.StatusOK, golden: true,
+		wantHeader: map[string]string{"Content-Type": v2ContentType, "Deprecation": ""}},
+	{name: "v1-negotiated-create-post-anonymous", method: http.MethodPost, path: "/api/v1/posts",
+		header: map[string]string{"Accept": v2MediaType},
+		body:   map[string]string{"title": "v2", "content": "匿名"},
+		status: http.StatusUnauthorized, golden: true},
+	// v2 没有 by-slug 接口，仍然由 v1 处理
+	{name: "v1-negotiated-without-v2-route", method: http.MethodGet, path: "/api/v1/posts/by-slug/hello-again",
+		header:     map[string]string{"Accept": v2MediaType},
+		status:     http.StatusOK,
+		wantHeader: map[string]string{"Content-Type": "application/json; charset=utf-8", "Deprecation": "@1792368000"}},
+}
+
+const (
+	v2MediaType   = "application/vnd.golearn.v2+json"
+	v2ContentType = v2MediaType + "; charset=utf-8"
+)
+
+// legacyWarning 是返回旧字段名时附带的 Warning 响应头
+const legacyWarning = `299 - "legacy field names are deprecated"`
+
+const oidcCallback = "/auth/oidc/" + oidcProvider + "/callback"
@@ -0,0 +1,49 @@
+package e2e
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/miffyG/golearn/task4/pkg/config"
+)
+
+// TestLegacyFieldNames 开启 LEGACY_FIELD_NAMES 后，未带 X-API-Compat 的请求默认返回旧字段名，
+// 客户端可以用 X-API-Compat: current 选择新字段名
+func TestLegacyFieldNames(t *testing.T) {
+	s := startServer(t, func(cfg *config.Config) { cfg.Api.LegacyFieldNames = true })
+	r := newRunner(t, s)
+
+	tests := []testCase{
+		{name: "legacy-default-create-post", as: "alice", method: http.MethodPost, path: "/api/v1/posts",
+			body:   map[string]string{"title": "Hello World", "content": "第一篇文章"},
+			status: http.StatusOK, golden: true},
+		{name: "create-comment", as: "bob", method: http.MethodPost, path: "/api/v1/posts/1/comments",
+			body:   map[string]string{"content": "第一条评论"},
+			status: http.StatusOK},
+		{name: "legacy-default-list-posts", method: http.MethodGet, path: "/api/v1/posts",
+			status:     http.StatusOK,
+			wantHeader: map[string]string{"Warning": legacyWarning}, golden: true},
+		{name: "legacy-default-get-post", method: http.MethodGet, path: "/api/v1/posts/1",
+			status:     http.StatusOK,
+			wantHeader: map[string]string{"Warning": legacyWarning}, golden: true},
+		{name: "legacy-default-list-comments", method: http.MethodGet, path: "/api/v1/posts/1/comments",
+			status:     http.StatusOK,
+			wantHeader: map[string]string{"Warning": legacyWarning}, golden: true},
+		// 请求头优先于全局开关
+		{name: "legacy-default-override-current", method: http.MethodGet, path: "/api/v1/posts/1",
+			header:     map[string]string{"X-API-Compat": "current"},
+			status:     http.StatusOK,
+			wantHeader: map[string]string{"Warning": ""}, golden: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := r.run(tc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := r.check(tc, resp); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package e2e
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/miffyG/golearn/task4/pkg/client"
+)
+
+// TestConcurrentUpdate 让两个请求带着同一个 If-Match 同时修改一篇文章，乐观锁只允许其中一个成功，
+// 另一个返回 412（If-Match 与当前版本不一致）或 409（检查版本之后被另一个请求抢先修改）
+func TestConcurrentUpdate(t *testing.T) {
+	s := startServer(t)
+	c := s.newUser(t, "writer")
+	ctx := context.Background()
+
+	t.Run("put", func(t *testing.T) {
+		post, err := c.CreatePost(ctx, client.PostRequest{Title: "并发更新", Content: "原始正文"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		errs := race(func(i int) error {
+			_, err := c.UpdatePost(ctx, post.ID, client.PostRequest{
+				Title:   "并发更新",
+				Content: fmt.Sprintf("第 %d 个请求的正文", i),
+			}, post.Version)
+			return err
+		})
+		checkOneWinner(t, errs)
+		checkVersion(t, c, post.ID, post.Version+1)
+	})
+
+	t.Run("patch", func(t *testing.T) {
+		post, err := c.CreatePost(ctx, client.PostRequest{Title: "并发补丁", Content: "原始正文"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := &runner{base: s.url}
+		header := http.Header{
+			"Authorization": {"Bearer " + c.Token()},
+			"Content-Type":  {"application/merge-patch+json"},
+			"If-Match":      {fmt.Sprintf("%q", fmt.Sprint(post.Version))},
+		}
+		errs := race(func(i int) error {
+			resp, err := r.do(http.DefaultClient, http.MethodPatch, fmt.Sprintf("/api/v1/posts/%d", post.ID), header,
+				map[string]string{"content": fmt.Sprintf("第 %d 个请求的正文", i)})
+			if err != nil {
+				return err
+			}
+			if resp.Status != http.StatusOK {
+				return &client.APIError{StatusCode: resp.Status, Message: string(resp.Body)}
+			}
+			return nil
+		})
+		checkOneWinner(t, errs)
+		checkVersion(t, c, post.ID, post.Version+1)
+	})
+}
+
+// race 同时执行两次 fn，返回各自的错误
+func race(fn func(i int) error) []error {
+	errs := make([]error, 2)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			errs[i] = fn(i)
+		}()
+	}
+	close(start)
+	wg.Wait()
+	return errs
+}
+
+// checkOneWinner 检查恰好一个请求成功，另一个因版本冲突失败
+func checkOneWinner(t *testing.T, errs []error) {
+	t.Helper()
+	succeeded := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			succeeded++
+		case errors.Is(err, client.ErrPreconditionFailed), errors.Is(err, client.ErrConflict):
+		default:
+			t.Errorf("失败的请求返回 %v，期望 412 或 409", err)
+		}
+	}
+	if succeeded != 1 {
+		t.Errorf("%d 个请求成功，期望恰好 1 个: %v", succeeded, errs)
+	}
+}
+
+// checkVersion 检查文章只被修改了一次
+func checkVersion(t *testing.T, c *client.Client, id, want uint) {
+	t.Helper()
+	post, err := c.GetPost(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if post.Version != want {
+		t.Errorf("文章的版本为 %d，期望 %d", post.Version, want)
+	}
+	revisions, err := c.ListRevisions(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revisions) != int(want) {
+		t.Errorf("文章有 %d 个修订版本，期望 %d", len(revisions), want)
+	}
+}
@@ -0,0 +1,188 @@
+// Package e2e 在内存中的 SQLite 上启动完整的服务，按 cases_test.go 中的用例依次调用 HTTP 接口，
+// 检查状态码，并把需要的响应与 testdata 中的 golden 文件比较，发现接口返回结构的意外变化。
+// 服务以 test 环境运行，v1 的每个响应都按 docs/openapi.json 校验，handler 返回文档中没有的状态码或字段时
+// 响应变为 500，对应的用例失败。第三方登录的用例使用 oidc_test.go 中基于 httptest 的模拟 OIDC 提供方。
+//
+// 用法：
+//
+//	go test ./test/e2e [-run 'TestAPI/<用例名>'] [-update] [-v]
+//
+// 接口的返回结构有意修改后，用 -update 重新生成 golden 文件并随代码一起提交。
+package e2e
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"github.com/miffyG/golearn/task4/internal/app"
+	"github.com/miffyG/golearn/task4/internal/models/entity"
+	"github.com/miffyG/golearn/task4/pkg/client"
+	"github.com/miffyG/golearn/task4/pkg/config"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+var update = flag.Bool("update", false, "用实际响应覆盖 golden 文件")
+
+func TestMain(m *testing.M) {
+	// 只输出测试结果，不输出 Gin 的访问日志和调试信息
+	gin.SetMode(app.GinMode(config.ProfileTest))
+	gin.DefaultWriter = io.Discard
+	os.Exit(m.Run())
+}
+
+// TestAPI 按顺序执行 cases 中的用例。后面的用例依赖前面用例创建的数据，所以请求在子测试之外发送，
+// 不匹配 -run 的用例照常执行，只是不检查结果
+func TestAPI(t *testing.T) {
+	s := startServer(t)
+	r := newRunner(t, s)
+	for _, tc := range cases {
+		resp, err := r.run(tc)
+		t.Run(tc.name, func(t *testing.T) {
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := r.check(tc, resp); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// allowedOrigin 是测试中允许跨域访问的前端地址
+const allowedOrigin = "https://app.example.com"
+
+// server 是一个测试用的服务实例
+type server struct {
+	cfg *config.Config
+	app *app.App
+	// url 是 HTTP 服务的地址，grpcAddr 是 gRPC 服务的地址，没有开启 gRPC 时为空
+	url      string
+	grpcAddr string
+	// idp 是服务配置的模拟 OIDC 提供方
+	idp *mockProvider
+}
+
+// databases 为每个服务实例生成不同的内存数据库名称
+var databases atomic.Int32
+
+// startServer 启动模拟的 OIDC 提供方，并在独立的内存数据库上创建 App，用 App.Serve 在随机端口上运行，
+// 测试结束时关闭服务并释放全部资源。configure 在校验配置之前调用，用于修改个别配置项，
+// 设置了 GRPC_ADDR 时 gRPC 服务同样监听随机端口
+func startServer(t *testing.T, configure ...func(*config.Config)) *server {
+	t.Helper()
+	// 只使用默认值，避免本机的 .env 和环境变量影响结果
+	cfg := config.Default(config.ProfileTest)
+	// 密钥在 App 创建时读取，测试结束后删除
+	keyFile, err := writeSigningKey(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	configureJwt(cfg, keyFile)
+	cfg.Grpc.Addr = ""
+	cfg.Trash.PurgeInterval = 0
+	cfg.Cors.AllowedOrigins = []string{allowedOrigin}
+	cfg.Cors.AllowCredentials = true
+	cfg.AuthCookie.Enabled = true
+
+	idp, err := startMockProvider(cfg.Site.URL + "/auth/oidc/" + oidcProvider + "/callback")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(idp.srv.Close)
+	idp.configureOidc(cfg)
+	for _, fn := range configure {
+		fn(cfg)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	dsn := fmt.Sprintf("file:e2e%d?mode=memory&cache=shared&_pragma=foreign_keys(1)", databases.Add(1))
+	gormDb, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: gormlogger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := gormDb.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 内存数据库在最后一个连接关闭时被删除，只保留一个连接同时避免 SQLite 的写锁冲突
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	httpLn := listen(t)
+	opts := []app.Option{app.WithDB(gormDb), app.WithLogger(zap.NewNop())}
+	var grpcAddr string
+	if cfg.Grpc.Addr != "" {
+		grpcLn := listen(t)
+		grpcAddr = grpcLn.Addr().String()
+		opts = append(opts, app.WithGRPCListener(grpcLn))
+	}
+	a, err := app.New(context.Background(), cfg, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { a.Close() })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- a.Serve(ctx, httpLn) }()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("服务退出: %v", err)
+		}
+	})
+	return &server{cfg: cfg, app: a, url: "http://" + httpLn.Addr().String(), grpcAddr: grpcAddr, idp: idp}
+}
+
+// listen 在本机的随机端口上监听，测试结束时关闭
+func listen(t *testing.T) net.Listener {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	return ln
+}
+
+// goldenDir 返回本文件旁的 testdata 目录
+func goldenDir() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), "testdata")
+}
+
+// newUser 注册并登录一个新用户，返回以该用户身份调用的客户端
+func (s *server) newUser(t *testing.T, username string) *client.Client {
+	t.Helper()
+	ctx := context.Background()
+	c := client.New(s.url, client.WithRetry(0, 0))
+	if _, err := c.Register(ctx, client.RegisterRequest{Username: username, Password: username + "-password", Email: username + "@example.com"}); err != nil {
+		t.Fatalf("注册 %s 失败: %v", username, err)
+	}
+	if _, err := c.Login(ctx, username, username+"-password"); err != nil {
+		t.Fatalf("登录 %s 失败: %v", username, err)
+	}
+	return c
+}
+
+// setRole 直接在数据库中修改用户的角色，接口没有授予角色的入口
+func (s *server) setRole(t *testing.T, username, role string) {
+	t.Helper()
+	if err := s.app.DB.Model(&entity.User{}).Where("user_name = ?", username).Update("role", role).Error; err != nil {
+		t.Fatal(err)
+	}
+}
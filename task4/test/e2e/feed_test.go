@@ -0,0 +1,131 @@
+package e2e
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/miffyG/golearn/task4/internal/models/entity"
+	"github.com/miffyG/golearn/task4/pkg/client"
+)
+
+// TestFeedETag 检查订阅源的条件请求：内容不变时返回 304，删除文章后即使最晚的更新时间不变也返回新内容
+func TestFeedETag(t *testing.T) {
+	s := startServer(t)
+	c := s.newUser(t, "feeder")
+	ctx := context.Background()
+	if _, err := c.CreatePost(ctx, client.PostRequest{Title: "较早的文章", Content: "保留"}); err != nil {
+		t.Fatal(err)
+	}
+	latest, err := c.CreatePost(ctx, client.PostRequest{Title: "较晚的文章", Content: "稍后删除"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &runner{base: s.url}
+
+	for _, path := range []string{"/feed.rss", "/feed.atom", "/feed.json", "/authors/feeder/feed.atom"} {
+		t.Run(path, func(t *testing.T) {
+			first, err := r.do(http.DefaultClient, http.MethodGet, path, nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			etag := first.Header.Get("ETag")
+			if first.Status != http.StatusOK || etag == "" {
+				t.Fatalf("状态码 %d，ETag %q", first.Status, etag)
+			}
+			cached, err := r.do(http.DefaultClient, http.MethodGet, path, http.Header{"If-None-Match": {etag}}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cached.Status != http.StatusNotModified {
+				t.Errorf("带 If-None-Match 的请求返回 %d，期望 304", cached.Status)
+			}
+		})
+	}
+
+	before, err := r.do(http.DefaultClient, http.MethodGet, "/feed.atom", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.DeletePost(ctx, latest.ID); err != nil {
+		t.Fatal(err)
+	}
+	after, err := r.do(http.DefaultClient, http.MethodGet, "/feed.atom", http.Header{"If-None-Match": {before.Header.Get("ETag")}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.Status != http.StatusOK || after.Header.Get("ETag") == before.Header.Get("ETag") {
+		t.Errorf("删除文章后返回 %d，ETag %q，期望 200 和新的 ETag", after.Status, after.Header.Get("ETag"))
+	}
+}
+
+// TestFeedLastModified 检查订阅源的 Last-Modified 和 If-Modified-Since：
+// 没有 If-None-Match 时按时间判断，两者都有时只看 If-None-Match
+func TestFeedLastModified(t *testing.T) {
+	s := startServer(t)
+	r := &runner{base: s.url}
+	empty, err := r.do(http.DefaultClient, http.MethodGet, "/feed.atom", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lm := empty.Header.Get("Last-Modified"); empty.Status != http.StatusOK || lm != "" {
+		t.Errorf("没有文章时返回 %d，Last-Modified %q，期望 200 且没有 Last-Modified", empty.Status, lm)
+	}
+
+	c := s.newUser(t, "feeder")
+	post, err := c.CreatePost(context.Background(), client.PostRequest{Title: "按时间缓存", Content: "正文"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := r.do(http.DefaultClient, http.MethodGet, "/feed.atom", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lastModified := first.Header.Get("Last-Modified")
+	modified, err := http.ParseTime(lastModified)
+	if err != nil {
+		t.Fatalf("Last-Modified %q 无法解析: %v", lastModified, err)
+	}
+	etag := first.Header.Get("ETag")
+	earlier := modified.Add(-time.Hour).Format(http.TimeFormat)
+
+	tests := []struct {
+		name   string
+		header http.Header
+		status int
+	}{
+		{"if-modified-since-same", http.Header{"If-Modified-Since": {lastModified}}, http.StatusNotModified},
+		{"if-modified-since-earlier", http.Header{"If-Modified-Since": {earlier}}, http.StatusOK},
+		{"if-modified-since-invalid", http.Header{"If-Modified-Since": {"yesterday"}}, http.StatusOK},
+		{"if-none-match-wins-over-date", http.Header{"If-None-Match": {`"other"`}, "If-Modified-Since": {lastModified}}, http.StatusOK},
+		{"if-none-match-matches", http.Header{"If-None-Match": {etag}, "If-Modified-Since": {earlier}}, http.StatusNotModified},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := r.do(http.DefaultClient, http.MethodGet, "/feed.atom", tc.header, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Status != tc.status {
+				t.Errorf("返回 %d，期望 %d", res.Status, tc.status)
+			}
+			if lm := res.Header.Get("Last-Modified"); lm != lastModified {
+				t.Errorf("Last-Modified 为 %q，期望 %q", lm, lastModified)
+			}
+		})
+	}
+
+	// 文章更新后 Last-Modified 变晚，旧的 If-Modified-Since 不再命中
+	later := modified.Add(time.Hour)
+	if err := s.app.DB.Model(&entity.Post{}).Where("id = ?", post.ID).Update("updated_at", later).Error; err != nil {
+		t.Fatal(err)
+	}
+	updated, err := r.do(http.DefaultClient, http.MethodGet, "/feed.atom", http.Header{"If-Modified-Since": {lastModified}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Status != http.StatusOK || updated.Header.Get("Last-Modified") != later.Format(http.TimeFormat) {
+		t.Errorf("文章更新后返回 %d，Last-Modified %q，期望 200 和 %q", updated.Status, updated.Header.Get("Last-Modified"), later.Format(http.TimeFormat))
+	}
+}
@@ -0,0 +1,58 @@
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/miffyG/golearn/task4/pkg/client"
+)
+
+// TestIfMatchList 检查 PUT 和 PATCH 对 If-Match 列表的处理：任意一个强校验 ETag 与当前版本相同即可修改，
+// 弱校验或不是版本号的 ETag 不匹配，返回 412；只有语法错误返回 400
+func TestIfMatchList(t *testing.T) {
+	s := startServer(t)
+	c := s.newUser(t, "matcher")
+	r := &runner{base: s.url}
+
+	// 每一步的版本号依赖前面成功的修改，文章从版本 1 开始
+	steps := []struct {
+		name    string
+		ifMatch string
+		want    int
+	}{
+		{"weak", `W/"1"`, http.StatusPreconditionFailed},
+		{"not-a-version", `"abc"`, http.StatusPreconditionFailed},
+		{"unterminated", `"1`, http.StatusBadRequest},
+		{"star-in-list", `*, "1"`, http.StatusBadRequest},
+		{"list", `"5", "1"`, http.StatusOK},
+		{"list-with-weak-current", `"1", W/"2"`, http.StatusPreconditionFailed},
+		{"list-with-weak-other", `W/"1", "2"`, http.StatusOK},
+		{"star", `*`, http.StatusOK},
+	}
+	for _, method := range []string{http.MethodPut, http.MethodPatch} {
+		t.Run(method, func(t *testing.T) {
+			post, err := c.CreatePost(context.Background(), client.PostRequest{Title: "If-Match 列表", Content: "原始正文"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			header := http.Header{"Authorization": {"Bearer " + c.Token()}}
+			if method == http.MethodPatch {
+				header.Set("Content-Type", "application/merge-patch+json")
+			}
+			for _, step := range steps {
+				header.Set("If-Match", step.ifMatch)
+				resp, err := r.do(http.DefaultClient, method, fmt.Sprintf("/api/v1/posts/%d", post.ID), header,
+					map[string]string{"title": "If-Match 列表", "content": "由 " + step.name + " 修改"})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if resp.Status != step.want {
+					t.Errorf("%s: If-Match %s 返回 %d，期望 %d: %s", step.name, step.ifMatch, resp.Status, step.want, resp.Body)
+				}
+			}
+			checkVersion(t, c, post.ID, 4)
+		})
+	}
+}
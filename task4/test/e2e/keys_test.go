@@ -0,0 +1,154 @@
+package e2e
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/miffyG/golearn/task4/internal/tokens"
+	"github.com/miffyG/golearn/task4/pkg/config"
+)
+
+const (
+	// jwtSecret 只用于校验迁移前签发的 HS256 token，服务用 signingKey 签名
+	jwtSecret = "e2e-secret-e2e-secret-e2e-secret"
+	issuer    = "golearn-blog"
+	audience  = "golearn-blog-api"
+)
+
+// signingKey 由固定的种子生成，kid 和 JWKS 的 golden 文件在每次运行时都相同
+var signingKey = ed25519.NewKeyFromSeed([]byte("e2e-ed25519-seed-e2e-ed25519-see"))
+
+// forged 是各种不应通过校验的 token，以及迁移前用 HS256 签发、仍应通过校验的 token，都以 alice（ID 1）的身份签发
+var forged = forgeTokens()
+
+type forgedTokens struct {
+	legacyHS256   string
+	preUpgrade    string
+	algNone       string
+	unknownKid    string
+	algMismatch   string
+	wrongAudience string
+	wrongIssuer   string
+	expired       string
+}
+
+// writeSigningKey 把 signingKey 以 PKCS#8 PEM 格式写入 dir，返回文件路径
+func writeSigningKey(dir string) (string, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(signingKey)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, "jwt-ed25519.pem")
+	return path, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600)
+}
+
+func forgeTokens() forgedTokens {
+	der, err := x509.MarshalPKCS8PrivateKey(signingKey)
+	if err != nil {
+		panic(err)
+	}
+	key, err := tokens.ParseKey(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	if err != nil {
+		panic(err)
+	}
+	_, otherKey, _ := ed25519.GenerateKey(nil)
+
+	claims := func(mod func(*tokens.Claims)) *tokens.Claims {
+		now := time.Now()
+		c := &tokens.Claims{UserID: 1, Username: "alice", RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    issuer,
+			Subject:   strconv.Itoa(1),
+			Audience:  jwt.ClaimStrings{audience},
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+			NotBefore: jwt.NewNumericDate(now),
+			IssuedAt:  jwt.NewNumericDate(now),
+		}}
+		if mod != nil {
+			mod(c)
+		}
+		return c
+	}
+	sign := func(method jwt.SigningMethod, kid string, c *tokens.Claims, signKey interface{}) string {
+		t := jwt.NewWithClaims(method, c)
+		if kid != "" {
+			t.Header["kid"] = kid
+		}
+		s, err := t.SignedString(signKey)
+		if err != nil {
+			panic(err)
+		}
+		return s
+	}
+
+	return forgedTokens{
+		legacyHS256: sign(jwt.SigningMethodHS256, "", claims(nil), []byte(jwtSecret)),
+		preUpgrade:  preUpgradeToken(1, "alice"),
+		algNone:     sign(jwt.SigningMethodNone, key.ID, claims(nil), jwt.UnsafeAllowNoneSignatureType),
+		unknownKid:  sign(jwt.SigningMethodEdDSA, "unknown", claims(nil), otherKey),
+		// 用 EdDSA 密钥的 kid 和 HS256 签名，服务不能按头部的 alg 选择校验方式
+		algMismatch: sign(jwt.SigningMethodHS256, key.ID, claims(nil), []byte(jwtSecret)),
+		wrongAudience: sign(jwt.SigningMethodEdDSA, key.ID, claims(func(c *tokens.Claims) {
+			c.Audience = jwt.ClaimStrings{"another-api"}
+		}), signingKey),
+		wrongIssuer: sign(jwt.SigningMethodEdDSA, key.ID, claims(func(c *tokens.Claims) {
+			c.Issuer = "https://evil.example.com"
+		}), signingKey),
+		expired: sign(jwt.SigningMethodEdDSA, key.ID, claims(func(c *tokens.Claims) {
+			c.IssuedAt = jwt.NewNumericDate(time.Now().Add(-2 * time.Hour))
+			c.NotBefore = c.IssuedAt
+			c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Hour))
+		}), signingKey),
+	}
+}
+
+// preUpgradeToken 按升级前的格式签发 token：HS256，只有 exp、iat、username、user_id
+func preUpgradeToken(userID uint, username string) string {
+	now := time.Now()
+	t := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"exp":      now.Add(time.Hour).Unix(),
+		"iat":      now.Unix(),
+		"username": username,
+		"user_id":  userID,
+	})
+	s, err := t.SignedString([]byte(jwtSecret))
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// configureJwt 让服务用 EdDSA 签名，同时接受用 jwtSecret 签发的 HS256 token，升级前签发的 token 在一小时内仍然有效
+func configureJwt(cfg *config.Config, keyFile string) {
+	cfg.Secret.JwtSecret = jwtSecret
+	cfg.Jwt.Algorithm = tokens.EdDSA
+	cfg.Jwt.Keys = []string{keyFile}
+	cfg.Jwt.AllowedAlgorithms = []string{tokens.EdDSA, tokens.HS256}
+	cfg.Jwt.Issuer = issuer
+	cfg.Jwt.Audience = audience
+	cfg.Jwt.LegacyUntil = time.Now().Add(time.Hour)
+}
+
+// TestLegacyUntil 检查过了 JWT_LEGACY_UNTIL 后不再接受升级前签发的 token
+func TestLegacyUntil(t *testing.T) {
+	s := startServer(t, func(cfg *config.Config) {
+		cfg.Jwt.LegacyUntil = time.Now().Add(-time.Minute)
+	})
+	s.newUser(t, "legacy")
+	r := &runner{base: s.url}
+	header := http.Header{"Authorization": {"Bearer " + preUpgradeToken(1, "legacy")}}
+	resp, err := r.do(http.DefaultClient, http.MethodGet, "/api/v1/me/trash", header, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status != http.StatusUnauthorized {
+		t.Errorf("状态码 %d，期望 401: %s", resp.Status, resp.Body)
+	}
+}
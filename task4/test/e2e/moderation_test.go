@@ -0,0 +1,260 @@
+package e2e
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/miffyG/golearn/task4/internal/audit"
+	"github.com/miffyG/golearn/task4/internal/models/entity"
+	"github.com/miffyG/golearn/task4/pkg/client"
+	"github.com/miffyG/golearn/task4/pkg/config"
+)
+
+// heldWord 是测试中触发人工审核的屏蔽词
+const heldWord = "spam"
+
+// TestModeration 检查待审核文章的修订记录只对作者、审核员和管理员可见，同一条举报只能被处理一次，
+// 以及封禁作者时对管理员、审核员和审核员本人的保护
+func TestModeration(t *testing.T) {
+	s := startServer(t, func(cfg *config.Config) {
+		cfg.Moderation.BlockWords = []string{heldWord}
+		cfg.Moderation.BlockAction = "hold"
+	})
+	ctx := context.Background()
+	mod := s.newUser(t, "mod")
+	s.setRole(t, "mod", entity.RoleModerator)
+	other := s.newUser(t, "othermod")
+	s.setRole(t, "othermod", entity.RoleModerator)
+	admin := s.newUser(t, "admin")
+	s.setRole(t, "admin", entity.RoleAdmin)
+	writer := s.newUser(t, "writer")
+	reader := s.newUser(t, "reader")
+
+	t.Run("held-revisions", func(t *testing.T) {
+		post, err := writer.CreatePost(ctx, client.PostRequest{Title: "待审核", Content: "含有 " + heldWord})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reportFor(t, mod, post.ID) == 0 {
+			t.Fatal("待审核的文章不在审核队列中")
+		}
+		anonymous := client.New(s.url)
+		for _, tc := range []struct {
+			name string
+			c    *client.Client
+			want error
+		}{
+			{"anonymous", anonymous, client.ErrNotFound},
+			{"reader", reader, client.ErrNotFound},
+			{"author", writer, nil},
+			{"moderator", mod, nil},
+			{"admin", admin, nil},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				if _, err := tc.c.ListRevisions(ctx, post.ID); !errorIs(err, tc.want) {
+					t.Errorf("修订历史返回 %v，期望 %v", err, tc.want)
+				}
+				if _, err := tc.c.GetRevision(ctx, post.ID, 1); !errorIs(err, tc.want) {
+					t.Errorf("修订版本返回 %v，期望 %v", err, tc.want)
+				}
+				r := &runner{base: s.url}
+				header := http.Header{}
+				if token := tc.c.Token(); token != "" {
+					header.Set("Authorization", "Bearer "+token)
+				}
+				resp, err := r.do(http.DefaultClient, http.MethodGet, fmt.Sprintf("/api/v1/posts/%d/revisions/1/diff", post.ID), header, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				want := http.StatusOK
+				if tc.want != nil {
+					want = http.StatusNotFound
+				}
+				if resp.Status != want {
+					t.Errorf("版本差异返回 %d，期望 %d", resp.Status, want)
+				}
+			})
+		}
+	})
+
+	// 待审核的文章对作者可见，作者可以用 PATCH 修改它；没有 If-Match 时不做版本校验，与 PUT 相同
+	t.Run("held-patch", func(t *testing.T) {
+		post, err := writer.CreatePost(ctx, client.PostRequest{Title: "待审核补丁", Content: "含有 " + heldWord})
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := &runner{base: s.url}
+		for _, tc := range []struct {
+			name    string
+			c       *client.Client
+			ifMatch string
+			want    int
+		}{
+			{"reader", reader, "", http.StatusForbidden},
+			{"author-stale-if-match", writer, fmt.Sprintf("%q", fmt.Sprint(post.Version+1)), http.StatusPreconditionFailed},
+			{"author-without-if-match", writer, "", http.StatusOK},
+			{"author-if-match", writer, fmt.Sprintf("%q", fmt.Sprint(post.Version+1)), http.StatusOK},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				header := http.Header{
+					"Authorization": {"Bearer " + tc.c.Token()},
+					"Content-Type":  {"application/merge-patch+json"},
+				}
+				if tc.ifMatch != "" {
+					header.Set("If-Match", tc.ifMatch)
+				}
+				resp, err := r.do(http.DefaultClient, http.MethodPatch, fmt.Sprintf("/api/v1/posts/%d", post.ID), header,
+					map[string]string{"content": "仍然含有 " + heldWord + " " + tc.name})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if resp.Status != tc.want {
+					t.Errorf("PATCH 返回 %d，期望 %d: %s", resp.Status, tc.want, resp.Body)
+				}
+			})
+		}
+		got, err := writer.GetPost(ctx, post.ID)
+		if !errorIs(err, client.ErrNotFound) {
+			t.Errorf("修改后的待审核文章对外返回 %+v, %v，期望仍然不可见", got, err)
+		}
+	})
+
+	// 两个审核员同时处理同一条举报：只有一方成功，另一方得到 409，内容状态、举报状态和审计日志都与成功的一方一致
+	t.Run("concurrent-resolve", func(t *testing.T) {
+		for round := 0; round < 5; round++ {
+			post, err := writer.CreatePost(ctx, client.PostRequest{Title: fmt.Sprintf("并发审核 %d", round), Content: heldWord})
+			if err != nil {
+				t.Fatal(err)
+			}
+			reportId := reportFor(t, mod, post.ID)
+			errs := race(func(i int) error {
+				if i == 0 {
+					_, err := mod.ApproveReport(ctx, reportId)
+					return err
+				}
+				_, err := other.RemoveReport(ctx, reportId)
+				return err
+			})
+			if (errs[0] == nil) == (errs[1] == nil) {
+				t.Fatalf("第 %d 轮: 通过返回 %v，移除返回 %v，期望恰好一方成功", round, errs[0], errs[1])
+			}
+			for _, err := range errs {
+				if err != nil && !errors.Is(err, client.ErrConflict) {
+					t.Fatalf("第 %d 轮: 失败的一方返回 %v，期望 409", round, err)
+				}
+			}
+			wantPost, wantReport := entity.StatusPublished, entity.ReportApproved
+			if errs[1] == nil {
+				wantPost, wantReport = entity.StatusRemoved, entity.ReportRemoved
+			}
+			var p entity.Post
+			var r entity.Report
+			var decisions int64
+			if err := s.app.DB.First(&p, post.ID).Error; err != nil {
+				t.Fatal(err)
+			}
+			if err := s.app.DB.First(&r, reportId).Error; err != nil {
+				t.Fatal(err)
+			}
+			if err := s.app.DB.Model(&entity.AuditLog{}).Where("target_type = ? AND target_id = ? AND action IN ?",
+				entity.ReportTargetPost, post.ID, []string{audit.ActionReportApprove, audit.ActionReportRemove}).Count(&decisions).Error; err != nil {
+				t.Fatal(err)
+			}
+			if p.Status != wantPost || r.Status != wantReport || decisions != 1 {
+				t.Errorf("第 %d 轮: 文章为 %s、举报为 %s、审核日志 %d 条，期望 %s、%s、1 条",
+					round, p.Status, r.Status, decisions, wantPost, wantReport)
+			}
+		}
+	})
+
+	t.Run("ban", func(t *testing.T) {
+		for _, tc := range []struct {
+			name   string
+			author *client.Client
+			want   error
+		}{
+			{"admin", admin, client.ErrForbidden},
+			{"other-moderator", other, client.ErrForbidden},
+			{"self", mod, client.ErrForbidden},
+			{"user", writer, nil},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				post, err := tc.author.CreatePost(ctx, client.PostRequest{Title: "封禁 " + tc.name, Content: heldWord})
+				if err != nil {
+					t.Fatal(err)
+				}
+				reportId := reportFor(t, mod, post.ID)
+				if _, err := mod.BanAuthor(ctx, reportId); !errorIs(err, tc.want) {
+					t.Fatalf("封禁返回 %v，期望 %v", err, tc.want)
+				}
+				var author entity.User
+				if err := s.app.DB.Where("id = ?", post.UserID).Take(&author).Error; err != nil {
+					t.Fatal(err)
+				}
+				if author.Banned != (tc.want == nil) {
+					t.Errorf("作者的封禁状态为 %v", author.Banned)
+				}
+				if tc.want != nil && reportFor(t, mod, post.ID) != reportId {
+					t.Error("拒绝封禁后举报不应被关闭")
+				}
+			})
+		}
+	})
+}
+
+// reportFor 返回审核队列中针对文章的举报 ID，不存在时返回 0
+func reportFor(t *testing.T, mod *client.Client, postId uint) uint {
+	t.Helper()
+	for item, err := range mod.ModerationQueue(context.Background(), 50) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if item.Report.TargetType == entity.ReportTargetPost && item.Report.TargetID == postId {
+			return item.Report.ID
+		}
+	}
+	return 0
+}
+
+func errorIs(err, target error) bool {
+	if target == nil {
+		return err == nil
+	}
+	return errors.Is(err, target)
+}
+
+// TestHeldCreateRollsBack 检查被拦截的文章和评论与审核队列中的举报一起写入：举报写入失败时内容也不保存
+func TestHeldCreateRollsBack(t *testing.T) {
+	s := startServer(t, func(cfg *config.Config) {
+		cfg.Moderation.BlockWords = []string{heldWord}
+		cfg.Moderation.BlockAction = "hold"
+	})
+	ctx := context.Background()
+	c := s.newUser(t, "writer")
+	post, err := c.CreatePost(ctx, client.PostRequest{Title: "正常文章", Content: "没有屏蔽词"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.app.DB.Migrator().DropTable(&entity.Report{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.CreatePost(ctx, client.PostRequest{Title: "被拦截", Content: heldWord}); err == nil {
+		t.Error("举报写入失败时创建文章成功")
+	}
+	if _, err := c.CreateComment(ctx, post.ID, heldWord); err == nil {
+		t.Error("举报写入失败时创建评论成功")
+	}
+	for _, model := range []interface{}{&entity.Post{}, &entity.Comment{}} {
+		var n int64
+		if err := s.app.DB.Unscoped().Model(model).Where("status = ?", entity.StatusPending).Count(&n).Error; err != nil {
+			t.Fatal(err)
+		}
+		if n != 0 {
+			t.Errorf("%T 中留下了 %d 条没有举报的待审核记录", model, n)
+		}
+	}
+}
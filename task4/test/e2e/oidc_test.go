@@ -0,0 +1,302 @@
+package e2e
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/miffyG/golearn/task4/internal/tokens"
+	"github.com/miffyG/golearn/task4/pkg/config"
+)
+
+const (
+	// oidcProvider 是模拟提供方在服务配置中的名称
+	oidcProvider     = "mock"
+	oidcClientID     = "golearn-e2e"
+	oidcClientSecret = "mock-client-secret"
+)
+
+// 模拟提供方按 tamper 签发不合法的 ID token，用于检查服务端的校验
+const (
+	tamperNonce    = "nonce"
+	tamperAudience = "audience"
+	tamperExpired  = "expired"
+	tamperHS256    = "hs256"
+)
+
+// mockProvider 是测试用的 OpenID Connect 提供方：授权端点不显示登录页面，直接以 login_hint 为 sub 签发授权码，
+// login_hint 为 deny 时返回 access_denied；令牌端点检查 client 认证、redirect_uri 和 PKCE，用 RS256 签发 ID token
+type mockProvider struct {
+	srv         *httptest.Server
+	key         *rsa.PrivateKey
+	jwk         tokens.JWK
+	redirectURI string
+
+	mu     sync.Mutex
+	codes  map[string]mockGrant
+	tamper string
+}
+
+// mockGrant 是授权码对应的登录信息
+type mockGrant struct {
+	subject     string
+	nonce       string
+	challenge   string
+	redirectURI string
+}
+
+// startMockProvider 启动模拟提供方，redirectURI 是服务的回调地址
+func startMockProvider(redirectURI string) (*mockProvider, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	pub, err := tokens.ParseKey(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+	if err != nil {
+		return nil, err
+	}
+	m := &mockProvider{key: key, jwk: pub.JWK(), redirectURI: redirectURI, codes: map[string]mockGrant{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", m.discovery)
+	mux.HandleFunc("GET /authorize", m.authorize)
+	mux.HandleFunc("POST /token", m.token)
+	mux.HandleFunc("GET /jwks", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, tokens.JWKS{Keys: []tokens.JWK{m.jwk}})
+	})
+	m.srv = httptest.NewServer(mux)
+	return m, nil
+}
+
+// configureOidc 把模拟提供方加入服务的配置
+func (m *mockProvider) configureOidc(cfg *config.Config) {
+	cfg.Oidc.Providers = map[string]string{oidcProvider: m.srv.URL}
+	cfg.Oidc.ClientIDs = map[string]string{oidcProvider: oidcClientID}
+	cfg.Oidc.ClientSecrets = map[string]string{oidcProvider: oidcClientSecret}
+}
+
+// setTamper 设置下一次签发 ID token 时的篡改方式，为空时正常签发
+func (m *mockProvider) setTamper(tamper string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tamper = tamper
+}
+
+func (m *mockProvider) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                m.srv.URL,
+		"authorization_endpoint":                m.srv.URL + "/authorize",
+		"token_endpoint":                        m.srv.URL + "/token",
+		"jwks_uri":                              m.srv.URL + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"code_challenge_methods_supported":      []string{"S256"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"token_endpoint_auth_methods_supported": []string{"client_secret_basic"},
+	})
+}
+
+func (m *mockProvider) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("client_id") != oidcClientID || q.Get("redirect_uri") != m.redirectURI || q.Get("response_type") != "code" ||
+		q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" || q.Get("state") == "" ||
+		!strings.Contains(" "+q.Get("scope")+" ", " openid ") {
+		http.Error(w, "invalid authorization request", http.StatusBadRequest)
+		return
+	}
+	callback := url.Values{"state": {q.Get("state")}}
+	if q.Get("login_hint") == "deny" {
+		callback.Set("error", "access_denied")
+	} else {
+		code := randomString()
+		m.mu.Lock()
+		m.codes[code] = mockGrant{subject: q.Get("login_hint"), nonce: q.Get("nonce"), challenge: q.Get("code_challenge"), redirectURI: q.Get("redirect_uri")}
+		m.mu.Unlock()
+		callback.Set("code", code)
+	}
+	http.Redirect(w, r, m.redirectURI+"?"+callback.Encode(), http.StatusFound)
+}
+
+func (m *mockProvider) token(w http.ResponseWriter, r *http.Request) {
+	id, secret, ok := r.BasicAuth()
+	if !ok || id != oidcClientID || secret != oidcClientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+	m.mu.Lock()
+	grant, ok := m.codes[r.PostFormValue("code")]
+	delete(m.codes, r.PostFormValue("code"))
+	tamper := m.tamper
+	m.mu.Unlock()
+	sum := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+	if !ok || r.PostFormValue("grant_type") != "authorization_code" || r.PostFormValue("redirect_uri") != grant.redirectURI ||
+		base64.RawURLEncoding.EncodeToString(sum[:]) != grant.challenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":                m.srv.URL,
+		"sub":                grant.subject,
+		"aud":                oidcClientID,
+		"iat":                now.Unix(),
+		"exp":                now.Add(5 * time.Minute).Unix(),
+		"nonce":              grant.nonce,
+		"email":              grant.subject + "@idp.example.com",
+		"email_verified":     true,
+		"preferred_username": grant.subject,
+	}
+	switch tamper {
+	case tamperNonce:
+		claims["nonce"] = "forged-nonce"
+	case tamperAudience:
+		claims["aud"] = "another-client"
+	case tamperExpired:
+		claims["iat"], claims["exp"] = now.Add(-time.Hour).Unix(), now.Add(-30*time.Minute).Unix()
+	}
+	var idToken string
+	var err error
+	if tamper == tamperHS256 {
+		idToken, err = jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(oidcClientSecret))
+	} else {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = m.jwk.Kid
+		idToken, err = token.SignedString(m.key)
+	}
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": randomString(),
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     idToken,
+	})
+}
+
+func randomString() string {
+	b := make([]byte, 24)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// oidcLogin 是一次经过模拟提供方的第三方登录：依次请求登录入口、提供方的授权端点和回调地址，用例检查回调的响应。
+// 用例的 as 不为空时以该用户的身份用 POST 发起关联，回调后把外部账号关联到该用户
+type oidcLogin struct {
+	// subject 是提供方的账号，作为 login_hint 传给模拟提供方
+	subject string
+	// tamper 是模拟提供方的篡改方式，或者 state（回调的 state 与 cookie 不一致）、cookie（回调不带 cookie）、
+	// replay（同一个回调请求两次，检查第二次）
+	tamper string
+	// saveAs 不为空时把登录得到的 token 保存为同名用户的会话，供后面的用例使用
+	saveAs string
+	// get 为 true 时即使 as 不为空也用 GET 的登录入口发起，检查携带的 token 被忽略
+	get bool
+}
+
+// noRedirect 是不跟随跳转的客户端，用于逐步检查登录流程中的跳转
+var noRedirect = &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+	return http.ErrUseLastResponse
+}}
+
+// oidcFlow 执行 tc.oidc 描述的登录，返回回调的响应
+func (r *runner) oidcFlow(tc testCase, header http.Header) (*response, error) {
+	flow := tc.oidc
+	hint := "?login_hint=" + url.QueryEscape(flow.subject)
+	var login *response
+	var authURL string
+	var err error
+	if tc.as != "" && !flow.get {
+		login, err = r.do(noRedirect, http.MethodPost, "/auth/oidc/"+oidcProvider+"/link"+hint, header, nil)
+		if err != nil {
+			return nil, err
+		}
+		var out struct {
+			Data struct {
+				AuthorizationURL string `json:"authorization_url"`
+			} `json:"data"`
+		}
+		if login.Status != http.StatusOK || json.Unmarshal(login.Body, &out) != nil {
+			return nil, fmt.Errorf("关联入口返回 %d，期望 200: %s", login.Status, login.Body)
+		}
+		authURL = out.Data.AuthorizationURL
+	} else {
+		login, err = r.do(noRedirect, http.MethodGet, "/auth/oidc/"+oidcProvider+"/login"+hint, header, nil)
+		if err != nil {
+			return nil, err
+		}
+		if login.Status != http.StatusFound {
+			return nil, fmt.Errorf("登录入口返回 %d，期望 302: %s", login.Status, login.Body)
+		}
+		authURL = login.Header.Get("Location")
+	}
+	var cookie *http.Cookie
+	for _, c := range (&http.Response{Header: login.Header}).Cookies() {
+		if c.Name == "blog_oidc" {
+			cookie = c
+		}
+	}
+	if cookie == nil || !cookie.HttpOnly {
+		return nil, fmt.Errorf("登录入口没有写入 HttpOnly 的 blog_oidc cookie")
+	}
+
+	r.idp.setTamper(flow.tamper)
+	defer r.idp.setTamper("")
+	resp, err := noRedirect.Get(authURL)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		return nil, fmt.Errorf("提供方的授权端点返回 %d，期望 302", resp.StatusCode)
+	}
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		return nil, err
+	}
+	query := location.Query()
+	if flow.tamper == "state" {
+		query.Set("state", cookie.Value+"x")
+	}
+	callback := http.Header{}
+	if flow.tamper != "cookie" {
+		callback.Set("Cookie", cookie.Name+"="+cookie.Value)
+	}
+	path := location.Path + "?" + query.Encode()
+	result, err := r.do(http.DefaultClient, http.MethodGet, path, callback, nil)
+	if err == nil && flow.tamper == "replay" {
+		result, err = r.do(http.DefaultClient, http.MethodGet, path, callback, nil)
+	}
+	if err != nil || flow.saveAs == "" || result.Status != http.StatusOK {
+		return result, err
+	}
+	var out struct {
+		Data struct {
+			Token string `json:"token"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(result.Body, &out); err != nil {
+		return nil, err
+	}
+	r.sessions[flow.saveAs] = &session{token: out.Data.Token}
+	return result, nil
+}
@@ -0,0 +1,159 @@
+package e2e
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	blogv1 "github.com/miffyG/golearn/task4/api/blog/v1"
+	"github.com/miffyG/golearn/task4/pkg/client"
+	"github.com/miffyG/golearn/task4/pkg/config"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// TestRESTGRPCParity 在同一个数据库上分别通过 v1 REST 接口和 gRPC 读取文章和评论，逐个字段比较，
+// 两种接口的转换函数各自维护，新增字段时容易只改了一边
+func TestRESTGRPCParity(t *testing.T) {
+	s := startServer(t, func(cfg *config.Config) {
+		cfg.Grpc.Addr = "127.0.0.1:0"
+	})
+	ctx := context.Background()
+	author := s.newUser(t, "author")
+	reader := s.newUser(t, "reader")
+
+	// 一篇修改过的文章带两条评论，另一篇没有评论
+	first, err := author.CreatePost(ctx, client.PostRequest{Title: "对照文章", Content: "第一版正文"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := author.UpdatePost(ctx, first.ID, client.PostRequest{Title: "对照文章", Content: "第二版正文"}, first.Version); err != nil {
+		t.Fatal(err)
+	}
+	for _, content := range []string{"第一条评论", "第二条评论"} {
+		if _, err := reader.CreateComment(ctx, first.ID, content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := reader.CreatePost(ctx, client.PostRequest{Title: "另一篇文章", Content: "没有评论"}); err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err := grpc.NewClient(s.grpcAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	posts := blogv1.NewPostServiceClient(conn)
+	comments := blogv1.NewCommentServiceClient(conn)
+
+	restPosts, err := author.ListPosts(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rpcList, err := posts.ListPosts(ctx, &blogv1.ListPostsRequest{PageSize: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if int(rpcList.GetTotal()) != len(restPosts) || len(rpcList.GetPosts()) != len(restPosts) {
+		t.Fatalf("REST 返回 %d 篇文章，gRPC 返回 %d 篇（total %d）", len(restPosts), len(rpcList.GetPosts()), rpcList.GetTotal())
+	}
+	rpcByID := make(map[uint64]*blogv1.Post, len(restPosts))
+	for _, p := range rpcList.GetPosts() {
+		rpcByID[p.GetId()] = p
+	}
+
+	for _, rest := range restPosts {
+		t.Run("list-post-"+rest.Title, func(t *testing.T) {
+			rpc, ok := rpcByID[uint64(rest.ID)]
+			if !ok {
+				t.Fatalf("gRPC 的文章列表中没有文章 %d", rest.ID)
+			}
+			comparePost(t, &rest, rpc)
+		})
+
+		t.Run("get-post-"+rest.Title, func(t *testing.T) {
+			restPost, err := author.GetPost(ctx, rest.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rpc, err := posts.GetPost(ctx, &blogv1.GetPostRequest{Id: uint64(rest.ID)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			comparePost(t, restPost, rpc)
+		})
+
+		t.Run("list-comments-"+rest.Title, func(t *testing.T) {
+			restComments, err := author.ListComments(ctx, rest.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rpc, err := comments.ListComments(ctx, &blogv1.ListCommentsRequest{PostId: uint64(rest.ID)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			compareComments(t, restComments, rpc.GetComments())
+		})
+	}
+}
+
+func comparePost(t *testing.T, rest *client.Post, rpc *blogv1.Post) {
+	t.Helper()
+	equal(t, "id", uint64(rest.ID), rpc.GetId())
+	equal(t, "title", rest.Title, rpc.GetTitle())
+	equal(t, "content", rest.Content, rpc.GetContent())
+	equal(t, "user_id", uint64(rest.UserID), rpc.GetUserId())
+	equal(t, "version", uint64(rest.Version), rpc.GetVersion())
+	equalTime(t, "created_at", rest.CreatedAt, rpc.GetCreatedAt())
+	equalTime(t, "updated_at", rest.UpdatedAt, rpc.GetUpdatedAt())
+	switch {
+	case rest.User == nil && rpc.GetUser() != nil:
+		t.Errorf("user: REST 没有作者，gRPC 为 %v", rpc.GetUser())
+	case rest.User != nil && rpc.GetUser() == nil:
+		t.Errorf("user: REST 为 %+v，gRPC 没有作者", rest.User)
+	case rest.User != nil:
+		equal(t, "user.id", uint64(rest.User.ID), rpc.GetUser().GetId())
+		equal(t, "user.username", rest.User.Username, rpc.GetUser().GetUsername())
+		equal(t, "user.email", rest.User.Email, rpc.GetUser().GetEmail())
+		equal(t, "user.phone", rest.User.Phone, rpc.GetUser().GetPhone())
+	}
+	compareComments(t, rest.Comments, rpc.GetComments())
+}
+
+func compareComments(t *testing.T, rest []client.Comment, rpc []*blogv1.Comment) {
+	t.Helper()
+	if len(rest) != len(rpc) {
+		t.Errorf("comments: REST 有 %d 条，gRPC 有 %d 条", len(rest), len(rpc))
+		return
+	}
+	for i := range rest {
+		equal(t, "comment.id", uint64(rest[i].ID), rpc[i].GetId())
+		equal(t, "comment.content", rest[i].Content, rpc[i].GetContent())
+		equal(t, "comment.user_id", uint64(rest[i].UserID), rpc[i].GetUserId())
+		equal(t, "comment.post_id", uint64(rest[i].PostID), rpc[i].GetPostId())
+		equalTime(t, "comment.created_at", rest[i].CreatedAt, rpc[i].GetCreatedAt())
+		equalTime(t, "comment.updated_at", rest[i].UpdatedAt, rpc[i].GetUpdatedAt())
+	}
+}
+
+func equal[T comparable](t *testing.T, field string, rest, rpc T) {
+	t.Helper()
+	if rest != rpc {
+		t.Errorf("%s: REST 为 %v，gRPC 为 %v", field, rest, rpc)
+	}
+}
+
+func equalTime(t *testing.T, field string, rest time.Time, rpc *timestamppb.Timestamp) {
+	t.Helper()
+	if rpc == nil {
+		if !rest.IsZero() {
+			t.Errorf("%s: REST 为 %v，gRPC 没有该字段", field, rest)
+		}
+		return
+	}
+	if !rest.Equal(rpc.AsTime()) {
+		t.Errorf("%s: REST 为 %v，gRPC 为 %v", field, rest, rpc.AsTime())
+	}
+}
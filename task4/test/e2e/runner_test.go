@@ -0,0 +1,283 @@
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/miffyG/golearn/task4/pkg/client"
+)
+
+// testCase 是一次 HTTP 调用及其期望结果
+type testCase struct {
+	name string
+	// as 是发起请求的用户，取自 fixtureUsers，为空时匿名调用
+	as string
+	// token 不为空时直接作为 Bearer token 使用，用于测试无效的 token
+	token string
+	// cookie 为 true 时用 as 登录得到的 cookie 代替 Authorization 头，csrf 为 true 时同时带上 CSRF 请求头
+	cookie bool
+	csrf   bool
+	method string
+	path   string
+	// header 是额外的请求头
+	header map[string]string
+	body   interface{}
+	// contentType 是请求体的类型，为空时使用 application/json
+	contentType string
+	status      int
+	// wantHeader 是期望的响应头，值为空表示不应出现该响应头
+	wantHeader map[string]string
+	// golden 为 true 时把响应体与 testdata/<name>.json 比较
+	golden bool
+	// oidc 不为空时执行第三方登录流程，不使用 method 和 path
+	oidc *oidcLogin
+}
+
+// fixtureUser 是 newRunner 注册并登录的用户
+type fixtureUser struct {
+	username string
+	password string
+	email    string
+}
+
+// session 是一次登录的结果，cookies 是开启 cookie 认证时写入的认证 cookie 和 CSRF cookie
+type session struct {
+	token   string
+	csrf    string
+	cookies []*http.Cookie
+}
+
+type runner struct {
+	base   string
+	golden string
+	update bool
+
+	sessions map[string]*session
+	// idp 是服务配置的模拟 OIDC 提供方
+	idp *mockProvider
+}
+
+// newRunner 用 pkg/client 注册并登录 fixtureUsers 中的全部用户
+func newRunner(t *testing.T, s *server) *runner {
+	t.Helper()
+	r := &runner{
+		base:     s.url,
+		golden:   goldenDir(),
+		update:   *update,
+		sessions: make(map[string]*session),
+		idp:      s.idp,
+	}
+	ctx := context.Background()
+	for _, u := range fixtureUsers {
+		rec := &cookieRecorder{}
+		c := client.New(s.url, client.WithHTTPClient(&http.Client{Transport: rec}), client.WithRetry(0, 0))
+		if _, err := c.Register(ctx, client.RegisterRequest{Username: u.username, Password: u.password, Email: u.email}); err != nil {
+			t.Fatalf("注册 %s 失败: %v", u.username, err)
+		}
+		token, err := c.Login(ctx, u.username, u.password)
+		if err != nil {
+			t.Fatalf("登录 %s 失败: %v", u.username, err)
+		}
+		sess := &session{token: token, cookies: rec.cookies}
+		for _, cookie := range rec.cookies {
+			if cookie.Name == s.cfg.AuthCookie.CSRFName {
+				sess.csrf = cookie.Value
+			}
+		}
+		r.sessions[u.username] = sess
+	}
+	return r
+}
+
+// cookieRecorder 记录响应写入的 cookie。测试服务不是 HTTPS，cookiejar 不会保存 Secure cookie，所以直接从响应头读取
+type cookieRecorder struct {
+	cookies []*http.Cookie
+}
+
+func (rec *cookieRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err == nil {
+		rec.cookies = append(rec.cookies, resp.Cookies()...)
+	}
+	return resp, err
+}
+
+// run 发送用例的请求
+func (r *runner) run(tc testCase) (*response, error) {
+	header, err := r.header(tc)
+	if err != nil {
+		return nil, err
+	}
+	if tc.oidc != nil {
+		return r.oidcFlow(tc, header)
+	}
+	return r.do(http.DefaultClient, tc.method, tc.path, header, tc.body)
+}
+
+// check 检查响应的状态码、响应头和 golden 文件
+func (r *runner) check(tc testCase, resp *response) error {
+	if resp.Status != tc.status {
+		return fmt.Errorf("%s %s: 状态码 %d，期望 %d: %s", tc.method, tc.path, resp.Status, tc.status, resp.Body)
+	}
+	for k, want := range tc.wantHeader {
+		if got := resp.Header.Get(k); got != want {
+			return fmt.Errorf("%s %s: 响应头 %s 为 %q，期望 %q", tc.method, tc.path, k, got, want)
+		}
+	}
+	if !tc.golden {
+		return nil
+	}
+	return r.compare(tc.name, resp.Body)
+}
+
+// response 是一次调用的状态码和原始响应体
+type response struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// do 用 httpClient 发送请求，body 不为 nil 时编码为 JSON，header 中没有 Content-Type 时使用 application/json。
+// 用例需要检查原始的响应体和响应头，所以不经过 pkg/client
+func (r *runner) do(httpClient *http.Client, method, path string, header http.Header, body interface{}) (*response, error) {
+	var rd io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		rd = bytes.NewReader(b)
+	}
+	req, err := http.NewRequest(method, r.base+path, rd)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if body != nil && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &response{Status: resp.StatusCode, Header: resp.Header, Body: data}, nil
+}
+
+// header 按用例的认证方式生成请求头
+func (r *runner) header(tc testCase) (http.Header, error) {
+	header := http.Header{}
+	for k, v := range tc.header {
+		header.Set(k, v)
+	}
+	if tc.contentType != "" {
+		header.Set("Content-Type", tc.contentType)
+	}
+	if tc.token != "" {
+		header.Set("Authorization", "Bearer "+tc.token)
+		return header, nil
+	}
+	if tc.as == "" {
+		return header, nil
+	}
+	s, ok := r.sessions[tc.as]
+	if !ok {
+		return nil, fmt.Errorf("未知的用户 %q", tc.as)
+	}
+	if !tc.cookie {
+		header.Set("Authorization", "Bearer "+s.token)
+		return header, nil
+	}
+	if len(s.cookies) == 0 {
+		return nil, fmt.Errorf("用户 %q 登录时没有得到 cookie", tc.as)
+	}
+	for _, c := range s.cookies {
+		header.Add("Cookie", c.Name+"="+c.Value)
+	}
+	if tc.csrf {
+		header.Set("X-CSRF-Token", s.csrf)
+	}
+	return header, nil
+}
+
+// compare 把规范化后的响应体与 golden 文件比较，-update 时改为写入 golden 文件
+func (r *runner) compare(name string, body []byte) error {
+	got, err := normalize(body)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(r.golden, name+".json")
+	if r.update {
+		if err := os.MkdirAll(r.golden, 0o755); err != nil {
+			return err
+		}
+		return os.WriteFile(path, got, 0o644)
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("读取 golden 文件失败，需要先用 -update 生成: %w", err)
+	}
+	if !bytes.Equal(got, want) {
+		return fmt.Errorf("响应与 %s 不一致\n--- 期望\n%s--- 实际\n%s", path, want, got)
+	}
+	return nil
+}
+
+// volatileKeys 是每次运行都会变化的字段，比较前替换为占位符，只检查字段是否存在
+var volatileKeys = map[string]bool{
+	"token":      true,
+	"csrf_token": true,
+}
+
+// normalize 把 JSON 按键排序、缩进输出，并替换时间、token 等每次运行都不同的值
+func normalize(body []byte) ([]byte, error) {
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return nil, fmt.Errorf("响应不是 JSON: %w: %s", err, body)
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(scrub(v)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func scrub(v interface{}) interface{} {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		for k, item := range x {
+			if item != nil && (volatileKeys[k] || isTimeKey(k)) {
+				x[k] = "<" + k + ">"
+				continue
+			}
+			x[k] = scrub(item)
+		}
+	case []interface{}:
+		for i, item := range x {
+			x[i] = scrub(item)
+		}
+	}
+	return v
+}
+
+// isTimeKey 判断字段是否为时间，兼容 created_at 和旧的 CreatedAt 两种命名
+func isTimeKey(k string) bool {
+	return strings.HasSuffix(k, "_at") || strings.HasSuffix(k, "At")
+}
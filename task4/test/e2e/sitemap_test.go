@@ -0,0 +1,95 @@
+package e2e
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/miffyG/golearn/task4/pkg/client"
+	"github.com/miffyG/golearn/task4/pkg/config"
+)
+
+// sitemapDoc 同时可以解析 urlset 和 sitemapindex，XMLName 区分两者
+type sitemapDoc struct {
+	XMLName  xml.Name
+	URLs     []string `xml:"url>loc"`
+	Sitemaps []string `xml:"sitemap>loc"`
+}
+
+// TestSitemapPages 把单个 sitemap 文件的容量设为 3，随着文章增加检查 /sitemap.xml 从文章列表变为索引，
+// 以及分页文件的内容和越界时的 404
+func TestSitemapPages(t *testing.T) {
+	const pageSize = 3
+	s := startServer(t, func(cfg *config.Config) { cfg.Site.SitemapPageSize = pageSize })
+	c := s.newUser(t, "mapper")
+	r := &runner{base: s.url}
+	posts := 0
+	publish := func(n int) {
+		t.Helper()
+		for ; n > 0; n-- {
+			posts++
+			if _, err := c.CreatePost(context.Background(), client.PostRequest{Title: fmt.Sprintf("第 %d 篇", posts), Content: "正文"}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	get := func(path string) (int, *sitemapDoc) {
+		t.Helper()
+		res, err := r.do(http.DefaultClient, http.MethodGet, path, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Status != http.StatusOK {
+			return res.Status, nil
+		}
+		var doc sitemapDoc
+		if err := xml.Unmarshal(res.Body, &doc); err != nil {
+			t.Fatalf("%s 不是合法的 XML: %v", path, err)
+		}
+		return res.Status, &doc
+	}
+	expectURLSet := func(path string, want int) {
+		t.Helper()
+		status, doc := get(path)
+		if status != http.StatusOK || doc.XMLName.Local != "urlset" || len(doc.URLs) != want {
+			t.Errorf("%s 返回 %d %+v，期望 urlset 包含 %d 个地址", path, status, doc, want)
+		}
+	}
+	expectNotFound := func(path string) {
+		t.Helper()
+		if status, _ := get(path); status != http.StatusNotFound {
+			t.Errorf("%s 返回 %d，期望 404", path, status)
+		}
+	}
+
+	publish(2)
+	expectURLSet("/sitemap.xml", 2)
+
+	// 文章数正好等于容量时仍然只有一页，第 1 页与 /sitemap.xml 相同，第 2 页不存在
+	publish(1)
+	expectURLSet("/sitemap.xml", pageSize)
+	expectURLSet("/sitemaps/posts-1.xml", pageSize)
+	expectNotFound("/sitemaps/posts-2.xml")
+
+	publish(4)
+	status, index := get("/sitemap.xml")
+	if status != http.StatusOK || index.XMLName.Local != "sitemapindex" {
+		t.Fatalf("/sitemap.xml 返回 %d %+v，期望索引文件", status, index)
+	}
+	want := []string{
+		s.cfg.Site.URL + "/sitemaps/posts-1.xml",
+		s.cfg.Site.URL + "/sitemaps/posts-2.xml",
+		s.cfg.Site.URL + "/sitemaps/posts-3.xml",
+	}
+	if fmt.Sprint(index.Sitemaps) != fmt.Sprint(want) {
+		t.Errorf("索引列出 %v，期望 %v", index.Sitemaps, want)
+	}
+	expectURLSet("/sitemaps/posts-1.xml", pageSize)
+	expectURLSet("/sitemaps/posts-2.xml", pageSize)
+	expectURLSet("/sitemaps/posts-3.xml", 1)
+	expectNotFound("/sitemaps/posts-4.xml")
+	expectNotFound("/sitemaps/posts-0.xml")
+	expectNotFound("/sitemaps/pages-1.xml")
+}
@@ -0,0 +1,61 @@
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/miffyG/golearn/task4/internal/repository"
+	"github.com/miffyG/golearn/task4/pkg/client"
+)
+
+// TestSlug 检查重名标题的 slug 后缀：同时创建的文章不会得到相同的 slug，占用者被彻底删除后改标题时换回不带后缀的 slug
+func TestSlug(t *testing.T) {
+	s := startServer(t)
+	c := s.newUser(t, "slugger")
+	ctx := context.Background()
+
+	posts := make([]*client.Post, 2)
+	errs := race(func(i int) error {
+		var err error
+		posts[i], err = c.CreatePost(ctx, client.PostRequest{Title: "Same Title", Content: fmt.Sprintf("第 %d 篇", i)})
+		return err
+	})
+	for _, err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	first, second := posts[0], posts[1]
+	if first.ID > second.ID {
+		first, second = second, first
+	}
+	if first.Slug != "same-title" || second.Slug != "same-title-2" {
+		t.Fatalf("slug 为 %q 和 %q，期望 same-title 和 same-title-2", first.Slug, second.Slug)
+	}
+
+	if err := c.DeletePost(ctx, first.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repository.NewPostRepository(s.app.DB).Purge(ctx, time.Now().Add(time.Minute), 10); err != nil {
+		t.Fatal(err)
+	}
+	updated, err := c.UpdatePost(ctx, second.ID, client.PostRequest{Title: "Same title", Content: "改了标题的大小写"}, second.Version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Slug != "same-title" {
+		t.Errorf("slug 为 %q，期望换回 same-title", updated.Slug)
+	}
+
+	r := &runner{base: s.url}
+	resp, err := r.do(noRedirect, http.MethodGet, "/api/v1/posts/by-slug/same-title-2", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status != http.StatusMovedPermanently || resp.Header.Get("Location") != "/api/v1/posts/by-slug/same-title" {
+		t.Errorf("旧 slug 返回 %d，Location %q", resp.Status, resp.Header.Get("Location"))
+	}
+}
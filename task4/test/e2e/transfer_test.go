@@ -0,0 +1,165 @@
+package e2e
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miffyG/golearn/task4/internal/models/dto"
+	"github.com/miffyG/golearn/task4/internal/models/entity"
+	"github.com/miffyG/golearn/task4/pkg/client"
+	"github.com/miffyG/golearn/task4/pkg/config"
+)
+
+// TestTransfer 把一个库导出后导入另一个空库，再重复导入，检查已删除的作者、没有密码哈希的用户和创建时间的精度
+func TestTransfer(t *testing.T) {
+	ctx := context.Background()
+	src := startServer(t)
+	admin := src.newUser(t, "admin")
+	src.setRole(t, "admin", entity.RoleAdmin)
+	ghost := src.newUser(t, "ghost")
+	post, err := ghost.CreatePost(ctx, client.PostRequest{Title: "作者已删除", Content: "文章仍然保留"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := admin.CreateComment(ctx, post.ID, "评论"); err != nil {
+		t.Fatal(err)
+	}
+	if err := src.app.DB.Delete(&entity.User{}, "user_name = ?", "ghost").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	export := adminCall(t, src, admin, http.MethodGet, "/api/v1/admin/export", nil, http.StatusOK)
+	records := decodeExport(t, export)
+	if u := records["user:ghost"]; u == nil || u["deleted"] != true {
+		t.Fatalf("导出的已删除用户为 %v，期望带有 deleted", u)
+	}
+	if p := records["post:"+post.Title]; p == nil || p["author"] != "ghost" {
+		t.Fatalf("导出的文章为 %v，期望作者为 ghost", p)
+	}
+
+	// 目标库的时间精度低于源库时，库中的创建时间与导出文件中的不完全相同
+	shifted := shiftCreatedAt(t, export, 400*time.Millisecond)
+
+	dst := startServer(t, func(cfg *config.Config) {
+		cfg.Api.ImportMaxBytes = int64(len(export)) + 1024
+	})
+	dstAdmin := dst.newUser(t, "admin")
+	dst.setRole(t, "admin", entity.RoleAdmin)
+
+	first := importResult(t, dst, dstAdmin, export)
+	if first.Users.Created != 1 || first.Posts.Created != 1 || first.Comments.Created != 1 {
+		t.Errorf("第一次导入的结果为 %+v，期望新建 ghost、一篇文章和一条评论", first)
+	}
+	var imported entity.User
+	if err := dst.app.DB.Unscoped().Where("user_name = ?", "ghost").First(&imported).Error; err != nil {
+		t.Fatal(err)
+	}
+	if !imported.DeletedAt.Valid {
+		t.Error("导入的 ghost 没有被删除")
+	}
+	if imported.Password == "" || imported.CheckPassword("") == nil {
+		t.Errorf("没有密码哈希的用户导入后密码为 %q，期望为无法使用的随机密码", imported.Password)
+	}
+
+	second := importResult(t, dst, dstAdmin, shifted)
+	if second.Users.Created != 0 || second.Posts.Created != 0 || second.Comments.Created != 0 {
+		t.Errorf("重复导入的结果为 %+v，不应新建记录", second)
+	}
+
+	dst.cfg.Api.ImportMaxBytes = 16
+	adminCall(t, dst, dstAdmin, http.MethodPost, "/api/v1/admin/import", export, http.StatusRequestEntityTooLarge)
+}
+
+// adminCall 以 c 的身份发送原始请求体，检查状态码后返回响应体
+func adminCall(t *testing.T, s *server, c *client.Client, method, path string, body []byte, want int) []byte {
+	t.Helper()
+	req, err := http.NewRequest(method, s.url+path, bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.Token())
+	if body != nil {
+		req.Header.Set("Content-Type", "application/x-ndjson")
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var buf bytes.Buffer
+	buf.ReadFrom(resp.Body)
+	if resp.StatusCode != want {
+		t.Fatalf("%s %s: 状态码 %d，期望 %d: %s", method, path, resp.StatusCode, want, buf.Bytes())
+	}
+	return buf.Bytes()
+}
+
+func importResult(t *testing.T, s *server, c *client.Client, export []byte) dto.ImportResult {
+	t.Helper()
+	var out struct {
+		Data dto.ImportResult `json:"data"`
+	}
+	if err := json.Unmarshal(adminCall(t, s, c, http.MethodPost, "/api/v1/admin/import", export, http.StatusOK), &out); err != nil {
+		t.Fatal(err)
+	}
+	return out.Data
+}
+
+// decodeExport 按 "类型:用户名" 或 "类型:标题" 索引 NDJSON 导出中的用户和文章
+func decodeExport(t *testing.T, export []byte) map[string]map[string]interface{} {
+	t.Helper()
+	records := make(map[string]map[string]interface{})
+	scanner := bufio.NewScanner(bytes.NewReader(export))
+	for scanner.Scan() {
+		var r struct {
+			Type string                 `json:"type"`
+			Data map[string]interface{} `json:"data"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			t.Fatal(err)
+		}
+		switch r.Type {
+		case dto.TransferTypeUser:
+			records["user:"+r.Data["username"].(string)] = r.Data
+		case dto.TransferTypePost:
+			records["post:"+r.Data["title"].(string)] = r.Data
+		}
+	}
+	return records
+}
+
+// shiftCreatedAt 把导出中文章和评论的 created_at 推后 d
+func shiftCreatedAt(t *testing.T, export []byte, d time.Duration) []byte {
+	t.Helper()
+	var out strings.Builder
+	scanner := bufio.NewScanner(bytes.NewReader(export))
+	for scanner.Scan() {
+		var r struct {
+			Type string                 `json:"type"`
+			Data map[string]interface{} `json:"data"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			t.Fatal(err)
+		}
+		if r.Type == dto.TransferTypePost || r.Type == dto.TransferTypeComment {
+			at, err := time.Parse(time.RFC3339Nano, r.Data["created_at"].(string))
+			if err != nil {
+				t.Fatal(err)
+			}
+			r.Data["created_at"] = at.Add(d)
+		}
+		line, err := json.Marshal(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out.Write(line)
+		out.WriteByte('\n')
+	}
+	return []byte(out.String())
+}
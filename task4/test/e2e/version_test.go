@@ -0,0 +1,89 @@
+package e2e
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/miffyG/golearn/task4/internal/models/entity"
+	"github.com/miffyG/golearn/task4/pkg/client"
+)
+
+// TestVersionNegotiation 检查协商到 v2 的请求只经过一次全局中间件：响应中只有一个请求 ID 和一个 Vary: Origin，
+// 审计日志记录的请求 ID 与响应头一致，返回的是 v2 的格式
+func TestVersionNegotiation(t *testing.T) {
+	s := startServer(t)
+	c := s.newUser(t, "negotiator")
+	post, err := c.CreatePost(context.Background(), client.PostRequest{Title: "协商", Content: "正文"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &runner{base: s.url}
+
+	tests := []struct {
+		name      string
+		requestID string
+	}{
+		{"generated-request-id", ""},
+		{"client-request-id", "negotiated-request"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			header := http.Header{
+				"Accept":        {v2MediaType},
+				"Origin":        {allowedOrigin},
+				"Authorization": {"Bearer " + c.Token()},
+			}
+			if tc.requestID != "" {
+				header.Set("X-Request-ID", tc.requestID)
+			}
+			resp, err := r.do(http.DefaultClient, http.MethodPut, fmt.Sprintf("/api/v1/posts/%d", post.ID), header,
+				map[string]string{"title": "协商", "content": "由 " + tc.name + " 修改"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.Status != http.StatusOK || resp.Header.Get("Content-Type") != v2ContentType {
+				t.Fatalf("返回 %d，Content-Type %q: %s", resp.Status, resp.Header.Get("Content-Type"), resp.Body)
+			}
+			var body struct {
+				Data struct {
+					ID     uint `json:"id"`
+					Author struct {
+						ID uint `json:"id"`
+					} `json:"author"`
+				} `json:"data"`
+				Code *int `json:"code"`
+			}
+			if err := json.Unmarshal(resp.Body, &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Data.ID != post.ID || body.Data.Author.ID == 0 || body.Code != nil {
+				t.Errorf("响应不是 v2 的格式: %s", resp.Body)
+			}
+
+			ids := resp.Header.Values("X-Request-ID")
+			if len(ids) != 1 || (tc.requestID != "" && ids[0] != tc.requestID) {
+				t.Fatalf("X-Request-ID 为 %q，期望一个值", ids)
+			}
+			vary := 0
+			for _, v := range resp.Header.Values("Vary") {
+				if v == "Origin" {
+					vary++
+				}
+			}
+			if vary != 1 {
+				t.Errorf("Vary 为 %q，期望 Origin 只出现一次", resp.Header.Values("Vary"))
+			}
+
+			var entry entity.AuditLog
+			if err := s.app.DB.Order("id DESC").First(&entry).Error; err != nil {
+				t.Fatal(err)
+			}
+			if entry.RequestID != ids[0] {
+				t.Errorf("审计日志的请求 ID 为 %q，响应头为 %q", entry.RequestID, ids[0])
+			}
+		})
+	}
+}